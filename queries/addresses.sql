@@ -0,0 +1,17 @@
+-- name: GetNearestAddress :one
+SELECT
+    id AS address_id,
+    display_name,
+    name,
+    house_number,
+    road,
+    neighbourhood,
+    city,
+    postcode,
+    state,
+    country
+FROM addresses
+WHERE latitude BETWEEN @latitude::float8 - 0.01 AND @latitude::float8 + 0.01
+    AND longitude BETWEEN @longitude::float8 - 0.02 AND @longitude::float8 + 0.02
+ORDER BY POWER(latitude::float8 - @latitude::float8, 2) + POWER((longitude::float8 - @longitude::float8) * COS(RADIANS(@latitude::float8)), 2) ASC, id ASC
+LIMIT 1;
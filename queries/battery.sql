@@ -0,0 +1,18 @@
+-- name: ListBatteryHealth :many
+SELECT
+    DATE_TRUNC('month', charging_processes.start_date AT TIME ZONE 'UTC' AT TIME ZONE @time_zone::text)::timestamp AS month,
+    COUNT(*) AS charges,
+    AVG((charging_processes.end_rated_range_km - charging_processes.start_rated_range_km)::float8 * cars.efficiency / (charging_processes.end_battery_level - charging_processes.start_battery_level) * 100) AS estimated_capacity,
+    AVG(charging_processes.charge_energy_added::float8 / (charging_processes.end_battery_level - charging_processes.start_battery_level) * 100) AS estimated_capacity_by_energy,
+    AVG(charging_processes.end_rated_range_km::float8 / charging_processes.end_battery_level * 100) AS projected_full_range_km,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    cars.name AS car_name
+FROM charging_processes
+INNER JOIN cars ON charging_processes.car_id = cars.id
+WHERE charging_processes.car_id = @car_id
+    AND charging_processes.end_date IS NOT NULL
+    AND charging_processes.start_rated_range_km IS NOT NULL
+    AND charging_processes.end_rated_range_km IS NOT NULL
+    AND charging_processes.end_battery_level - charging_processes.start_battery_level >= sqlc.arg('min_battery_level_added')::smallint
+GROUP BY month, cars.name
+ORDER BY month ASC;
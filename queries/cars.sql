@@ -1,6 +1,63 @@
+-- name: ListCars :many
+SELECT
+    cars.id,
+    cars.eid,
+    cars.vid,
+    cars.model,
+    cars.efficiency,
+    cars.inserted_at,
+    cars.updated_at,
+    cars.vin,
+    cars.name,
+    cars.trim_badging,
+    cars.exterior_color,
+    cars.spoiler_type,
+    cars.wheel_type,
+    car_settings.suspend_min,
+    car_settings.suspend_after_idle_min,
+    car_settings.req_not_unlocked,
+    car_settings.free_supercharging,
+    car_settings.use_streaming_api,
+    (SELECT COUNT(*) FROM charging_processes WHERE car_id = cars.id) AS total_charges,
+    (SELECT COUNT(*) FROM drives WHERE car_id = cars.id) AS total_drives,
+    (SELECT COUNT(*) FROM updates WHERE car_id = cars.id) AS total_updates
+FROM cars
+LEFT JOIN car_settings ON cars.id = car_settings.id
+ORDER BY cars.id;
+
+-- name: CarExists :one
+SELECT EXISTS(SELECT 1 FROM cars WHERE id=$1);
+
+-- name: GetCarCommandDetails :one
+SELECT
+    eid,
+    vin,
+    (SELECT access FROM tokens LIMIT 1) AS access_token
+FROM cars
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetCarStatusFingerprint :one
+SELECT
+    COALESCE((SELECT MAX(date) FROM positions WHERE car_id = $1), 'epoch')::timestamp AS position_date,
+    COALESCE((SELECT MAX(start_date) FROM states WHERE car_id = $1), 'epoch')::timestamp AS state_since,
+    COALESCE((SELECT MAX(id) FROM charging_processes WHERE car_id = $1 AND end_date IS NULL), 0)::integer AS charging_process_id,
+    COALESCE((SELECT MAX(ch.date) FROM charges ch
+        JOIN charging_processes cp ON ch.charging_process_id = cp.id
+        WHERE cp.car_id = $1 AND cp.end_date IS NULL), 'epoch')::timestamp AS charge_date;
+
+-- name: ListCarIDs :many
+SELECT
+    id,
+    name
+FROM cars
+ORDER BY id;
+
 -- name: GetCarStatus :one
-SELECT 
-    c.id,
+-- The vehicle state columns of positions are read through to_jsonb, since not every
+-- TeslaMate schema has them, and are NULL when missing.
+SELECT
+    c.id AS car_id,
     c.name,
     c.model,
     c.trim_badging,
@@ -8,14 +65,6 @@ SELECT
     c.wheel_type,
     c.spoiler_type,
     c.vin,
-    c.eid,
-    c.vid,
-    cs.suspend_min,
-    cs.suspend_after_idle_min,
-    cs.req_not_unlocked,
-    cs.free_supercharging,
-    cs.use_streaming_api,
-    -- Latest position data
     p.date AS position_date,
     p.latitude,
     p.longitude,
@@ -24,54 +73,58 @@ SELECT
     p.odometer,
     p.battery_level,
     p.usable_battery_level,
-    p.ideal_battery_range_km,
-    p.est_battery_range_km,
-    p.rated_battery_range_km,
+    p.ideal_battery_range_km AS ideal_battery_range,
+    p.est_battery_range_km AS est_battery_range,
+    p.rated_battery_range_km AS rated_battery_range,
     p.outside_temp,
     p.inside_temp,
     p.is_climate_on,
-    p.is_preconditioning,
-    p.locked,
-    p.sentry_mode,
-    p.windows_open,
-    p.doors_open,
-    p.trunk_open,
-    p.frunk_open,
-    p.is_user_present,
-    p.shift_state,
-    p.heading,
     p.elevation,
-    -- Charging status
-    COALESCE(p.charge_energy_added, 0) AS charge_energy_added,
-    COALESCE(p.charger_power, 0) AS charger_power,
-    COALESCE(p.charger_voltage, 0) AS charger_voltage,
-    COALESCE(p.charger_phases, 0) AS charger_phases,
-    COALESCE(p.charger_actual_current, 0) AS charger_actual_current,
-    COALESCE(p.charge_current_request, 0) AS charge_current_request,
-    COALESCE(p.charge_current_request_max, 0) AS charge_current_request_max,
-    p.charge_limit_soc,
-    p.charge_port_door_open,
-    p.time_to_full_charge,
-    p.scheduled_charging_start_time,
-    CASE 
-        WHEN cp.id IS NOT NULL AND cp.end_date IS NULL THEN 'charging'
-        WHEN p.charge_port_door_open = true THEN 'plugged_in' 
-        ELSE 'disconnected'
-    END AS charging_state,
-    CASE 
-        WHEN cp.id IS NOT NULL AND cp.end_date IS NULL THEN true
-        WHEN p.charge_port_door_open = true THEN true
-        ELSE false
-    END AS plugged_in,
-    -- Latest state information
+    p.tpms_pressure_fl,
+    p.tpms_pressure_fr,
+    p.tpms_pressure_rl,
+    p.tpms_pressure_rr,
+    p.driver_temp_setting,
+    p.passenger_temp_setting,
+    p.fan_status,
+    p.is_front_defroster_on,
+    p.is_rear_defroster_on,
+    p.battery_heater,
+    p.battery_heater_on,
+    p.battery_heater_no_power,
+    (to_jsonb(p) ->> 'heading')::integer AS heading,
+    (to_jsonb(p) ->> 'shift_state')::text AS shift_state,
+    (to_jsonb(p) ->> 'locked')::boolean AS locked,
+    (to_jsonb(p) ->> 'sentry_mode')::boolean AS sentry_mode,
+    (to_jsonb(p) ->> 'windows_open')::boolean AS windows_open,
+    (to_jsonb(p) ->> 'doors_open')::boolean AS doors_open,
+    (to_jsonb(p) ->> 'trunk_open')::boolean AS trunk_open,
+    (to_jsonb(p) ->> 'frunk_open')::boolean AS frunk_open,
+    (to_jsonb(p) ->> 'is_user_present')::boolean AS is_user_present,
+    (to_jsonb(p) ->> 'is_preconditioning')::boolean AS is_preconditioning,
+    (to_jsonb(p) ->> 'charge_limit_soc')::integer AS charge_limit_soc,
+    (to_jsonb(p) ->> 'time_to_full_charge')::float8 AS time_to_full_charge,
+    (to_jsonb(p) ->> 'scheduled_charging_start_time')::timestamp AS scheduled_charging_start_time,
+    (to_jsonb(p) ->> 'charge_port_door_open')::boolean AS charge_port_door_open,
     s.state,
     s.start_date AS state_since,
-    -- Settings
-    (SELECT unit_of_length FROM settings LIMIT 1) AS unit_of_length,
-    (SELECT unit_of_pressure FROM settings LIMIT 1) AS unit_of_pressure,
-    (SELECT unit_of_temperature FROM settings LIMIT 1) AS unit_of_temperature
+    CASE WHEN cp.id IS NOT NULL THEN true ELSE false END AS is_charging,
+    CASE WHEN cp.id IS NOT NULL THEN 'charging' ELSE 'disconnected' END AS charging_state,
+    ch.charger_power,
+    ch.charger_voltage,
+    ch.charger_phases,
+    ch.charger_actual_current,
+    ch.charge_energy_added,
+    ch.charger_pilot_current,
+    ch.conn_charge_cable,
+    ch.fast_charger_present,
+    ch.fast_charger_brand,
+    ch.fast_charger_type,
+    (SELECT version FROM updates WHERE updates.car_id = c.id ORDER BY updates.start_date DESC LIMIT 1) AS version,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    (SELECT unit_of_pressure::text FROM settings LIMIT 1) AS unit_of_pressure,
+    (SELECT unit_of_temperature::text FROM settings LIMIT 1) AS unit_of_temperature
 FROM cars c
-LEFT JOIN car_settings cs ON c.id = cs.id
 LEFT JOIN positions p ON c.id = p.car_id AND p.date = (
     SELECT MAX(date) FROM positions p2 WHERE p2.car_id = c.id
 )
@@ -79,33 +132,7 @@ LEFT JOIN states s ON c.id = s.car_id AND s.start_date = (
     SELECT MAX(start_date) FROM states s2 WHERE s2.car_id = c.id
 )
 LEFT JOIN charging_processes cp ON c.id = cp.car_id AND cp.end_date IS NULL
-WHERE c.id = $1;
-
--- name: GetCarBasicInfo :one
-SELECT 
-    id,
-    name,
-    vin,
-    model
-FROM cars 
-WHERE id = $1;
-
--- name: IsCarConnectedToCharger :one
-SELECT 
-    CASE 
-        WHEN cp.id IS NOT NULL AND cp.end_date IS NULL THEN true
-        WHEN p.charge_port_door_open = true THEN true
-        ELSE false
-    END AS connected_to_charger,
-    CASE 
-        WHEN cp.id IS NOT NULL AND cp.end_date IS NULL THEN 'charging'
-        WHEN p.charge_port_door_open = true THEN 'plugged_in' 
-        ELSE 'disconnected'
-    END AS connection_status,
-    p.date AS last_update
-FROM cars c
-LEFT JOIN positions p ON c.id = p.car_id AND p.date = (
-    SELECT MAX(date) FROM positions p2 WHERE p2.car_id = c.id
+LEFT JOIN charges ch ON cp.id = ch.charging_process_id AND ch.date = (
+    SELECT MAX(date) FROM charges ch2 WHERE ch2.charging_process_id = cp.id
 )
-LEFT JOIN charging_processes cp ON c.id = cp.car_id AND cp.end_date IS NULL
-WHERE c.id = $1;
\ No newline at end of file
+WHERE c.id = $1;
@@ -0,0 +1,200 @@
+-- name: ListCharges :many
+SELECT
+    charging_processes.id AS charge_id,
+    charging_processes.start_date,
+    charging_processes.end_date,
+    COALESCE(geofence.name, CONCAT_WS(', ', COALESCE(address.name, NULLIF(CONCAT_WS(' ', address.road, address.house_number), '')), address.city))::text AS address,
+    COALESCE(charging_processes.charge_energy_added, 0) AS charge_energy_added,
+    COALESCE(charging_processes.charge_energy_used, 0) AS charge_energy_used,
+    COALESCE(charging_processes.cost, 0) AS cost,
+    charging_processes.start_ideal_range_km AS start_ideal_range,
+    charging_processes.end_ideal_range_km AS end_ideal_range,
+    charging_processes.start_rated_range_km AS start_rated_range,
+    charging_processes.end_rated_range_km AS end_rated_range,
+    charging_processes.start_battery_level,
+    charging_processes.end_battery_level,
+    charging_processes.duration_min,
+    TO_CHAR((charging_processes.duration_min * INTERVAL '1 minute'), 'HH24:MI') AS duration_str,
+    charging_processes.outside_temp_avg,
+    position.odometer,
+    position.latitude,
+    position.longitude,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    (SELECT unit_of_temperature::text FROM settings LIMIT 1) AS unit_of_temperature,
+    cars.name AS car_name
+FROM charging_processes
+LEFT JOIN cars ON charging_processes.car_id = cars.id
+LEFT JOIN addresses address ON charging_processes.address_id = address.id
+LEFT JOIN positions position ON charging_processes.position_id = position.id
+LEFT JOIN geofences geofence ON charging_processes.geofence_id = geofence.id
+WHERE charging_processes.car_id = @car_id
+    AND charging_processes.end_date IS NOT NULL
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR charging_processes.start_date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR charging_processes.end_date <= sqlc.narg('end_date'))
+ORDER BY charging_processes.start_date DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ExportCharges :many
+SELECT
+    charging_processes.id AS charge_id,
+    charging_processes.start_date,
+    charging_processes.end_date,
+    COALESCE(geofence.name, CONCAT_WS(', ', COALESCE(address.name, NULLIF(CONCAT_WS(' ', address.road, address.house_number), '')), address.city))::text AS address,
+    COALESCE(charging_processes.charge_energy_added, 0) AS charge_energy_added,
+    COALESCE(charging_processes.charge_energy_used, 0) AS charge_energy_used,
+    COALESCE(charging_processes.cost, 0) AS cost,
+    charging_processes.start_ideal_range_km AS start_ideal_range,
+    charging_processes.end_ideal_range_km AS end_ideal_range,
+    charging_processes.start_rated_range_km AS start_rated_range,
+    charging_processes.end_rated_range_km AS end_rated_range,
+    charging_processes.start_battery_level,
+    charging_processes.end_battery_level,
+    charging_processes.duration_min,
+    TO_CHAR((charging_processes.duration_min * INTERVAL '1 minute'), 'HH24:MI') AS duration_str,
+    charging_processes.outside_temp_avg,
+    position.odometer,
+    position.latitude,
+    position.longitude,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    (SELECT unit_of_temperature::text FROM settings LIMIT 1) AS unit_of_temperature,
+    cars.name AS car_name
+FROM charging_processes
+LEFT JOIN cars ON charging_processes.car_id = cars.id
+LEFT JOIN addresses address ON charging_processes.address_id = address.id
+LEFT JOIN positions position ON charging_processes.position_id = position.id
+LEFT JOIN geofences geofence ON charging_processes.geofence_id = geofence.id
+WHERE charging_processes.car_id = @car_id
+    AND charging_processes.end_date IS NOT NULL
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR charging_processes.start_date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR charging_processes.end_date <= sqlc.narg('end_date'))
+ORDER BY charging_processes.start_date ASC;
+
+-- name: GetCharge :one
+SELECT
+    charging_processes.id AS charge_id,
+    charging_processes.start_date,
+    charging_processes.end_date,
+    COALESCE(geofence.name, CONCAT_WS(', ', COALESCE(address.name, NULLIF(CONCAT_WS(' ', address.road, address.house_number), '')), address.city))::text AS address,
+    COALESCE(charging_processes.charge_energy_added, 0) AS charge_energy_added,
+    COALESCE(charging_processes.charge_energy_used, 0) AS charge_energy_used,
+    COALESCE(charging_processes.cost, 0) AS cost,
+    charging_processes.start_ideal_range_km AS start_ideal_range,
+    charging_processes.end_ideal_range_km AS end_ideal_range,
+    charging_processes.start_rated_range_km AS start_rated_range,
+    charging_processes.end_rated_range_km AS end_rated_range,
+    charging_processes.start_battery_level,
+    charging_processes.end_battery_level,
+    charging_processes.duration_min,
+    TO_CHAR((charging_processes.duration_min * INTERVAL '1 minute'), 'HH24:MI') AS duration_str,
+    charging_processes.outside_temp_avg,
+    position.odometer,
+    position.latitude,
+    position.longitude,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    (SELECT unit_of_temperature::text FROM settings LIMIT 1) AS unit_of_temperature,
+    cars.name AS car_name
+FROM charging_processes
+LEFT JOIN cars ON charging_processes.car_id = cars.id
+LEFT JOIN addresses address ON charging_processes.address_id = address.id
+LEFT JOIN positions position ON charging_processes.position_id = position.id
+LEFT JOIN geofences geofence ON charging_processes.geofence_id = geofence.id
+WHERE charging_processes.car_id = $1 AND charging_processes.id = $2 AND charging_processes.end_date IS NOT NULL;
+
+-- name: ListChargeDetails :many
+SELECT
+    id AS detail_id,
+    date,
+    battery_level,
+    usable_battery_level,
+    charge_energy_added,
+    not_enough_power_to_heat,
+    COALESCE(charger_actual_current, 0) AS charger_actual_current,
+    COALESCE(charger_phases, 0) AS charger_phases,
+    COALESCE(charger_pilot_current, 0) AS charger_pilot_current,
+    COALESCE(charger_power, 0) AS charger_power,
+    COALESCE(charger_voltage, 0) AS charger_voltage,
+    ideal_battery_range_km AS ideal_battery_range,
+    rated_battery_range_km AS rated_battery_range,
+    battery_heater,
+    battery_heater_on,
+    battery_heater_no_power,
+    conn_charge_cable,
+    fast_charger_present,
+    fast_charger_brand,
+    fast_charger_type,
+    outside_temp
+FROM charges
+WHERE charging_process_id = $1
+ORDER BY id ASC;
+
+-- name: ListChargeCurve :many
+SELECT
+    charges.battery_level::smallint AS battery_level,
+    COUNT(DISTINCT charges.charging_process_id) AS sessions,
+    COUNT(*) AS samples,
+    MIN(charges.charger_power)::float8 AS charger_power_min,
+    AVG(charges.charger_power)::float8 AS charger_power_avg,
+    MAX(charges.charger_power)::float8 AS charger_power_max,
+    COALESCE(MIN(charges.charger_actual_current), 0)::float8 AS charger_actual_current_min,
+    COALESCE(AVG(charges.charger_actual_current), 0)::float8 AS charger_actual_current_avg,
+    COALESCE(MAX(charges.charger_actual_current), 0)::float8 AS charger_actual_current_max,
+    COALESCE(MIN(charges.charger_voltage), 0)::float8 AS charger_voltage_min,
+    COALESCE(AVG(charges.charger_voltage), 0)::float8 AS charger_voltage_avg,
+    COALESCE(MAX(charges.charger_voltage), 0)::float8 AS charger_voltage_max
+FROM charges
+WHERE charges.charging_process_id = @charging_process_id
+    AND charges.battery_level IS NOT NULL
+    AND charges.charger_power > 0
+GROUP BY charges.battery_level
+ORDER BY charges.battery_level ASC;
+
+-- name: ListFastChargeCurve :many
+SELECT
+    charges.battery_level::smallint AS battery_level,
+    COUNT(DISTINCT charges.charging_process_id) AS sessions,
+    COUNT(*) AS samples,
+    MIN(charges.charger_power)::float8 AS charger_power_min,
+    AVG(charges.charger_power)::float8 AS charger_power_avg,
+    MAX(charges.charger_power)::float8 AS charger_power_max,
+    COALESCE(MIN(charges.charger_actual_current), 0)::float8 AS charger_actual_current_min,
+    COALESCE(AVG(charges.charger_actual_current), 0)::float8 AS charger_actual_current_avg,
+    COALESCE(MAX(charges.charger_actual_current), 0)::float8 AS charger_actual_current_max,
+    COALESCE(MIN(charges.charger_voltage), 0)::float8 AS charger_voltage_min,
+    COALESCE(AVG(charges.charger_voltage), 0)::float8 AS charger_voltage_avg,
+    COALESCE(MAX(charges.charger_voltage), 0)::float8 AS charger_voltage_max
+FROM charges
+INNER JOIN charging_processes ON charges.charging_process_id = charging_processes.id
+WHERE charging_processes.car_id = @car_id
+    AND charges.fast_charger_present = true
+    AND charges.fast_charger_type = @fast_charger_type
+    AND charges.battery_level IS NOT NULL
+    AND charges.charger_power > 0
+GROUP BY charges.battery_level
+ORDER BY charges.battery_level ASC;
+
+-- name: GetChargeTariffDetails :one
+SELECT
+    charging_processes.id AS charge_id,
+    charging_processes.start_date,
+    charging_processes.end_date,
+    charging_processes.geofence_id,
+    geofence.name AS geofence_name,
+    COALESCE(charging_processes.charge_energy_added, 0)::float8 AS charge_energy_added,
+    charging_processes.cost::float8 AS cost,
+    cars.name AS car_name
+FROM charging_processes
+LEFT JOIN cars ON charging_processes.car_id = cars.id
+LEFT JOIN geofences geofence ON charging_processes.geofence_id = geofence.id
+WHERE charging_processes.car_id = @car_id AND charging_processes.id = @id AND charging_processes.end_date IS NOT NULL;
+
+-- name: UpdateChargeCost :one
+UPDATE charging_processes
+SET cost = sqlc.narg('cost')
+FROM (
+    SELECT id, cost
+    FROM charging_processes
+    WHERE car_id = @car_id AND id = @id AND end_date IS NOT NULL
+    FOR UPDATE
+) old
+WHERE charging_processes.id = old.id
+RETURNING old.cost::float8 AS old_cost, charging_processes.cost::float8 AS new_cost;
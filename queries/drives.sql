@@ -0,0 +1,185 @@
+-- name: ListDrives :many
+SELECT
+    drives.id AS drive_id,
+    drives.start_date,
+    drives.end_date,
+    COALESCE(start_geofence.name, CONCAT_WS(', ', COALESCE(start_address.name, NULLIF(CONCAT_WS(' ', start_address.road, start_address.house_number), '')), start_address.city))::text AS start_address,
+    COALESCE(end_geofence.name, CONCAT_WS(', ', COALESCE(end_address.name, NULLIF(CONCAT_WS(' ', end_address.road, end_address.house_number), '')), end_address.city))::text AS end_address,
+    drives.start_km,
+    drives.end_km,
+    drives.distance,
+    drives.duration_min,
+    TO_CHAR((drives.duration_min * INTERVAL '1 minute'), 'HH24:MI') AS duration_str,
+    drives.speed_max,
+    COALESCE(drives.distance / NULLIF(drives.duration_min, 0) * 60, 0)::float8 AS speed_avg,
+    drives.power_max,
+    drives.power_min,
+    COALESCE(start_position.usable_battery_level, start_position.battery_level) AS start_usable_battery_level,
+    start_position.battery_level AS start_battery_level,
+    COALESCE(end_position.usable_battery_level, end_position.battery_level) AS end_usable_battery_level,
+    end_position.battery_level AS end_battery_level,
+    (CASE WHEN (start_position.battery_level != start_position.usable_battery_level OR end_position.battery_level != end_position.usable_battery_level) = true THEN true ELSE false END)::boolean AS reduced_range,
+    (drives.duration_min > 1 AND drives.distance > 1 AND (start_position.usable_battery_level IS NULL OR end_position.usable_battery_level IS NULL OR (end_position.battery_level - end_position.usable_battery_level) = 0))::boolean AS is_sufficiently_precise,
+    drives.start_ideal_range_km,
+    drives.end_ideal_range_km,
+    COALESCE(NULLIF(GREATEST(drives.start_ideal_range_km - drives.end_ideal_range_km, 0), 0), 0)::float8 AS range_diff_ideal_km,
+    drives.start_rated_range_km,
+    drives.end_rated_range_km,
+    COALESCE(NULLIF(GREATEST(drives.start_rated_range_km - drives.end_rated_range_km, 0), 0), 0)::float8 AS range_diff_rated_km,
+    drives.outside_temp_avg,
+    drives.inside_temp_avg,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    (SELECT unit_of_temperature::text FROM settings LIMIT 1) AS unit_of_temperature,
+    cars.name AS car_name
+FROM drives
+LEFT JOIN cars ON drives.car_id = cars.id
+LEFT JOIN addresses start_address ON drives.start_address_id = start_address.id
+LEFT JOIN addresses end_address ON drives.end_address_id = end_address.id
+LEFT JOIN positions start_position ON drives.start_position_id = start_position.id
+LEFT JOIN positions end_position ON drives.end_position_id = end_position.id
+LEFT JOIN geofences start_geofence ON drives.start_geofence_id = start_geofence.id
+LEFT JOIN geofences end_geofence ON drives.end_geofence_id = end_geofence.id
+WHERE drives.car_id = @car_id
+    AND drives.end_date IS NOT NULL
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR drives.start_date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR drives.end_date <= sqlc.narg('end_date'))
+ORDER BY drives.start_date DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ExportDrives :many
+SELECT
+    drives.id AS drive_id,
+    drives.start_date,
+    drives.end_date,
+    COALESCE(start_geofence.name, CONCAT_WS(', ', COALESCE(start_address.name, NULLIF(CONCAT_WS(' ', start_address.road, start_address.house_number), '')), start_address.city))::text AS start_address,
+    COALESCE(end_geofence.name, CONCAT_WS(', ', COALESCE(end_address.name, NULLIF(CONCAT_WS(' ', end_address.road, end_address.house_number), '')), end_address.city))::text AS end_address,
+    drives.start_km,
+    drives.end_km,
+    drives.distance,
+    drives.duration_min,
+    TO_CHAR((drives.duration_min * INTERVAL '1 minute'), 'HH24:MI') AS duration_str,
+    drives.speed_max,
+    COALESCE(drives.distance / NULLIF(drives.duration_min, 0) * 60, 0)::float8 AS speed_avg,
+    drives.power_max,
+    drives.power_min,
+    COALESCE(start_position.usable_battery_level, start_position.battery_level) AS start_usable_battery_level,
+    start_position.battery_level AS start_battery_level,
+    COALESCE(end_position.usable_battery_level, end_position.battery_level) AS end_usable_battery_level,
+    end_position.battery_level AS end_battery_level,
+    (CASE WHEN (start_position.battery_level != start_position.usable_battery_level OR end_position.battery_level != end_position.usable_battery_level) = true THEN true ELSE false END)::boolean AS reduced_range,
+    (drives.duration_min > 1 AND drives.distance > 1 AND (start_position.usable_battery_level IS NULL OR end_position.usable_battery_level IS NULL OR (end_position.battery_level - end_position.usable_battery_level) = 0))::boolean AS is_sufficiently_precise,
+    drives.start_ideal_range_km,
+    drives.end_ideal_range_km,
+    COALESCE(NULLIF(GREATEST(drives.start_ideal_range_km - drives.end_ideal_range_km, 0), 0), 0)::float8 AS range_diff_ideal_km,
+    drives.start_rated_range_km,
+    drives.end_rated_range_km,
+    COALESCE(NULLIF(GREATEST(drives.start_rated_range_km - drives.end_rated_range_km, 0), 0), 0)::float8 AS range_diff_rated_km,
+    drives.outside_temp_avg,
+    drives.inside_temp_avg,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    (SELECT unit_of_temperature::text FROM settings LIMIT 1) AS unit_of_temperature,
+    cars.name AS car_name
+FROM drives
+LEFT JOIN cars ON drives.car_id = cars.id
+LEFT JOIN addresses start_address ON drives.start_address_id = start_address.id
+LEFT JOIN addresses end_address ON drives.end_address_id = end_address.id
+LEFT JOIN positions start_position ON drives.start_position_id = start_position.id
+LEFT JOIN positions end_position ON drives.end_position_id = end_position.id
+LEFT JOIN geofences start_geofence ON drives.start_geofence_id = start_geofence.id
+LEFT JOIN geofences end_geofence ON drives.end_geofence_id = end_geofence.id
+WHERE drives.car_id = @car_id
+    AND drives.end_date IS NOT NULL
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR drives.start_date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR drives.end_date <= sqlc.narg('end_date'))
+ORDER BY drives.start_date ASC;
+
+-- name: GetDrive :one
+SELECT
+    drives.id AS drive_id,
+    drives.start_date,
+    drives.end_date,
+    COALESCE(start_geofence.name, CONCAT_WS(', ', COALESCE(start_address.name, NULLIF(CONCAT_WS(' ', start_address.road, start_address.house_number), '')), start_address.city))::text AS start_address,
+    COALESCE(end_geofence.name, CONCAT_WS(', ', COALESCE(end_address.name, NULLIF(CONCAT_WS(' ', end_address.road, end_address.house_number), '')), end_address.city))::text AS end_address,
+    drives.start_km,
+    drives.end_km,
+    drives.distance,
+    drives.duration_min,
+    TO_CHAR((drives.duration_min * INTERVAL '1 minute'), 'HH24:MI') AS duration_str,
+    drives.speed_max,
+    COALESCE(drives.distance / NULLIF(drives.duration_min, 0) * 60, 0)::float8 AS speed_avg,
+    drives.power_max,
+    drives.power_min,
+    COALESCE(start_position.usable_battery_level, start_position.battery_level) AS start_usable_battery_level,
+    start_position.battery_level AS start_battery_level,
+    COALESCE(end_position.usable_battery_level, end_position.battery_level) AS end_usable_battery_level,
+    end_position.battery_level AS end_battery_level,
+    (CASE WHEN (start_position.battery_level != start_position.usable_battery_level OR end_position.battery_level != end_position.usable_battery_level) = true THEN true ELSE false END)::boolean AS reduced_range,
+    (drives.duration_min > 1 AND drives.distance > 1 AND (start_position.usable_battery_level IS NULL OR end_position.usable_battery_level IS NULL OR (end_position.battery_level - end_position.usable_battery_level) = 0))::boolean AS is_sufficiently_precise,
+    drives.start_ideal_range_km,
+    drives.end_ideal_range_km,
+    COALESCE(NULLIF(GREATEST(drives.start_ideal_range_km - drives.end_ideal_range_km, 0), 0), 0)::float8 AS range_diff_ideal_km,
+    drives.start_rated_range_km,
+    drives.end_rated_range_km,
+    COALESCE(NULLIF(GREATEST(drives.start_rated_range_km - drives.end_rated_range_km, 0), 0), 0)::float8 AS range_diff_rated_km,
+    drives.outside_temp_avg,
+    drives.inside_temp_avg,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    (SELECT unit_of_temperature::text FROM settings LIMIT 1) AS unit_of_temperature,
+    cars.name AS car_name
+FROM drives
+LEFT JOIN cars ON drives.car_id = cars.id
+LEFT JOIN addresses start_address ON drives.start_address_id = start_address.id
+LEFT JOIN addresses end_address ON drives.end_address_id = end_address.id
+LEFT JOIN positions start_position ON drives.start_position_id = start_position.id
+LEFT JOIN positions end_position ON drives.end_position_id = end_position.id
+LEFT JOIN geofences start_geofence ON drives.start_geofence_id = start_geofence.id
+LEFT JOIN geofences end_geofence ON drives.end_geofence_id = end_geofence.id
+WHERE drives.car_id = $1 AND drives.end_date IS NOT NULL AND drives.id = $2;
+
+-- name: ListDrivePositions :many
+SELECT
+    id AS detail_id,
+    date,
+    latitude,
+    longitude,
+    COALESCE(speed, 0) AS speed,
+    power,
+    odometer,
+    battery_level,
+    usable_battery_level,
+    elevation,
+    inside_temp,
+    outside_temp,
+    is_climate_on,
+    fan_status,
+    driver_temp_setting,
+    passenger_temp_setting,
+    is_rear_defroster_on,
+    is_front_defroster_on,
+    est_battery_range_km,
+    ideal_battery_range_km,
+    rated_battery_range_km,
+    battery_heater,
+    battery_heater_on,
+    battery_heater_no_power
+FROM positions
+WHERE drive_id = $1
+ORDER BY id ASC;
+
+-- name: UpdateDriveGeofences :one
+UPDATE drives
+SET
+    start_geofence_id = CASE WHEN @set_start_geofence::boolean THEN sqlc.narg('start_geofence_id')::integer ELSE drives.start_geofence_id END,
+    end_geofence_id = CASE WHEN @set_end_geofence::boolean THEN sqlc.narg('end_geofence_id')::integer ELSE drives.end_geofence_id END
+FROM (
+    SELECT id, start_geofence_id, end_geofence_id
+    FROM drives
+    WHERE car_id = @car_id AND id = @id AND end_date IS NOT NULL
+    FOR UPDATE
+) old
+WHERE drives.id = old.id
+RETURNING
+    old.start_geofence_id AS old_start_geofence_id,
+    old.end_geofence_id AS old_end_geofence_id,
+    drives.start_geofence_id AS new_start_geofence_id,
+    drives.end_geofence_id AS new_end_geofence_id;
@@ -0,0 +1,174 @@
+-- name: GeofenceExists :one
+SELECT EXISTS (SELECT 1 FROM geofences WHERE id = $1);
+
+-- name: ListGeofences :many
+SELECT
+    id AS geofence_id,
+    name,
+    latitude::float8 AS latitude,
+    longitude::float8 AS longitude,
+    radius,
+    billing_type::text AS billing_type,
+    cost_per_unit::float8 AS cost_per_unit,
+    session_fee::float8 AS session_fee,
+    inserted_at,
+    updated_at
+FROM geofences
+ORDER BY name ASC, id ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: GetGeofence :one
+SELECT
+    id AS geofence_id,
+    name,
+    latitude::float8 AS latitude,
+    longitude::float8 AS longitude,
+    radius,
+    billing_type::text AS billing_type,
+    cost_per_unit::float8 AS cost_per_unit,
+    session_fee::float8 AS session_fee,
+    inserted_at,
+    updated_at
+FROM geofences
+WHERE id = @id;
+
+-- name: CreateGeofence :one
+INSERT INTO geofences (name, latitude, longitude, radius, billing_type, cost_per_unit, session_fee, inserted_at, updated_at)
+VALUES (@name, @latitude, @longitude, @radius, @billing_type::text::billing_type, sqlc.narg('cost_per_unit'), sqlc.narg('session_fee'), NOW() AT TIME ZONE 'UTC', NOW() AT TIME ZONE 'UTC')
+RETURNING
+    id AS geofence_id,
+    name,
+    latitude::float8 AS latitude,
+    longitude::float8 AS longitude,
+    radius,
+    billing_type::text AS billing_type,
+    cost_per_unit::float8 AS cost_per_unit,
+    session_fee::float8 AS session_fee,
+    inserted_at,
+    updated_at;
+
+-- name: UpdateGeofence :one
+UPDATE geofences
+SET
+    name = @name,
+    latitude = @latitude,
+    longitude = @longitude,
+    radius = @radius,
+    billing_type = @billing_type::text::billing_type,
+    cost_per_unit = sqlc.narg('cost_per_unit'),
+    session_fee = sqlc.narg('session_fee'),
+    updated_at = NOW() AT TIME ZONE 'UTC'
+FROM (
+    SELECT id, name, latitude, longitude, radius, billing_type, cost_per_unit, session_fee
+    FROM geofences
+    WHERE id = @id
+    FOR UPDATE
+) old
+WHERE geofences.id = old.id
+RETURNING
+    geofences.id AS geofence_id,
+    geofences.name,
+    geofences.latitude::float8 AS latitude,
+    geofences.longitude::float8 AS longitude,
+    geofences.radius,
+    geofences.billing_type::text AS billing_type,
+    geofences.cost_per_unit::float8 AS cost_per_unit,
+    geofences.session_fee::float8 AS session_fee,
+    geofences.inserted_at,
+    geofences.updated_at,
+    old.name AS old_name,
+    old.latitude::float8 AS old_latitude,
+    old.longitude::float8 AS old_longitude,
+    old.radius AS old_radius,
+    old.billing_type::text AS old_billing_type,
+    old.cost_per_unit::float8 AS old_cost_per_unit,
+    old.session_fee::float8 AS old_session_fee;
+
+-- name: DeleteGeofence :one
+DELETE FROM geofences
+WHERE id = @id
+RETURNING
+    id AS geofence_id,
+    name,
+    latitude::float8 AS latitude,
+    longitude::float8 AS longitude,
+    radius,
+    billing_type::text AS billing_type,
+    cost_per_unit::float8 AS cost_per_unit,
+    session_fee::float8 AS session_fee,
+    inserted_at,
+    updated_at;
+
+-- name: ListGeofenceDriveVisits :many
+SELECT
+    drives.id AS drive_id,
+    drives.car_id,
+    cars.name AS car_name,
+    drives.start_date,
+    drives.end_date,
+    drives.distance,
+    drives.duration_min,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length
+FROM drives
+INNER JOIN geofences ON geofences.id = @geofence_id
+LEFT JOIN cars ON drives.car_id = cars.id
+LEFT JOIN positions end_position ON drives.end_position_id = end_position.id
+WHERE drives.end_date IS NOT NULL
+    AND (
+        drives.end_geofence_id = geofences.id
+        OR 2 * 6371000 * ASIN(SQRT(
+            POWER(SIN(RADIANS(end_position.latitude - geofences.latitude) / 2), 2)
+            + COS(RADIANS(geofences.latitude)) * COS(RADIANS(end_position.latitude)) * POWER(SIN(RADIANS(end_position.longitude - geofences.longitude) / 2), 2)
+        )) <= geofences.radius
+    )
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR drives.start_date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR drives.end_date <= sqlc.narg('end_date'))
+ORDER BY drives.start_date DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListGeofenceChargeVisits :many
+SELECT
+    charging_processes.id AS charge_id,
+    charging_processes.car_id,
+    cars.name AS car_name,
+    charging_processes.start_date,
+    charging_processes.end_date,
+    COALESCE(charging_processes.charge_energy_added, 0)::float8 AS charge_energy_added,
+    charging_processes.cost::float8 AS cost,
+    charging_processes.duration_min
+FROM charging_processes
+INNER JOIN geofences ON geofences.id = @geofence_id
+LEFT JOIN cars ON charging_processes.car_id = cars.id
+LEFT JOIN positions position ON charging_processes.position_id = position.id
+WHERE charging_processes.end_date IS NOT NULL
+    AND (
+        charging_processes.geofence_id = geofences.id
+        OR 2 * 6371000 * ASIN(SQRT(
+            POWER(SIN(RADIANS(position.latitude - geofences.latitude) / 2), 2)
+            + COS(RADIANS(geofences.latitude)) * COS(RADIANS(position.latitude)) * POWER(SIN(RADIANS(position.longitude - geofences.longitude) / 2), 2)
+        )) <= geofences.radius
+    )
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR charging_processes.start_date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR charging_processes.end_date <= sqlc.narg('end_date'))
+ORDER BY charging_processes.start_date DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: ListGeofencesAtPosition :many
+SELECT
+    geofence_id,
+    name,
+    radius,
+    distance
+FROM (
+    SELECT
+        id AS geofence_id,
+        name,
+        radius,
+        (2 * 6371000 * ASIN(SQRT(
+            POWER(SIN(RADIANS(@latitude::float8 - latitude::float8) / 2), 2)
+            + COS(RADIANS(latitude::float8)) * COS(RADIANS(@latitude::float8)) * POWER(SIN(RADIANS(@longitude::float8 - longitude::float8) / 2), 2)
+        )))::float8 AS distance
+    FROM geofences
+) geofence_distances
+WHERE distance <= radius OR geofence_id = @home_geofence_id
+ORDER BY distance ASC, geofence_id ASC;
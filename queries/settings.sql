@@ -0,0 +1,13 @@
+-- name: GetSettings :one
+SELECT
+    id,
+    inserted_at,
+    updated_at,
+    unit_of_length::text AS unit_of_length,
+    unit_of_temperature::text AS unit_of_temperature,
+    preferred_range::text AS preferred_range,
+    language,
+    base_url,
+    grafana_url
+FROM settings
+LIMIT 1;
@@ -0,0 +1,49 @@
+-- name: ListStatistics :many
+WITH drive_stats AS (
+    SELECT
+        DATE_TRUNC(@period::text, drives.start_date AT TIME ZONE 'UTC' AT TIME ZONE @time_zone::text) AS period,
+        COUNT(*) AS drives,
+        SUM(drives.distance) AS distance_km,
+        SUM(drives.duration_min) AS duration_min,
+        SUM(GREATEST(drives.start_rated_range_km - drives.end_rated_range_km, 0) * cars.efficiency) AS energy_consumed,
+        SUM(drives.distance) FILTER (WHERE drives.start_rated_range_km IS NOT NULL AND drives.end_rated_range_km IS NOT NULL AND cars.efficiency IS NOT NULL) AS energy_consumed_distance_km,
+        AVG(drives.outside_temp_avg) AS outside_temp_avg
+    FROM drives
+    INNER JOIN cars ON drives.car_id = cars.id
+    WHERE drives.car_id = @car_id
+        AND drives.end_date IS NOT NULL
+        AND (sqlc.narg('start_date')::timestamp IS NULL OR drives.start_date >= sqlc.narg('start_date'))
+        AND (sqlc.narg('end_date')::timestamp IS NULL OR drives.end_date <= sqlc.narg('end_date'))
+    GROUP BY 1
+),
+charge_stats AS (
+    SELECT
+        DATE_TRUNC(@period::text, charging_processes.start_date AT TIME ZONE 'UTC' AT TIME ZONE @time_zone::text) AS period,
+        COUNT(*) AS charges,
+        SUM(charging_processes.charge_energy_added) AS energy_charged,
+        SUM(charging_processes.cost) AS cost
+    FROM charging_processes
+    WHERE charging_processes.car_id = @car_id
+        AND charging_processes.end_date IS NOT NULL
+        AND (sqlc.narg('start_date')::timestamp IS NULL OR charging_processes.start_date >= sqlc.narg('start_date'))
+        AND (sqlc.narg('end_date')::timestamp IS NULL OR charging_processes.end_date <= sqlc.narg('end_date'))
+    GROUP BY 1
+)
+SELECT
+    COALESCE(drive_stats.period, charge_stats.period)::timestamp AS period,
+    COALESCE(drive_stats.drives, 0)::bigint AS drives,
+    COALESCE(drive_stats.distance_km, 0)::float8 AS distance_km,
+    COALESCE(drive_stats.duration_min, 0)::bigint AS duration_min,
+    drive_stats.energy_consumed::float8 AS energy_consumed,
+    drive_stats.energy_consumed_distance_km::float8 AS energy_consumed_distance_km,
+    drive_stats.outside_temp_avg::float8 AS outside_temp_avg,
+    COALESCE(charge_stats.charges, 0)::bigint AS charges,
+    COALESCE(charge_stats.energy_charged, 0)::float8 AS energy_charged,
+    charge_stats.cost::float8 AS cost,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    (SELECT unit_of_temperature::text FROM settings LIMIT 1) AS unit_of_temperature,
+    (SELECT cars.name FROM cars WHERE cars.id = @car_id) AS car_name
+FROM drive_stats
+FULL OUTER JOIN charge_stats ON drive_stats.period = charge_stats.period
+ORDER BY 1 DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
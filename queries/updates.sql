@@ -0,0 +1,12 @@
+-- name: ListUpdates :many
+SELECT
+    updates.id,
+    cars.name AS car_name,
+    updates.start_date,
+    updates.end_date,
+    updates.version
+FROM updates
+LEFT JOIN cars ON updates.car_id = cars.id
+WHERE updates.car_id = $1
+ORDER BY updates.start_date DESC
+LIMIT $2 OFFSET $3;
@@ -0,0 +1,57 @@
+-- name: ListVampireDrain :many
+WITH idle AS (
+    SELECT
+        drives.end_date AS start_date,
+        LEAD(drives.start_date) OVER (ORDER BY drives.start_date) AS end_date,
+        drives.end_position_id AS start_position_id,
+        LEAD(drives.start_position_id) OVER (ORDER BY drives.start_date) AS end_position_id
+    FROM drives
+    WHERE drives.car_id = @car_id
+        AND drives.end_date IS NOT NULL
+)
+SELECT
+    idle.start_date::timestamp AS start_date,
+    idle.end_date::timestamp AS end_date,
+    EXTRACT(EPOCH FROM idle.end_date - idle.start_date)::float8 AS duration_sec,
+    start_position.ideal_battery_range_km AS start_ideal_range_km,
+    end_position.ideal_battery_range_km AS end_ideal_range_km,
+    start_position.rated_battery_range_km AS start_rated_range_km,
+    end_position.rated_battery_range_km AS end_rated_range_km,
+    start_position.battery_level AS start_battery_level,
+    end_position.battery_level AS end_battery_level,
+    cars.efficiency,
+    COALESCE((
+        SELECT SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(states.end_date, idle.end_date), idle.end_date) - GREATEST(states.start_date, idle.start_date)))
+        FROM states
+        WHERE states.car_id = @car_id
+            AND states.state = 'asleep'
+            AND states.start_date < idle.end_date
+            AND (states.end_date IS NULL OR states.end_date > idle.start_date)
+    ), 0)::float8 AS asleep_sec,
+    COALESCE((
+        SELECT SUM(EXTRACT(EPOCH FROM LEAST(COALESCE(states.end_date, idle.end_date), idle.end_date) - GREATEST(states.start_date, idle.start_date)))
+        FROM states
+        WHERE states.car_id = @car_id
+            AND states.state = 'online'
+            AND states.start_date < idle.end_date
+            AND (states.end_date IS NULL OR states.end_date > idle.start_date)
+    ), 0)::float8 AS online_sec,
+    (SELECT unit_of_length::text FROM settings LIMIT 1) AS unit_of_length,
+    cars.name AS car_name
+FROM idle
+INNER JOIN positions start_position ON idle.start_position_id = start_position.id
+INNER JOIN positions end_position ON idle.end_position_id = end_position.id
+INNER JOIN cars ON cars.id = @car_id
+WHERE idle.end_date IS NOT NULL
+    AND idle.end_date - idle.start_date >= sqlc.arg('min_duration_min')::integer * INTERVAL '1 minute'
+    AND NOT EXISTS (
+        SELECT 1
+        FROM charging_processes
+        WHERE charging_processes.car_id = @car_id
+            AND charging_processes.start_date < idle.end_date
+            AND (charging_processes.end_date IS NULL OR charging_processes.end_date > idle.start_date)
+    )
+    AND (sqlc.narg('start_date')::timestamp IS NULL OR idle.start_date >= sqlc.narg('start_date'))
+    AND (sqlc.narg('end_date')::timestamp IS NULL OR idle.end_date <= sqlc.narg('end_date'))
+ORDER BY idle.start_date DESC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
@@ -0,0 +1,219 @@
+-- Snapshot of the TeslaMate database schema, limited to the tables TeslaMateApi reads.
+-- It is only used by sqlc to type check queries/*.sql and is never applied to a database.
+-- Update it together with the queries when TeslaMate changes its migrations.
+
+CREATE TYPE billing_type AS ENUM ('per_kwh', 'per_minute');
+CREATE TYPE range AS ENUM ('ideal', 'rated');
+CREATE TYPE states_status AS ENUM ('online', 'offline', 'asleep');
+CREATE TYPE unit_of_length AS ENUM ('km', 'mi');
+CREATE TYPE unit_of_pressure AS ENUM ('bar', 'psi');
+CREATE TYPE unit_of_temperature AS ENUM ('C', 'F');
+
+CREATE TABLE addresses (
+    id serial PRIMARY KEY,
+    display_name varchar(512),
+    latitude numeric(8,6),
+    longitude numeric(9,6),
+    name varchar(255),
+    house_number varchar(255),
+    road varchar(255),
+    neighbourhood varchar(255),
+    city varchar(255),
+    county varchar(255),
+    postcode varchar(255),
+    state varchar(255),
+    state_district varchar(255),
+    country varchar(255),
+    raw jsonb,
+    inserted_at timestamp(0) NOT NULL,
+    updated_at timestamp(0) NOT NULL,
+    osm_id bigint,
+    osm_type text
+);
+
+CREATE TABLE car_settings (
+    id bigserial PRIMARY KEY,
+    suspend_min integer NOT NULL DEFAULT 21,
+    suspend_after_idle_min integer NOT NULL DEFAULT 15,
+    req_not_unlocked boolean NOT NULL DEFAULT false,
+    free_supercharging boolean NOT NULL DEFAULT false,
+    use_streaming_api boolean NOT NULL DEFAULT true,
+    enabled boolean NOT NULL DEFAULT true,
+    lfp_battery boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE cars (
+    id smallserial PRIMARY KEY,
+    eid bigint NOT NULL,
+    vid bigint NOT NULL,
+    model varchar(255),
+    efficiency double precision,
+    inserted_at timestamp(0) NOT NULL,
+    updated_at timestamp(0) NOT NULL,
+    vin text,
+    name text,
+    trim_badging text,
+    settings_id bigint NOT NULL REFERENCES car_settings (id),
+    exterior_color text,
+    spoiler_type text,
+    wheel_type text,
+    display_priority smallint NOT NULL DEFAULT 1,
+    marketing_name varchar(255)
+);
+
+CREATE TABLE geofences (
+    id serial PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    latitude numeric(8,6) NOT NULL,
+    longitude numeric(9,6) NOT NULL,
+    radius smallint NOT NULL DEFAULT 25,
+    inserted_at timestamp(0) NOT NULL,
+    updated_at timestamp(0) NOT NULL,
+    cost_per_unit numeric(6,4),
+    session_fee numeric(6,2),
+    billing_type billing_type NOT NULL DEFAULT 'per_kwh'
+);
+
+CREATE TABLE drives (
+    id serial PRIMARY KEY,
+    start_date timestamp NOT NULL,
+    end_date timestamp,
+    outside_temp_avg numeric(4,1),
+    speed_max smallint,
+    power_max smallint,
+    power_min smallint,
+    start_ideal_range_km numeric(6,2),
+    end_ideal_range_km numeric(6,2),
+    start_km double precision,
+    end_km double precision,
+    distance double precision,
+    duration_min smallint,
+    car_id smallint NOT NULL REFERENCES cars (id),
+    inside_temp_avg numeric(4,1),
+    start_address_id integer REFERENCES addresses (id),
+    end_address_id integer REFERENCES addresses (id),
+    start_rated_range_km numeric(6,2),
+    end_rated_range_km numeric(6,2),
+    start_position_id integer,
+    end_position_id integer,
+    start_geofence_id integer REFERENCES geofences (id),
+    end_geofence_id integer REFERENCES geofences (id),
+    ascent smallint,
+    descent smallint
+);
+
+CREATE TABLE positions (
+    id serial PRIMARY KEY,
+    date timestamp NOT NULL,
+    latitude numeric(8,6) NOT NULL,
+    longitude numeric(9,6) NOT NULL,
+    speed smallint,
+    power smallint,
+    odometer double precision,
+    ideal_battery_range_km numeric(6,2),
+    battery_level smallint,
+    outside_temp numeric(4,1),
+    elevation smallint,
+    fan_status integer,
+    driver_temp_setting numeric(4,1),
+    passenger_temp_setting numeric(4,1),
+    is_climate_on boolean,
+    is_rear_defroster_on boolean,
+    is_front_defroster_on boolean,
+    car_id smallint NOT NULL REFERENCES cars (id),
+    drive_id integer REFERENCES drives (id),
+    inside_temp numeric(4,1),
+    battery_heater boolean,
+    battery_heater_on boolean,
+    battery_heater_no_power boolean,
+    est_battery_range_km numeric(6,2),
+    rated_battery_range_km numeric(6,2),
+    usable_battery_level smallint,
+    tpms_pressure_fl numeric(4,1),
+    tpms_pressure_fr numeric(4,1),
+    tpms_pressure_rl numeric(4,1),
+    tpms_pressure_rr numeric(4,1)
+);
+
+CREATE TABLE charging_processes (
+    id serial PRIMARY KEY,
+    start_date timestamp NOT NULL,
+    end_date timestamp,
+    charge_energy_added numeric(8,2),
+    start_ideal_range_km numeric(6,2),
+    end_ideal_range_km numeric(6,2),
+    start_battery_level smallint,
+    end_battery_level smallint,
+    duration_min smallint,
+    outside_temp_avg numeric(4,1),
+    car_id smallint NOT NULL REFERENCES cars (id),
+    position_id integer NOT NULL REFERENCES positions (id),
+    address_id integer REFERENCES addresses (id),
+    start_rated_range_km numeric(6,2),
+    end_rated_range_km numeric(6,2),
+    geofence_id integer REFERENCES geofences (id),
+    charge_energy_used numeric(8,2),
+    cost numeric(6,2)
+);
+
+CREATE TABLE charges (
+    id serial PRIMARY KEY,
+    date timestamp NOT NULL,
+    battery_heater_on boolean,
+    battery_level smallint,
+    charge_energy_added numeric(8,2) NOT NULL,
+    charger_actual_current smallint,
+    charger_phases smallint,
+    charger_pilot_current smallint,
+    charger_power smallint NOT NULL,
+    charger_voltage smallint,
+    fast_charger_present boolean,
+    conn_charge_cable varchar(255),
+    fast_charger_brand varchar(255),
+    fast_charger_type varchar(255),
+    ideal_battery_range_km numeric(6,2),
+    not_enough_power_to_heat boolean,
+    outside_temp numeric(4,1),
+    charging_process_id integer NOT NULL REFERENCES charging_processes (id),
+    battery_heater boolean,
+    battery_heater_no_power boolean,
+    rated_battery_range_km numeric(6,2),
+    usable_battery_level smallint
+);
+
+CREATE TABLE settings (
+    id bigserial PRIMARY KEY,
+    inserted_at timestamp(0) NOT NULL,
+    updated_at timestamp(0) NOT NULL,
+    unit_of_length unit_of_length NOT NULL DEFAULT 'km',
+    unit_of_temperature unit_of_temperature NOT NULL DEFAULT 'C',
+    preferred_range range NOT NULL DEFAULT 'rated',
+    base_url varchar(255),
+    grafana_url varchar(255),
+    language text NOT NULL DEFAULT 'en',
+    unit_of_pressure unit_of_pressure NOT NULL DEFAULT 'bar'
+);
+
+CREATE TABLE states (
+    id serial PRIMARY KEY,
+    state states_status NOT NULL,
+    start_date timestamp NOT NULL,
+    end_date timestamp,
+    car_id smallint NOT NULL REFERENCES cars (id)
+);
+
+CREATE TABLE tokens (
+    id serial PRIMARY KEY,
+    refresh bytea,
+    access bytea,
+    inserted_at timestamp(0) NOT NULL,
+    updated_at timestamp(0) NOT NULL
+);
+
+CREATE TABLE updates (
+    id serial PRIMARY KEY,
+    start_date timestamp NOT NULL,
+    end_date timestamp,
+    version varchar(255),
+    car_id smallint NOT NULL REFERENCES cars (id)
+);
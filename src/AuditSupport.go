@@ -0,0 +1,88 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditLogMutex serializes the writes to AUDIT_LOG
+var auditLogMutex sync.Mutex
+
+// auditChange is the value of a field before and after an edit
+type auditChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// auditRecord is written for every edit of TeslaMate data
+type auditRecord struct {
+	Time       string                 `json:"time"`
+	RequestID  string                 `json:"request_id"`
+	Identity   string                 `json:"identity"` // name of the token, JWT or client certificate of the edit
+	RemoteAddr string                 `json:"remote_addr"`
+	Method     string                 `json:"method"`
+	Path       string                 `json:"path"`
+	CarID      int                    `json:"car_id"`
+	Resource   string                 `json:"resource"`
+	ResourceID int                    `json:"resource_id"`
+	Changes    map[string]auditChange `json:"changes"`
+}
+
+// writeAuditRecord func - logs an edit and appends it as json line to AUDIT_LOG if set
+func writeAuditRecord(c *gin.Context, carID int, resource string, resourceID int, changes map[string]auditChange) {
+	record := auditRecord{
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		RequestID:  c.GetString(requestIDKey),
+		Identity:   auditIdentity(c),
+		RemoteAddr: c.ClientIP(),
+		Method:     c.Request.Method,
+		Path:       c.Request.URL.Path,
+		CarID:      carID,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Changes:    changes,
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Println("[error] writeAuditRecord - unable to encode audit record:", err)
+		return
+	}
+	log.Println("[info] audit - " + string(line))
+
+	auditLogLocation := getEnv("AUDIT_LOG", "")
+	if auditLogLocation == "" {
+		return
+	}
+
+	auditLogMutex.Lock()
+	defer auditLogMutex.Unlock()
+	auditLogFile, err := os.OpenFile(auditLogLocation, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Println("[error] writeAuditRecord - unable to open AUDIT_LOG: " + auditLogLocation + ": " + err.Error())
+		return
+	}
+	defer auditLogFile.Close()
+	if _, err := auditLogFile.Write(append(line, '\n')); err != nil {
+		log.Println("[error] writeAuditRecord - unable to write AUDIT_LOG: " + auditLogLocation + ": " + err.Error())
+	}
+}
+
+// auditIdentity func - returns the name of the authenticated identity of a request, empty if there is none
+func auditIdentity(c *gin.Context) string {
+	if identity, ok := c.Get(identityKey); ok {
+		return identity.(*apiIdentity).Name
+	}
+	return ""
+}
+
+// auditValue func - returns the value of a nullable database value, nil if it's null
+func auditValue(v driver.Valuer) any {
+	value, _ := v.Value()
+	return value
+}
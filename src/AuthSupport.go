@@ -1,16 +1,132 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
 	"log"
+	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// gin context keys of the authenticated apiIdentity, or of the reason why the request isn't authenticated
+const (
+	identityKey      = "Identity"
+	identityErrorKey = "IdentityError"
+	queryTokenKey    = "QueryToken"
+)
+
+// scopes of API tokens, "*" allows everything and "<prefix>:*" all scopes with that prefix
+const (
+	scopeReadStatus     = "read:status"     // /cars and /status
+	scopeReadHistory    = "read:history"    // charges, drives, stats, battery health, vampire drain and updates
+	scopeReadGeofences  = "read:geofences"  // geofences and their visits
+	scopeReadSettings   = "read:settings"   // /globalsettings
+	scopeReadMetrics    = "read:metrics"    // /metrics
+	scopeWriteCharges   = "write:charges"   // edits and costs of charges
+	scopeWriteDrives    = "write:drives"    // edits of drives
+	scopeWriteGeofences = "write:geofences" // creating, replacing and deleting geofences
+	scopeLogging        = "logging"         // /logging
+	scopeAdmin          = "admin"           // /auth/lockouts
+	scopeCommandPrefix  = "command:"        // command:<group> of CommandList, like command:climate
+)
+
+// knownScopes are all scopes except the command scopes
+var knownScopes = []string{scopeReadStatus, scopeReadHistory, scopeReadGeofences, scopeReadSettings, scopeReadMetrics, scopeWriteCharges, scopeWriteDrives, scopeWriteGeofences, scopeLogging, scopeAdmin}
+
+// apiIdentity is the caller of a request with the scopes and cars it may access
+type apiIdentity struct {
+	Name   string
+	Scopes []string
+	CarIDs []int // all cars if empty
+}
+
+// fullAccessIdentity is used for API_TOKEN and API_TOKEN_DISABLE
+var fullAccessIdentity = &apiIdentity{Name: "API_TOKEN", Scopes: []string{"*"}}
+
+// hasScope func - checks if one of the scopes of the identity grants scope
+func (i *apiIdentity) hasScope(scope string) bool {
+	for _, s := range i.Scopes {
+		if s == "*" || s == scope || strings.HasSuffix(s, ":*") && strings.HasPrefix(scope, strings.TrimSuffix(s, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsCar func - checks if the identity may access a car
+func (i *apiIdentity) allowsCar(carID int) bool {
+	if len(i.CarIDs) == 0 {
+		return true
+	}
+	for _, id := range i.CarIDs {
+		if id == carID {
+			return true
+		}
+	}
+	return false
+}
+
+// validScope func - checks if a scope of a token is known
+func validScope(scope string) bool {
+	switch {
+	case scope == "*" || checkArrayContainsString(knownScopes, scope):
+		return true
+	case strings.HasSuffix(scope, ":*"):
+		prefix := strings.TrimSuffix(scope, "*")
+		if prefix == scopeCommandPrefix {
+			return true
+		}
+		for _, known := range knownScopes {
+			if strings.HasPrefix(known, prefix) {
+				return true
+			}
+		}
+	case strings.HasPrefix(scope, scopeCommandPrefix):
+		return len(scope) > len(scopeCommandPrefix)
+	}
+	return false
+}
+
+// hashedSecret is the salted sha256 of a token, tokens are never kept in plain text
+type hashedSecret struct {
+	salt []byte
+	sum  []byte
+}
+
+// newHashedSecret func - hashes a token with a random salt
+func newHashedSecret(token string) *hashedSecret {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		log.Panic(err)
+	}
+	salt = []byte(hex.EncodeToString(salt))
+	return &hashedSecret{salt: salt, sum: saltedSum(salt, token)}
+}
+
+// saltedSum func - returns the sha256 of the salt followed by the token
+func saltedSum(salt []byte, token string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(token))
+	return h.Sum(nil)
+}
+
+// matches func - compares a token with the secret in constant time
+func (s *hashedSecret) matches(token string) bool {
+	return subtle.ConstantTimeCompare(saltedSum(s.salt, token), s.sum) == 1
+}
+
 // initAuthToken func
 func initAuthToken() {
 	// get token from environment variable API_TOKEN
-	envToken = getEnv("API_TOKEN", "")
+	envToken := getEnv("API_TOKEN", "")
 	if envToken == "" {
 		log.Println("[warning] initAuthToken - environment variable API_TOKEN not set or is empty.")
 	} else if len(envToken) < 32 {
@@ -18,15 +134,59 @@ func initAuthToken() {
 	} else {
 		log.Println("[info] initAuthToken - environment variable API_TOKEN is set and good.")
 	}
+	setEnvToken(envToken)
+
+	if getEnvAsBool("API_TOKEN_QUERY_DISABLE", false) {
+		log.Println("[info] initAuthToken - token query parameter disabled, only the Authorization: Bearer header is accepted.")
+	}
+}
+
+// setEnvToken func - keeps the salted hash of the API_TOKEN, an empty token disables it
+func setEnvToken(token string) {
+	envTokenSecret = nil
+	if token != "" {
+		envTokenSecret = newHashedSecret(token)
+	}
 }
 
 // validateAuthToken func
 func validateAuthToken(c *gin.Context) (bool, string) {
+	identity, errorMessage := authenticate(c)
+	return identity != nil, errorMessage
+}
+
+// authenticate func - returns the identity of the request, or the reason why the request isn't authenticated,
+// invalid tokens are reported to the AuthLimiter of the request
+func authenticate(c *gin.Context) (*apiIdentity, string) {
+
+	// result of a previous check of this request
+	if identity, ok := c.Get(identityKey); ok {
+		return identity.(*apiIdentity), ""
+	}
+	if errorMessage, ok := c.Get(identityErrorKey); ok {
+		return nil, errorMessage.(string)
+	}
+
+	identity, errorMessage, checked := checkRequestToken(c)
+	if identity != nil {
+		c.Set(identityKey, identity)
+	} else {
+		c.Set(identityErrorKey, errorMessage)
+	}
+	if checked {
+		recordAuthentication(c, identity != nil)
+	}
+	return identity, errorMessage
+}
+
+// checkRequestToken func - returns the identity of the token of the request, checked is false if
+// there was no token to check
+func checkRequestToken(c *gin.Context) (identity *apiIdentity, errorMessage string, checked bool) {
 
-	// if API_TOKEN_DISABLE is true, skip token validation.
-	if getEnvAsBool("API_TOKEN_DISABLE", false) {
-		log.Println("[debug] validateAuthToken - header authorization bearer token disabled.")
-		return true, ""
+	// if API_TOKEN_DISABLE is true, skip token validation. It's ignored with API_TOKENS_FILE or JWT_JWKS,
+	// which need a token with the scope of every endpoint.
+	if getEnvAsBool("API_TOKEN_DISABLE", false) && !scopedAuthEnabled() {
+		return fullAccessIdentity, "", false
 	}
 
 	// trying with http header - Authorization: Bearer <token>
@@ -40,61 +200,185 @@ func validateAuthToken(c *gin.Context) (bool, string) {
 
 		if len(splitToken) != 2 {
 			// bearer token is not proper formatted.. returning bad request
-			log.Println("[info] validateAuthToken - header authorization bearer token is not proper formatted.. returning 401")
-			return false, "header authorization bearer token is not proper formatted"
+			return nil, "header authorization bearer token is not proper formatted", false
 
 		} else if strings.TrimSpace(splitToken[1]) == "" {
 			// bearer token is empty string.. we'll return unauthorized
-			log.Println("[info] validateAuthToken - header authorization bearer token is empty.. returning 401")
-			return false, "header authorization bearer token is empty"
+			return nil, "header authorization bearer token is empty", false
 
-		} else if checkAuthToken(strings.TrimSpace(splitToken[1])) {
+		} else if identity := checkAuthToken(c.Request.Context(), strings.TrimSpace(splitToken[1])); identity != nil {
 			// the bearer token is valid!
-			log.Println("[debug] validateAuthToken - header authorization bearer token valid.")
-			return true, ""
+			return identity, "", true
 
 		}
 		// the check did fail.. bearer token is invalid
-		log.Println("[info] validateAuthToken - header authorization bearer token invalid.. returning 401")
-		return false, "header authorization bearer token invalid"
+		return nil, "header authorization bearer token invalid", true
 	}
 
-	// trying with http parameter - ?token=<token>
-	tokenParamsValue := c.DefaultQuery("token", "")
+	// trying with http parameter - ?token=<token> (moved to the context by redactTokenQuery)
+	tokenParamsValue := c.GetString(queryTokenKey)
 
 	// if validTokenParams is longer than zero
 	if len(tokenParamsValue) > 0 {
 
+		// the token query parameter ends up in the logs of proxies, it can be disabled
+		if getEnvAsBool("API_TOKEN_QUERY_DISABLE", false) {
+			return nil, "param token disabled, use the header authorization bearer token", false
+		}
+
 		// checking if token is valid (since it's over zero length)
-		if checkAuthToken(tokenParamsValue) {
+		if identity := checkAuthToken(c.Request.Context(), tokenParamsValue); identity != nil {
 			// the token is valid!
-			log.Println("[debug] validateAuthToken - param token valid.")
-			return true, ""
+			return identity, "", true
 
 		}
 		// the token is invalid.
-		log.Println("[info] validateAuthToken - param token invalid.. returning 401")
-		return false, "param token invalid"
+		return nil, "param token invalid", true
+	}
+
+	// trying with the client certificate verified with TLS_CLIENT_CA_FILE
+	if c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) > 0 {
+		cert := c.Request.TLS.VerifiedChains[0][0]
+
+		// without API_TOKENS_FILE certificates of the CA only have full access like the API_TOKEN
+		// if TLS_CLIENT_CERT_FULL_ACCESS is set, there is no client_subject to map them to scopes
+		if tokenStore == nil {
+			if getEnvAsBool("TLS_CLIENT_CERT_FULL_ACCESS", false) {
+				return &apiIdentity{Name: "client:" + cert.Subject.CommonName, Scopes: []string{"*"}}, "", false
+			}
+			return nil, "client certificate has no client_subject of API_TOKENS_FILE", false
+		}
+		identity, errorMessage := tokenStore.LookupCertificate(cert)
+		return identity, errorMessage, false
 	}
 
 	// unauthozie all calls!
-	return false, "failed validation"
+	return nil, "failed validation", false
 }
 
-// checkAuthToken func
-func checkAuthToken(token string) bool {
-	// checking if it's valid or not
-	if token == envToken {
-		// check that envToken was longer than zero
-		if len(envToken) == 0 {
-			log.Println("[warning] checkAuthToken - returning false (API_TOKEN is not set or empty)")
-			return false
+// checkAuthToken func - returns the identity of API_TOKEN, of a token of the API_TOKENS_FILE or of a JWT
+func checkAuthToken(ctx context.Context, token string) *apiIdentity {
+	// checking the API_TOKEN, if it's set
+	if envTokenSecret != nil && envTokenSecret.matches(token) {
+		return fullAccessIdentity
+	}
+
+	// checking the JWTs of the identity provider of JWT_JWKS, a token that isn't a valid JWT can still be
+	// a token of API_TOKENS_FILE
+	if jwtVerifier != nil && looksLikeJWT(token) {
+		identity, err := jwtVerifier.Verify(ctx, token)
+		if err == nil {
+			return identity
 		}
-		log.Println("[info] checkAuthToken - returning true")
-		return true
+		log.Println("[info] checkAuthToken - jwt invalid: " + err.Error())
 	}
 
-	// failing check what so ever..
-	log.Println("[info] checkAuthToken - returning false (other reason)")
-	return false
+	// checking the tokens of API_TOKENS_FILE
+	if tokenStore != nil {
+		identity, _ := tokenStore.Lookup(token)
+		return identity
+	}
+	return nil
+}
+
+// scopedAuthEnabled func - checks if API_TOKENS_FILE or JWT_JWKS is set, every endpoint needs
+// a token with its scope then
+func scopedAuthEnabled() bool {
+	return tokenStore != nil || jwtVerifier != nil
+}
+
+// redactTokenQuery func - gin middleware that moves the token query parameter to the context,
+// so it doesn't show up in the logs of TeslaMateApi
+func redactTokenQuery(c *gin.Context) {
+	query := c.Request.URL.Query()
+	if token := query.Get("token"); token != "" {
+		c.Set(queryTokenKey, token)
+		query.Del("token")
+		c.Request.URL.RawQuery = query.Encode()
+		c.Request.RequestURI = c.Request.URL.RequestURI()
+	}
+	c.Next()
+}
+
+// requireIdentity func - gin middleware that authenticates every request if API_TOKENS_FILE or JWT_JWKS
+// is set and aborts if the identity may not access :CarID
+func requireIdentity(c *gin.Context) {
+	if !scopedAuthEnabled() {
+		c.Next()
+		return
+	}
+
+	identity, errorMessage := authenticate(c)
+	if identity == nil {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusUnauthorized, "requireIdentity", errorMessage, "invalid or missing bearer token")
+		c.Abort()
+		return
+	}
+
+	if ParamCarID := c.Param("CarID"); ParamCarID != "" {
+		CarID, err := strconv.Atoi(ParamCarID)
+		if err == nil && !identity.allowsCar(CarID) {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, "requireIdentity", "You are not allowed to access this car", fmt.Sprintf("token %s is not allowed to access car %d", identity.Name, CarID))
+			c.Abort()
+			return
+		}
+	}
+	c.Next()
+}
+
+// requireScope func - returns a gin middleware that aborts if API_TOKENS_FILE or JWT_JWKS is set and
+// the identity of the request doesn't have scope, requireIdentity has to run before
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !scopedAuthEnabled() {
+			c.Next()
+			return
+		}
+
+		identity, errorMessage := authenticate(c)
+		if identity == nil {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusUnauthorized, "requireScope", errorMessage, "invalid or missing bearer token")
+			c.Abort()
+			return
+		}
+		if !identity.hasScope(scope) {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, "requireScope", "You are not allowed to access this endpoint", fmt.Sprintf("token %s is missing scope %s", identity.Name, scope))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireAllCars func - gin middleware that aborts if the identity of the request is restricted to
+// some cars, used by endpoints returning data of all cars
+func requireAllCars(c *gin.Context) {
+	if identity, ok := c.Get(identityKey); ok && len(identity.(*apiIdentity).CarIDs) > 0 {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, "requireAllCars", "You are not allowed to access data of all cars", "token "+identity.(*apiIdentity).Name+" is restricted to some cars")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// identityAllowsCar func - checks if the identity of the request may access a car, true without identity
+func identityAllowsCar(c *gin.Context, carID int) bool {
+	if identity, ok := c.Get(identityKey); ok {
+		return identity.(*apiIdentity).allowsCar(carID)
+	}
+	return true
+}
+
+// checkCommandScope func - aborts with 403 if the identity of the request may not run command
+func checkCommandScope(c *gin.Context, s1 string, command string) bool {
+	identity, ok := c.Get(identityKey)
+	if !ok {
+		return true
+	}
+	scope := commandScope(command)
+	if !identity.(*apiIdentity).hasScope(scope) {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, s1, "You are not allowed to run this command", fmt.Sprintf("token %s is missing scope %s", identity.(*apiIdentity).Name, scope))
+		return false
+	}
+	return true
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// clockWindowWeekdays are the values allowed in the days of a clock window
+var clockWindowWeekdays = map[string]time.Weekday{"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday}
+
+// clockWindow is a time window repeating on some days, from and to are HH:MM in TZ and the window wraps past midnight if to is before from
+type clockWindow struct {
+	Days []string `json:"days,omitempty"` // mon, tue, .. sun, every day if empty
+	From string   `json:"from"`           // HH:MM, inclusive
+	To   string   `json:"to"`             // HH:MM, exclusive
+
+	days     [7]bool
+	from, to int // minutes since midnight
+}
+
+// parse func - validates the days, from and to of the window
+func (w *clockWindow) parse() error {
+	var err error
+	if w.from, err = parseClock(w.From); err != nil {
+		return fmt.Errorf("invalid from %q", w.From)
+	}
+	if w.to, err = parseClock(w.To); err != nil {
+		return fmt.Errorf("invalid to %q", w.To)
+	}
+	w.days = [7]bool{}
+	for _, day := range w.Days {
+		weekday, ok := clockWindowWeekdays[strings.ToLower(day)]
+		if !ok {
+			return fmt.Errorf("invalid day %q", day)
+		}
+		w.days[weekday] = true
+	}
+	if len(w.Days) == 0 {
+		w.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	return nil
+}
+
+// contains func - checks if a time in loc is in the window
+func (w *clockWindow) contains(at time.Time, loc *time.Location) bool {
+	local := at.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	// the part after midnight of a window wrapping past midnight belongs to the day the window began
+	weekday := local.Weekday()
+	if w.from > w.to && minute < w.to {
+		weekday = (weekday + 6) % 7
+	}
+	if !w.days[weekday] {
+		return false
+	}
+	return w.from == w.to || (w.from < w.to && minute >= w.from && minute < w.to) || (w.from > w.to && (minute >= w.from || minute < w.to))
+}
+
+// parseClock func - returns the minutes since midnight of HH:MM
+func parseClock(s string) (int, error) {
+	clock, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockWindow(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	overnight := clockWindow{Days: []string{"Fri"}, From: "22:00", To: "06:00"}
+	if err := overnight.parse(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	allDay := clockWindow{From: "00:00", To: "00:00"}
+	if err := allDay.parse(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		window   *clockWindow
+		at       time.Time
+		expected bool
+	}{
+		{&overnight, time.Date(2024, 5, 3, 22, 0, 0, 0, loc), true},      // friday at from
+		{&overnight, time.Date(2024, 5, 4, 5, 59, 0, 0, loc), true},      // saturday morning belongs to friday
+		{&overnight, time.Date(2024, 5, 4, 6, 0, 0, 0, loc), false},      // to is exclusive
+		{&overnight, time.Date(2024, 5, 3, 5, 0, 0, 0, loc), false},      // friday morning belongs to thursday
+		{&overnight, time.Date(2024, 5, 3, 20, 0, 0, 0, time.UTC), true}, // 22:00 in loc
+		{&allDay, time.Date(2024, 5, 5, 12, 0, 0, 0, loc), true},
+	}
+	for _, test := range tests {
+		if got := test.window.contains(test.at, loc); got != test.expected {
+			t.Errorf("Expected %s-%s to contain %s: %v, got %v", test.window.From, test.window.To, test.at, test.expected, got)
+		}
+	}
+
+	for _, invalid := range []clockWindow{{From: "24:00", To: "06:00"}, {From: "22:00", To: "6"}, {Days: []string{"friday"}, From: "22:00", To: "06:00"}} {
+		if err := invalid.parse(); err == nil {
+			t.Errorf("Expected an error for %+v", invalid)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CarRegionAPI string
+
+const (
+	ChinaAPI  CarRegionAPI = "China"
+	GlobalAPI CarRegionAPI = "Global"
+)
+
+// names of the command backends used in TESLA_COMMAND_BACKEND and TESLA_COMMAND_BACKENDS
+const (
+	commandBackendOwner = "owner"
+	commandBackendFleet = "fleet"
+	commandBackendProxy = "proxy"
+)
+
+// commandTimeout limits a command, signed commands of the proxy wait for the vehicle to answer
+const commandTimeout = 60 * time.Second
+
+// fleetAPIBaseURLs are the regional base URLs of the Fleet API
+var fleetAPIBaseURLs = map[string]string{
+	"na": "https://fleet-api.prd.na.vn.cloud.tesla.com",
+	"eu": "https://fleet-api.prd.eu.vn.cloud.tesla.com",
+	"cn": "https://fleet-api.prd.cn.vn.cloud.tesla.cn",
+}
+
+// CommandVehicle is the vehicle a command is sent to
+type CommandVehicle struct {
+	CarID       int
+	ID          string // id of the vehicle at Tesla, eid in TeslaMate
+	VIN         string
+	AccessToken string // decrypted access token of TeslaMate
+}
+
+// CommandBackend sends commands to a vehicle, the response of Tesla is returned to the client as is
+type CommandBackend interface {
+	Name() string
+	Send(ctx context.Context, vehicle CommandVehicle, command string, body []byte) (*http.Response, error)
+}
+
+var (
+	// commandBackends by name, the owner backend is always available
+	commandBackends = map[string]CommandBackend{commandBackendOwner: NewOwnerAPIBackend("", nil)}
+	// defaultCommandBackend is used for cars not in carCommandBackends
+	defaultCommandBackend = commandBackendOwner
+	// carCommandBackends holds the backend of single cars by CarID
+	carCommandBackends = map[int]string{}
+)
+
+// initCommandBackends func
+func initCommandBackends() {
+	client := &http.Client{Timeout: commandTimeout}
+	fleetToken := getEnv("TESLA_FLEET_API_TOKEN", "")
+	fleetRegion := strings.ToLower(getEnv("TESLA_FLEET_API_REGION", ""))
+	if _, ok := fleetAPIBaseURLs[fleetRegion]; fleetRegion != "" && !ok {
+		log.Println("[error] initCommandBackends - TESLA_FLEET_API_REGION has to be na, eu or cn, it will be ignored: " + fleetRegion)
+		fleetRegion = ""
+	}
+
+	if teslaApiHost := getEnv("TESLA_API_HOST", ""); teslaApiHost != "" {
+		log.Printf("[info] TESLA_API_HOST is set: %s", teslaApiHost)
+	}
+	commandBackends = map[string]CommandBackend{
+		commandBackendOwner: NewOwnerAPIBackend(getEnv("TESLA_API_HOST", ""), client),
+		commandBackendFleet: NewFleetAPIBackend(getEnv("TESLA_FLEET_API_HOST", ""), fleetRegion, fleetToken, client),
+	}
+
+	// the proxy is only available with TESLA_HTTP_PROXY_URL
+	if proxyURL := getEnv("TESLA_HTTP_PROXY_URL", ""); proxyURL != "" {
+		proxyClient, err := newProxyClient(getEnv("TESLA_HTTP_PROXY_CA_FILE", ""))
+		if err != nil {
+			log.Println("[error] initCommandBackends error with TESLA_HTTP_PROXY_CA_FILE, the proxy backend is not available: " + err.Error())
+		} else {
+			commandBackends[commandBackendProxy] = NewProxyBackend(proxyURL, fleetToken, proxyClient)
+		}
+	}
+
+	defaultCommandBackend = getEnv("TESLA_COMMAND_BACKEND", commandBackendOwner)
+	if _, ok := commandBackends[defaultCommandBackend]; !ok {
+		log.Println("[error] initCommandBackends - TESLA_COMMAND_BACKEND " + defaultCommandBackend + " is not available, using " + commandBackendOwner)
+		defaultCommandBackend = commandBackendOwner
+	}
+
+	carCommandBackends = map[int]string{}
+	if value := getEnv("TESLA_COMMAND_BACKENDS", ""); value != "" {
+		var backends map[string]string
+		if err := json.Unmarshal([]byte(value), &backends); err != nil {
+			log.Println("[error] initCommandBackends - TESLA_COMMAND_BACKENDS has to be a json object of car ids with their backend, ignoring it: " + err.Error())
+		}
+		for car, backend := range backends {
+			carID, err := strconv.Atoi(car)
+			if err != nil || carID <= 0 {
+				log.Printf("[error] initCommandBackends - invalid car id %q in TESLA_COMMAND_BACKENDS, ignoring it", car)
+				continue
+			}
+			if _, ok := commandBackends[backend]; !ok {
+				log.Printf("[error] initCommandBackends - backend %q of car %d in TESLA_COMMAND_BACKENDS is not available, using %s", backend, carID, defaultCommandBackend)
+				continue
+			}
+			carCommandBackends[carID] = backend
+		}
+	}
+
+	log.Printf("[info] initCommandBackends - sending commands with the %s backend, %d cars with their own backend", defaultCommandBackend, len(carCommandBackends))
+}
+
+// commandBackendForCar func - returns the backend of TESLA_COMMAND_BACKENDS for a car, TESLA_COMMAND_BACKEND otherwise
+func commandBackendForCar(carID int) CommandBackend {
+	if backend, ok := commandBackends[carCommandBackends[carID]]; ok {
+		return backend
+	}
+	if backend, ok := commandBackends[defaultCommandBackend]; ok {
+		return backend
+	}
+	return commandBackends[commandBackendOwner]
+}
+
+// OwnerAPIBackend sends commands to the legacy Owner API, newer vehicles reject most of them without signing
+type OwnerAPIBackend struct {
+	host   string // TESLA_API_HOST, the host is retrieved by the access token if empty
+	client *http.Client
+}
+
+func NewOwnerAPIBackend(host string, client *http.Client) *OwnerAPIBackend {
+	if client == nil {
+		client = &http.Client{Timeout: commandTimeout}
+	}
+	return &OwnerAPIBackend{host: host, client: client}
+}
+
+func (b *OwnerAPIBackend) Name() string {
+	return commandBackendOwner
+}
+
+func (b *OwnerAPIBackend) Send(ctx context.Context, vehicle CommandVehicle, command string, body []byte) (*http.Response, error) {
+	host := b.host
+	if host == "" {
+		switch getCarRegionAPI(vehicle.AccessToken) {
+		case ChinaAPI:
+			host = "https://owner-api.vn.cloud.tesla.cn"
+		default:
+			host = "https://owner-api.teslamotors.com"
+		}
+	}
+	return postCommand(ctx, b.client, host+"/api/1/vehicles/"+vehicle.ID+command, vehicle.AccessToken, body)
+}
+
+// FleetAPIBackend sends commands to the Fleet API of the region of the vehicle
+type FleetAPIBackend struct {
+	host   string // TESLA_FLEET_API_HOST, the regional base URL is used if empty
+	region string // TESLA_FLEET_API_REGION, retrieved by the access token if empty
+	token  string // TESLA_FLEET_API_TOKEN, the access token of TeslaMate is used if empty
+	client *http.Client
+}
+
+func NewFleetAPIBackend(host string, region string, token string, client *http.Client) *FleetAPIBackend {
+	return &FleetAPIBackend{host: host, region: region, token: token, client: client}
+}
+
+func (b *FleetAPIBackend) Name() string {
+	return commandBackendFleet
+}
+
+func (b *FleetAPIBackend) Send(ctx context.Context, vehicle CommandVehicle, command string, body []byte) (*http.Response, error) {
+	token := fleetAPIToken(b.token, vehicle)
+	host := b.host
+	if host == "" {
+		region := b.region
+		if region == "" {
+			region = fleetAPIRegion(token)
+		}
+		host = fleetAPIBaseURLs[region]
+	}
+
+	// the Fleet API accepts the VIN or the id of the vehicle
+	tag := vehicle.VIN
+	if tag == "" {
+		tag = vehicle.ID
+	}
+	return postCommand(ctx, b.client, host+"/api/1/vehicles/"+tag+command, token, body)
+}
+
+// ProxyBackend sends commands to a tesla-http-proxy, which signs them with the key of the vehicle
+// and forwards them to the Fleet API
+type ProxyBackend struct {
+	url    string // TESLA_HTTP_PROXY_URL
+	token  string // TESLA_FLEET_API_TOKEN, the access token of TeslaMate is used if empty
+	client *http.Client
+}
+
+func NewProxyBackend(url string, token string, client *http.Client) *ProxyBackend {
+	return &ProxyBackend{url: strings.TrimSuffix(url, "/"), token: token, client: client}
+}
+
+func (b *ProxyBackend) Name() string {
+	return commandBackendProxy
+}
+
+func (b *ProxyBackend) Send(ctx context.Context, vehicle CommandVehicle, command string, body []byte) (*http.Response, error) {
+	// the proxy needs the VIN to sign commands
+	if vehicle.VIN == "" {
+		return nil, errors.New("the proxy backend needs the VIN of the vehicle")
+	}
+	return postCommand(ctx, b.client, b.url+"/api/1/vehicles/"+vehicle.VIN+command, fleetAPIToken(b.token, vehicle), body)
+}
+
+// newProxyClient func - returns the http client of the proxy, trusting the certificates of caFile besides the system ones
+func newProxyClient(caFile string) (*http.Client, error) {
+	client := &http.Client{Timeout: commandTimeout}
+	if caFile == "" {
+		return client, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, errors.New(caFile + " contains no PEM certificates")
+	}
+	client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}}
+	return client, nil
+}
+
+// postCommand func - posts a command with the access token to Tesla
+func postCommand(ctx context.Context, client *http.Client, url string, accessToken string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "TeslaMateApi/"+apiVersion+" (+https://github.com/tobiasehlert/teslamateapi)")
+	return client.Do(req)
+}
+
+// fleetAPIToken func - returns the token of TESLA_FLEET_API_TOKEN, the access token of TeslaMate if it's not set
+func fleetAPIToken(token string, vehicle CommandVehicle) string {
+	if token != "" {
+		return token
+	}
+	return vehicle.AccessToken
+}
+
+// fleetAPIRegion func - returns the Fleet API region of an access token by its issuer and ou_code, na by default
+func fleetAPIRegion(accessToken string) string {
+	if getCarRegionAPI(accessToken) == ChinaAPI {
+		return "cn"
+	}
+	if ouCode, ok := accessTokenClaims(accessToken)["ou_code"].(string); ok {
+		if region := strings.ToLower(ouCode); fleetAPIBaseURLs[region] != "" {
+			return region
+		}
+	}
+	return "na"
+}
+
+// getCarRegionAPI function to get URL from iis in accessToken
+func getCarRegionAPI(accessToken string) CarRegionAPI {
+	iss, ok := accessTokenClaims(accessToken)["iss"].(string)
+	if !ok {
+		return GlobalAPI
+	}
+	issUrl, err := url.Parse(iss)
+	if err != nil {
+		return GlobalAPI
+	}
+	if strings.HasSuffix(issUrl.Host, ".cn") {
+		return ChinaAPI
+	}
+	return GlobalAPI
+}
+
+// accessTokenClaims func - returns the claims of a JWT access token without verifying it, nil if it's no JWT
+func accessTokenClaims(accessToken string) map[string]interface{} {
+	payload := strings.Split(accessToken, ".")
+	if len(payload) != 3 {
+		return nil
+	}
+	decodedStr, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(payload[1], "="))
+	if err != nil {
+		return nil
+	}
+	var result map[string]interface{}
+	if err = json.Unmarshal(decodedStr, &result); err != nil {
+		return nil
+	}
+	return result
+}
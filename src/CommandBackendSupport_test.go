@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// unsignedJWT returns a JWT with claims like the access tokens of Tesla, the signature isn't checked
+func unsignedJWT(claims map[string]interface{}) string {
+	payload, _ := json.Marshal(claims)
+	return "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".c2lnbmF0dXJl"
+}
+
+// commandRequest is a command received by a test server
+type commandRequest struct {
+	path          string
+	authorization string
+	body          string
+}
+
+func newCommandServer(t *testing.T, tls bool) (*httptest.Server, *commandRequest) {
+	t.Helper()
+	received := &commandRequest{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*received = commandRequest{path: r.URL.Path, authorization: r.Header.Get("Authorization"), body: string(body)}
+		_, _ = w.Write([]byte(`{"response":{"result":true,"reason":""}}`))
+	})
+	server := httptest.NewUnstartedServer(handler)
+	if tls {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestAccessTokenRegion(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		region CarRegionAPI
+		fleet  string
+	}{
+		{"global token", unsignedJWT(map[string]interface{}{"iss": "https://auth.tesla.com/oauth2/v3"}), GlobalAPI, "na"},
+		{"china token", unsignedJWT(map[string]interface{}{"iss": "https://auth.tesla.cn/oauth2/v3"}), ChinaAPI, "cn"},
+		{"european fleet token", unsignedJWT(map[string]interface{}{"iss": "https://auth.tesla.com/oauth2/v3/nts", "ou_code": "EU"}), GlobalAPI, "eu"},
+		{"issuer without string", unsignedJWT(map[string]interface{}{"iss": 1}), GlobalAPI, "na"},
+		{"opaque token", "qts-0123456789abcdef", GlobalAPI, "na"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if region := getCarRegionAPI(tt.token); region != tt.region {
+				t.Errorf("Expected region %s, got %s", tt.region, region)
+			}
+			if region := fleetAPIRegion(tt.token); region != tt.fleet {
+				t.Errorf("Expected Fleet API region %s, got %s", tt.fleet, region)
+			}
+		})
+	}
+}
+
+func TestCommandBackends(t *testing.T) {
+	ctx := context.Background()
+	vehicle := CommandVehicle{CarID: 1, ID: "1492931520123456", VIN: "5YJ3E7EA1KF000001", AccessToken: "teslamate-token"}
+	body := []byte(`{"percent":80}`)
+
+	t.Run("owner api", func(t *testing.T) {
+		server, received := newCommandServer(t, false)
+		resp, err := NewOwnerAPIBackend(server.URL, nil).Send(ctx, vehicle, "/command/set_charge_limit", body)
+		if err != nil {
+			t.Fatalf("Expected command to be sent, got %v", err)
+		}
+		resp.Body.Close()
+		if received.path != "/api/1/vehicles/1492931520123456/command/set_charge_limit" || received.authorization != "Bearer teslamate-token" || received.body != string(body) {
+			t.Errorf("Expected command with id and access token of TeslaMate, got %+v", received)
+		}
+	})
+
+	t.Run("fleet api", func(t *testing.T) {
+		server, received := newCommandServer(t, false)
+		resp, err := NewFleetAPIBackend(server.URL, "", "fleet-token", server.Client()).Send(ctx, vehicle, "/wake_up", nil)
+		if err != nil {
+			t.Fatalf("Expected command to be sent, got %v", err)
+		}
+		resp.Body.Close()
+		if received.path != "/api/1/vehicles/5YJ3E7EA1KF000001/wake_up" || received.authorization != "Bearer fleet-token" {
+			t.Errorf("Expected command with VIN and TESLA_FLEET_API_TOKEN, got %+v", received)
+		}
+	})
+
+	t.Run("http proxy", func(t *testing.T) {
+		server, received := newCommandServer(t, true)
+		caFile := filepath.Join(t.TempDir(), "proxy.crt")
+		if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600); err != nil {
+			t.Fatalf("Failed to write certificate: %v", err)
+		}
+		client, err := newProxyClient(caFile)
+		if err != nil {
+			t.Fatalf("Expected proxy client, got %v", err)
+		}
+
+		backend := NewProxyBackend(server.URL+"/", "", client)
+		resp, err := backend.Send(ctx, vehicle, "/command/door_lock", nil)
+		if err != nil {
+			t.Fatalf("Expected command to be sent with the CA of TESLA_HTTP_PROXY_CA_FILE, got %v", err)
+		}
+		resp.Body.Close()
+		if received.path != "/api/1/vehicles/5YJ3E7EA1KF000001/command/door_lock" || received.authorization != "Bearer teslamate-token" {
+			t.Errorf("Expected command with VIN and access token of TeslaMate, got %+v", received)
+		}
+
+		if _, err := backend.Send(ctx, CommandVehicle{ID: vehicle.ID, AccessToken: vehicle.AccessToken}, "/command/door_lock", nil); err == nil {
+			t.Error("Expected error for a vehicle without VIN")
+		}
+		if _, err := NewProxyBackend(server.URL, "", &http.Client{}).Send(ctx, vehicle, "/command/door_lock", nil); err == nil {
+			t.Error("Expected error for a proxy certificate that isn't trusted")
+		}
+	})
+}
+
+func TestInitCommandBackends(t *testing.T) {
+	originalBackends, originalDefault, originalCars := commandBackends, defaultCommandBackend, carCommandBackends
+	defer func() {
+		commandBackends, defaultCommandBackend, carCommandBackends = originalBackends, originalDefault, originalCars
+	}()
+
+	t.Setenv("TESLA_HTTP_PROXY_URL", "https://localhost:4443")
+	t.Setenv("TESLA_COMMAND_BACKEND", "fleet")
+	t.Setenv("TESLA_COMMAND_BACKENDS", `{"1": "proxy", "2": "owner", "3": "unknown", "x": "proxy"}`)
+	initCommandBackends()
+
+	for carID, expected := range map[int]string{1: commandBackendProxy, 2: commandBackendOwner, 3: commandBackendFleet, 4: commandBackendFleet} {
+		if backend := commandBackendForCar(carID); backend.Name() != expected {
+			t.Errorf("Expected %s backend for car %d, got %s", expected, carID, backend.Name())
+		}
+	}
+
+	// the proxy isn't available without TESLA_HTTP_PROXY_URL, the owner backend is used instead
+	t.Setenv("TESLA_HTTP_PROXY_URL", "")
+	t.Setenv("TESLA_COMMAND_BACKEND", "proxy")
+	t.Setenv("TESLA_COMMAND_BACKENDS", "")
+	initCommandBackends()
+	if backend := commandBackendForCar(1); backend.Name() != commandBackendOwner {
+		t.Errorf("Expected owner backend, got %s", backend.Name())
+	}
+}
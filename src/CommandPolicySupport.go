@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// commandAllowList holds the allowed commands of the COMMANDS_* groups or of COMMANDS_ALLOWLIST
+var commandAllowList = NewStaticCommandAllowList(nil)
+
+// CommandPolicy are the constraints of a command in the allow list, a command without policy is always allowed
+type CommandPolicy struct {
+	Command     string                    `json:"command"`
+	Parameters  map[string]*CommandSchema `json:"parameters,omitempty"`   // constraints of parameters besides the schema of the command
+	MaxPerHour  int                       `json:"max_per_hour,omitempty"` // invocations per car in the last hour, unlimited if zero
+	Scopes      []string                  `json:"scopes,omitempty"`       // scopes needed besides command:<group>
+	TimeWindows []clockWindow             `json:"time_windows,omitempty"` // times the command is allowed in TZ, always if empty
+}
+
+// CommandAllowList holds the allowed commands and their policies, a file is reloaded when it changes
+type CommandAllowList struct {
+	path string // COMMANDS_ALLOWLIST, empty for the commands of the COMMANDS_* groups
+	now  func() time.Time
+
+	mu          sync.RWMutex
+	commands    []string
+	policies    map[string]*CommandPolicy
+	modTime     time.Time
+	invocations map[string][]time.Time // by car and command, for max_per_hour
+}
+
+func NewCommandAllowList(path string) *CommandAllowList {
+	return &CommandAllowList{path: path, now: time.Now, policies: map[string]*CommandPolicy{}, invocations: map[string][]time.Time{}}
+}
+
+// NewStaticCommandAllowList returns an allow list of commands without policies that isn't reloaded
+func NewStaticCommandAllowList(commands []string) *CommandAllowList {
+	allowList := NewCommandAllowList("")
+	allowList.commands = commands
+	return allowList
+}
+
+// Commands returns the allowed commands
+func (a *CommandAllowList) Commands() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]string{}, a.commands...)
+}
+
+// Allowed checks if command is in the allow list
+func (a *CommandAllowList) Allowed(command string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return checkArrayContainsString(a.commands, command)
+}
+
+// Policies returns the policies of the allowed commands
+func (a *CommandAllowList) Policies() map[string]*CommandPolicy {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	policies := make(map[string]*CommandPolicy, len(a.policies))
+	for command, policy := range a.policies {
+		policies[command] = policy
+	}
+	return policies
+}
+
+// Policy returns the policy of a command, nil if it has none
+func (a *CommandAllowList) Policy(command string) *CommandPolicy {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.policies[command]
+}
+
+// Reload reads the file again if it changed since the last load, the loaded commands are kept on errors
+func (a *CommandAllowList) Reload() (bool, error) {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return false, err
+	}
+
+	a.mu.RLock()
+	unchanged := info.ModTime().Equal(a.modTime)
+	a.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return false, err
+	}
+	commands, policies, err := parseCommandAllowList(data)
+	if err != nil {
+		return false, err
+	}
+
+	a.mu.Lock()
+	a.commands = commands
+	a.policies = policies
+	a.modTime = info.ModTime()
+	a.mu.Unlock()
+	return true, nil
+}
+
+// Watch reloads the file every interval until stop is closed, the same error is only logged once
+func (a *CommandAllowList) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastErr string
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			changed, err := a.Reload()
+			if err != nil {
+				if err.Error() != lastErr {
+					log.Println("[error] CommandAllowList - unable to reload " + a.path + ", keeping the loaded commands: " + err.Error())
+				}
+				lastErr = err.Error()
+				continue
+			}
+			lastErr = ""
+			if changed {
+				log.Printf("[info] CommandAllowList - reloaded %d commands from %s", len(a.Commands()), a.path)
+			}
+		}
+	}
+}
+
+// Reserve counts an invocation of command by a car if it had less than limit invocations in the last hour
+// and returns a func to release it again if the command wasn't sent, otherwise it returns how long the car has to wait
+func (a *CommandAllowList) Reserve(carID int, command string, limit int) (func(), time.Duration) {
+	now := a.now()
+	key := strconv.Itoa(carID) + command
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	recent := a.invocations[key][:0]
+	for _, at := range a.invocations[key] {
+		if now.Sub(at) < time.Hour {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= limit {
+		a.invocations[key] = recent
+		return nil, recent[0].Add(time.Hour).Sub(now)
+	}
+	a.invocations[key] = append(recent, now)
+
+	var once sync.Once
+	return func() { once.Do(func() { a.release(key, now) }) }, 0
+}
+
+// release removes an invocation counted by Reserve
+func (a *CommandAllowList) release(key string, at time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, invoked := range a.invocations[key] {
+		if invoked.Equal(at) {
+			a.invocations[key] = append(a.invocations[key][:i], a.invocations[key][i+1:]...)
+			return
+		}
+	}
+}
+
+// parseCommandAllowList func - parses a COMMANDS_ALLOWLIST file of command strings and CommandPolicy objects
+func parseCommandAllowList(data []byte) ([]string, map[string]*CommandPolicy, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, err
+	}
+
+	commands := make([]string, 0, len(entries))
+	policies := map[string]*CommandPolicy{}
+	for i, entry := range entries {
+		var command string
+		if err := json.Unmarshal(entry, &command); err == nil {
+			if checkArrayContainsString(commands, command) {
+				return nil, nil, fmt.Errorf("entry %d: duplicate command %s", i+1, command)
+			}
+			commands = append(commands, command)
+			continue
+		}
+
+		policy := &CommandPolicy{}
+		decoder := json.NewDecoder(bytes.NewReader(entry))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(policy); err != nil {
+			return nil, nil, fmt.Errorf("entry %d has to be a command or an object with its policy: %w", i+1, err)
+		}
+		if err := policy.prepare(); err != nil {
+			return nil, nil, fmt.Errorf("entry %d (%s): %w", i+1, policy.Command, err)
+		}
+		if checkArrayContainsString(commands, policy.Command) {
+			return nil, nil, fmt.Errorf("entry %d: duplicate command %s", i+1, policy.Command)
+		}
+		commands = append(commands, policy.Command)
+		policies[policy.Command] = policy
+	}
+	return commands, policies, nil
+}
+
+// prepare func - validates a policy and parses its patterns and time windows
+func (p *CommandPolicy) prepare() error {
+	if p.Command == "" {
+		return errors.New("command is missing")
+	}
+	if p.MaxPerHour < 0 {
+		return errors.New("max_per_hour can't be negative")
+	}
+	for _, scope := range p.Scopes {
+		if !validScope(scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	schema := commandSchemas[p.Command]
+	for name, constraint := range p.Parameters {
+		if constraint == nil {
+			return fmt.Errorf("parameter %s has no constraints", name)
+		}
+		if schema != nil && schema.AdditionalProperties != nil && !*schema.AdditionalProperties && schema.Properties[name] == nil {
+			return fmt.Errorf("%s is not a parameter of this command", name)
+		}
+		if constraint.Pattern != "" {
+			pattern, err := regexp.Compile(constraint.Pattern)
+			if err != nil {
+				return fmt.Errorf("parameter %s: invalid pattern: %w", name, err)
+			}
+			constraint.pattern = pattern
+		}
+	}
+
+	for i := range p.TimeWindows {
+		if err := p.TimeWindows[i].parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allowedAt func - checks if a time is in one of the time windows of the policy in loc
+func (p *CommandPolicy) allowedAt(at time.Time, loc *time.Location) bool {
+	if len(p.TimeWindows) == 0 {
+		return true
+	}
+	for _, w := range p.TimeWindows {
+		if w.contains(at, loc) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateParameters func - validates the parameters of a body against the constraints of the policy,
+// the body already matches the schema of the command
+func (p *CommandPolicy) validateParameters(body []byte) []FieldError {
+	var values map[string]interface{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		// bodies that are no object are rejected by the schema of known commands
+		return nil
+	}
+	var fieldErrors []FieldError
+	for name, constraint := range p.Parameters {
+		if value, ok := values[name]; ok {
+			fieldErrors = append(fieldErrors, constraint.Validate(name, value)...)
+		}
+	}
+	sort.SliceStable(fieldErrors, func(i, j int) bool { return fieldErrors[i].Field < fieldErrors[j].Field })
+	return fieldErrors
+}
+
+// checkCommandPolicy func - aborts with 403, 422 or 429 if a command of a car breaks the policy of the allow list,
+// otherwise the invocation is reserved for max_per_hour and release has to be called if the command isn't sent
+func checkCommandPolicy(c *gin.Context, s1 string, carID int, command string, body []byte) (release func(), ok bool) {
+	release = func() {}
+	policy := commandAllowList.Policy(command)
+	if policy == nil {
+		return release, true
+	}
+
+	if identity, ok := c.Get(identityKey); ok {
+		for _, scope := range policy.Scopes {
+			if !identity.(*apiIdentity).hasScope(scope) {
+				TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, s1, "You are not allowed to run this command", fmt.Sprintf("token %s is missing scope %s", identity.(*apiIdentity).Name, scope))
+				return nil, false
+			}
+		}
+	}
+
+	loc := appUsersTimezone
+	if loc == nil {
+		loc = time.Local
+	}
+	if !policy.allowedAt(commandAllowList.now(), loc) {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, s1, "command "+command+" is not allowed at this time", "outside of the time_windows of the allow list")
+		return nil, false
+	}
+
+	if fieldErrors := policy.validateParameters(body); len(fieldErrors) > 0 {
+		TeslaMateAPIHandleValidationErrorResponse(c, s1, "parameters not allowed for command "+command, fieldErrors)
+		return nil, false
+	}
+
+	if policy.MaxPerHour > 0 {
+		reserved, wait := commandAllowList.Reserve(carID, command, policy.MaxPerHour)
+		if wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusTooManyRequests, s1, "command "+command+" was run too often", fmt.Sprintf("max_per_hour of %d reached for car %d", policy.MaxPerHour, carID))
+			return nil, false
+		}
+		release = reserved
+	}
+	return release, true
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeCommandQuerier knows no car, all other queries are not implemented
+type fakeCommandQuerier struct {
+	teslamatedb.Querier
+}
+
+func (q *fakeCommandQuerier) GetCarCommandDetails(ctx context.Context, id int16) (teslamatedb.GetCarCommandDetailsRow, error) {
+	return teslamatedb.GetCarCommandDetailsRow{}, pgx.ErrNoRows
+}
+
+func TestParseCommandAllowList(t *testing.T) {
+	withCommands(t)
+
+	commands, policies, err := parseCommandAllowList([]byte(`["/wake_up", "/command/flash_lights"]`))
+	if err != nil || len(commands) != 2 || len(policies) != 0 {
+		t.Fatalf("Expected the legacy list of commands, got %v, %v, %v", commands, policies, err)
+	}
+
+	commands, policies, err = parseCommandAllowList([]byte(`[
+		"/wake_up",
+		{
+			"command": "/command/set_charge_limit",
+			"parameters": {"percent": {"maximum": 80}},
+			"max_per_hour": 2,
+			"scopes": ["admin"],
+			"time_windows": [{"days": ["sat", "sun"], "from": "22:00", "to": "06:00"}]
+		}
+	]`))
+	if err != nil {
+		t.Fatalf("Expected allow list with a policy, got %v", err)
+	}
+	if len(commands) != 2 || policies["/command/set_charge_limit"] == nil || policies["/command/set_charge_limit"].MaxPerHour != 2 {
+		t.Errorf("Expected both commands and the policy of set_charge_limit, got %v, %v", commands, policies)
+	}
+
+	invalid := map[string]string{
+		"not a list":            `{"command": "/wake_up"}`,
+		"missing command":       `[{"max_per_hour": 1}]`,
+		"unknown field":         `[{"command": "/wake_up", "max_per_hours": 1}]`,
+		"duplicate command":     `["/wake_up", {"command": "/wake_up", "max_per_hour": 1}]`,
+		"unknown scope":         `[{"command": "/wake_up", "scopes": ["read:everything"]}]`,
+		"unknown parameter":     `[{"command": "/command/set_charge_limit", "parameters": {"percentage": {"maximum": 80}}}]`,
+		"invalid pattern":       `[{"command": "/command/share", "parameters": {"locale": {"pattern": "("}}}]`,
+		"invalid time window":   `[{"command": "/wake_up", "time_windows": [{"from": "25:00", "to": "06:00"}]}]`,
+		"invalid day":           `[{"command": "/wake_up", "time_windows": [{"days": ["someday"], "from": "22:00", "to": "06:00"}]}]`,
+		"negative max_per_hour": `[{"command": "/wake_up", "max_per_hour": -1}]`,
+	}
+	for name, data := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := parseCommandAllowList([]byte(data)); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestCommandPolicyTimeWindows(t *testing.T) {
+	policy := &CommandPolicy{Command: "/command/charge_start", TimeWindows: []clockWindow{
+		{Days: []string{"mon", "tue", "wed", "thu", "fri"}, From: "22:00", To: "06:00"},
+		{Days: []string{"sat", "sun"}, From: "00:00", To: "00:00"},
+	}}
+	if err := policy.prepare(); err != nil {
+		t.Fatalf("Failed to prepare policy: %v", err)
+	}
+	loc, _ := time.LoadLocation("Europe/Berlin")
+
+	tests := map[string]bool{
+		"2024-01-08T23:30:00+01:00": true,  // monday night
+		"2024-01-08T02:00:00+01:00": false, // monday morning after sunday night
+		"2024-01-09T05:59:00+01:00": true,  // tuesday morning
+		"2024-01-09T06:00:00+01:00": false, // end of the window
+		"2024-01-09T12:00:00+01:00": false, // tuesday noon
+		"2024-01-13T12:00:00+01:00": true,  // saturday
+		"2024-01-08T21:30:00Z":      true,  // monday night in TZ
+	}
+	for at, expected := range tests {
+		parsed, _ := time.Parse(time.RFC3339, at)
+		if allowed := policy.allowedAt(parsed, loc); allowed != expected {
+			t.Errorf("Expected %v at %s, got %v", expected, at, allowed)
+		}
+	}
+}
+
+func TestCommandAllowListReloadAndReserve(t *testing.T) {
+	withCommands(t)
+	path := filepath.Join(t.TempDir(), "allow_list.json")
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write allow list: %v", err)
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+
+	write(`["/wake_up"]`, time.Now().Add(-time.Hour))
+	allowList := NewCommandAllowList(path)
+	if changed, err := allowList.Reload(); err != nil || !changed {
+		t.Fatalf("Expected allow list to be loaded, got %v, %v", changed, err)
+	}
+	if !allowList.Allowed("/wake_up") || allowList.Allowed("/command/door_unlock") {
+		t.Errorf("Expected only /wake_up to be allowed, got %v", allowList.Commands())
+	}
+
+	// an invalid file keeps the loaded commands
+	write(`["/wake_up",`, time.Now().Add(-time.Minute))
+	if _, err := allowList.Reload(); err == nil {
+		t.Error("Expected error for invalid json")
+	}
+	if !allowList.Allowed("/wake_up") {
+		t.Error("Expected /wake_up to stay allowed")
+	}
+
+	write(`[{"command": "/command/door_unlock", "max_per_hour": 2}]`, time.Now())
+	if changed, err := allowList.Reload(); err != nil || !changed {
+		t.Fatalf("Expected allow list to be reloaded, got %v, %v", changed, err)
+	}
+	if allowList.Allowed("/wake_up") || allowList.Policy("/command/door_unlock") == nil {
+		t.Errorf("Expected the commands of the changed file, got %v", allowList.Commands())
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	allowList.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if _, wait := allowList.Reserve(1, "/command/door_unlock", 2); wait != 0 {
+			t.Errorf("Expected invocation %d to be allowed, got %s", i+1, wait)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+	if _, wait := allowList.Reserve(1, "/command/door_unlock", 2); wait != 40*time.Minute {
+		t.Errorf("Expected to wait 40 minutes, got %s", wait)
+	}
+	release, wait := allowList.Reserve(2, "/command/door_unlock", 2)
+	if wait != 0 {
+		t.Errorf("Expected other car not to be limited, got %s", wait)
+	}
+	now = now.Add(40 * time.Minute)
+	if _, wait := allowList.Reserve(1, "/command/door_unlock", 2); wait != 0 {
+		t.Errorf("Expected invocation to be allowed after an hour, got %s", wait)
+	}
+
+	// a released invocation, of a command that wasn't sent, doesn't count
+	release()
+	release()
+	for i := 0; i < 2; i++ {
+		if _, wait := allowList.Reserve(2, "/command/door_unlock", 2); wait != 0 {
+			t.Errorf("Expected invocation %d of car 2 to be allowed after the release, got %s", i+1, wait)
+		}
+	}
+	if _, wait := allowList.Reserve(2, "/command/door_unlock", 2); wait == 0 {
+		t.Error("Expected car 2 to be limited again")
+	}
+}
+
+func TestCommandAllowListWatchMissingFile(t *testing.T) {
+	withCommands(t)
+	path := filepath.Join(t.TempDir(), "allow_list.json")
+	allowList := NewCommandAllowList(path)
+	if _, err := allowList.Reload(); err == nil {
+		t.Fatal("Expected error for a missing file")
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go allowList.Watch(10*time.Millisecond, stop)
+
+	// the file is loaded once it's created
+	if err := os.WriteFile(path, []byte(`["/wake_up"]`), 0o600); err != nil {
+		t.Fatalf("Failed to write allow list: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !allowList.Allowed("/wake_up") {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected /wake_up to be allowed after the file was created")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTeslaMateAPICarsCommandPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN_DISABLE", "false")
+	t.Setenv("ENABLE_COMMANDS", "true")
+	t.Setenv("AUTH_BACKOFF_BASE", "0")
+
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken(envToken)
+
+	dir := t.TempDir()
+	allowListFile := filepath.Join(dir, "allow_list.json")
+	if err := os.WriteFile(allowListFile, []byte(`[
+		{"command": "/command/set_charge_limit", "parameters": {"percent": {"maximum": 80}}, "max_per_hour": 1},
+		{"command": "/command/door_unlock", "scopes": ["admin"]},
+		{"command": "/command/honk_horn", "time_windows": [{"from": "00:00", "to": "06:00"}]}
+	]`), 0o600); err != nil {
+		t.Fatalf("Failed to write allow list: %v", err)
+	}
+	t.Setenv("COMMANDS_ALLOWLIST", allowListFile)
+	withCommands(t)
+	commandAllowList.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	// the token of the file only has the command scopes, the admin scope is missing
+	writeTokensFile(t, filepath.Join(dir, "api_tokens.json"), `[{"name": "home-assistant", "secret": "`+tokenSecret("home-assistant-token")+`", "scopes": ["command:*"]}]`, time.Now())
+	store := NewTokenStore(filepath.Join(dir, "api_tokens.json"))
+	if _, err := store.Reload(); err != nil {
+		t.Fatalf("Failed to load tokens: %v", err)
+	}
+
+	router := gin.New()
+	router.POST("/api/v1/cars/:CarID/command/:Command", TeslaMateAPICarsCommandV1)
+	request := func(command string, token string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/cars/1/command/"+command, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := request("set_charge_limit", envToken, `{"percent": 90}`); w.Code != http.StatusUnprocessableEntity || !contains(w.Body.String(), `"fields":[{"field":"percent","message":"has to be at most 80"}]`) {
+		t.Errorf("Expected status 422 for a charge limit above the policy, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := request("honk_horn", envToken, ``); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 outside of the time windows, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// commands that aren't sent, like the ones of an unknown car, don't count for max_per_hour
+	originalQueries := queries
+	queries = &fakeCommandQuerier{}
+	defer func() { queries = originalQueries }()
+	for i := 0; i < 2; i++ {
+		if w := request("set_charge_limit", envToken, `{"percent": 80}`); !contains(w.Body.String(), "No rows were returned!") {
+			t.Errorf("Expected command %d to be allowed by the policy, got %d: %s", i+1, w.Code, w.Body.String())
+		}
+	}
+
+	// invocations are counted once they are sent
+	if _, wait := commandAllowList.Reserve(1, "/command/set_charge_limit", 1); wait != 0 {
+		t.Fatalf("Expected invocation to be reserved, got %s", wait)
+	}
+	if w := request("set_charge_limit", envToken, `{"percent": 80}`); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "3600" {
+		t.Errorf("Expected status 429 with Retry-After after max_per_hour, got %d with %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	originalStore := tokenStore
+	tokenStore = store
+	defer func() { tokenStore = originalStore }()
+	if w := request("door_unlock", "home-assistant-token", ``); w.Code != http.StatusForbidden || !contains(w.Body.String(), "You are not allowed to run this command") {
+		t.Errorf("Expected status 403 without the scope of the policy, got %d: %s", w.Code, w.Body.String())
+	}
+}
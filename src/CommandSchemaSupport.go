@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CommandSchema is the subset of JSON schema used to describe and validate the body of a command
+type CommandSchema struct {
+	Type                 string                    `json:"type,omitempty"` // object, integer, number, string or boolean, any value if empty
+	Description          string                    `json:"description,omitempty"`
+	Properties           map[string]*CommandSchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *bool                     `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}             `json:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// FieldError is a field of a request body that is invalid
+type FieldError struct {
+	Field   string `json:"field"`   // path of the field like value.text, empty for the body itself
+	Message string `json:"message"` // what is wrong with the field
+}
+
+// commandSchemas maps every command of CommandList to the schema of its body
+var commandSchemas = map[string]*CommandSchema{}
+
+// noParameters is the schema of commands without parameters
+var noParameters = objectSchema(nil)
+
+// undocumentedParameters is the schema of commands with unknown parameters, any object is forwarded
+var undocumentedParameters = &CommandSchema{Type: "object", Description: "parameters are not documented and not validated"}
+
+// objectSchema func - returns the schema of an object with properties, other properties are rejected
+// and properties of required are needed
+func objectSchema(properties map[string]*CommandSchema, required ...string) *CommandSchema {
+	additional := false
+	return &CommandSchema{Type: "object", Properties: properties, Required: required, AdditionalProperties: &additional}
+}
+
+// integerSchema func - returns the schema of an integer between minimum and maximum
+func integerSchema(description string, minimum float64, maximum float64) *CommandSchema {
+	return &CommandSchema{Type: "integer", Description: description, Minimum: &minimum, Maximum: &maximum}
+}
+
+// numberSchema func - returns the schema of a number between minimum and maximum
+func numberSchema(description string, minimum float64, maximum float64) *CommandSchema {
+	return &CommandSchema{Type: "number", Description: description, Minimum: &minimum, Maximum: &maximum}
+}
+
+// booleanSchema func
+func booleanSchema(description string) *CommandSchema {
+	return &CommandSchema{Type: "boolean", Description: description}
+}
+
+// stringSchema func - returns the schema of a string matching pattern, any string if pattern is empty
+func stringSchema(description string, pattern string) *CommandSchema {
+	schema := &CommandSchema{Type: "string", Description: description, Pattern: pattern}
+	if pattern != "" {
+		schema.pattern = regexp.MustCompile(pattern)
+	}
+	return schema
+}
+
+// enumSchema func - returns the schema of a string that has to be one of values
+func enumSchema(description string, values ...string) *CommandSchema {
+	schema := &CommandSchema{Type: "string", Description: description}
+	for _, value := range values {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return schema
+}
+
+// validateCommandBody func - validates the body of a command against its schema, an empty body is an empty object
+func validateCommandBody(command string, body []byte) []FieldError {
+	schema, ok := commandSchemas[command]
+	if !ok {
+		return nil
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		body = []byte("{}")
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []FieldError{{Field: "", Message: "has to be a json object: " + err.Error()}}
+	}
+	errors := schema.Validate("", value)
+	sort.SliceStable(errors, func(i, j int) bool { return errors[i].Field < errors[j].Field })
+	return errors
+}
+
+// Validate returns the errors of value and its fields, path is the path of value
+func (s *CommandSchema) Validate(path string, value interface{}) []FieldError {
+	fail := func(format string, a ...interface{}) []FieldError {
+		return []FieldError{{Field: path, Message: fmt.Sprintf(format, a...)}}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("has to be an object")
+		}
+		return s.validateObject(path, object)
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return fail("has to be an integer")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fail("has to be a number")
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fail("has to be a string")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("has to be a boolean")
+		}
+	}
+
+	if len(s.Enum) > 0 && !containsValue(s.Enum, value) {
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			values[i] = fmt.Sprint(v)
+		}
+		return fail("has to be one of %s", strings.Join(values, ", "))
+	}
+	if text, ok := value.(string); ok && s.pattern != nil && !s.pattern.MatchString(text) {
+		return fail("has to match %s", s.Pattern)
+	}
+	if number, ok := value.(float64); ok {
+		if s.Minimum != nil && number < *s.Minimum {
+			return fail("has to be at least %g", *s.Minimum)
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			return fail("has to be at most %g", *s.Maximum)
+		}
+	}
+	return nil
+}
+
+// validateObject func - validates the required, known and additional properties of an object
+func (s *CommandSchema) validateObject(path string, object map[string]interface{}) []FieldError {
+	var errors []FieldError
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			errors = append(errors, FieldError{Field: joinFieldPath(path, name), Message: "is required"})
+		}
+	}
+	for name, value := range object {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errors = append(errors, FieldError{Field: joinFieldPath(path, name), Message: "is not a parameter of this command"})
+			}
+			continue
+		}
+		errors = append(errors, property.Validate(joinFieldPath(path, name), value)...)
+	}
+	return errors
+}
+
+// joinFieldPath func
+func joinFieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// containsValue func - checks if values contains value
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// enabledCommandSchemas func - returns the schemas of the commands of the allow list
+func enabledCommandSchemas() map[string]*CommandSchema {
+	commands := commandAllowList.Commands()
+	schemas := make(map[string]*CommandSchema, len(commands))
+	for _, command := range commands {
+		if schema, ok := commandSchemas[command]; ok {
+			schemas[command] = schema
+		}
+	}
+	return schemas
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withCommands initializes CommandList with the commands of the COMMANDS_* groups set by the test
+func withCommands(t *testing.T) {
+	t.Helper()
+	originalAllowList, originalSchemas := commandAllowList, commandSchemas
+	t.Cleanup(func() { commandAllowList, commandSchemas = originalAllowList, originalSchemas })
+	commandSchemas = map[string]*CommandSchema{}
+	initCommandAllowList()
+}
+
+func TestValidateCommandBody(t *testing.T) {
+	withCommands(t)
+
+	tests := []struct {
+		name     string
+		command  string
+		body     string
+		expected []FieldError
+	}{
+		{"valid charge limit", "/command/set_charge_limit", `{"percent": 80}`, nil},
+		{"charge limit as text", "/command/set_charge_limit", `{"percent": "eighty"}`, []FieldError{{"percent", "has to be an integer"}}},
+		{"charge limit too low", "/command/set_charge_limit", `{"percent": 20}`, []FieldError{{"percent", "has to be at least 50"}}},
+		{"charge limit with fraction", "/command/set_charge_limit", `{"percent": 80.5}`, []FieldError{{"percent", "has to be an integer"}}},
+		{"missing charging amps", "/command/set_charging_amps", `{}`, []FieldError{{"charging_amps", "is required"}}},
+		{"charging amps too high", "/command/set_charging_amps", `{"charging_amps": 64}`, []FieldError{{"charging_amps", "has to be at most 48"}}},
+		{"temperatures out of range", "/command/set_temps", `{"driver_temp": 10, "passenger_temp": 30}`, []FieldError{{"driver_temp", "has to be at least 15"}, {"passenger_temp", "has to be at most 28"}}},
+		{"window vent", "/command/window_control", `{"command": "vent", "lat": 52.52, "lon": 13.40}`, nil},
+		{"unknown window command", "/command/window_control", `{"command": "open"}`, []FieldError{{"command", "has to be one of vent, close"}}},
+		{"typo in a parameter", "/command/set_sentry_mode", `{"on": true, "onn": false}`, []FieldError{{"onn", "is not a parameter of this command"}}},
+		{"invalid pin", "/command/speed_limit_activate", `{"pin": "12345"}`, []FieldError{{"pin", "has to match ^[0-9]{4}$"}}},
+		{"command without parameters and body", "/command/flash_lights", ``, nil},
+		{"body that is no object", "/command/flash_lights", `[]`, []FieldError{{"", "has to be an object"}}},
+		{"shared content", "/command/share", `{"type": "share_ext_content_raw", "value": {"android.intent.extra.TEXT": "Berlin"}, "timestamp_ms": "1700000000000"}`, nil},
+		{"undocumented command", "/command/remote_boombox", `{"sound": 2000}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if errors := validateCommandBody(tt.command, []byte(tt.body)); !reflect.DeepEqual(errors, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, errors)
+			}
+		})
+	}
+
+	if errors := validateCommandBody("/command/set_charge_limit", []byte(`{"percent":`)); len(errors) != 1 || !strings.HasPrefix(errors[0].Message, "has to be a json object") {
+		t.Errorf("Expected error for invalid json, got %v", errors)
+	}
+
+	// every command has a schema
+	for command := range commandGroups {
+		if _, ok := commandSchemas[command]; !ok {
+			t.Errorf("Expected schema of command %s", command)
+		}
+	}
+}
+
+func TestTeslaMateAPICarsCommandValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN_DISABLE", "false")
+	t.Setenv("ENABLE_COMMANDS", "true")
+	t.Setenv("COMMANDS_CHARGING", "true")
+	withCommands(t)
+
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken(envToken)
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/commands", TeslaMateAPICarsCommandV1)
+	router.POST("/api/v1/cars/:CarID/command/:Command", TeslaMateAPICarsCommandV1)
+	router.POST("/api/v2/cars/:CarID/command/:Command", errorEnvelope, TeslaMateAPICarsCommandV1)
+
+	request := func(method string, path string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+envToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("v2 returns the invalid fields", func(t *testing.T) {
+		w := request(http.MethodPost, "/api/v2/cars/1/command/set_charge_limit", `{"percent":"eighty"}`)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+		}
+		var response APIErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Error.Code != "UNPROCESSABLE_ENTITY" || !reflect.DeepEqual(response.Error.Fields, []FieldError{{"percent", "has to be an integer"}}) {
+			t.Errorf("Expected field error of percent, got %+v", response.Error)
+		}
+	})
+
+	t.Run("v1 returns the invalid fields", func(t *testing.T) {
+		w := request(http.MethodPost, "/api/v1/cars/1/command/set_charging_amps", `{}`)
+		if w.Code != http.StatusUnprocessableEntity || !contains(w.Body.String(), `"fields":[{"field":"charging_amps","message":"is required"}]`) {
+			t.Errorf("Expected status 422 with field error, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("disabled command", func(t *testing.T) {
+		if w := request(http.MethodPost, "/api/v1/cars/1/command/honk_horn", ``); w.Code != http.StatusUnauthorized || w.Body.String() != `{"error":"unauthorized"}` {
+			t.Errorf("Expected v1 to keep answering 401, got %d: %s", w.Code, w.Body.String())
+		}
+		if w := request(http.MethodPost, "/api/v2/cars/1/command/honk_horn", ``); w.Code != http.StatusForbidden || !contains(w.Body.String(), `"code":"FORBIDDEN"`) {
+			t.Errorf("Expected v2 to answer 403 for a valid token, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("schemas of the enabled commands", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/cars/1/commands", "")
+		var response struct {
+			EnabledCommands []string                  `json:"enabled_commands"`
+			CommandSchemas  map[string]*CommandSchema `json:"command_schemas"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.CommandSchemas) != len(response.EnabledCommands) {
+			t.Errorf("Expected a schema of every enabled command, got %d schemas of %d commands", len(response.CommandSchemas), len(response.EnabledCommands))
+		}
+		percent := response.CommandSchemas["/command/set_charge_limit"].Properties["percent"]
+		if percent == nil || percent.Type != "integer" || *percent.Minimum != 50 || *percent.Maximum != 100 {
+			t.Errorf("Expected percent between 50 and 100, got %+v", percent)
+		}
+		if _, ok := response.CommandSchemas["/command/set_temps"]; ok {
+			t.Error("Expected no schemas of disabled commands")
+		}
+	})
+}
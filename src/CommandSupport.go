@@ -1,200 +1,288 @@
 package main
 
 import (
-	"encoding/json"
-	"io"
+	"errors"
+	"io/fs"
 	"log"
-	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// commandGroups maps every command of CommandList to its group, like /command/set_temps to climate
+var commandGroups = map[string]string{}
+
+// commandScope func - returns the command:<group> scope needed to run a command
+func commandScope(command string) string {
+	if group, ok := commandGroups[command]; ok {
+		return scopeCommandPrefix + group
+	}
+	return scopeCommandPrefix + "unknown"
+}
+
 // initCommandAllowList func
 func initCommandAllowList() {
 
-	// generate map of all available commands
-	CommandList := make(map[string][]string)
+	// generate map of all available commands with the schema of their body
+	CommandList := make(map[string]map[string]*CommandSchema)
 
 	// https://github.com/teslamate-org/teslamate/discussions/1433
-	CommandList["COMMANDS_LOGGING"] = []string{
-		"/logging/resume",
-		"/logging/suspend",
+	CommandList["COMMANDS_LOGGING"] = map[string]*CommandSchema{
+		"/logging/resume":  noParameters,
+		"/logging/suspend": noParameters,
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/wake
-	CommandList["COMMANDS_WAKE"] = []string{
-		"/wake_up",
+	CommandList["COMMANDS_WAKE"] = map[string]*CommandSchema{
+		"/wake_up": noParameters,
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/alerts
-	CommandList["COMMANDS_ALERT"] = []string{
-		"/command/honk_horn",
-		"/command/flash_lights",
+	CommandList["COMMANDS_ALERT"] = map[string]*CommandSchema{
+		"/command/honk_horn":    noParameters,
+		"/command/flash_lights": noParameters,
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/remotestart
-	CommandList["COMMANDS_REMOTESTART"] = []string{
-		"/command/remote_start_drive",
+	CommandList["COMMANDS_REMOTESTART"] = map[string]*CommandSchema{
+		"/command/remote_start_drive": noParameters,
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/homelink
-	CommandList["COMMANDS_HOMELINK"] = []string{
-		"/command/trigger_homelink",
+	CommandList["COMMANDS_HOMELINK"] = map[string]*CommandSchema{
+		"/command/trigger_homelink": objectSchema(map[string]*CommandSchema{
+			"lat": numberSchema("current latitude of the car", -90, 90),
+			"lon": numberSchema("current longitude of the car", -180, 180),
+		}, "lat", "lon"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/speedlimit
-	CommandList["COMMANDS_SPEEDLIMIT"] = []string{
-		"/command/speed_limit_set_limit",
-		"/command/speed_limit_activate",
-		"/command/speed_limit_deactivate",
-		"/command/speed_limit_clear_pin",
+	speedLimitPin := map[string]*CommandSchema{"pin": stringSchema("4 digit pin of the speed limit", "^[0-9]{4}$")}
+	CommandList["COMMANDS_SPEEDLIMIT"] = map[string]*CommandSchema{
+		"/command/speed_limit_set_limit": objectSchema(map[string]*CommandSchema{
+			"limit_mph": numberSchema("speed limit in mph", 50, 90),
+		}, "limit_mph"),
+		"/command/speed_limit_activate":   objectSchema(speedLimitPin, "pin"),
+		"/command/speed_limit_deactivate": objectSchema(speedLimitPin, "pin"),
+		"/command/speed_limit_clear_pin":  objectSchema(speedLimitPin, "pin"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/valet
-	CommandList["COMMANDS_VALET"] = []string{
-		"/command/set_valet_mode",
-		"/command/reset_valet_pin",
+	CommandList["COMMANDS_VALET"] = map[string]*CommandSchema{
+		"/command/set_valet_mode": objectSchema(map[string]*CommandSchema{
+			"on":       booleanSchema("activate or deactivate valet mode"),
+			"password": stringSchema("4 digit pin of valet mode", "^[0-9]{4}$"),
+		}, "on"),
+		"/command/reset_valet_pin": noParameters,
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/sentrymode
-	CommandList["COMMANDS_SENTRYMODE"] = []string{
-		"/command/set_sentry_mode",
+	CommandList["COMMANDS_SENTRYMODE"] = map[string]*CommandSchema{
+		"/command/set_sentry_mode": objectSchema(map[string]*CommandSchema{
+			"on": booleanSchema("activate or deactivate sentry mode"),
+		}, "on"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/doors
-	CommandList["COMMANDS_DOORS"] = []string{
-		"/command/door_unlock",
-		"/command/door_lock",
+	CommandList["COMMANDS_DOORS"] = map[string]*CommandSchema{
+		"/command/door_unlock": noParameters,
+		"/command/door_lock":   noParameters,
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/trunk
-	CommandList["COMMANDS_TRUNK"] = []string{
-		"/command/actuate_trunk",
+	CommandList["COMMANDS_TRUNK"] = map[string]*CommandSchema{
+		"/command/actuate_trunk": objectSchema(map[string]*CommandSchema{
+			"which_trunk": enumSchema("trunk to open or close", "rear", "front"),
+		}, "which_trunk"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/windows
-	CommandList["COMMANDS_WINDOWS"] = []string{
-		"/command/window_control",
+	CommandList["COMMANDS_WINDOWS"] = map[string]*CommandSchema{
+		"/command/window_control": objectSchema(map[string]*CommandSchema{
+			"command": enumSchema("vent or close all windows", "vent", "close"),
+			"lat":     numberSchema("current latitude of the user, needed to close the windows by some cars", -90, 90),
+			"lon":     numberSchema("current longitude of the user, needed to close the windows by some cars", -180, 180),
+		}, "command"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/sunroof
-	CommandList["COMMANDS_SUNROOF"] = []string{
-		"/command/sun_roof_control",
+	CommandList["COMMANDS_SUNROOF"] = map[string]*CommandSchema{
+		"/command/sun_roof_control": objectSchema(map[string]*CommandSchema{
+			"state": enumSchema("vent or close the sunroof", "vent", "close"),
+		}, "state"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/charging
-	CommandList["COMMANDS_CHARGING"] = []string{
-		"/command/charge_port_door_open",
-		"/command/charge_port_door_close",
-		"/command/charge_start",
-		"/command/charge_stop",
-		"/command/charge_standard",
-		"/command/charge_max_range",
-		"/command/set_charge_limit",
-		"/command/set_charging_amps",
-		"/command/set_scheduled_charging",
-		"/command/set_scheduled_departure",
+	minutesAfterMidnight := func(description string) *CommandSchema {
+		return integerSchema(description+" in minutes after midnight", 0, 1439)
+	}
+	CommandList["COMMANDS_CHARGING"] = map[string]*CommandSchema{
+		"/command/charge_port_door_open":  noParameters,
+		"/command/charge_port_door_close": noParameters,
+		"/command/charge_start":           noParameters,
+		"/command/charge_stop":            noParameters,
+		"/command/charge_standard":        noParameters,
+		"/command/charge_max_range":       noParameters,
+		"/command/set_charge_limit": objectSchema(map[string]*CommandSchema{
+			"percent": integerSchema("charge limit in percent", 50, 100),
+		}, "percent"),
+		"/command/set_charging_amps": objectSchema(map[string]*CommandSchema{
+			"charging_amps": integerSchema("charging current in ampere", 1, 48),
+		}, "charging_amps"),
+		"/command/set_scheduled_charging": objectSchema(map[string]*CommandSchema{
+			"enable": booleanSchema("enable or disable scheduled charging"),
+			"time":   minutesAfterMidnight("start of charging"),
+		}, "enable"),
+		"/command/set_scheduled_departure": objectSchema(map[string]*CommandSchema{
+			"enable":                          booleanSchema("enable or disable scheduled departure"),
+			"departure_time":                  minutesAfterMidnight("departure"),
+			"preconditioning_enabled":         booleanSchema("precondition the cabin for the departure"),
+			"preconditioning_weekdays_only":   booleanSchema("precondition on weekdays only"),
+			"off_peak_charging_enabled":       booleanSchema("charge during off peak hours"),
+			"off_peak_charging_weekdays_only": booleanSchema("charge during off peak hours on weekdays only"),
+			"end_off_peak_time":               minutesAfterMidnight("end of off peak hours"),
+		}, "enable"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/climate
-	CommandList["COMMANDS_CLIMATE"] = []string{
-		"/command/auto_conditioning_start",
-		"/command/auto_conditioning_stop",
-		"/command/set_temps",
-		"/command/set_preconditioning_max",
-		"/command/remote_seat_heater_request",
-		"/command/remote_seat_cooler_request",
-		"/command/remote_steering_wheel_heater_request",
-		"/command/set_bioweapon_mode",
-		"/command/set_climate_keeper_mode",
-		"/command/remote_auto_seat_climate_request",
-		"/command/set_cop_temp",
-		"/command/set_cabin_overheat_protection",
-		"/command/remote_auto_steering_wheel_heat_climate_request", // missing documentation
-		"/command/remote_steering_wheel_heat_level_request",        // missing documentation
+	seatPosition := integerSchema("seat, 0 driver, 1 passenger, 2 rear left, 4 rear center, 5 rear right, 6 third row left, 7 third row right", 0, 8)
+	onOff := func(description string) map[string]*CommandSchema {
+		return map[string]*CommandSchema{"on": booleanSchema(description)}
+	}
+	CommandList["COMMANDS_CLIMATE"] = map[string]*CommandSchema{
+		"/command/auto_conditioning_start": noParameters,
+		"/command/auto_conditioning_stop":  noParameters,
+		"/command/set_temps": objectSchema(map[string]*CommandSchema{
+			"driver_temp":    numberSchema("temperature of the driver side in celsius", 15, 28),
+			"passenger_temp": numberSchema("temperature of the passenger side in celsius", 15, 28),
+		}, "driver_temp", "passenger_temp"),
+		"/command/set_preconditioning_max": objectSchema(map[string]*CommandSchema{
+			"on":              booleanSchema("activate or deactivate defrost"),
+			"manual_override": booleanSchema("override the automatic defrost"),
+		}, "on"),
+		"/command/remote_seat_heater_request": objectSchema(map[string]*CommandSchema{
+			"heater": seatPosition,
+			"level":  integerSchema("heating level, 0 off to 3 high", 0, 3),
+		}, "heater", "level"),
+		"/command/remote_seat_cooler_request": objectSchema(map[string]*CommandSchema{
+			"seat_position":     seatPosition,
+			"seat_cooler_level": integerSchema("cooling level, 0 off to 3 high", 0, 3),
+		}, "seat_position", "seat_cooler_level"),
+		"/command/remote_steering_wheel_heater_request": objectSchema(onOff("activate or deactivate the steering wheel heater"), "on"),
+		"/command/set_bioweapon_mode": objectSchema(map[string]*CommandSchema{
+			"on":              booleanSchema("activate or deactivate bioweapon defense mode"),
+			"manual_override": booleanSchema("override the automatic bioweapon defense mode"),
+		}, "on"),
+		"/command/set_climate_keeper_mode": objectSchema(map[string]*CommandSchema{
+			"climate_keeper_mode": integerSchema("0 off, 1 keep, 2 dog, 3 camp", 0, 3),
+		}, "climate_keeper_mode"),
+		"/command/remote_auto_seat_climate_request": objectSchema(map[string]*CommandSchema{
+			"auto_seat_position": seatPosition,
+			"auto_climate_on":    booleanSchema("activate or deactivate the automatic seat climate"),
+		}, "auto_seat_position", "auto_climate_on"),
+		"/command/set_cop_temp": objectSchema(map[string]*CommandSchema{
+			"cop_temp": integerSchema("cabin overheat protection temperature, 0 low, 1 medium, 2 high", 0, 2),
+		}, "cop_temp"),
+		"/command/set_cabin_overheat_protection": objectSchema(map[string]*CommandSchema{
+			"on":       booleanSchema("activate or deactivate cabin overheat protection"),
+			"fan_only": booleanSchema("protect the cabin with the fan only, without air conditioning"),
+		}, "on"),
+		"/command/remote_auto_steering_wheel_heat_climate_request": objectSchema(onOff("activate or deactivate the automatic steering wheel heater"), "on"), // missing documentation
+		"/command/remote_steering_wheel_heat_level_request": objectSchema(map[string]*CommandSchema{ // missing documentation
+			"level": integerSchema("heating level, 0 off, 1 low, 3 high", 0, 3),
+		}, "level"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/media
-	CommandList["COMMANDS_MEDIA"] = []string{
-		"/command/media_toggle_playback",
-		"/command/media_next_track",
-		"/command/media_prev_track",
-		"/command/media_next_fav",
-		"/command/media_prev_fav",
-		"/command/media_volume_up",
-		"/command/media_volume_down",
-		"/command/adjust_volume",
+	CommandList["COMMANDS_MEDIA"] = map[string]*CommandSchema{
+		"/command/media_toggle_playback": noParameters,
+		"/command/media_next_track":      noParameters,
+		"/command/media_prev_track":      noParameters,
+		"/command/media_next_fav":        noParameters,
+		"/command/media_prev_fav":        noParameters,
+		"/command/media_volume_up":       noParameters,
+		"/command/media_volume_down":     noParameters,
+		"/command/adjust_volume": objectSchema(map[string]*CommandSchema{
+			"volume": numberSchema("volume", 0, 11),
+		}, "volume"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/sharing
-	CommandList["COMMANDS_SHARING"] = []string{
-		"/command/share",
+	CommandList["COMMANDS_SHARING"] = map[string]*CommandSchema{
+		"/command/share": objectSchema(map[string]*CommandSchema{
+			"type":         enumSchema("type of the shared content", "share_ext_content_raw"),
+			"value":        {Type: "object", Description: "shared content, an address or a video url as android.intent.extra.TEXT"},
+			"locale":       stringSchema("locale of the content like en-US", ""),
+			"timestamp_ms": {Description: "time of the share in milliseconds since epoch"},
+		}, "type", "value"),
 	}
 
 	// https://tesla-api.timdorr.com/vehicle/commands/softwareupdate
-	CommandList["COMMANDS_SOFTWAREUPDATE"] = []string{
-		"/command/schedule_software_update",
-		"/command/cancel_software_update",
+	CommandList["COMMANDS_SOFTWAREUPDATE"] = map[string]*CommandSchema{
+		"/command/schedule_software_update": objectSchema(map[string]*CommandSchema{
+			"offset_sec": integerSchema("seconds until the update is installed", 0, 86400),
+		}, "offset_sec"),
+		"/command/cancel_software_update": noParameters,
 	}
 
 	// not documentet and unsorted new endpoints
-	CommandList["COMMANDS_UNKNOWN"] = []string{
-		"/command/upcoming_calendar_entries",
-		"/command/dashcam_save_clip",
-		"/command/navigation_sc_request",
-		"/command/remote_boombox",
-		"/command/get_active_route",
-		"/command/get_managed_charging_sites",
-		"/command/add_managed_charging_site",
-		"/command/remove_managed_charging_site",
-		"/command/update_charge_on_solar_feature",
-		"/command/get_charge_on_solar_feature",
-		"/command/take_drivenote",
-		"/command/navigation_gps_request",
+	CommandList["COMMANDS_UNKNOWN"] = map[string]*CommandSchema{
+		"/command/upcoming_calendar_entries":      undocumentedParameters,
+		"/command/dashcam_save_clip":              undocumentedParameters,
+		"/command/navigation_sc_request":          undocumentedParameters,
+		"/command/remote_boombox":                 undocumentedParameters,
+		"/command/get_active_route":               undocumentedParameters,
+		"/command/get_managed_charging_sites":     undocumentedParameters,
+		"/command/add_managed_charging_site":      undocumentedParameters,
+		"/command/remove_managed_charging_site":   undocumentedParameters,
+		"/command/update_charge_on_solar_feature": undocumentedParameters,
+		"/command/get_charge_on_solar_feature":    undocumentedParameters,
+		"/command/take_drivenote":                 undocumentedParameters,
+		"/command/navigation_gps_request":         undocumentedParameters,
 	}
 
 	// allow all commands available below
 	allowAll := getEnvAsBool("COMMANDS_ALL", false)
+	var allowList []string
 
-	// looping over CommandList to generate allowList
+	// looping over CommandList to generate commandGroups, commandSchemas and allowList
 	for key := range CommandList {
+		commands := make([]string, 0, len(CommandList[key]))
+		for command, schema := range CommandList[key] {
+			commandGroups[command] = strings.ToLower(strings.TrimPrefix(key, "COMMANDS_"))
+			commandSchemas[command] = schema
+			commands = append(commands, command)
+		}
+		sort.Strings(commands)
+
 		// checking if env is set from key or if all should be allowed
 		if getEnvAsBool(key, false) || allowAll {
 			// appending to allowList
-			allowList = append(allowList, CommandList[key]...)
+			allowList = append(allowList, commands...)
 		}
 	}
 
-	// if allowList is empty, read COMMANDS_ALLOWLIST and append to allowList
+	// if allowList is empty, the commands and policies of COMMANDS_ALLOWLIST are allowed, the file is reloaded when it changes
 	commandAllowListLocation := getEnv("COMMANDS_ALLOWLIST", "allow_list.json")
 	if len(allowList) == 0 {
-		var allowListFile []string
-		commandAllowListFile, err := os.Open(commandAllowListLocation)
-		if err != nil {
-			log.Println("[error] getAllowList error with COMMANDS_ALLOWLIST: " + commandAllowListLocation + " not found and will be ignored")
-			return
-		}
+		commandAllowList = NewCommandAllowList(commandAllowListLocation)
 
-		defer commandAllowListFile.Close()
-		byteValue, err := io.ReadAll(commandAllowListFile)
-		if err != nil {
-			log.Println("[error] getAllowList error while reading COMMANDS_ALLOWLIST: " + commandAllowListLocation + " it will be ignored")
-			return
+		// a missing or invalid file is watched as well, so the commands are allowed once it's created or fixed
+		if _, err := commandAllowList.Reload(); errors.Is(err, fs.ErrNotExist) {
+			log.Println("[error] getAllowList error with COMMANDS_ALLOWLIST: " + commandAllowListLocation + " not found and will be ignored until it's created")
+		} else if err != nil {
+			log.Println("[error] getAllowList error while parsing COMMANDS_ALLOWLIST: " + commandAllowListLocation + " it will be ignored until it's fixed: " + err.Error())
 		}
-
-		err = json.Unmarshal(byteValue, &allowListFile)
-		if err != nil {
-			log.Println("[error] getAllowList error while parsing JSON.. COMMANDS_ALLOWLIST: " + commandAllowListLocation + " it will be ignored")
-			return
-		}
-
-		allowList = append(allowList, allowListFile...)
+		interval := time.Duration(getEnvAsInt("COMMANDS_ALLOWLIST_RELOAD_INTERVAL", 10)) * time.Second
+		go commandAllowList.Watch(interval, nil)
 	} else {
+		commandAllowList = NewStaticCommandAllowList(allowList)
 		log.Print("[info] getAllowList COMMANDS from environment variables set, " + commandAllowListLocation + " will be ignored.")
 	}
 
 	if gin.IsDebugging() {
-		log.Println("[info] initCommandAllowList - generated following list of allowed commands: " + strings.Join(allowList, ", "))
+		log.Println("[info] initCommandAllowList - generated following list of allowed commands: " + strings.Join(commandAllowList.Commands(), ", "))
 	}
 }
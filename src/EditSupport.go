@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// editBodyLimit is the maximum size of a PATCH body
+const editBodyLimit = 64 << 10
+
+// checkEditPermission func - edits of TeslaMate data need ENABLE_EDITS and authentication, aborts the request otherwise
+func checkEditPermission(c *gin.Context, s1 string) bool {
+	if !getEnvAsBool("ENABLE_EDITS", false) {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, s1, "You are not allowed to edit TeslaMate data", "ENABLE_EDITS is not true")
+		return false
+	}
+	validToken, errorMessage := validateAuthToken(c)
+	if !validToken {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusUnauthorized, s1, errorMessage, "invalid or missing bearer token")
+		return false
+	}
+	return true
+}
+
+// parsePatchBody func - returns the fields of a json object, fields missing in the body aren't changed and null clears a field
+func parsePatchBody(c *gin.Context, allowedFields ...string) (map[string]json.RawMessage, error) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, editBodyLimit+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > editBodyLimit {
+		return nil, fmt.Errorf("body is larger than %d bytes", editBodyLimit)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("body has to be a json object")
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("body has to contain at least one of %s", strings.Join(allowedFields, ", "))
+	}
+
+	var unknown []string
+	for name := range fields {
+		if !checkArrayContainsString(allowedFields, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown fields %s, allowed are %s", strings.Join(unknown, ", "), strings.Join(allowedFields, ", "))
+	}
+	return fields, nil
+}
+
+// isJSONNull func - returns true if a field of a PATCH body is null
+func isJSONNull(value json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// requestIDHeader is read from and returned to the client on every request
+	requestIDHeader = "X-Request-ID"
+
+	// gin context keys used by the error handling
+	requestIDKey     = "RequestID"
+	errorEnvelopeKey = "ErrorEnvelope"
+)
+
+// APIError is the typed error model returned by /api/v2 endpoints
+type APIError struct {
+	Code      string       `json:"code"`                 // machine readable error code
+	Message   string       `json:"message"`              // human readable error message
+	Details   string       `json:"details,omitempty"`    // underlying cause, if any
+	Fields    []FieldError `json:"fields,omitempty"`     // invalid fields of the request body, if any
+	RequestID string       `json:"request_id,omitempty"` // value of the X-Request-ID header
+}
+
+// APIErrorResponse wraps an APIError the way it's returned to the client
+type APIErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// notFoundError keeps the message of a missing resource while matching pgx.ErrNoRows
+type notFoundError struct {
+	message string
+}
+
+func (e *notFoundError) Error() string { return e.message }
+func (e *notFoundError) Unwrap() error { return pgx.ErrNoRows }
+
+// errNotFound func - returns an error that is mapped to 404 not found
+func errNotFound(format string, a ...any) error {
+	return &notFoundError{message: fmt.Sprintf(format, a...)}
+}
+
+// requestID func - gin middleware that sets the X-Request-ID of a request
+func requestID(c *gin.Context) {
+	id := c.GetHeader(requestIDHeader)
+	if !validRequestID(id) {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			log.Println("[warning] requestID - unable to generate request id:", err)
+		}
+		id = hex.EncodeToString(b)
+	}
+	c.Set(requestIDKey, id)
+	c.Header(requestIDHeader, id)
+	c.Next()
+}
+
+// validRequestID func - only accept short printable request ids from clients
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// errorEnvelope func - gin middleware making error responses use APIErrorResponse and real status codes
+func errorEnvelope(c *gin.Context) {
+	c.Set(errorEnvelopeKey, true)
+	c.Next()
+}
+
+// maxIDs are the largest ids of the path parameters, TeslaMate stores cars as smallint and the others as integer
+var maxIDs = map[string]int{
+	"CarID":      math.MaxInt16,
+	"ChargeID":   math.MaxInt32,
+	"DriveID":    math.MaxInt32,
+	"GeofenceID": math.MaxInt32,
+}
+
+// parseID func - parses the id of a path parameter, ok is false if it isn't between 1 and the max of maxIDs
+func parseID(name string, value string) (id int, ok bool) {
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 || id > maxIDs[name] {
+		return 0, false
+	}
+	return id, true
+}
+
+// requireValidIDs func - gin middleware that aborts with 400 if an id of the path is out of range,
+// so handlers can convert them to the types of the queries without wrapping around
+func requireValidIDs(c *gin.Context) {
+	for name := range maxIDs {
+		if value := c.Param(name); value != "" {
+			if _, ok := parseID(name, value); !ok {
+				TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "requireValidIDs", name+" invalid", fmt.Sprintf("%q is not a valid id", value))
+				c.Abort()
+				return
+			}
+		}
+	}
+	c.Next()
+}
+
+// requireCar func - gin middleware that validates :CarID and aborts if the car doesn't exist
+func requireCar(c *gin.Context) {
+	CarID, ok := parseID("CarID", c.Param("CarID"))
+	if !ok {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "requireCar", "CarID invalid", fmt.Sprintf("%q is not a valid car id", c.Param("CarID")))
+		c.Abort()
+		return
+	}
+
+	exists, err := queries.CarExists(c.Request.Context(), int16(CarID))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "requireCar", "Unable to load car.", err.Error())
+		c.Abort()
+		return
+	}
+	if !exists {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "requireCar", "Car not found.", fmt.Sprintf("car with ID %d does not exist", CarID))
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// httpStatusForError func - maps errors returned by the database to a http status code
+func httpStatusForError(err error) int {
+	var (
+		pgErr      *pgconn.PgError
+		connectErr *pgconn.ConnectError
+		netErr     net.Error
+	)
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.As(err, &pgErr) && len(pgErr.Code) == 5:
+		switch pgErr.Code[:2] {
+		case "08", "53", "57":
+			// connection exception, insufficient resources, operator intervention
+			return http.StatusServiceUnavailable
+		case "23":
+			// integrity constraint violation
+			return http.StatusConflict
+		case "22":
+			// data exception
+			return http.StatusBadRequest
+		}
+		return http.StatusInternalServerError
+	case errors.As(err, &connectErr), errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// errorCodeForStatus func - returns the APIError code of a http status code
+func errorCodeForStatus(httpCode int) string {
+	switch httpCode {
+	case http.StatusBadRequest:
+		return "BAD_REQUEST"
+	case http.StatusUnauthorized:
+		return "UNAUTHORIZED"
+	case http.StatusForbidden:
+		return "FORBIDDEN"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "CONFLICT"
+	case http.StatusUnprocessableEntity:
+		return "UNPROCESSABLE_ENTITY"
+	case http.StatusTooManyRequests:
+		return "TOO_MANY_REQUESTS"
+	case http.StatusServiceUnavailable:
+		return "SERVICE_UNAVAILABLE"
+	}
+	if httpCode >= 500 {
+		return "INTERNAL_ERROR"
+	}
+	return "ERROR"
+}
+
+// TeslaMateAPIHandleErrorResponse func - v1 keeps returning 200 with {"error": s2} for these errors
+func TeslaMateAPIHandleErrorResponse(c *gin.Context, httpCode int, s1 string, s2 string, s3 string) {
+	handleErrorResponse(c, http.StatusOK, httpCode, s1, s2, s3)
+}
+
+// TeslaMateAPIHandleOtherErrorResponse func - v1 returns httpCode with {"error": s2} for these errors
+func TeslaMateAPIHandleOtherErrorResponse(c *gin.Context, httpCode int, s1 string, s2 string, s3 string) {
+	handleErrorResponse(c, httpCode, httpCode, s1, s2, s3)
+}
+
+// TeslaMateAPIHandleValidationErrorResponse func - v1 and v2 return 422 with the invalid fields of the request body
+func TeslaMateAPIHandleValidationErrorResponse(c *gin.Context, s1 string, s2 string, fields []FieldError) {
+	details := make([]string, len(fields))
+	for i, field := range fields {
+		details[i] = strings.TrimSpace(field.Field + " " + field.Message)
+	}
+	handleErrorResponse(c, http.StatusUnprocessableEntity, http.StatusUnprocessableEntity, s1, s2, strings.Join(details, ", "), fields...)
+}
+
+// handleErrorResponse func - writes the legacy or the APIErrorResponse error
+func handleErrorResponse(c *gin.Context, legacyCode int, httpCode int, s1 string, s2 string, s3 string, fields ...FieldError) {
+	log.Println("[error] " + s1 + " - (" + c.Request.RequestURI + "). " + s2 + "; " + s3)
+
+	if !c.GetBool(errorEnvelopeKey) {
+		if len(fields) > 0 {
+			c.JSON(legacyCode, gin.H{"error": s2, "fields": fields})
+			return
+		}
+		c.JSON(legacyCode, gin.H{"error": s2})
+		return
+	}
+	c.JSON(httpCode, APIErrorResponse{Error: APIError{
+		Code:      errorCodeForStatus(httpCode),
+		Message:   s2,
+		Details:   s3,
+		Fields:    fields,
+		RequestID: c.GetString(requestIDKey),
+	}})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeErrorsQuerier knows car 1 only and has no charges, all other queries are not implemented
+type fakeErrorsQuerier struct {
+	teslamatedb.Querier
+	carExistsErr error
+}
+
+func (q *fakeErrorsQuerier) CarExists(ctx context.Context, id int16) (bool, error) {
+	return id == 1, q.carExistsErr
+}
+
+func (q *fakeErrorsQuerier) GetCharge(ctx context.Context, arg teslamatedb.GetChargeParams) (teslamatedb.GetChargeRow, error) {
+	return teslamatedb.GetChargeRow{}, pgx.ErrNoRows
+}
+
+func TestHttpStatusForError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"no error", nil, http.StatusOK},
+		{"no rows", pgx.ErrNoRows, http.StatusNotFound},
+		{"wrapped no rows", fmt.Errorf("query failed: %w", pgx.ErrNoRows), http.StatusNotFound},
+		{"not found", errNotFound("car with ID %d does not exist", 7), http.StatusNotFound},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, http.StatusConflict},
+		{"invalid text representation", &pgconn.PgError{Code: "22P02"}, http.StatusBadRequest},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, http.StatusServiceUnavailable},
+		{"cannot connect now", fmt.Errorf("database error: %w", &pgconn.PgError{Code: "57P03"}), http.StatusServiceUnavailable},
+		{"syntax error", &pgconn.PgError{Code: "42601"}, http.StatusInternalServerError},
+		{"unable to connect", &pgconn.ConnectError{Config: &pgconn.Config{Host: "database"}}, http.StatusServiceUnavailable},
+		{"timeout", context.DeadlineExceeded, http.StatusServiceUnavailable},
+		{"other error", errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := httpStatusForError(tt.err); got != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeErrorsQuerier{}
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.Use(requestID)
+	authLimiter := newAuthLimiterFromEnv()
+	registerAPIRoutes(router.Group("/api/v1"), authLimiter)
+	registerAPIRoutes(router.Group("/api/v2", errorEnvelope), authLimiter, requireCar)
+
+	request := func(path string) (*httptest.ResponseRecorder, APIErrorResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set(requestIDHeader, "test-request")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp APIErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	t.Run("v1 keeps legacy error shape", func(t *testing.T) {
+		w, _ := request("/api/v1/cars/1/charges/99")
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if body := w.Body.String(); body != `{"error":"No rows were returned!"}` {
+			t.Errorf("Expected legacy error body, got: %s", body)
+		}
+	})
+
+	t.Run("v2 charge not found", func(t *testing.T) {
+		w, resp := request("/api/v2/cars/1/charges/99")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		if resp.Error.Code != "NOT_FOUND" || resp.Error.Message != "No rows were returned!" || resp.Error.RequestID != "test-request" {
+			t.Errorf("Unexpected error: %+v", resp.Error)
+		}
+		if w.Header().Get(requestIDHeader) != "test-request" {
+			t.Errorf("Expected X-Request-ID to be returned, got '%s'", w.Header().Get(requestIDHeader))
+		}
+	})
+
+	t.Run("v2 car not found", func(t *testing.T) {
+		w, resp := request("/api/v2/cars/7/charges")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		if resp.Error.Code != "NOT_FOUND" || resp.Error.Details != "car with ID 7 does not exist" {
+			t.Errorf("Unexpected error: %+v", resp.Error)
+		}
+	})
+
+	t.Run("v2 invalid car id", func(t *testing.T) {
+		w, resp := request("/api/v2/cars/abc/status")
+		if w.Code != http.StatusBadRequest || resp.Error.Code != "BAD_REQUEST" {
+			t.Errorf("Expected 400 BAD_REQUEST, got %d %+v", w.Code, resp.Error)
+		}
+	})
+
+	t.Run("ids out of range don't wrap around", func(t *testing.T) {
+		w, _ := request("/api/v1/cars/65537/status")
+		if w.Code != http.StatusOK || w.Body.String() != `{"error":"CarID invalid"}` {
+			t.Errorf("Expected legacy error for car 65537 instead of car 1, got %d: %s", w.Code, w.Body.String())
+		}
+		w, resp := request("/api/v2/cars/1/drives/4294967297")
+		if w.Code != http.StatusBadRequest || resp.Error.Message != "DriveID invalid" {
+			t.Errorf("Expected 400 for drive 4294967297, got %d %+v", w.Code, resp.Error)
+		}
+	})
+
+	t.Run("v2 invalid date", func(t *testing.T) {
+		w, resp := request("/api/v2/cars/1/drives?startDate=yesterday")
+		if w.Code != http.StatusBadRequest || resp.Error.Message != "Invalid date format." {
+			t.Errorf("Expected 400 with invalid date message, got %d %+v", w.Code, resp.Error)
+		}
+	})
+
+	t.Run("v2 database unavailable", func(t *testing.T) {
+		fake.carExistsErr = &pgconn.PgError{Code: "57P03"}
+		defer func() { fake.carExistsErr = nil }()
+
+		w, resp := request("/api/v2/cars/1/updates")
+		if w.Code != http.StatusServiceUnavailable || resp.Error.Code != "SERVICE_UNAVAILABLE" {
+			t.Errorf("Expected 503 SERVICE_UNAVAILABLE, got %d %+v", w.Code, resp.Error)
+		}
+	})
+
+	t.Run("Generated request id", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/v2/cars/7", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp APIErrorResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Error.RequestID) != 32 || resp.Error.RequestID != w.Header().Get(requestIDHeader) {
+			t.Errorf("Expected generated request id in body and header, got '%s' and '%s'", resp.Error.RequestID, w.Header().Get(requestIDHeader))
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// bulkExportFormats are the formats supported by the bulk exports of drives and charges
+var bulkExportFormats = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+}
+
+// exportCursorBatchSize is the number of rows fetched per round trip by the export cursors
+const exportCursorBatchSize = 500
+
+// exportCursor is a DBTX running the query of a generated :many method through the
+// server-side cursor name, every call of the method returns the next batch of rows.
+type exportCursor struct {
+	teslamatedb.DBTX
+	name     string
+	declared bool
+}
+
+// Query func - declares the cursor for query on the first call and fetches the next batch
+func (c *exportCursor) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	if !c.declared {
+		if _, err := c.DBTX.Exec(ctx, "DECLARE "+c.name+" NO SCROLL CURSOR FOR "+query, args...); err != nil {
+			return nil, err
+		}
+		c.declared = true
+	}
+	return c.DBTX.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM %s", exportCursorBatchSize, c.name))
+}
+
+// readExportCursor func - calls fetch with queries reading through the server-side cursor name
+// and fn with the batches it returns, until a batch has less than exportCursorBatchSize rows.
+// Cursors only live inside a transaction, so tx has to be one.
+func readExportCursor[T any](ctx context.Context, tx teslamatedb.DBTX, name string, fetch func(*teslamatedb.Queries) ([]T, error), fn func([]T) error) error {
+	cursor := &exportCursor{DBTX: tx, name: name}
+	queries := teslamatedb.New(cursor)
+	for {
+		batch, err := fetch(queries)
+		if err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err := fn(batch); err != nil {
+				return err
+			}
+		}
+		if len(batch) < exportCursorBatchSize {
+			break
+		}
+	}
+	_, err := tx.Exec(ctx, "CLOSE "+name)
+	return err
+}
+
+// bulkExportWriter streams rows of type T as CSV or newline-delimited JSON, the
+// CSV columns are the json tags of T. Nothing is written until the first row or
+// Close, so errors happening before can still be returned as error response.
+type bulkExportWriter[T any] struct {
+	c        *gin.Context
+	format   string
+	filename string
+	started  bool
+	csv      *csv.Writer
+	json     *json.Encoder
+}
+
+// newBulkExportWriter func - format has to be a key of bulkExportFormats
+func newBulkExportWriter[T any](c *gin.Context, format string, filename string) *bulkExportWriter[T] {
+	return &bulkExportWriter[T]{c: c, format: format, filename: filename}
+}
+
+// start func - sends the headers and the CSV header row
+func (w *bulkExportWriter[T]) start() error {
+	w.started = true
+	w.c.Header("Content-Type", bulkExportFormats[w.format])
+	w.c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, w.filename, w.format))
+	w.c.Status(http.StatusOK)
+
+	if w.format == "ndjson" {
+		w.json = json.NewEncoder(w.c.Writer)
+		return nil
+	}
+	w.csv = csv.NewWriter(w.c.Writer)
+	var header []string
+	t := reflect.TypeFor[T]()
+	for i := 0; i < t.NumField(); i++ {
+		header = append(header, csvColumnName(t.Field(i)))
+	}
+	return w.csv.Write(header)
+}
+
+// Write func - writes a single row
+func (w *bulkExportWriter[T]) Write(row T) error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	if w.json != nil {
+		return w.json.Encode(row)
+	}
+	v := reflect.ValueOf(row)
+	record := make([]string, v.NumField())
+	for i := range record {
+		record[i] = csvValue(v.Field(i))
+	}
+	return w.csv.Write(record)
+}
+
+// Flush func - sends the rows written so far to the client
+func (w *bulkExportWriter[T]) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// Close func - starts the export if there were no rows and flushes it
+func (w *bulkExportWriter[T]) Close() error {
+	if !w.started {
+		if err := w.start(); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// csvColumnName func - returns the json name of a struct field
+func csvColumnName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// csvValue func - formats a value the same way encoding/json does, NULL is an empty string
+func csvValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return csvValue(v.Elem())
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// geofenceBillingTypes are the values of the billing_type enum of TeslaMate
+var geofenceBillingTypes = []string{"per_kwh", "per_minute"}
+
+// geofence is a geofence of TeslaMate like it's returned by the geofence endpoints
+type geofence struct {
+	GeofenceID  int         `json:"geofence_id"`   // int
+	Name        string      `json:"name"`          // string
+	Latitude    float64     `json:"latitude"`      // float64
+	Longitude   float64     `json:"longitude"`     // float64
+	Radius      int         `json:"radius"`        // int (m)
+	BillingType string      `json:"billing_type"`  // string (per_kwh or per_minute)
+	CostPerUnit NullFloat64 `json:"cost_per_unit"` // float64 (nullable)
+	SessionFee  NullFloat64 `json:"session_fee"`   // float64 (nullable)
+	InsertedAt  string      `json:"inserted_at"`   // string
+	UpdatedAt   string      `json:"updated_at"`    // string
+}
+
+// geofenceRequest is the body of POST and PUT /geofences, PUT replaces all fields
+type geofenceRequest struct {
+	Name        *string  `json:"name"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	Radius      *int     `json:"radius"`
+	BillingType *string  `json:"billing_type"`
+	CostPerUnit *float64 `json:"cost_per_unit"`
+	SessionFee  *float64 `json:"session_fee"`
+}
+
+// newGeofence func - converts a geofence row, all geofence queries return the columns of ListGeofencesRow
+func newGeofence(row teslamatedb.ListGeofencesRow) *geofence {
+	return &geofence{
+		GeofenceID:  int(row.GeofenceID),
+		Name:        row.Name,
+		Latitude:    row.Latitude,
+		Longitude:   row.Longitude,
+		Radius:      int(row.Radius),
+		BillingType: row.BillingType,
+		CostPerUnit: NullFloat64{row.CostPerUnit},
+		SessionFee:  NullFloat64{row.SessionFee},
+		InsertedAt:  getTimeInTimeZone(row.InsertedAt),
+		UpdatedAt:   getTimeInTimeZone(row.UpdatedAt),
+	}
+}
+
+// parseGeofenceRequest func - decodes and validates a geofence, returns the parameters of CreateGeofence
+func parseGeofenceRequest(body io.Reader) (teslamatedb.CreateGeofenceParams, error) {
+	var request geofenceRequest
+	decoder := json.NewDecoder(io.LimitReader(body, editBodyLimit))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		return teslamatedb.CreateGeofenceParams{}, fmt.Errorf("body has to be a geofence json object: %s", err.Error())
+	}
+
+	switch {
+	case request.Name == nil || strings.TrimSpace(*request.Name) == "" || len(*request.Name) > 255:
+		return teslamatedb.CreateGeofenceParams{}, fmt.Errorf("name is required and can have up to 255 characters")
+	case request.Latitude == nil || *request.Latitude < -90 || *request.Latitude > 90:
+		return teslamatedb.CreateGeofenceParams{}, fmt.Errorf("latitude is required and has to be between -90 and 90")
+	case request.Longitude == nil || *request.Longitude < -180 || *request.Longitude > 180:
+		return teslamatedb.CreateGeofenceParams{}, fmt.Errorf("longitude is required and has to be between -180 and 180")
+	case request.Radius == nil || *request.Radius < 1 || *request.Radius > math.MaxInt16:
+		return teslamatedb.CreateGeofenceParams{}, fmt.Errorf("radius is required and has to be between 1 and %d meters", math.MaxInt16)
+	case request.BillingType != nil && !checkArrayContainsString(geofenceBillingTypes, *request.BillingType):
+		return teslamatedb.CreateGeofenceParams{}, fmt.Errorf("billing_type has to be one of %s", strings.Join(geofenceBillingTypes, ", "))
+	case request.CostPerUnit != nil && (*request.CostPerUnit < 0 || *request.CostPerUnit > 99.9999):
+		return teslamatedb.CreateGeofenceParams{}, fmt.Errorf("cost_per_unit has to be null or between 0 and 99.9999")
+	case request.SessionFee != nil && (*request.SessionFee < 0 || *request.SessionFee > 9999.99):
+		return teslamatedb.CreateGeofenceParams{}, fmt.Errorf("session_fee has to be null or between 0 and 9999.99")
+	}
+
+	params := teslamatedb.CreateGeofenceParams{
+		Name:        strings.TrimSpace(*request.Name),
+		Latitude:    math.Round(*request.Latitude*1e6) / 1e6,
+		Longitude:   math.Round(*request.Longitude*1e6) / 1e6,
+		Radius:      int16(*request.Radius),
+		BillingType: geofenceBillingTypes[0],
+	}
+	if request.BillingType != nil {
+		params.BillingType = *request.BillingType
+	}
+	if request.CostPerUnit != nil {
+		params.CostPerUnit = pgtype.Float8{Float64: *request.CostPerUnit, Valid: true}
+	}
+	if request.SessionFee != nil {
+		params.SessionFee = pgtype.Float8{Float64: *request.SessionFee, Valid: true}
+	}
+	return params, nil
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwtVerifier is nil unless JWT_JWKS is set, all api routes need a token with matching scopes otherwise
+var jwtVerifier *JWTVerifier
+
+// jwtAlgorithms are the accepted signing algorithms, HMAC is left out since the keys come from a JWKS
+var jwtAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// JWTVerifier validates JWTs of an identity provider and maps their claims to an apiIdentity
+type JWTVerifier struct {
+	keys        *JWKSet
+	issuer      string
+	audience    string
+	leeway      time.Duration
+	nameClaim   string              // claim used as name of the identity
+	scopeClaim  string              // claim with api scopes, a space separated string or an array
+	groupsClaim string              // claim with the groups of groupScopes
+	carsClaim   string              // claim with the car ids the identity may access
+	groupScopes map[string][]string // scopes granted by a group
+	err         error               // set if the verifier is misconfigured, every JWT is rejected with it
+}
+
+// initJWTVerifier func
+func initJWTVerifier() {
+	jwksLocation := getEnv("JWT_JWKS", "")
+	if jwksLocation == "" {
+		return
+	}
+
+	var groupScopes map[string][]string
+	if value := getEnv("JWT_GROUP_SCOPES", ""); value != "" {
+		if err := json.Unmarshal([]byte(value), &groupScopes); err != nil {
+			log.Println("[error] initJWTVerifier - JWT_GROUP_SCOPES has to be a json object of groups with their scopes, ignoring it: " + err.Error())
+			groupScopes = nil
+		}
+	}
+	for group, scopes := range groupScopes {
+		for _, scope := range scopes {
+			if !validScope(scope) {
+				log.Printf("[warning] initJWTVerifier - unknown scope %q of group %s in JWT_GROUP_SCOPES", scope, group)
+			}
+		}
+	}
+
+	// without iss and aud tokens issued for other applications by the same identity provider would be accepted,
+	// the verifier rejects every JWT then so the api stays closed instead of falling back to no authentication
+	issuer, audience := getEnv("JWT_ISSUER", ""), getEnv("JWT_AUDIENCE", "")
+	if issuer == "" || audience == "" {
+		log.Println("[error] initJWTVerifier - JWT_ISSUER and JWT_AUDIENCE have to be set with JWT_JWKS, no JWT will be accepted.")
+		jwtVerifier = &JWTVerifier{err: errors.New("JWT_ISSUER and JWT_AUDIENCE are not set")}
+		return
+	}
+
+	// an unreachable JWKS still enables the verifier, so no request is allowed without a valid token
+	jwtVerifier = &JWTVerifier{
+		keys:        NewJWKSet(jwksLocation, time.Duration(getEnvAsInt("JWT_JWKS_REFRESH_INTERVAL", 3600))*time.Second),
+		issuer:      issuer,
+		audience:    audience,
+		leeway:      time.Duration(getEnvAsInt("JWT_LEEWAY", 60)) * time.Second,
+		nameClaim:   getEnv("JWT_NAME_CLAIM", "sub"),
+		scopeClaim:  getEnv("JWT_SCOPE_CLAIM", "scope"),
+		groupsClaim: getEnv("JWT_GROUPS_CLAIM", "groups"),
+		carsClaim:   getEnv("JWT_CARS_CLAIM", "car_ids"),
+		groupScopes: groupScopes,
+	}
+	if err := jwtVerifier.keys.Refresh(context.Background()); err != nil {
+		log.Println("[error] initJWTVerifier error with JWT_JWKS: " + jwksLocation + " no JWT will be accepted until it's available: " + err.Error())
+	} else {
+		log.Printf("[info] initJWTVerifier - loaded %d keys from %s", jwtVerifier.keys.Len(), jwksLocation)
+	}
+}
+
+// looksLikeJWT func - checks if a token has the three parts of a JWT
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// Verify validates the signature, iss, aud, exp and nbf of a JWT and returns its identity
+func (v *JWTVerifier) Verify(ctx context.Context, token string) (*apiIdentity, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(jwtAlgorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(v.leeway),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	name, _ := claims[v.nameClaim].(string)
+	identity := &apiIdentity{Name: "jwt:" + name}
+
+	// scopes of the scope claim that are api scopes, others like openid are left out
+	for _, scope := range claimStrings(claims[v.scopeClaim]) {
+		if validScope(scope) {
+			identity.Scopes = append(identity.Scopes, scope)
+		}
+	}
+	for _, group := range claimStrings(claims[v.groupsClaim]) {
+		identity.Scopes = append(identity.Scopes, v.groupScopes[group]...)
+	}
+
+	if cars, ok := claims[v.carsClaim].([]interface{}); ok {
+		for _, car := range cars {
+			id, ok := car.(float64)
+			if !ok || id <= 0 || id != float64(int(id)) {
+				return nil, fmt.Errorf("claim %s has to be an array of car ids", v.carsClaim)
+			}
+			identity.CarIDs = append(identity.CarIDs, int(id))
+		}
+	}
+	return identity, nil
+}
+
+// claimStrings func - returns the values of a space separated string or an array of strings claim
+func claimStrings(claim interface{}) []string {
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []interface{}:
+		values := make([]string, 0, len(claim))
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// JWKSet holds the public keys of a JWKS file or URL, they are loaded again every refresh interval
+// and when a token is signed by an unknown key, so rotated keys are picked up
+type JWKSet struct {
+	location string
+	refresh  time.Duration
+	client   *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey // by kid
+	fetched   time.Time                   // last successful load
+	attempted time.Time                   // last load, limits reloads for unknown keys
+}
+
+// jwksMinRefresh limits how often unknown keys make the JWKS load again
+const jwksMinRefresh = 30 * time.Second
+
+func NewJWKSet(location string, refresh time.Duration) *JWKSet {
+	return &JWKSet{
+		location: location,
+		refresh:  refresh,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		keys:     map[string]crypto.PublicKey{},
+	}
+}
+
+// Len returns the number of loaded keys
+func (s *JWKSet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.keys)
+}
+
+// Key returns the key with kid, the only key if kid is empty and the set has one key,
+// stale or missing keys are loaded again at most every jwksMinRefresh
+func (s *JWKSet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	stale := time.Since(s.fetched) >= s.refresh
+	key, ok := s.lookup(kid)
+	retry := time.Since(s.attempted) >= jwksMinRefresh
+	s.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+	if retry {
+		if err := s.Refresh(ctx); err != nil {
+			log.Println("[warning] JWKSet - unable to load " + s.location + ", using the loaded keys: " + err.Error())
+		}
+		s.mu.RLock()
+		key, ok = s.lookup(kid)
+		s.mu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// lookup func - needs s.mu to be held
+func (s *JWKSet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// Refresh loads the keys of the file or URL, the loaded keys are kept on errors
+func (s *JWKSet) Refresh(ctx context.Context) error {
+	s.mu.Lock()
+	s.attempted = time.Now()
+	s.mu.Unlock()
+
+	data, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetched = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// read func - reads the JWKS from a http(s) URL or a file
+func (s *JWKSet) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.location, "http://") && !strings.HasPrefix(s.location, "https://") {
+		return os.ReadFile(s.location)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", s.location, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// jwk is a key of a JWKS, only the fields of RSA and EC public keys are read
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS func - parses the signing keys of a JWKS, keys of other types or uses are skipped
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsaPublicKey()
+		case "EC":
+			key, err = k.ecdsaPublicKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %d (%s): %w", i+1, k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RSA or EC signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+func (k jwk) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil {
+		return nil, errors.New("invalid coordinates")
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return key, nil
+}
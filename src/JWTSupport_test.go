@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// rsaJWK returns the JWK of the public key of an RSA key pair
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// ecJWK returns the JWK of the public key of a P-256 key pair
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+// testJWKS serves the keys it holds as JWKS and counts the requests
+type testJWKS struct {
+	keys     atomic.Value // []map[string]string
+	requests atomic.Int32
+	server   *httptest.Server
+}
+
+func newTestJWKS(t *testing.T, keys ...map[string]string) *testJWKS {
+	t.Helper()
+	jwks := &testJWKS{}
+	jwks.keys.Store(keys)
+	jwks.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jwks.requests.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": jwks.keys.Load()})
+	}))
+	t.Cleanup(jwks.server.Close)
+	return jwks
+}
+
+// signJWT returns a JWT of the test issuer signed by key, claims overwrite the defaults
+func signJWT(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	all := jwt.MapClaims{
+		"iss": "https://idp.example.com",
+		"aud": "teslamateapi",
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		if value == nil {
+			delete(all, name)
+			continue
+		}
+		all[name] = value
+	}
+	token := jwt.NewWithClaims(method, all)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func newTestJWTVerifier(location string) *JWTVerifier {
+	return &JWTVerifier{
+		keys:        NewJWKSet(location, time.Hour),
+		issuer:      "https://idp.example.com",
+		audience:    "teslamateapi",
+		nameClaim:   "sub",
+		scopeClaim:  "scope",
+		groupsClaim: "groups",
+		carsClaim:   "car_ids",
+		groupScopes: map[string][]string{"family": {"read:*"}, "admins": {"*"}},
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	jwks := newTestJWKS(t, rsaJWK("rsa-1", rsaKey))
+	verifier := newTestJWTVerifier(jwks.server.URL)
+	ctx := context.Background()
+
+	t.Run("valid token with scopes, groups and cars", func(t *testing.T) {
+		token := signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{
+			"scope":   "openid profile command:climate",
+			"groups":  []string{"family", "friends"},
+			"car_ids": []int{1},
+		})
+		identity, err := verifier.Verify(ctx, token)
+		if err != nil {
+			t.Fatalf("Expected valid token, got %v", err)
+		}
+		if identity.Name != "jwt:alice" {
+			t.Errorf("Expected name jwt:alice, got %s", identity.Name)
+		}
+		if !identity.hasScope("command:climate") || !identity.hasScope(scopeReadHistory) || identity.hasScope(scopeWriteDrives) || identity.hasScope("openid") {
+			t.Errorf("Expected command:climate and read:* scopes, got %v", identity.Scopes)
+		}
+		if !identity.allowsCar(1) || identity.allowsCar(2) {
+			t.Errorf("Expected only car 1, got %v", identity.CarIDs)
+		}
+	})
+
+	invalid := map[string]string{
+		"wrong issuer":      signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"iss": "https://other.example.com"}),
+		"wrong audience":    signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"aud": []string{"grafana"}}),
+		"expired":           signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}),
+		"without exp":       signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"exp": nil}),
+		"not yet valid":     signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()}),
+		"other key":         signJWT(t, jwt.SigningMethodRS256, "rsa-1", otherKey, nil),
+		"hmac with the jwk": signJWT(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), nil),
+		"invalid car ids":   signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"car_ids": []string{"1"}}),
+	}
+	for name, token := range invalid {
+		t.Run(name, func(t *testing.T) {
+			if identity, err := verifier.Verify(ctx, token); err == nil {
+				t.Errorf("Expected token to be rejected, got %v", identity)
+			}
+		})
+	}
+
+	t.Run("rotated keys are loaded again", func(t *testing.T) {
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate key: %v", err)
+		}
+		jwks.keys.Store([]map[string]string{ecJWK("ec-1", ecKey)})
+		verifier.keys.mu.Lock()
+		verifier.keys.attempted = time.Time{}
+		verifier.keys.mu.Unlock()
+		requests := jwks.requests.Load()
+
+		token := signJWT(t, jwt.SigningMethodES256, "ec-1", ecKey, jwt.MapClaims{"groups": []string{"admins"}})
+		identity, err := verifier.Verify(ctx, token)
+		if err != nil {
+			t.Fatalf("Expected token of the new key to be valid, got %v", err)
+		}
+		if !identity.hasScope(scopeAdmin) {
+			t.Errorf("Expected all scopes of the admins group, got %v", identity.Scopes)
+		}
+		if jwks.requests.Load() != requests+1 {
+			t.Errorf("Expected the JWKS to be loaded once more, got %d requests", jwks.requests.Load()-requests)
+		}
+
+		// the cached keys are used, unknown keys don't load the JWKS again right away
+		if _, err := verifier.Verify(ctx, token); err != nil {
+			t.Errorf("Expected token to stay valid, got %v", err)
+		}
+		if _, err := verifier.Verify(ctx, signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, nil)); err == nil {
+			t.Error("Expected token of the removed key to be rejected")
+		}
+		if jwks.requests.Load() != requests+1 {
+			t.Errorf("Expected the JWKS not to be loaded again, got %d requests", jwks.requests.Load()-requests)
+		}
+	})
+}
+
+func TestParseJWKSFile(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	data, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		ecJWK("ec-1", ecKey),
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		{"kty": "oct", "kid": "hmac-1", "k": "c2VjcmV0"},
+	}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+
+	keys := NewJWKSet(path, time.Hour)
+	if err := keys.Refresh(context.Background()); err != nil {
+		t.Fatalf("Expected JWKS to be loaded, got %v", err)
+	}
+	if keys.Len() != 1 {
+		t.Errorf("Expected only the signing key to be loaded, got %d keys", keys.Len())
+	}
+	if _, err := keys.Key(context.Background(), ""); err != nil {
+		t.Errorf("Expected the only key to be used without kid, got %v", err)
+	}
+
+	if _, err := parseJWKS([]byte(`{"keys": [{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": "AQAB", "y": "AQAB"}]}`)); err == nil {
+		t.Error("Expected error for a point that is not on the curve")
+	}
+	if _, err := parseJWKS([]byte(`{"keys": []}`)); err == nil {
+		t.Error("Expected error without signing keys")
+	}
+}
+
+func TestRouterWithJWT(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN_DISABLE", "false")
+	t.Setenv("AUTH_BACKOFF_BASE", "0")
+
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken("")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	jwks := newTestJWKS(t, rsaJWK("rsa-1", rsaKey))
+
+	originalVerifier := jwtVerifier
+	jwtVerifier = newTestJWTVerifier(jwks.server.URL)
+	defer func() { jwtVerifier = originalVerifier }()
+
+	router := newRouter()
+	request := func(path string, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request("/api/v2/auth/lockouts", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", code)
+	}
+	if code := request("/api/v2/auth/lockouts", signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"groups": []string{"family"}})); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without the admin scope, got %d", code)
+	}
+	if code := request("/api/v2/auth/lockouts", signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"scope": "admin"})); code != http.StatusOK {
+		t.Errorf("Expected status 200 with the admin scope, got %d", code)
+	}
+	if code := request("/api/v2/auth/lockouts", signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"scope": "admin", "aud": "other"})); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for another audience, got %d", code)
+	}
+
+	// a token of API_TOKENS_FILE with the three parts of a JWT is still accepted
+	path := filepath.Join(t.TempDir(), "api_tokens.json")
+	writeTokensFile(t, path, `[{"name": "dotted", "secret": "`+tokenSecret("svc.admin.token")+`", "scopes": ["admin"]}]`, time.Now())
+	store := NewTokenStore(path)
+	if _, err := store.Reload(); err != nil {
+		t.Fatalf("Failed to load tokens: %v", err)
+	}
+	originalStore := tokenStore
+	tokenStore = store
+	defer func() { tokenStore = originalStore }()
+	if code := request("/api/v2/auth/lockouts", "svc.admin.token"); code != http.StatusOK {
+		t.Errorf("Expected status 200 for a token of API_TOKENS_FILE, got %d", code)
+	}
+	if code := request("/api/v2/auth/lockouts", "svc.other.token"); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an unknown token, got %d", code)
+	}
+}
+
+func TestInitJWTVerifierWithoutIssuerOrAudience(t *testing.T) {
+	originalVerifier, originalStore := jwtVerifier, tokenStore
+	defer func() { jwtVerifier, tokenStore = originalVerifier, originalStore }()
+	tokenStore = nil
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	jwks := newTestJWKS(t, rsaJWK("rsa-1", rsaKey))
+	t.Setenv("JWT_JWKS", jwks.server.URL)
+	t.Setenv("JWT_ISSUER", "https://idp.example.com")
+	t.Setenv("JWT_AUDIENCE", "")
+	initJWTVerifier()
+
+	// the api stays closed, but no token of the identity provider is accepted
+	if !scopedAuthEnabled() {
+		t.Fatal("Expected tokens to be required without JWT_AUDIENCE")
+	}
+	if _, err := jwtVerifier.Verify(context.Background(), signJWT(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, jwt.MapClaims{"scope": "admin"})); err == nil {
+		t.Error("Expected JWT to be rejected without JWT_AUDIENCE")
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin context key of the AuthLimiter of the router
+const authLimiterKey = "AuthLimiter"
+
+// AuthLimiter slows down clients sending invalid tokens, every failure doubles the time a client
+// has to wait before the next attempt, and a client is locked out after AUTH_LOCKOUT_THRESHOLD failures
+type AuthLimiter struct {
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	threshold   int
+	lockout     time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*authClient // by client ip
+}
+
+// authClient is the state of a client with failed authentications
+type authClient struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+	lockedOut    bool
+}
+
+// AuthLockout is a client of AuthLimiter as returned by /auth/lockouts
+type AuthLockout struct {
+	IP           string    `json:"ip"`            // string
+	Failures     int       `json:"failures"`      // int
+	LastFailure  time.Time `json:"last_failure"`  // time
+	BlockedUntil time.Time `json:"blocked_until"` // time
+	Blocked      bool      `json:"blocked"`       // bool (backoff or lockout is still running)
+	LockedOut    bool      `json:"locked_out"`    // bool (AUTH_LOCKOUT_THRESHOLD was reached)
+}
+
+func NewAuthLimiter(backoffBase time.Duration, backoffMax time.Duration, threshold int, lockout time.Duration) *AuthLimiter {
+	return &AuthLimiter{
+		backoffBase: backoffBase,
+		backoffMax:  backoffMax,
+		threshold:   threshold,
+		lockout:     lockout,
+		now:         time.Now,
+		clients:     map[string]*authClient{},
+	}
+}
+
+// newAuthLimiterFromEnv func - returns an AuthLimiter configured by the AUTH_* environment variables
+func newAuthLimiterFromEnv() *AuthLimiter {
+	return NewAuthLimiter(
+		time.Duration(getEnvAsInt("AUTH_BACKOFF_BASE", 1))*time.Second,
+		time.Duration(getEnvAsInt("AUTH_BACKOFF_MAX", 60))*time.Second,
+		getEnvAsInt("AUTH_LOCKOUT_THRESHOLD", 10),
+		time.Duration(getEnvAsInt("AUTH_LOCKOUT_DURATION", 900))*time.Second,
+	)
+}
+
+// Blocked returns how long a client has to wait before its next attempt, zero if it may try now
+func (l *AuthLimiter) Blocked(ip string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	client, ok := l.clients[ip]
+	if !ok {
+		return 0
+	}
+	if wait := client.blockedUntil.Sub(l.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Failure records a failed authentication of a client
+func (l *AuthLimiter) Failure(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	client, ok := l.clients[ip]
+	if !ok {
+		client = &authClient{}
+		l.clients[ip] = client
+	}
+	client.failures++
+	client.lastFailure = now
+
+	if l.threshold > 0 && client.failures >= l.threshold {
+		if !client.lockedOut {
+			log.Printf("[warning] AuthLimiter - locking out %s for %s after %d failed authentications", ip, l.lockout, client.failures)
+		}
+		client.lockedOut = true
+		client.blockedUntil = now.Add(l.lockout)
+		return
+	}
+
+	backoff := time.Duration(float64(l.backoffBase) * math.Pow(2, float64(client.failures-1)))
+	if backoff > l.backoffMax || backoff < 0 {
+		backoff = l.backoffMax
+	}
+	client.blockedUntil = now.Add(backoff)
+}
+
+// Success forgets the failures of a client
+func (l *AuthLimiter) Success(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.clients, ip)
+}
+
+// Lockouts returns all clients with failed authentications, sorted by ip
+func (l *AuthLimiter) Lockouts() []AuthLockout {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	lockouts := make([]AuthLockout, 0, len(l.clients))
+	for ip, client := range l.clients {
+		lockouts = append(lockouts, AuthLockout{
+			IP:           ip,
+			Failures:     client.failures,
+			LastFailure:  client.lastFailure,
+			BlockedUntil: client.blockedUntil,
+			Blocked:      client.blockedUntil.After(now),
+			LockedOut:    client.lockedOut,
+		})
+	}
+	sort.Slice(lockouts, func(i, j int) bool { return lockouts[i].IP < lockouts[j].IP })
+	return lockouts
+}
+
+// prune forgets clients that didn't fail for the lockout duration after they were last blocked
+func (l *AuthLimiter) prune(now time.Time) {
+	for ip, client := range l.clients {
+		if now.After(client.blockedUntil.Add(l.lockout)) {
+			delete(l.clients, ip)
+		}
+	}
+}
+
+// authBackoff func - returns a gin middleware that rejects requests with a token while the client
+// has to wait after failed authentications, authenticate reports its results to limiter
+func authBackoff(limiter *AuthLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(authLimiterKey, limiter)
+
+		if !hasCredentials(c) || !hasClientIP(c) {
+			c.Next()
+			return
+		}
+		if wait := limiter.Blocked(c.ClientIP()); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusTooManyRequests, "authBackoff", "Too many failed authentications", "retry after "+wait.Round(time.Second).String())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// hasCredentials func - checks if a request contains a token
+func hasCredentials(c *gin.Context) bool {
+	return c.Request.Header.Get("Authorization") != "" || c.GetString(queryTokenKey) != ""
+}
+
+// hasClientIP func - checks if the request has an ip to limit, clients of a unix socket have none
+// and share the socket, so one of them sending invalid tokens must not lock out the others
+func hasClientIP(c *gin.Context) bool {
+	return c.ClientIP() != ""
+}
+
+// recordAuthentication func - reports the result of an authentication to the AuthLimiter of the request
+func recordAuthentication(c *gin.Context, success bool) {
+	limiter, ok := c.Get(authLimiterKey)
+	if !ok || !hasClientIP(c) {
+		return
+	}
+	if success {
+		limiter.(*AuthLimiter).Success(c.ClientIP())
+	} else {
+		limiter.(*AuthLimiter).Failure(c.ClientIP())
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewAuthLimiter(time.Second, 4*time.Second, 5, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	if wait := limiter.Blocked("192.0.2.1"); wait != 0 {
+		t.Fatalf("Expected unknown client not to be blocked, got %s", wait)
+	}
+
+	// every failure doubles the backoff up to backoffMax
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		limiter.Failure("192.0.2.1")
+		if wait := limiter.Blocked("192.0.2.1"); wait != expected {
+			t.Errorf("Expected backoff %s after failure %d, got %s", expected, i+1, wait)
+		}
+	}
+	if wait := limiter.Blocked("192.0.2.2"); wait != 0 {
+		t.Errorf("Expected other client not to be blocked, got %s", wait)
+	}
+
+	// the threshold locks the client out
+	limiter.Failure("192.0.2.1")
+	if wait := limiter.Blocked("192.0.2.1"); wait != time.Minute {
+		t.Errorf("Expected lockout of a minute, got %s", wait)
+	}
+	lockouts := limiter.Lockouts()
+	if len(lockouts) != 1 || lockouts[0].IP != "192.0.2.1" || lockouts[0].Failures != 5 || !lockouts[0].LockedOut || !lockouts[0].Blocked {
+		t.Errorf("Expected 192.0.2.1 to be locked out, got %+v", lockouts)
+	}
+
+	// the lockout ends, the client is forgotten after another lockout duration
+	now = now.Add(time.Minute)
+	if wait := limiter.Blocked("192.0.2.1"); wait != 0 {
+		t.Errorf("Expected lockout to have ended, got %s", wait)
+	}
+	if lockouts := limiter.Lockouts(); len(lockouts) != 1 || lockouts[0].Blocked {
+		t.Errorf("Expected 192.0.2.1 to be shown as not blocked, got %+v", lockouts)
+	}
+	now = now.Add(time.Minute + time.Second)
+	if lockouts := limiter.Lockouts(); len(lockouts) != 0 {
+		t.Errorf("Expected 192.0.2.1 to be forgotten, got %+v", lockouts)
+	}
+
+	// a success forgets the failures
+	limiter.Failure("192.0.2.3")
+	limiter.Success("192.0.2.3")
+	if wait := limiter.Blocked("192.0.2.3"); wait != 0 {
+		t.Errorf("Expected client to be forgotten after a success, got %s", wait)
+	}
+}
+
+func TestHashedSecret(t *testing.T) {
+	first, second := newHashedSecret("0123456789abcdef0123456789abcdef"), newHashedSecret("0123456789abcdef0123456789abcdef")
+	if string(first.salt) == string(second.salt) || string(first.sum) == string(second.sum) {
+		t.Error("Expected every hash of the same token to have its own salt")
+	}
+	if !first.matches("0123456789abcdef0123456789abcdef") || first.matches("0123456789abcdef0123456789abcde") {
+		t.Error("Expected only the token to match its hash")
+	}
+}
+
+func TestRouterAuthLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN_DISABLE", "false")
+	t.Setenv("AUTH_LOCKOUT_THRESHOLD", "2")
+
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken(envToken)
+
+	request := func(router *gin.Engine, path string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("invalid tokens lock the client out", func(t *testing.T) {
+		router := newRouter()
+
+		if w := request(router, "/api/v2/auth/lockouts", "invalid-token"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status 401, got %d: %s", w.Code, w.Body.String())
+		}
+		w := request(router, "/api/v2/auth/lockouts", envToken)
+		if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+			t.Fatalf("Expected status 429 with Retry-After 1 during the backoff, got %d with %q", w.Code, w.Header().Get("Retry-After"))
+		}
+		if !contains(w.Body.String(), `"code":"TOO_MANY_REQUESTS"`) {
+			t.Errorf("Expected TOO_MANY_REQUESTS error, got %s", w.Body.String())
+		}
+
+		// requests without token aren't affected
+		if w := request(router, "/api/v2/", ""); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200 without token, got %d", w.Code)
+		}
+	})
+
+	t.Run("admin endpoint shows lockouts", func(t *testing.T) {
+		t.Setenv("AUTH_BACKOFF_BASE", "0")
+		router := newRouter()
+
+		request(router, "/api/v2/auth/lockouts", "invalid-token")
+		w := request(router, "/api/v2/auth/lockouts", envToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data struct {
+				Lockouts []AuthLockout `json:"lockouts"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		// the valid token of the same client forgets its failures
+		if len(response.Data.Lockouts) != 0 {
+			t.Errorf("Expected no lockouts after a valid token, got %+v", response.Data.Lockouts)
+		}
+
+		request(router, "/api/v2/auth/lockouts", "invalid-token")
+		request(router, "/api/v2/auth/lockouts", "invalid-token")
+		if w := request(router, "/api/v2/auth/lockouts", envToken); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected status 429 after the threshold, got %d", w.Code)
+		}
+	})
+
+	t.Run("admin endpoint needs a token", func(t *testing.T) {
+		router := newRouter()
+		if w := request(router, "/api/v2/auth/lockouts", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestTokenQueryParameter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN_DISABLE", "false")
+
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken(envToken)
+
+	router := newRouter()
+	router.GET("/test/uri", func(c *gin.Context) { c.String(http.StatusOK, c.Request.RequestURI) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/test/uri?page=2&token="+envToken, nil))
+	if w.Body.String() != "/test/uri?page=2" {
+		t.Errorf("Expected token to be removed from the request uri, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/auth/lockouts?token="+envToken, nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with token query parameter, got %d: %s", w.Code, w.Body.String())
+	}
+
+	t.Setenv("API_TOKEN_QUERY_DISABLE", "true")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/auth/lockouts?token="+envToken, nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with disabled token query parameter, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -4,15 +4,16 @@ package main
 //   https://gist.github.com/rsudip90/022c4ef5d98130a224c9239e0a1ab397
 
 import (
-	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-// NullInt64 is an alias for sql.NullInt64 data type
+// NullInt64 is an alias for pgtype.Int8 data type
 type NullInt64 struct {
-	sql.NullInt64
+	pgtype.Int8
 }
 
 // MarshalJSON for NullInt64
@@ -23,9 +24,19 @@ func (ni *NullInt64) MarshalJSON() ([]byte, error) {
 	return json.Marshal(ni.Int64)
 }
 
-// NullBool is an alias for sql.NullBool data type
+// nullInt16ToNullInt64 converts a smallint column into NullInt64
+func nullInt16ToNullInt64(ni pgtype.Int2) NullInt64 {
+	return NullInt64{pgtype.Int8{Int64: int64(ni.Int16), Valid: ni.Valid}}
+}
+
+// nullInt32ToNullInt64 converts an integer column into NullInt64
+func nullInt32ToNullInt64(ni pgtype.Int4) NullInt64 {
+	return NullInt64{pgtype.Int8{Int64: int64(ni.Int32), Valid: ni.Valid}}
+}
+
+// NullBool is an alias for pgtype.Bool data type
 type NullBool struct {
-	sql.NullBool
+	pgtype.Bool
 }
 
 // MarshalJSON for NullBool
@@ -33,12 +44,12 @@ func (nb *NullBool) MarshalJSON() ([]byte, error) {
 	if !nb.Valid {
 		return []byte("null"), nil
 	}
-	return json.Marshal(nb.Bool)
+	return json.Marshal(nb.Bool.Bool)
 }
 
-// NullFloat64 is an alias for sql.NullFloat64 data type
+// NullFloat64 is an alias for pgtype.Float8 data type
 type NullFloat64 struct {
-	sql.NullFloat64
+	pgtype.Float8
 }
 
 // MarshalJSON for NullFloat64
@@ -49,6 +60,32 @@ func (nf *NullFloat64) MarshalJSON() ([]byte, error) {
 	return json.Marshal(nf.Float64)
 }
 
+// NullText is an alias for pgtype.Text data type (unlike NullString, NULL is returned as null)
+type NullText struct {
+	pgtype.Text
+}
+
+// MarshalJSON for NullText
+func (nt *NullText) MarshalJSON() ([]byte, error) {
+	if !nt.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(nt.String)
+}
+
+// NullTime is an alias for pgtype.Timestamp data type
+type NullTime struct {
+	pgtype.Timestamp
+}
+
+// MarshalJSON for NullTime
+func (nt *NullTime) MarshalJSON() ([]byte, error) {
+	if !nt.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(nt.Time)
+}
+
 type NullString string
 
 func (s *NullString) Scan(value interface{}) error {
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// linearRegression func - least squares fit of y = intercept + slope * x, ok is false
+// if there are less than two different x
+func linearRegression(xs []float64, ys []float64) (slope float64, intercept float64, ok bool) {
+	n := float64(len(xs))
+	if len(xs) < 2 || len(xs) != len(ys) {
+		return 0, 0, false
+	}
+
+	var sumX, sumY, sumXX, sumXY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+		sumXX += xs[i] * xs[i]
+		sumXY += xs[i] * ys[i]
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sumXY - sumX*sumY) / denominator
+	intercept = (sumY - slope*sumX) / n
+	return slope, intercept, true
+}
+
+// monthsBetween func - number of calendar months from the month of a to the month of b
+func monthsBetween(a time.Time, b time.Time) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
+
+// statisticsPeriodFormats - label of a bucket for each period supported by DATE_TRUNC, weeks start on monday
+var statisticsPeriodFormats = map[string]func(t time.Time) string{
+	"day":   func(t time.Time) string { return t.Format("2006-01-02") },
+	"week":  func(t time.Time) string { year, week := t.ISOWeek(); return fmt.Sprintf("%04d-W%02d", year, week) },
+	"month": func(t time.Time) string { return t.Format("2006-01") },
+	"year":  func(t time.Time) string { return t.Format("2006") },
+}
+
+// statisticsPeriodStart func - start of a bucket truncated by the database in time zone loc
+func statisticsPeriodStart(bucket time.Time, loc *time.Location) time.Time {
+	return time.Date(bucket.Year(), bucket.Month(), bucket.Day(), 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLinearRegression(t *testing.T) {
+	slope, intercept, ok := linearRegression([]float64{0, 1, 2, 3}, []float64{75, 74.5, 74, 73.5})
+	if !ok || math.Abs(slope+0.5) > 1e-9 || math.Abs(intercept-75) > 1e-9 {
+		t.Errorf("Expected slope -0.5 and intercept 75, got %v %v %v", slope, intercept, ok)
+	}
+	if _, _, ok := linearRegression([]float64{1}, []float64{75}); ok {
+		t.Errorf("Expected no regression of a single point")
+	}
+	if _, _, ok := linearRegression([]float64{2, 2}, []float64{75, 74}); ok {
+		t.Errorf("Expected no regression of points with the same x")
+	}
+}
+
+func TestMonthsBetween(t *testing.T) {
+	a := time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC)
+	b := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	if got := monthsBetween(a, b); got != 3 {
+		t.Errorf("Expected 3 months, got %d", got)
+	}
+}
+
+func TestStatisticsPeriodFormats(t *testing.T) {
+	bucket := time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)
+	expected := map[string]string{"day": "2024-12-30", "week": "2025-W01", "month": "2024-12", "year": "2024"}
+	for period, label := range expected {
+		if got := statisticsPeriodFormats[period](bucket); got != label {
+			t.Errorf("Expected %s label %s, got %s", period, label, got)
+		}
+	}
+}
+
+func TestStatisticsPeriodStart(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	start := statisticsPeriodStart(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), loc)
+	if got := start.Format(time.RFC3339); got != "2024-07-01T00:00:00+02:00" {
+		t.Errorf("Expected the bucket to start at local midnight, got %s", got)
+	}
+}
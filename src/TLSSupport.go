@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unixSocketPrefix marks a LISTEN_ADDRESS as path of a unix domain socket
+const unixSocketPrefix = "unix:"
+
+// listen func - listens on a tcp address like :8080 or on a unix domain socket like unix:/run/teslamateapi.sock
+func listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, unixSocketPrefix)
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+
+	// a socket of a previous run is removed, other files are kept
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := strconv.ParseUint(getEnv("LISTEN_SOCKET_MODE", "0660"), 8, 32)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("LISTEN_SOCKET_MODE has to be an octal file mode: %w", err)
+	}
+	if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// TLSFiles holds the certificate, key and client CAs of TLS_CERT_FILE, TLS_KEY_FILE and TLS_CLIENT_CA_FILE
+// and reloads them when one of the files changes
+type TLSFiles struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+}
+
+func NewTLSFiles(certFile string, keyFile string, clientCAFile string) *TLSFiles {
+	return &TLSFiles{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+}
+
+// files returns the paths of all files
+func (f *TLSFiles) files() []string {
+	files := []string{f.certFile, f.keyFile}
+	if f.clientCAFile != "" {
+		files = append(files, f.clientCAFile)
+	}
+	return files
+}
+
+// Reload reads the files again if one of them changed since the last load, the loaded files are kept on errors
+func (f *TLSFiles) Reload() (bool, error) {
+	modTimes := make([]time.Time, 0, 3)
+	for _, file := range f.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+
+	f.mu.RLock()
+	unchanged := len(f.modTimes) == len(modTimes)
+	for i := 0; unchanged && i < len(modTimes); i++ {
+		unchanged = modTimes[i].Equal(f.modTimes[i])
+	}
+	f.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return false, err
+	}
+	var clientCAs *x509.CertPool
+	if f.clientCAFile != "" {
+		pem, err := os.ReadFile(f.clientCAFile)
+		if err != nil {
+			return false, err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return false, errors.New(f.clientCAFile + " contains no PEM certificates")
+		}
+	}
+
+	f.mu.Lock()
+	f.cert = &cert
+	f.clientCAs = clientCAs
+	f.modTimes = modTimes
+	f.mu.Unlock()
+	return true, nil
+}
+
+// Watch reloads the files every interval until stop is closed
+func (f *TLSFiles) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if changed, err := f.Reload(); err != nil {
+				log.Println("[error] TLSFiles - unable to reload the certificate, keeping the loaded one: " + err.Error())
+			} else if changed {
+				log.Println("[info] TLSFiles - reloaded the certificate " + f.certFile)
+			}
+		}
+	}
+}
+
+// TLSConfig returns a tls.Config serving the loaded certificate, client certificates signed by the
+// client CAs are verified (and required if requireClientCert is true)
+func (f *TLSFiles) TLSConfig(requireClientCert bool) *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			f.mu.RLock()
+			defer f.mu.RUnlock()
+			return f.cert, nil
+		},
+	}
+	if f.clientCAFile == "" {
+		return config
+	}
+
+	clientAuth := tls.VerifyClientCertIfGiven
+	if requireClientCert {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		f.mu.RLock()
+		defer f.mu.RUnlock()
+		clientConfig := config.Clone()
+		clientConfig.GetConfigForClient = nil
+		clientConfig.ClientAuth = clientAuth
+		clientConfig.ClientCAs = f.clientCAs
+		return clientConfig, nil
+	}
+	return config
+}
+
+// initTLS func - returns the TLSFiles of TLS_CERT_FILE and TLS_KEY_FILE, nil to serve plain http
+func initTLS() (*TLSFiles, error) {
+	certFile, keyFile := getEnv("TLS_CERT_FILE", ""), getEnv("TLS_KEY_FILE", "")
+	clientCAFile := getEnv("TLS_CLIENT_CA_FILE", "")
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, errors.New("TLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE have to be set both")
+	}
+
+	files := NewTLSFiles(certFile, keyFile, clientCAFile)
+	if _, err := files.Reload(); err != nil {
+		return nil, err
+	}
+
+	interval := time.Duration(getEnvAsInt("TLS_RELOAD_INTERVAL", 10)) * time.Second
+	go files.Watch(interval, nil)
+	return files, nil
+}
+
+// serve func - serves server on LISTEN_ADDRESS, with TLS if TLS_CERT_FILE and TLS_KEY_FILE are set
+func serve(server *http.Server) error {
+	files, err := initTLS()
+	if err != nil {
+		return err
+	}
+
+	address := getEnv("LISTEN_ADDRESS", ":8080")
+	listener, err := listen(address)
+	if err != nil {
+		return err
+	}
+
+	if files == nil {
+		log.Println("[info] TeslaMateApi listening on " + address)
+		return server.Serve(listener)
+	}
+
+	server.TLSConfig = files.TLSConfig(getEnvAsBool("TLS_CLIENT_AUTH_REQUIRED", false))
+	if files.clientCAFile != "" {
+		if getEnv("API_TOKENS_FILE", "") == "" && !getEnvAsBool("TLS_CLIENT_CERT_FULL_ACCESS", false) {
+			log.Println("[warning] serve - client certificates are only accepted with a client_subject of API_TOKENS_FILE, or with full access if TLS_CLIENT_CERT_FULL_ACCESS is true.")
+		}
+		log.Println("[info] TeslaMateApi listening with TLS and client certificates on " + address)
+	} else {
+		log.Println("[info] TeslaMateApi listening with TLS on " + address)
+	}
+	return server.ServeTLS(listener, "", "")
+}
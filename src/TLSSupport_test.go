@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testCertificate is a certificate with its key, signed by parent or self-signed if parent is nil
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate, modify func(*x509.Certificate)) *testCertificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if modify != nil {
+		modify(template)
+	}
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return &testCertificate{cert: cert, key: key}
+}
+
+// write stores the certificate and key as PEM files
+func (c *testCertificate) write(t *testing.T, certFile string, keyFile string) {
+	t.Helper()
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if keyFile != "" {
+		if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+			t.Fatalf("Failed to write key: %v", err)
+		}
+	}
+}
+
+func (c *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+
+	if err := os.WriteFile(path, []byte("not a socket"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := listen(unixSocketPrefix + path); err == nil {
+		t.Fatal("Expected error for a file that is not a socket")
+	}
+	os.Remove(path)
+
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN_DISABLE", "false")
+	t.Setenv("AUTH_BACKOFF_BASE", "0")
+	t.Setenv("AUTH_LOCKOUT_THRESHOLD", "1")
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken(envToken)
+
+	listener, err := listen(unixSocketPrefix + path)
+	if err != nil {
+		t.Fatalf("Expected to listen on the socket, got %v", err)
+	}
+	server := &http.Server{Handler: newRouter()}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o660 {
+		t.Errorf("Expected socket with mode 0660, got %v, %v", info, err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "unix", path)
+	}}}
+	request := func(token string) int {
+		req, _ := http.NewRequest(http.MethodGet, "http://teslamateapi/api/v2/auth/lockouts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Expected request over the socket to succeed, got %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := request(envToken); code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", code)
+	}
+
+	// the clients of the socket have no ip, one sending invalid tokens doesn't lock out the others
+	for i := 0; i < 3; i++ {
+		if code := request("invalid-token"); code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for an invalid token, got %d", code)
+		}
+	}
+	if code := request(envToken); code != http.StatusOK {
+		t.Errorf("Expected clients of the socket not to be locked out, got %d", code)
+	}
+}
+
+func TestTLSFilesReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+
+	first := newTestCertificate(t, "first", nil, nil)
+	first.write(t, certFile, keyFile)
+	modTime := time.Now().Add(-time.Hour)
+	os.Chtimes(certFile, modTime, modTime)
+
+	files := NewTLSFiles(certFile, keyFile, "")
+	if changed, err := files.Reload(); err != nil || !changed {
+		t.Fatalf("Expected certificate to be loaded, got %v, %v", changed, err)
+	}
+	config := files.TLSConfig(false)
+	served := func() string {
+		cert, err := config.GetCertificate(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatalf("Expected certificate, got %v", err)
+		}
+		leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+		return leaf.Subject.CommonName
+	}
+	if name := served(); name != "first" {
+		t.Errorf("Expected first certificate, got %s", name)
+	}
+
+	if changed, err := files.Reload(); err != nil || changed {
+		t.Errorf("Expected no reload of unchanged files, got %v, %v", changed, err)
+	}
+
+	// a broken key keeps the loaded certificate
+	second := newTestCertificate(t, "second", nil, nil)
+	second.write(t, certFile, "")
+	if _, err := files.Reload(); err == nil {
+		t.Error("Expected error for a certificate not matching the key")
+	}
+	if name := served(); name != "first" {
+		t.Errorf("Expected first certificate to be kept, got %s", name)
+	}
+
+	second.write(t, certFile, keyFile)
+	if changed, err := files.Reload(); err != nil || !changed {
+		t.Fatalf("Expected certificate to be reloaded, got %v, %v", changed, err)
+	}
+	if name := served(); name != "second" {
+		t.Errorf("Expected second certificate, got %s", name)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN_DISABLE", "false")
+	t.Setenv("AUTH_BACKOFF_BASE", "0")
+
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken("")
+
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "TeslaMateApi Test CA", nil, func(c *x509.Certificate) {
+		c.IsCA = true
+		c.BasicConstraintsValid = true
+		c.KeyUsage |= x509.KeyUsageCertSign
+	})
+	ca.write(t, filepath.Join(dir, "ca.crt"), "")
+	server := newTestCertificate(t, "teslamateapi", ca, func(c *x509.Certificate) {
+		c.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	})
+	server.write(t, filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	clientCert := func(commonName string, dnsNames ...string) tls.Certificate {
+		return newTestCertificate(t, commonName, ca, func(c *x509.Certificate) {
+			c.DNSNames = dnsNames
+			c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		}).tlsCertificate()
+	}
+
+	writeTokensFile(t, filepath.Join(dir, "api_tokens.json"), `[
+		{"name": "home-assistant", "client_subject": "home-assistant.local", "scopes": ["admin"]},
+		{"name": "dashboard", "client_subject": "dashboard", "scopes": ["read:*"]}
+	]`, time.Now())
+	store := NewTokenStore(filepath.Join(dir, "api_tokens.json"))
+	if _, err := store.Reload(); err != nil {
+		t.Fatalf("Failed to load tokens: %v", err)
+	}
+	originalStore := tokenStore
+	tokenStore = store
+	defer func() { tokenStore = originalStore }()
+
+	files := NewTLSFiles(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt"))
+	if _, err := files.Reload(); err != nil {
+		t.Fatalf("Failed to load certificates: %v", err)
+	}
+	listener, err := listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	httpServer := &http.Server{Handler: newRouter(), TLSConfig: files.TLSConfig(false)}
+	go func() { _ = httpServer.ServeTLS(listener, "", "") }()
+	defer httpServer.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	request := func(certificates ...tls.Certificate) int {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates}}}
+		resp, err := client.Get("https://" + listener.Addr().String() + "/api/v2/auth/lockouts")
+		if err != nil {
+			t.Fatalf("Expected request to succeed, got %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := request(); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without client certificate, got %d", code)
+	}
+	if code := request(clientCert("Home Assistant", "home-assistant.local")); code != http.StatusOK {
+		t.Errorf("Expected status 200 for the SAN of a token with the admin scope, got %d", code)
+	}
+	if code := request(clientCert("dashboard")); code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for the CN of a token without the admin scope, got %d", code)
+	}
+	if code := request(clientCert("unknown")); code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for a certificate without token, got %d", code)
+	}
+
+	// certificates of other CAs are rejected in the handshake
+	other := newTestCertificate(t, "home-assistant.local", nil, nil).tlsCertificate()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return &other, nil
+	}}}}
+	if resp, err := client.Get("https://" + listener.Addr().String() + "/api/v2/auth/lockouts"); err == nil {
+		resp.Body.Close()
+		t.Errorf("Expected certificate of another CA to be rejected, got status %d", resp.StatusCode)
+	}
+}
+
+func TestClientCertificateWithoutTokensFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN_DISABLE", "false")
+	originalStore := tokenStore
+	tokenStore = nil
+	defer func() { tokenStore = originalStore }()
+
+	cert := newTestCertificate(t, "grafana", nil, nil).cert
+	check := func() (*apiIdentity, string) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/cars", nil)
+		c.Request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		identity, errorMessage, _ := checkRequestToken(c)
+		return identity, errorMessage
+	}
+
+	if identity, errorMessage := check(); identity != nil || errorMessage == "" {
+		t.Errorf("Expected certificate without client_subject to be rejected, got %+v", identity)
+	}
+	t.Setenv("TLS_CLIENT_CERT_FULL_ACCESS", "true")
+	if identity, _ := check(); identity == nil || identity.Name != "client:grafana" || !identity.hasScope("command:*") {
+		t.Errorf("Expected full access with TLS_CLIENT_CERT_FULL_ACCESS, got %+v", identity)
+	}
+}
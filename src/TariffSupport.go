@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tariffs are the time-of-use tariffs read from TARIFFS_CONFIG
+var tariffs []tariff
+
+// tariffRate is the price of a time window
+type tariffRate struct {
+	clockWindow
+	PricePerKWh float64 `json:"price_per_kwh"` // price of the window
+}
+
+// tariff is the price of charging at some geofences between valid_from and valid_until
+type tariff struct {
+	Name        string       `json:"name"`
+	GeofenceIDs []int        `json:"geofence_ids"`  // TeslaMate geofences, every location if empty
+	ValidFrom   string       `json:"valid_from"`    // YYYY-MM-DD in TZ, inclusive
+	ValidUntil  string       `json:"valid_until"`   // YYYY-MM-DD in TZ, exclusive
+	SessionFee  float64      `json:"session_fee"`   // fixed fee per charge
+	PricePerKWh float64      `json:"price_per_kwh"` // price outside of the rates
+	Rates       []tariffRate `json:"rates"`
+
+	validFrom, validUntil time.Time
+}
+
+// tariffSample is the energy added to the car up to a point in time
+type tariffSample struct {
+	Date        time.Time
+	EnergyAdded float64
+}
+
+// tariffCostWindow is the energy and cost of a charge at one price
+type tariffCostWindow struct {
+	Tariff      string  `json:"tariff"`        // string
+	Window      string  `json:"window"`        // string (HH:MM-HH:MM or default)
+	PricePerKWh float64 `json:"price_per_kwh"` // float64
+	Energy      float64 `json:"energy"`        // float64 (kWh)
+	Cost        float64 `json:"cost"`          // float64
+}
+
+// tariffCost is the cost breakdown of a charge
+type tariffCost struct {
+	Tariff         string             `json:"tariff"`          // string (tariff at the start of the charge)
+	SessionFee     float64            `json:"session_fee"`     // float64
+	Energy         float64            `json:"energy"`          // float64 (kWh)
+	UnpricedEnergy float64            `json:"unpriced_energy"` // float64 (kWh without a matching tariff)
+	Cost           float64            `json:"cost"`            // float64 (rounded to cents)
+	Windows        []tariffCostWindow `json:"windows"`         // []tariffCostWindow
+}
+
+// initTariffs func
+func initTariffs() {
+	tariffsLocation := getEnv("TARIFFS_CONFIG", "tariffs.json")
+	data, err := os.ReadFile(tariffsLocation)
+	if err != nil {
+		log.Println("[info] initTariffs - TARIFFS_CONFIG: " + tariffsLocation + " not found, charge costs can't be calculated")
+		return
+	}
+
+	tariffs, err = parseTariffs(data, appUsersTimezone)
+	if err != nil {
+		log.Println("[error] initTariffs error with TARIFFS_CONFIG: " + tariffsLocation + " it will be ignored: " + err.Error())
+		return
+	}
+
+	if gin.IsDebugging() {
+		log.Printf("[info] initTariffs - loaded %d tariffs from %s", len(tariffs), tariffsLocation)
+	}
+}
+
+// parseTariffs func - parses and validates the tariffs of a TARIFFS_CONFIG file
+func parseTariffs(data []byte, loc *time.Location) ([]tariff, error) {
+	var parsed []tariff
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	for i := range parsed {
+		t := &parsed[i]
+		if t.Name == "" {
+			t.Name = fmt.Sprintf("tariff %d", i+1)
+		}
+
+		var err error
+		if t.ValidFrom != "" {
+			if t.validFrom, err = time.ParseInLocation(time.DateOnly, t.ValidFrom, loc); err != nil {
+				return nil, fmt.Errorf("%s: invalid valid_from %q", t.Name, t.ValidFrom)
+			}
+		}
+		if t.ValidUntil != "" {
+			if t.validUntil, err = time.ParseInLocation(time.DateOnly, t.ValidUntil, loc); err != nil {
+				return nil, fmt.Errorf("%s: invalid valid_until %q", t.Name, t.ValidUntil)
+			}
+		}
+
+		for j := range t.Rates {
+			if err := t.Rates[j].parse(); err != nil {
+				return nil, fmt.Errorf("%s: %w", t.Name, err)
+			}
+		}
+	}
+	return parsed, nil
+}
+
+// findTariff func - returns the tariff of a geofence at a time, tariffs of the geofence take precedence over tariffs of every location
+func findTariff(tariffs []tariff, geofenceID int, t time.Time) *tariff {
+	var fallback *tariff
+	for i := range tariffs {
+		candidate := &tariffs[i]
+		if (!candidate.validFrom.IsZero() && t.Before(candidate.validFrom)) || (!candidate.validUntil.IsZero() && !t.Before(candidate.validUntil)) {
+			continue
+		}
+		if len(candidate.GeofenceIDs) == 0 {
+			if fallback == nil {
+				fallback = candidate
+			}
+			continue
+		}
+		for _, id := range candidate.GeofenceIDs {
+			if id == geofenceID {
+				return candidate
+			}
+		}
+	}
+	return fallback
+}
+
+// priceAt func - returns the price and the name of the window of a tariff at a time in loc
+func (t *tariff) priceAt(at time.Time, loc *time.Location) (float64, string) {
+	for _, r := range t.Rates {
+		if r.contains(at, loc) {
+			return r.PricePerKWh, r.From + "-" + r.To
+		}
+	}
+	return t.PricePerKWh, "default"
+}
+
+// nextTariffBoundary func - returns the next time after at where the price of any tariff could change
+func nextTariffBoundary(tariffs []tariff, at time.Time, loc *time.Location) time.Time {
+	var next time.Time
+	consider := func(candidate time.Time) {
+		if candidate.After(at) && (next.IsZero() || candidate.Before(next)) {
+			next = candidate
+		}
+	}
+
+	local := at.In(loc)
+	for day := 0; day <= 1; day++ {
+		consider(time.Date(local.Year(), local.Month(), local.Day()+day, 0, 0, 0, 0, loc))
+		for _, t := range tariffs {
+			for _, r := range t.Rates {
+				consider(time.Date(local.Year(), local.Month(), local.Day()+day, 0, r.from, 0, 0, loc))
+				consider(time.Date(local.Year(), local.Month(), local.Day()+day, 0, r.to, 0, 0, loc))
+			}
+		}
+	}
+	for _, t := range tariffs {
+		if !t.validFrom.IsZero() {
+			consider(t.validFrom)
+		}
+		if !t.validUntil.IsZero() {
+			consider(t.validUntil)
+		}
+	}
+	return next
+}
+
+// calculateChargeCost func - integrates the energy added between the samples of a charge over the tariff windows,
+// energy added between two samples is split proportional to the time spent in each window
+func calculateChargeCost(tariffs []tariff, geofenceID int, start time.Time, samples []tariffSample, loc *time.Location) tariffCost {
+	cost := tariffCost{Windows: []tariffCostWindow{}}
+	if startTariff := findTariff(tariffs, geofenceID, start); startTariff != nil {
+		cost.Tariff = startTariff.Name
+		cost.SessionFee = startTariff.SessionFee
+	}
+
+	windows := map[[2]string]*tariffCostWindow{}
+	addEnergy := func(at time.Time, energy float64) {
+		cost.Energy += energy
+		t := findTariff(tariffs, geofenceID, at)
+		if t == nil {
+			cost.UnpricedEnergy += energy
+			return
+		}
+		price, name := t.priceAt(at, loc)
+		key := [2]string{t.Name, name}
+		if windows[key] == nil {
+			windows[key] = &tariffCostWindow{Tariff: t.Name, Window: name, PricePerKWh: price}
+		}
+		windows[key].Energy += energy
+		windows[key].Cost += energy * price
+	}
+
+	for i := 1; i < len(samples); i++ {
+		energy := samples[i].EnergyAdded - samples[i-1].EnergyAdded
+		from, to := samples[i-1].Date, samples[i].Date
+		if energy <= 0 {
+			continue
+		}
+		if !to.After(from) {
+			addEnergy(from, energy)
+			continue
+		}
+		for at := from; at.Before(to); {
+			next := nextTariffBoundary(tariffs, at, loc)
+			if next.After(to) {
+				next = to
+			}
+			addEnergy(at, energy*float64(next.Sub(at))/float64(to.Sub(from)))
+			at = next
+		}
+	}
+
+	total := cost.SessionFee
+	for _, window := range windows {
+		total += window.Cost
+		cost.Windows = append(cost.Windows, *window)
+	}
+	sort.Slice(cost.Windows, func(i, j int) bool {
+		if cost.Windows[i].Tariff != cost.Windows[j].Tariff {
+			return cost.Windows[i].Tariff < cost.Windows[j].Tariff
+		}
+		return cost.Windows[i].Window < cost.Windows[j].Window
+	})
+	cost.Cost = math.Round(total*100) / 100
+	return cost
+}
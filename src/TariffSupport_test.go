@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const testTariffs = `[
+	{"name": "home", "geofence_ids": [3], "valid_from": "2024-01-01", "session_fee": 0.5, "price_per_kwh": 0.30,
+	 "rates": [{"days": ["mon", "tue", "wed", "thu", "fri"], "from": "22:00", "to": "06:00", "price_per_kwh": 0.10}]},
+	{"name": "public", "price_per_kwh": 0.50}
+]`
+
+func TestParseTariffs(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	parsed, err := parseTariffs([]byte(testTariffs), loc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(parsed) != 2 || parsed[0].validFrom.Format(time.RFC3339) != "2024-01-01T00:00:00+01:00" {
+		t.Errorf("Unexpected tariffs: %+v", parsed)
+	}
+	if rate := parsed[0].Rates[0]; rate.from != 22*60 || rate.to != 6*60 || !rate.days[time.Monday] || rate.days[time.Sunday] {
+		t.Errorf("Unexpected rate: %+v", rate)
+	}
+
+	for _, invalid := range []string{`[{"rates": [{"from": "25:00", "to": "06:00"}]}]`, `[{"rates": [{"days": ["monday"], "from": "22:00", "to": "06:00"}]}]`, `[{"valid_from": "01.01.2024"}]`, `{}`} {
+		if _, err := parseTariffs([]byte(invalid), loc); err == nil {
+			t.Errorf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestFindTariff(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	parsed, _ := parseTariffs([]byte(testTariffs), loc)
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, loc)
+	if tariff := findTariff(parsed, 3, at); tariff == nil || tariff.Name != "home" {
+		t.Errorf("Expected the home tariff at geofence 3, got %v", tariff)
+	}
+	if tariff := findTariff(parsed, 4, at); tariff == nil || tariff.Name != "public" {
+		t.Errorf("Expected the public tariff at other geofences, got %v", tariff)
+	}
+	if tariff := findTariff(parsed, 3, time.Date(2023, 12, 31, 23, 0, 0, 0, loc)); tariff == nil || tariff.Name != "public" {
+		t.Errorf("Expected the public tariff before the home tariff is valid, got %v", tariff)
+	}
+}
+
+func TestTariffPriceAt(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	parsed, _ := parseTariffs([]byte(testTariffs), loc)
+	home := &parsed[0]
+
+	tests := []struct {
+		at     time.Time
+		price  float64
+		window string
+	}{
+		{time.Date(2024, 5, 1, 23, 0, 0, 0, loc), 0.10, "22:00-06:00"}, // wednesday night
+		{time.Date(2024, 5, 2, 5, 59, 0, 0, loc), 0.10, "22:00-06:00"}, // thursday morning
+		{time.Date(2024, 5, 2, 6, 0, 0, 0, loc), 0.30, "default"},
+		{time.Date(2024, 5, 4, 2, 0, 0, 0, loc), 0.10, "22:00-06:00"}, // saturday morning after friday night
+		{time.Date(2024, 5, 5, 23, 0, 0, 0, loc), 0.30, "default"},    // sunday
+		{time.Date(2024, 5, 6, 2, 0, 0, 0, loc), 0.30, "default"},     // monday morning after sunday night
+	}
+	for _, test := range tests {
+		if price, window := home.priceAt(test.at, loc); price != test.price || window != test.window {
+			t.Errorf("Expected %v (%s) at %s, got %v (%s)", test.price, test.window, test.at, price, window)
+		}
+	}
+}
+
+func TestCalculateChargeCost(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Berlin")
+	parsed, _ := parseTariffs([]byte(testTariffs), loc)
+
+	// 10 kWh added evenly from 21:00 to 23:00, half of it before the night rate starts
+	start := time.Date(2024, 5, 1, 21, 0, 0, 0, loc)
+	samples := []tariffSample{{Date: start}, {Date: start.Add(2 * time.Hour), EnergyAdded: 10}}
+	cost := calculateChargeCost(parsed, 3, start, samples, loc)
+
+	if cost.Tariff != "home" || cost.SessionFee != 0.5 || cost.Energy != 10 || cost.UnpricedEnergy != 0 {
+		t.Errorf("Unexpected cost: %+v", cost)
+	}
+	if len(cost.Windows) != 2 || cost.Windows[0].Window != "22:00-06:00" || math.Abs(cost.Windows[0].Energy-5) > 1e-9 || math.Abs(cost.Windows[1].Energy-5) > 1e-9 {
+		t.Errorf("Expected 5 kWh in each window, got %+v", cost.Windows)
+	}
+	if cost.Cost != 2.5 {
+		t.Errorf("Expected a cost of 0.5 + 5 * 0.10 + 5 * 0.30 = 2.5, got %v", cost.Cost)
+	}
+
+	// energy without any tariff is reported as unpriced
+	cost = calculateChargeCost(parsed[:1], 4, start, samples, loc)
+	if cost.Tariff != "" || cost.UnpricedEnergy != 10 || cost.Cost != 0 {
+		t.Errorf("Expected unpriced energy, got %+v", cost)
+	}
+}
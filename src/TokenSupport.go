@@ -0,0 +1,223 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenStore is nil unless API_TOKENS_FILE is set, all api routes need a token with matching scopes otherwise
+var tokenStore *TokenStore
+
+// apiToken is a token of the API_TOKENS_FILE
+type apiToken struct {
+	Name          string     `json:"name"`           // string (shown in logs)
+	Secret        string     `json:"secret"`         // string (sha256:<salt>:<hex of the sha256 of salt and token>)
+	ClientSubject string     `json:"client_subject"` // string (CN or SAN of a client certificate, instead of or besides secret)
+	Scopes        []string   `json:"scopes"`         // []string
+	CarIDs        []int      `json:"car_ids"`        // []int (all cars if empty)
+	ExpiresAt     *time.Time `json:"expires_at"`     // time (RFC3339, never if null)
+}
+
+// storedToken is an apiToken with its parsed secret, nil for tokens of client certificates only
+type storedToken struct {
+	apiToken
+	secret *hashedSecret
+}
+
+// identity returns the apiIdentity of a token, or an error message if it expired
+func (t *storedToken) identity() (*apiIdentity, string) {
+	if t.ExpiresAt != nil && !time.Now().Before(*t.ExpiresAt) {
+		return nil, "token " + t.Name + " expired"
+	}
+	return &apiIdentity{Name: t.Name, Scopes: t.Scopes, CarIDs: t.CarIDs}, ""
+}
+
+// TokenStore holds the tokens of a file and reloads them when the file changes
+type TokenStore struct {
+	path string
+
+	mu      sync.RWMutex
+	tokens  []storedToken
+	modTime time.Time
+}
+
+// initTokenStore func
+func initTokenStore() {
+	tokensLocation := getEnv("API_TOKENS_FILE", "")
+	if tokensLocation == "" {
+		return
+	}
+
+	// an invalid file still enables the store, so no request is allowed without a valid token
+	tokenStore = NewTokenStore(tokensLocation)
+	if _, err := tokenStore.Reload(); err != nil {
+		log.Println("[error] initTokenStore error with API_TOKENS_FILE: " + tokensLocation + " no token will be accepted until it's fixed: " + err.Error())
+	} else {
+		log.Printf("[info] initTokenStore - loaded %d tokens from %s", tokenStore.Len(), tokensLocation)
+	}
+
+	interval := time.Duration(getEnvAsInt("API_TOKENS_RELOAD_INTERVAL", 10)) * time.Second
+	go tokenStore.Watch(interval, nil)
+}
+
+func NewTokenStore(path string) *TokenStore {
+	return &TokenStore{path: path}
+}
+
+// Len returns the number of loaded tokens
+func (s *TokenStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tokens)
+}
+
+// Reload reads the file again if it changed since the last load, the loaded tokens are kept on errors
+func (s *TokenStore) Reload() (bool, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	unchanged := info.ModTime().Equal(s.modTime)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, err
+	}
+	tokens, err := parseAPITokens(data)
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.tokens = tokens
+	s.modTime = info.ModTime()
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Watch reloads the file every interval until stop is closed
+func (s *TokenStore) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if changed, err := s.Reload(); err != nil {
+				log.Println("[error] TokenStore - unable to reload " + s.path + ", keeping the loaded tokens: " + err.Error())
+			} else if changed {
+				log.Printf("[info] TokenStore - reloaded %d tokens from %s", s.Len(), s.path)
+			}
+		}
+	}
+}
+
+// Lookup returns the identity of a token, or an error message if the token is unknown or expired,
+// all secrets are compared so the time doesn't depend on which token matched
+func (s *TokenStore) Lookup(token string) (*apiIdentity, string) {
+	s.mu.RLock()
+	var match *storedToken
+	for i := range s.tokens {
+		if s.tokens[i].secret != nil && s.tokens[i].secret.matches(token) {
+			match = &s.tokens[i]
+		}
+	}
+	s.mu.RUnlock()
+
+	if match == nil {
+		return nil, "token invalid"
+	}
+	return match.identity()
+}
+
+// LookupCertificate returns the identity of the token with the CN or a SAN of a verified client
+// certificate as client_subject, or an error message if there is none or it expired
+func (s *TokenStore) LookupCertificate(cert *x509.Certificate) (*apiIdentity, string) {
+	subjects := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	subjects = append(subjects, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	for _, ip := range cert.IPAddresses {
+		subjects = append(subjects, ip.String())
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := range s.tokens {
+		if s.tokens[i].ClientSubject != "" && checkArrayContainsString(subjects, s.tokens[i].ClientSubject) {
+			return s.tokens[i].identity()
+		}
+	}
+	return nil, "client certificate " + cert.Subject.CommonName + " has no token"
+}
+
+// parseAPITokens func - parses and validates the tokens of an API_TOKENS_FILE
+func parseAPITokens(data []byte) ([]storedToken, error) {
+	var parsed []apiToken
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	tokens := make([]storedToken, 0, len(parsed))
+	secrets, subjects := map[string]bool{}, map[string]bool{}
+	for i, t := range parsed {
+		if t.Name == "" {
+			t.Name = fmt.Sprintf("token %d", i+1)
+		}
+
+		var secret *hashedSecret
+		if t.Secret != "" || t.ClientSubject == "" {
+			var err error
+			if secret, err = parseHashedSecret(t.Secret); err != nil {
+				return nil, fmt.Errorf("%s: %w", t.Name, err)
+			}
+			if secrets[t.Secret] {
+				return nil, fmt.Errorf("%s: secret is used by another token", t.Name)
+			}
+			secrets[t.Secret] = true
+		}
+		if t.ClientSubject != "" {
+			if subjects[t.ClientSubject] {
+				return nil, fmt.Errorf("%s: client_subject is used by another token", t.Name)
+			}
+			subjects[t.ClientSubject] = true
+		}
+
+		for _, scope := range t.Scopes {
+			if !validScope(scope) {
+				return nil, fmt.Errorf("%s: unknown scope %q", t.Name, scope)
+			}
+		}
+		tokens = append(tokens, storedToken{apiToken: t, secret: secret})
+	}
+	return tokens, nil
+}
+
+// parseHashedSecret func - parses a secret of the format sha256:<salt>:<hex of the sha256 of salt and token>
+func parseHashedSecret(secret string) (*hashedSecret, error) {
+	parts := strings.Split(secret, ":")
+	if len(parts) != 3 || parts[0] != "sha256" || parts[1] == "" {
+		return nil, fmt.Errorf("secret has to be sha256:<salt>:<hex of the sha256 of salt and token>")
+	}
+	sum, err := hex.DecodeString(parts[2])
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("secret has to end with the 64 hex characters of a sha256")
+	}
+	return &hashedSecret{salt: []byte(parts[1]), sum: sum}, nil
+}
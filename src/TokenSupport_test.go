@@ -0,0 +1,214 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// tokenSecret returns the secret of a token as written in the API_TOKENS_FILE
+func tokenSecret(token string) string {
+	sum := sha256.Sum256([]byte("salt-" + token + token))
+	return "sha256:salt-" + token + ":" + hex.EncodeToString(sum[:])
+}
+
+func writeTokensFile(t *testing.T, path string, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write tokens file: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set modification time: %v", err)
+	}
+}
+
+func TestParseAPITokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: `[{"name": "a", "secret": "` + tokenSecret("a") + `", "scopes": ["read:*", "command:climate"], "car_ids": [1]}]`},
+		{name: "plain secret", content: `[{"name": "a", "secret": "a", "scopes": ["read:status"]}]`, wantErr: true},
+		{name: "unsalted secret", content: `[{"name": "a", "secret": "sha256:ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"}]`, wantErr: true},
+		{name: "short hash", content: `[{"name": "a", "secret": "sha256:salt:ca978112"}]`, wantErr: true},
+		{name: "duplicate secret", content: `[{"secret": "` + tokenSecret("a") + `"}, {"secret": "` + tokenSecret("a") + `"}]`, wantErr: true},
+		{name: "unknown scope", content: `[{"secret": "` + tokenSecret("a") + `", "scopes": ["write:cars"]}]`, wantErr: true},
+		{name: "no array", content: `{"secret": "` + tokenSecret("a") + `"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseAPITokens([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTokenStoreLookupAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_tokens.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeTokensFile(t, path, `[
+		{"name": "dashboard", "secret": "`+tokenSecret("dashboard-token")+`", "scopes": ["read:*"], "car_ids": [1]},
+		{"name": "old", "secret": "`+tokenSecret("old-token")+`", "scopes": ["*"], "expires_at": "2020-01-01T00:00:00Z"}
+	]`, modTime)
+
+	store := NewTokenStore(path)
+	if changed, err := store.Reload(); err != nil || !changed {
+		t.Fatalf("Expected tokens to be loaded, got %v, %v", changed, err)
+	}
+
+	identity, _ := store.Lookup("dashboard-token")
+	if identity == nil || identity.Name != "dashboard" {
+		t.Fatalf("Expected dashboard identity, got %v", identity)
+	}
+	if !identity.hasScope(scopeReadHistory) || identity.hasScope(scopeWriteDrives) {
+		t.Errorf("Expected read:* to grant only read scopes, got %v", identity.Scopes)
+	}
+	if !identity.allowsCar(1) || identity.allowsCar(2) {
+		t.Errorf("Expected only car 1 to be allowed, got %v", identity.CarIDs)
+	}
+	if identity, reason := store.Lookup("old-token"); identity != nil || reason != "token old expired" {
+		t.Errorf("Expected expired token to be rejected, got %v, %s", identity, reason)
+	}
+	if identity, _ := store.Lookup("unknown-token"); identity != nil {
+		t.Errorf("Expected unknown token to be rejected, got %v", identity)
+	}
+
+	// unchanged file isn't read again
+	if changed, err := store.Reload(); err != nil || changed {
+		t.Errorf("Expected no reload of the unchanged file, got %v, %v", changed, err)
+	}
+
+	// invalid file keeps the loaded tokens
+	writeTokensFile(t, path, `[{"secret": "invalid"}]`, modTime.Add(time.Minute))
+	if _, err := store.Reload(); err == nil {
+		t.Error("Expected error for invalid file")
+	}
+	if identity, _ := store.Lookup("dashboard-token"); identity == nil {
+		t.Error("Expected loaded tokens to be kept after an invalid file")
+	}
+
+	writeTokensFile(t, path, `[{"name": "new", "secret": "`+tokenSecret("new-token")+`", "scopes": ["read:status"]}]`, modTime.Add(2*time.Minute))
+	if changed, err := store.Reload(); err != nil || !changed {
+		t.Fatalf("Expected tokens to be reloaded, got %v, %v", changed, err)
+	}
+	if identity, _ := store.Lookup("dashboard-token"); identity != nil {
+		t.Error("Expected removed token to be rejected")
+	}
+	if identity, _ := store.Lookup("new-token"); identity == nil || store.Len() != 1 {
+		t.Errorf("Expected only the new token, got %v and %d tokens", identity, store.Len())
+	}
+}
+
+func TestValidScope(t *testing.T) {
+	tests := map[string]bool{
+		"*":               true,
+		"read:status":     true,
+		"read:*":          true,
+		"write:*":         true,
+		"command:climate": true,
+		"command:*":       true,
+		"logging":         true,
+		"command:":        false,
+		"read:cars":       false,
+		"admin:*":         false,
+	}
+	for scope, expected := range tests {
+		if got := validScope(scope); got != expected {
+			t.Errorf("Expected validScope(%q) to be %v, got %v", scope, expected, got)
+		}
+	}
+}
+
+func TestRouterWithAPITokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("API_TOKEN", "")
+	t.Setenv("API_TOKEN_DISABLE", "false")
+	t.Setenv("AUTH_BACKOFF_BASE", "0") // the invalid tokens don't delay the other requests
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken("")
+
+	path := filepath.Join(t.TempDir(), "api_tokens.json")
+	writeTokensFile(t, path, `[
+		{"name": "car1", "secret": "`+tokenSecret("car1-token")+`", "scopes": ["read:status"], "car_ids": [1]},
+		{"name": "history", "secret": "`+tokenSecret("history-token")+`", "scopes": ["read:history"]}
+	]`, time.Now())
+	store := NewTokenStore(path)
+	if _, err := store.Reload(); err != nil {
+		t.Fatalf("Failed to load tokens: %v", err)
+	}
+
+	originalStore := tokenStore
+	tokenStore = store
+	defer func() { tokenStore = originalStore }()
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	originalDB := db
+	originalQueries := queries
+	db = mock
+	queries = teslamatedb.New(mock)
+	defer func() { db, queries = originalDB, originalQueries }()
+
+	router := newRouter()
+
+	tests := []struct {
+		name     string
+		path     string
+		token    string
+		expected int
+	}{
+		{name: "missing token", path: "/api/v2/cars/1/status", expected: http.StatusUnauthorized},
+		{name: "invalid token", path: "/api/v2/cars/1/status", token: "unknown-token", expected: http.StatusUnauthorized},
+		{name: "other car", path: "/api/v2/cars/2/status", token: "car1-token", expected: http.StatusForbidden},
+		{name: "missing scope", path: "/api/v2/cars/1/drives", token: "car1-token", expected: http.StatusForbidden},
+		{name: "missing scope of other endpoint", path: "/api/v2/globalsettings", token: "history-token", expected: http.StatusForbidden},
+		{name: "allowed", path: "/api/v2/cars/1/status", token: "car1-token", expected: http.StatusNotFound},
+	}
+
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
+		WithArgs(int16(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// API_TOKEN_DISABLE doesn't open the endpoints with API_TOKENS_FILE
+	t.Setenv("API_TOKEN_DISABLE", "true")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/cars/1/status", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 with API_TOKEN_DISABLE, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
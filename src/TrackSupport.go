@@ -0,0 +1,160 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// earthRadius in meters, used to project coordinates for the simplification
+	earthRadius = 6371008.8
+
+	// changes between two positions that are always kept when simplifying a track,
+	// so speed and power charts keep their peaks
+	trackSharpSpeedChange = 20 // km/h
+	trackSharpPowerChange = 30 // kW
+)
+
+// trackPoint is a position of a track as used by simplifyTrack
+type trackPoint struct {
+	Latitude  float64
+	Longitude float64
+	Speed     float64 // km/h
+	Power     float64 // kW
+}
+
+// trackRank orders the points of a track by how important they are for its shape
+type trackRank struct {
+	tier  int     // 2 = first and last point, 1 = sharp speed or power change, 0 = others
+	value float64 // Douglas-Peucker distance in meters, or size of the change
+}
+
+func (r trackRank) less(o trackRank) bool {
+	if r.tier != o.tier {
+		return r.tier < o.tier
+	}
+	return r.value < o.value
+}
+
+// simplifyTrack func - returns the indexes of the points to keep, in order. Points closer
+// than tolerance meters to the simplified line are dropped (Douglas-Peucker), maxPoints
+// limits the result to the most important points. First and last point as well as sharp
+// speed or power changes are kept before any other point. 0 disables either option.
+func simplifyTrack(points []trackPoint, tolerance float64, maxPoints int) []int {
+	ranks := rankTrack(points)
+
+	indexes := []int{}
+	for i, rank := range ranks {
+		if rank.tier > 0 || rank.value > tolerance {
+			indexes = append(indexes, i)
+		}
+	}
+
+	if maxPoints > 0 && len(indexes) > maxPoints {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return ranks[indexes[b]].less(ranks[indexes[a]])
+		})
+		indexes = indexes[:maxPoints]
+		sort.Ints(indexes)
+	}
+	return indexes
+}
+
+// rankTrack func - ranks all points of a track, the distance of a point is capped by the
+// distance of the point that split its segment, so keeping all points above a tolerance
+// is the same as running Douglas-Peucker with that tolerance
+func rankTrack(points []trackPoint) []trackRank {
+	ranks := make([]trackRank, len(points))
+	if len(points) == 0 {
+		return ranks
+	}
+
+	// sharp changes are anchors the line has to go through
+	anchors := []int{0}
+	for i := 1; i < len(points)-1; i++ {
+		speedChange := math.Abs(points[i].Speed-points[i-1].Speed) / trackSharpSpeedChange
+		powerChange := math.Abs(points[i].Power-points[i-1].Power) / trackSharpPowerChange
+		if change := math.Max(speedChange, powerChange); change >= 1 {
+			ranks[i] = trackRank{tier: 1, value: change}
+			anchors = append(anchors, i)
+		}
+	}
+	anchors = append(anchors, len(points)-1)
+	ranks[0] = trackRank{tier: 2, value: math.Inf(1)}
+	ranks[len(points)-1] = trackRank{tier: 2, value: math.Inf(1)}
+
+	type segment struct {
+		first, last int
+		limit       float64
+	}
+	var stack []segment
+	for i := 1; i < len(anchors); i++ {
+		stack = append(stack, segment{anchors[i-1], anchors[i], math.Inf(1)})
+	}
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if s.last-s.first < 2 {
+			continue
+		}
+
+		farthest, distance := -1, -1.0
+		for i := s.first + 1; i < s.last; i++ {
+			if d := distanceToSegment(points[i], points[s.first], points[s.last]); d > distance {
+				farthest, distance = i, d
+			}
+		}
+		distance = math.Min(distance, s.limit)
+		ranks[farthest].value = distance
+		stack = append(stack, segment{s.first, farthest, distance}, segment{farthest, s.last, distance})
+	}
+	return ranks
+}
+
+// distanceToSegment func - distance in meters of p to the segment a-b, using an
+// equirectangular projection which is precise enough for the distances of a track
+func distanceToSegment(p, a, b trackPoint) float64 {
+	cosLatitude := math.Cos(a.Latitude * math.Pi / 180)
+	project := func(q trackPoint) (float64, float64) {
+		x := (q.Longitude - a.Longitude) * math.Pi / 180 * cosLatitude * earthRadius
+		y := (q.Latitude - a.Latitude) * math.Pi / 180 * earthRadius
+		return x, y
+	}
+	px, py := project(p)
+	bx, by := project(b)
+
+	t := 0.0
+	if length := bx*bx + by*by; length > 0 {
+		t = math.Max(0, math.Min(1, (px*bx+py*by)/length))
+	}
+	return math.Hypot(px-t*bx, py-t*by)
+}
+
+// encodePolyline func - encodes latitude/longitude pairs with the Google encoded polyline
+// algorithm format at a precision of 5 decimals
+func encodePolyline(coordinates [][2]float64) string {
+	var (
+		b                 strings.Builder
+		lastLat, lastLong int64
+	)
+	encode := func(v int64) {
+		u := uint64(v) << 1
+		if v < 0 {
+			u = ^u
+		}
+		for u >= 0x20 {
+			b.WriteByte(byte((0x20 | (u & 0x1f)) + 63))
+			u >>= 5
+		}
+		b.WriteByte(byte(u + 63))
+	}
+	for _, coordinate := range coordinates {
+		lat := int64(math.Round(coordinate[0] * 1e5))
+		long := int64(math.Round(coordinate[1] * 1e5))
+		encode(lat - lastLat)
+		encode(long - lastLong)
+		lastLat, lastLong = lat, long
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodePolyline(t *testing.T) {
+	// example of the Google encoded polyline algorithm format documentation
+	coordinates := [][2]float64{{38.5, -120.2}, {40.7, -120.95}, {43.252, -126.453}}
+	if got := encodePolyline(coordinates); got != "_p~iF~ps|U_ulLnnqC_mqNvxq`@" {
+		t.Errorf("Unexpected polyline '%s'", got)
+	}
+	if got := encodePolyline(nil); got != "" {
+		t.Errorf("Expected empty polyline, got '%s'", got)
+	}
+}
+
+func TestSimplifyTrack(t *testing.T) {
+	// a straight line going north with 0.0001 degrees (~11 m) per position and a detour of ~55 m at index 5
+	var points []trackPoint
+	for i := 0; i <= 10; i++ {
+		points = append(points, trackPoint{Latitude: 59 + float64(i)*0.0001, Longitude: 18, Speed: 50, Power: 10})
+	}
+	points[5].Longitude = 18.001
+
+	tests := []struct {
+		name      string
+		points    func() []trackPoint
+		tolerance float64
+		maxPoints int
+		expected  []int
+	}{
+		{"straight line is reduced to its ends", func() []trackPoint {
+			p := append([]trackPoint{}, points...)
+			p[5].Longitude = 18
+			return p
+		}, 1, 0, []int{0, 10}},
+		{"detour above tolerance is kept", func() []trackPoint { return points }, 40, 0, []int{0, 5, 10}},
+		{"detour below tolerance is dropped", func() []trackPoint { return points }, 100, 0, []int{0, 10}},
+		{"max points keeps the most important points", func() []trackPoint { return points }, 0, 3, []int{0, 5, 10}},
+		{"max points keeps the ends", func() []trackPoint { return points }, 0, 2, []int{0, 10}},
+		{"sharp speed change is kept", func() []trackPoint {
+			p := append([]trackPoint{}, points...)
+			p[3].Speed = 90
+			return p
+		}, 100, 0, []int{0, 3, 4, 10}},
+		{"sharp power change is kept before the detour", func() []trackPoint {
+			p := append([]trackPoint{}, points...)
+			p[8].Power = 80
+			return p
+		}, 0, 3, []int{0, 8, 10}},
+		{"short tracks", func() []trackPoint { return points[:2] }, 100, 0, []int{0, 1}},
+		{"no positions", func() []trackPoint { return nil }, 100, 5, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := simplifyTrack(tt.points(), tt.tolerance, tt.maxPoints); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -6,27 +6,27 @@ import (
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
 )
 
 func TestTeslaMateAPICarsStatusV1_Integration(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
-	
+
 	// Initialize timezone for tests (normally done in main)
 	appUsersTimezone, _ = time.LoadLocation("UTC")
 
 	// Create mock database
-	mockDB, mock, err := sqlmock.New()
+	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatalf("Failed to create mock database: %v", err)
 	}
-	defer mockDB.Close()
+	defer mock.Close()
 
 	// Replace global db with mock
 	originalDB := db
-	db = mockDB
+	db = mock
 	defer func() { db = originalDB }()
 
 	t.Run("Successful car status retrieval", func(t *testing.T) {
@@ -36,32 +36,37 @@ func TestTeslaMateAPICarsStatusV1_Integration(t *testing.T) {
 
 		// Mock car existence check
 		mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
-			WithArgs(carID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			WithArgs(int16(carID)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
 
 		// Mock main status query
-		rows := sqlmock.NewRows([]string{
-			"id", "name", "model", "trim_badging", "exterior_color", "wheel_type", "spoiler_type", "vin",
-			"position_date", "latitude", "longitude", "speed", "power", "odometer", "battery_level",
-			"usable_battery_level", "ideal_battery_range_km", "est_battery_range_km", "rated_battery_range_km",
-			"outside_temp", "inside_temp", "is_climate_on",
-			"state", "state_since", "is_charging", "charging_state",
-			"charger_power", "charger_voltage", "charger_phases", "charger_actual_current", "charge_energy_added",
-			"unit_of_length", "unit_of_pressure", "unit_of_temperature",
-		}).AddRow(
-			1, "Test Tesla", "Model 3", "Performance", "Red", "Sport", "None", "5YJ3E1EA4JF123456",
-			now, 37.7749, -122.4194, 65, 150, 12345.6, 85,
-			83, 400.5, 380.2, 420.8,
-			18.5, 22.3, true,
-			"online", now, true, "charging",
-			11000, 240, 3, 45, 5.2,
-			"km", "bar", "C",
-		)
+		rows := pgxmock.NewRows(carStatusColumns).AddRow(carStatusRow(map[string]any{
+			"car_id": 1, "name": "Test Tesla", "model": "Model 3", "trim_badging": "Performance",
+			"exterior_color": "Red", "wheel_type": "Sport", "spoiler_type": "None", "vin": "5YJ3E1EA4JF123456",
+			"position_date": now, "latitude": 37.7749, "longitude": -122.4194, "speed": 65, "power": 150,
+			"odometer": 12345.6, "battery_level": 85, "usable_battery_level": 83,
+			"ideal_battery_range": 400.5, "est_battery_range": 380.2, "rated_battery_range": 420.8,
+			"outside_temp": 18.5, "inside_temp": 22.3, "is_climate_on": true,
+			"state": "online", "state_since": now, "is_charging": true, "charging_state": "charging",
+			"charger_power": 11000, "charger_voltage": 240, "charger_phases": 3, "charger_actual_current": 45,
+			"charge_energy_added": 5.2,
+			"locked":              true,
+			"unit_of_length":      "km", "unit_of_pressure": "bar", "unit_of_temperature": "C",
+		})...)
 
 		mock.ExpectQuery("SELECT.*FROM cars c.*WHERE c.id = \\$1").
-			WithArgs(carID).
+			WithArgs(int16(carID)).
 			WillReturnRows(rows)
 
+		// Mock geofence and address of the position
+		mock.ExpectQuery("SELECT.*FROM geofences.*geofence_distances").
+			WithArgs(37.7749, -122.4194, int32(0)).
+			WillReturnRows(pgxmock.NewRows([]string{"geofence_id", "name", "radius", "distance"}).AddRow(4, "Work", 150, 42.0))
+		mock.ExpectQuery("SELECT.*FROM addresses").
+			WithArgs(37.7749, -122.4194).
+			WillReturnRows(pgxmock.NewRows([]string{"address_id", "display_name", "name", "house_number", "road", "neighbourhood", "city", "postcode", "state", "country"}).
+				AddRow(9, "Market Street, San Francisco", nil, nil, "Market Street", nil, "San Francisco", "94103", "California", "United States"))
+
 		// Setup Gin router and request
 		router := gin.New()
 		router.GET("/api/v1/cars/:CarID/status", TeslaMateAPICarsStatusV1)
@@ -96,6 +101,12 @@ func TestTeslaMateAPICarsStatusV1_Integration(t *testing.T) {
 			`"battery_level":85`,
 			`"latitude":37.7749`,
 			`"longitude":-122.4194`,
+			`"locked":true`,
+			`"sentry_mode":null`,
+			`"geofence":"Work"`,
+			`"geofence_id":4`,
+			`"road":"Market Street"`,
+			`"distance_to_home":null`,
 		}
 
 		for _, expected := range expectedSubstrings {
@@ -114,8 +125,8 @@ func TestTeslaMateAPICarsStatusV1_Integration(t *testing.T) {
 
 		// Mock car existence check returning false
 		mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
-			WithArgs(carID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			WithArgs(int16(carID)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
 
 		// Setup Gin router and request
 		router := gin.New()
@@ -152,8 +163,8 @@ func TestTeslaMateAPICarsStatusV1_Integration(t *testing.T) {
 		// convertStringToInteger will return 0 for "abc"
 		// So we need to mock the query for car ID 0
 		mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
-			WithArgs(0).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			WithArgs(int16(0)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
 
 		// Setup Gin router and request with invalid car ID
 		router := gin.New()
@@ -184,32 +195,20 @@ func TestTeslaMateAPICarsStatusV1_Integration(t *testing.T) {
 	t.Run("Car exists but no position data", func(t *testing.T) {
 		carID := 2
 
-		// Mock car existence check  
+		// Mock car existence check
 		mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
-			WithArgs(carID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			WithArgs(int16(carID)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
 
 		// Mock main status query with mostly null data
-		rows := sqlmock.NewRows([]string{
-			"id", "name", "model", "trim_badging", "exterior_color", "wheel_type", "spoiler_type", "vin",
-			"position_date", "latitude", "longitude", "speed", "power", "odometer", "battery_level",
-			"usable_battery_level", "ideal_battery_range_km", "est_battery_range_km", "rated_battery_range_km",
-			"outside_temp", "inside_temp", "is_climate_on",
-			"state", "state_since", "is_charging", "charging_state",
-			"charger_power", "charger_voltage", "charger_phases", "charger_actual_current", "charge_energy_added",
-			"unit_of_length", "unit_of_pressure", "unit_of_temperature",
-		}).AddRow(
-			2, "Minimal Car", "Model Y", nil, nil, nil, nil, nil,
-			nil, nil, nil, nil, nil, nil, nil,
-			nil, nil, nil, nil,
-			nil, nil, nil,
-			nil, nil, false, "disconnected",
-			nil, nil, nil, nil, nil,
-			"km", "bar", "C",
-		)
+		rows := pgxmock.NewRows(carStatusColumns).AddRow(carStatusRow(map[string]any{
+			"car_id": 2, "name": "Minimal Car", "model": "Model Y",
+			"is_charging": false, "charging_state": "disconnected",
+			"unit_of_length": "km", "unit_of_pressure": "bar", "unit_of_temperature": "C",
+		})...)
 
 		mock.ExpectQuery("SELECT.*FROM cars c.*WHERE c.id = \\$1").
-			WithArgs(carID).
+			WithArgs(int16(carID)).
 			WillReturnRows(rows)
 
 		// Setup Gin router and request
@@ -235,6 +234,9 @@ func TestTeslaMateAPICarsStatusV1_Integration(t *testing.T) {
 			`"state":"unknown"`, // Should default to unknown when no position data
 			`"plugged_in":false`,
 			`"charging_state":"disconnected"`,
+			`"locked":null`,
+			`"heading":null`,
+			`"charge_limit_soc":null`,
 		}
 
 		for _, expected := range expectedSubstrings {
@@ -261,4 +263,4 @@ func containsMiddle(s, substr string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
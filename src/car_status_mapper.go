@@ -1,9 +1,10 @@
 package main
 
 import (
-	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // CarStatusMapper converts database results to API response format
@@ -18,7 +19,7 @@ func (m *CarStatusMapper) MapToResponse(data *CarStatusData, vehicleState string
 	response := &CarStatusResponse{}
 
 	// Car information
-	response.Car.CarID = data.CarID
+	response.Car.CarID = int(data.CarID)
 	response.Car.CarName = m.getStringValue(data.Name)
 
 	// Status information
@@ -39,28 +40,33 @@ func (m *CarStatusMapper) MapToResponse(data *CarStatusData, vehicleState string
 	// Geo data
 	response.Status.CarGeodata.Latitude = m.getFloat64Value(data.Latitude)
 	response.Status.CarGeodata.Longitude = m.getFloat64Value(data.Longitude)
-	response.Status.CarGeodata.Geofence = "" // Not available in database
+	response.Status.CarGeodata.Geofence = "" // Set by ApplyLocation
 
-	// Physical status (not available from positions table, setting defaults)
+	// Physical status (null if not persisted by the TeslaMate schema)
 	response.Status.CarStatus.Healthy = true
-	response.Status.CarStatus.Locked = false
-	response.Status.CarStatus.SentryMode = false
-	response.Status.CarStatus.WindowsOpen = false
-	response.Status.CarStatus.DoorsOpen = false
-	response.Status.CarStatus.TrunkOpen = false
-	response.Status.CarStatus.FrunkOpen = false
-	response.Status.CarStatus.IsUserPresent = false
-
-	// Car versions (not available from database)
+	response.Status.CarStatus.Locked = NullBool{data.Locked}
+	response.Status.CarStatus.SentryMode = NullBool{data.SentryMode}
+	response.Status.CarStatus.WindowsOpen = NullBool{data.WindowsOpen}
+	response.Status.CarStatus.DoorsOpen = NullBool{data.DoorsOpen}
+	response.Status.CarStatus.TrunkOpen = NullBool{data.TrunkOpen}
+	response.Status.CarStatus.FrunkOpen = NullBool{data.FrunkOpen}
+	response.Status.CarStatus.IsUserPresent = NullBool{data.IsUserPresent}
+
+	// Car versions (update availability not available from database)
 	response.Status.CarVersions.UpdateAvailable = false
 	response.Status.CarVersions.UpdateVersion = ""
-	response.Status.CarVersions.Version = ""
+	response.Status.CarVersions.Version = m.getStringValue(data.Version)
 
 	// Climate details
 	response.Status.ClimateDetails.IsClimateOn = m.getBoolValue(data.IsClimateOn)
 	response.Status.ClimateDetails.InsideTemp = m.getFloat64Value(data.InsideTemp)
 	response.Status.ClimateDetails.OutsideTemp = m.getFloat64Value(data.OutsideTemp)
-	response.Status.ClimateDetails.IsPreconditioning = false // Not available in database
+	response.Status.ClimateDetails.IsPreconditioning = NullBool{data.IsPreconditioning}
+	response.Status.ClimateDetails.DriverTempSetting = NullFloat64{data.DriverTempSetting}
+	response.Status.ClimateDetails.PassengerTempSetting = NullFloat64{data.PassengerTempSetting}
+	response.Status.ClimateDetails.FanStatus = nullInt32ToNullInt64(data.FanStatus)
+	response.Status.ClimateDetails.IsFrontDefrosterOn = NullBool{data.IsFrontDefrosterOn}
+	response.Status.ClimateDetails.IsRearDefrosterOn = NullBool{data.IsRearDefrosterOn}
 
 	// Battery details
 	response.Status.BatteryDetails.BatteryLevel = m.getIntValue(data.BatteryLevel)
@@ -68,29 +74,46 @@ func (m *CarStatusMapper) MapToResponse(data *CarStatusData, vehicleState string
 	response.Status.BatteryDetails.RatedBatteryRange = m.getFloat64Value(data.RatedBatteryRange)
 	response.Status.BatteryDetails.IdealBatteryRange = m.getFloat64Value(data.IdealBatteryRange)
 	response.Status.BatteryDetails.UsableBatteryLevel = m.getIntValue(data.UsableBatteryLevel)
+	response.Status.BatteryDetails.BatteryHeater = NullBool{data.BatteryHeater}
+	response.Status.BatteryDetails.BatteryHeaterOn = NullBool{data.BatteryHeaterOn}
+	response.Status.BatteryDetails.BatteryHeaterNoPower = NullBool{data.BatteryHeaterNoPower}
 
 	// Driving details
 	response.Status.DrivingDetails.Elevation = m.getIntValue(data.Elevation)
-	response.Status.DrivingDetails.Heading = 0 // Not available in database
+	response.Status.DrivingDetails.Heading = nullInt32ToNullInt64(data.Heading)
 	response.Status.DrivingDetails.Power = m.getIntValue(data.Power)
-	response.Status.DrivingDetails.ShiftState = "" // Not available in database
+	response.Status.DrivingDetails.ShiftState = NullText{data.ShiftState}
 	response.Status.DrivingDetails.Speed = m.getIntValue(data.Speed)
 
 	// Charging details
-	response.Status.ChargingDetails.PluggedIn = m.getBoolValue(data.IsCharging)
+	response.Status.ChargingDetails.PluggedIn = data.IsCharging
 	response.Status.ChargingDetails.ChargeEnergyAdded = float32(m.getFloat64Value(data.ChargeEnergyAdded))
 	response.Status.ChargingDetails.ChargerActualCurrent = float32(m.getIntValue(data.ChargerActualCurrent))
 	response.Status.ChargingDetails.ChargerPhases = m.getIntValue(data.ChargerPhases)
 	response.Status.ChargingDetails.ChargerPower = float32(m.getIntValue(data.ChargerPower))
 	response.Status.ChargingDetails.ChargerVoltage = float32(m.getIntValue(data.ChargerVoltage))
-	response.Status.ChargingDetails.ChargePortDoorOpen = m.getBoolValue(data.IsCharging) // Approximate
+	response.Status.ChargingDetails.ChargingState = m.getStringValueWithDefault(data.ChargingState, "disconnected")
+	response.Status.ChargingDetails.ChargerPilotCurrent = nullInt16ToNullInt64(data.ChargerPilotCurrent)
+	response.Status.ChargingDetails.ConnChargeCable = NullText{data.ConnChargeCable}
+	response.Status.ChargingDetails.FastChargerPresent = NullBool{data.FastChargerPresent}
+	response.Status.ChargingDetails.FastChargerBrand = NullText{data.FastChargerBrand}
+	response.Status.ChargingDetails.FastChargerType = NullText{data.FastChargerType}
+
+	// Charge port door approximated by charging state, unless the TeslaMate schema persists it
+	if data.ChargePortDoorOpen.Valid {
+		response.Status.ChargingDetails.ChargePortDoorOpen = data.ChargePortDoorOpen.Bool
+	} else {
+		response.Status.ChargingDetails.ChargePortDoorOpen = data.IsCharging
+	}
+
+	// Fields null if not persisted by the TeslaMate schema
+	response.Status.ChargingDetails.ChargeLimitSoc = nullInt32ToNullInt64(data.ChargeLimitSoc)
+	response.Status.ChargingDetails.ScheduledChargingStartTime = NullTime{data.ScheduledChargingStartTime}
+	response.Status.ChargingDetails.TimeToFullCharge = NullFloat64{data.TimeToFullCharge}
 
 	// Fields not available in database - set to defaults
 	response.Status.ChargingDetails.ChargeCurrentRequest = 0
 	response.Status.ChargingDetails.ChargeCurrentRequestMax = 0
-	response.Status.ChargingDetails.ChargeLimitSoc = 0
-	response.Status.ChargingDetails.ScheduledChargingStartTime = time.Time{}
-	response.Status.ChargingDetails.TimeToFullCharge = 0
 
 	// TPMS details
 	response.Status.TpmsDetails.TpmsPressureFl = m.getFloat64Value(data.TpmsPressureFl)
@@ -100,11 +123,44 @@ func (m *CarStatusMapper) MapToResponse(data *CarStatusData, vehicleState string
 
 	// Units
 	response.Units.UnitOfLength = m.getStringValueWithDefault(data.UnitOfLength, "km")
+	response.Units.UnitOfPressure = m.getStringValueWithDefault(data.UnitOfPressure, "bar")
 	response.Units.UnitOfTemperature = m.getStringValueWithDefault(data.UnitOfTemperature, "C")
 
 	return response
 }
 
+// ApplyLocation sets the geofence, address and distance to home resolved by GetCarLocation
+func (m *CarStatusMapper) ApplyLocation(response *CarStatusResponse, location *CarLocation) {
+	if location == nil {
+		return
+	}
+
+	if location.Geofence != nil {
+		response.Status.CarGeodata.Geofence = location.Geofence.Name
+		response.Status.CarGeodata.GeofenceID = NullInt64{pgtype.Int8{Int64: int64(location.Geofence.GeofenceID), Valid: true}}
+	}
+
+	if location.Address != nil {
+		response.Status.CarGeodata.Address = &GeoAddress{
+			AddressID:     int(location.Address.AddressID),
+			City:          NullText{location.Address.City},
+			Country:       NullText{location.Address.Country},
+			DisplayName:   NullText{location.Address.DisplayName},
+			HouseNumber:   NullText{location.Address.HouseNumber},
+			Name:          NullText{location.Address.Name},
+			Neighbourhood: NullText{location.Address.Neighbourhood},
+			Postcode:      NullText{location.Address.Postcode},
+			Road:          NullText{location.Address.Road},
+			State:         NullText{location.Address.State},
+		}
+	}
+
+	// distance to home in km, converted with the other lengths
+	if location.HomeDistance.Valid {
+		response.Status.CarGeodata.DistanceToHome = NullFloat64{pgtype.Float8{Float64: location.HomeDistance.Float64 / 1000, Valid: true}}
+	}
+}
+
 // ApplyUnitConversions applies unit conversions based on user preferences
 func (m *CarStatusMapper) ApplyUnitConversions(response *CarStatusResponse) {
 	// Length conversions
@@ -113,12 +169,25 @@ func (m *CarStatusMapper) ApplyUnitConversions(response *CarStatusResponse) {
 		response.Status.BatteryDetails.EstBatteryRange = kilometersToMiles(response.Status.BatteryDetails.EstBatteryRange)
 		response.Status.BatteryDetails.RatedBatteryRange = kilometersToMiles(response.Status.BatteryDetails.RatedBatteryRange)
 		response.Status.BatteryDetails.IdealBatteryRange = kilometersToMiles(response.Status.BatteryDetails.IdealBatteryRange)
+		if response.Status.CarGeodata.DistanceToHome.Valid {
+			response.Status.CarGeodata.DistanceToHome = kilometersToMilesNilSupport(response.Status.CarGeodata.DistanceToHome)
+		}
+	}
+
+	// Pressure conversions
+	if response.Units.UnitOfPressure == "psi" {
+		response.Status.TpmsDetails.TpmsPressureFl = barToPsi(response.Status.TpmsDetails.TpmsPressureFl)
+		response.Status.TpmsDetails.TpmsPressureFr = barToPsi(response.Status.TpmsDetails.TpmsPressureFr)
+		response.Status.TpmsDetails.TpmsPressureRl = barToPsi(response.Status.TpmsDetails.TpmsPressureRl)
+		response.Status.TpmsDetails.TpmsPressureRr = barToPsi(response.Status.TpmsDetails.TpmsPressureRr)
 	}
 
 	// Temperature conversions
 	if response.Units.UnitOfTemperature == "F" {
 		response.Status.ClimateDetails.InsideTemp = celsiusToFahrenheit(response.Status.ClimateDetails.InsideTemp)
 		response.Status.ClimateDetails.OutsideTemp = celsiusToFahrenheit(response.Status.ClimateDetails.OutsideTemp)
+		response.Status.ClimateDetails.DriverTempSetting = celsiusToFahrenheitNilSupport(response.Status.ClimateDetails.DriverTempSetting)
+		response.Status.ClimateDetails.PassengerTempSetting = celsiusToFahrenheitNilSupport(response.Status.ClimateDetails.PassengerTempSetting)
 	}
 }
 
@@ -133,44 +202,44 @@ func (m *CarStatusMapper) getDisplayName(data *CarStatusData) string {
 	return fmt.Sprintf("Car %d", data.CarID)
 }
 
-func (m *CarStatusMapper) getTimeValue(nullTime sql.NullTime) time.Time {
+func (m *CarStatusMapper) getTimeValue(nullTime pgtype.Timestamp) time.Time {
 	if nullTime.Valid {
 		return nullTime.Time
 	}
 	return time.Now()
 }
 
-func (m *CarStatusMapper) getStringValue(nullStr sql.NullString) string {
+func (m *CarStatusMapper) getStringValue(nullStr pgtype.Text) string {
 	if nullStr.Valid {
 		return nullStr.String
 	}
 	return ""
 }
 
-func (m *CarStatusMapper) getStringValueWithDefault(nullStr sql.NullString, defaultVal string) string {
-	if nullStr.Valid {
-		return nullStr.String
+func (m *CarStatusMapper) getStringValueWithDefault(str string, defaultVal string) string {
+	if str != "" {
+		return str
 	}
 	return defaultVal
 }
 
-func (m *CarStatusMapper) getFloat64Value(nullFloat sql.NullFloat64) float64 {
+func (m *CarStatusMapper) getFloat64Value(nullFloat pgtype.Float8) float64 {
 	if nullFloat.Valid {
 		return nullFloat.Float64
 	}
 	return 0.0
 }
 
-func (m *CarStatusMapper) getIntValue(nullInt sql.NullInt32) int {
+func (m *CarStatusMapper) getIntValue(nullInt pgtype.Int2) int {
 	if nullInt.Valid {
-		return int(nullInt.Int32)
+		return int(nullInt.Int16)
 	}
 	return 0
 }
 
-func (m *CarStatusMapper) getBoolValue(nullBool sql.NullBool) bool {
+func (m *CarStatusMapper) getBoolValue(nullBool pgtype.Bool) bool {
 	if nullBool.Valid {
 		return nullBool.Bool
 	}
 	return false
-}
\ No newline at end of file
+}
@@ -1,140 +1,154 @@
 package main
 
 import (
-	"database/sql"
+	"math"
 	"testing"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
 func TestCarStatusMapper_MapToResponse(t *testing.T) {
 	// Initialize timezone for tests (normally done in main)
 	appUsersTimezone, _ = time.LoadLocation("UTC")
-	
+
 	mapper := NewCarStatusMapper()
 
 	t.Run("Complete data mapping", func(t *testing.T) {
 		now := time.Now()
 		data := &CarStatusData{
-			CarID:             1,
-			Name:              sql.NullString{String: "Test Car", Valid: true},
-			Model:             sql.NullString{String: "Model 3", Valid: true},
-			TrimBadging:       sql.NullString{String: "Performance", Valid: true},
-			ExteriorColor:     sql.NullString{String: "Red", Valid: true},
-			WheelType:         sql.NullString{String: "Sport", Valid: true},
-			SpoilerType:       sql.NullString{String: "None", Valid: true},
-			Latitude:          sql.NullFloat64{Float64: 37.7749, Valid: true},
-			Longitude:         sql.NullFloat64{Float64: -122.4194, Valid: true},
-			Odometer:          sql.NullFloat64{Float64: 12345.6, Valid: true},
-			BatteryLevel:      sql.NullInt32{Int32: 85, Valid: true},
-			UsableBatteryLevel: sql.NullInt32{Int32: 83, Valid: true},
-			EstBatteryRange:   sql.NullFloat64{Float64: 380.2, Valid: true},
-			RatedBatteryRange: sql.NullFloat64{Float64: 420.8, Valid: true},
-			IdealBatteryRange: sql.NullFloat64{Float64: 400.5, Valid: true},
-			OutsideTemp: sql.NullFloat64{Float64: 18.5, Valid: true},
-			InsideTemp:  sql.NullFloat64{Float64: 22.3, Valid: true},
-			IsClimateOn: sql.NullBool{Bool: true, Valid: true},
-			StateSince:        sql.NullTime{Time: now, Valid: true},
-			IsCharging:        sql.NullBool{Bool: true, Valid: true},
-			ChargingState:     sql.NullString{String: "charging", Valid: true},
-			ChargerPower:      sql.NullInt32{Int32: 11000, Valid: true},
-			ChargerVoltage:    sql.NullInt32{Int32: 240, Valid: true},
-			ChargerPhases:     sql.NullInt32{Int32: 3, Valid: true},
-			ChargerActualCurrent: sql.NullInt32{Int32: 45, Valid: true},
-			ChargeEnergyAdded: sql.NullFloat64{Float64: 5.2, Valid: true},
-			UnitOfLength:      sql.NullString{String: "km", Valid: true},
-			UnitOfPressure:    sql.NullString{String: "bar", Valid: true},
-			UnitOfTemperature: sql.NullString{String: "C", Valid: true},
+			CarID:                1,
+			Name:                 pgtype.Text{String: "Test Car", Valid: true},
+			Model:                pgtype.Text{String: "Model 3", Valid: true},
+			TrimBadging:          pgtype.Text{String: "Performance", Valid: true},
+			ExteriorColor:        pgtype.Text{String: "Red", Valid: true},
+			WheelType:            pgtype.Text{String: "Sport", Valid: true},
+			SpoilerType:          pgtype.Text{String: "None", Valid: true},
+			Latitude:             pgtype.Float8{Float64: 37.7749, Valid: true},
+			Longitude:            pgtype.Float8{Float64: -122.4194, Valid: true},
+			Odometer:             pgtype.Float8{Float64: 12345.6, Valid: true},
+			BatteryLevel:         pgtype.Int2{Int16: 85, Valid: true},
+			UsableBatteryLevel:   pgtype.Int2{Int16: 83, Valid: true},
+			EstBatteryRange:      pgtype.Float8{Float64: 380.2, Valid: true},
+			RatedBatteryRange:    pgtype.Float8{Float64: 420.8, Valid: true},
+			IdealBatteryRange:    pgtype.Float8{Float64: 400.5, Valid: true},
+			OutsideTemp:          pgtype.Float8{Float64: 18.5, Valid: true},
+			InsideTemp:           pgtype.Float8{Float64: 22.3, Valid: true},
+			IsClimateOn:          pgtype.Bool{Bool: true, Valid: true},
+			StateSince:           pgtype.Timestamp{Time: now, Valid: true},
+			IsCharging:           true,
+			ChargingState:        "charging",
+			ChargerPower:         pgtype.Int2{Int16: 11000, Valid: true},
+			ChargerVoltage:       pgtype.Int2{Int16: 240, Valid: true},
+			ChargerPhases:        pgtype.Int2{Int16: 3, Valid: true},
+			ChargerActualCurrent: pgtype.Int2{Int16: 45, Valid: true},
+			ChargeEnergyAdded:    pgtype.Float8{Float64: 5.2, Valid: true},
+			UnitOfLength:         "km",
+			UnitOfPressure:       "bar",
+			UnitOfTemperature:    "C",
+			Locked:               pgtype.Bool{Bool: false, Valid: true},
+			ShiftState:           pgtype.Text{String: "P", Valid: true},
 		}
 
 		response := mapper.MapToResponse(data, "online")
 
 		// Verify basic car info
-		if response.Data.Car.CarID != 1 {
-			t.Errorf("Expected CarID 1, got %d", response.Data.Car.CarID)
+		if response.Car.CarID != 1 {
+			t.Errorf("Expected CarID 1, got %d", response.Car.CarID)
 		}
 
-		if string(response.Data.Car.CarName) != "Test Car" {
-			t.Errorf("Expected CarName 'Test Car', got %s", response.Data.Car.CarName)
+		if string(response.Car.CarName) != "Test Car" {
+			t.Errorf("Expected CarName 'Test Car', got %s", response.Car.CarName)
 		}
 
 		// Verify status info
-		if response.Data.Status.DisplayName != "Test Car" {
-			t.Errorf("Expected DisplayName 'Test Car', got %s", response.Data.Status.DisplayName)
+		if response.Status.DisplayName != "Test Car" {
+			t.Errorf("Expected DisplayName 'Test Car', got %s", response.Status.DisplayName)
 		}
 
-		if response.Data.Status.State != "online" {
-			t.Errorf("Expected State 'online', got %s", response.Data.Status.State)
+		if response.Status.State != "online" {
+			t.Errorf("Expected State 'online', got %s", response.Status.State)
 		}
 
-		if response.Data.Status.Odometer != 12345.6 {
-			t.Errorf("Expected Odometer 12345.6, got %f", response.Data.Status.Odometer)
+		if response.Status.Odometer != 12345.6 {
+			t.Errorf("Expected Odometer 12345.6, got %f", response.Status.Odometer)
 		}
 
 		// Verify car details
-		if response.Data.Status.CarDetails.Model != "Model 3" {
-			t.Errorf("Expected Model 'Model 3', got %s", response.Data.Status.CarDetails.Model)
+		if response.Status.CarDetails.Model != "Model 3" {
+			t.Errorf("Expected Model 'Model 3', got %s", response.Status.CarDetails.Model)
 		}
 
-		if response.Data.Status.CarDetails.TrimBadging != "Performance" {
-			t.Errorf("Expected TrimBadging 'Performance', got %s", response.Data.Status.CarDetails.TrimBadging)
+		if response.Status.CarDetails.TrimBadging != "Performance" {
+			t.Errorf("Expected TrimBadging 'Performance', got %s", response.Status.CarDetails.TrimBadging)
 		}
 
 		// Verify car exterior
-		if response.Data.Status.CarExterior.ExteriorColor != "Red" {
-			t.Errorf("Expected ExteriorColor 'Red', got %s", response.Data.Status.CarExterior.ExteriorColor)
+		if response.Status.CarExterior.ExteriorColor != "Red" {
+			t.Errorf("Expected ExteriorColor 'Red', got %s", response.Status.CarExterior.ExteriorColor)
 		}
 
 		// Verify location
-		if response.Data.Status.CarGeodata.Location.Latitude != 37.7749 {
-			t.Errorf("Expected Latitude 37.7749, got %f", response.Data.Status.CarGeodata.Location.Latitude)
+		if response.Status.CarGeodata.Latitude != 37.7749 {
+			t.Errorf("Expected Latitude 37.7749, got %f", response.Status.CarGeodata.Latitude)
 		}
 
-		if response.Data.Status.CarGeodata.Location.Longitude != -122.4194 {
-			t.Errorf("Expected Longitude -122.4194, got %f", response.Data.Status.CarGeodata.Location.Longitude)
+		if response.Status.CarGeodata.Longitude != -122.4194 {
+			t.Errorf("Expected Longitude -122.4194, got %f", response.Status.CarGeodata.Longitude)
 		}
 
 		// Verify battery details
-		if response.Data.Status.BatteryDetails.BatteryLevel != 85 {
-			t.Errorf("Expected BatteryLevel 85, got %d", response.Data.Status.BatteryDetails.BatteryLevel)
+		if response.Status.BatteryDetails.BatteryLevel != 85 {
+			t.Errorf("Expected BatteryLevel 85, got %d", response.Status.BatteryDetails.BatteryLevel)
 		}
 
-		if response.Data.Status.BatteryDetails.EstBatteryRange != 380.2 {
-			t.Errorf("Expected EstBatteryRange 380.2, got %f", response.Data.Status.BatteryDetails.EstBatteryRange)
+		if response.Status.BatteryDetails.EstBatteryRange != 380.2 {
+			t.Errorf("Expected EstBatteryRange 380.2, got %f", response.Status.BatteryDetails.EstBatteryRange)
 		}
 
 		// Verify climate details
-		if !response.Data.Status.ClimateDetails.IsClimateOn {
+		if !response.Status.ClimateDetails.IsClimateOn {
 			t.Error("Expected IsClimateOn true, got false")
 		}
 
-		if response.Data.Status.ClimateDetails.OutsideTemp != 18.5 {
-			t.Errorf("Expected OutsideTemp 18.5, got %f", response.Data.Status.ClimateDetails.OutsideTemp)
+		if response.Status.ClimateDetails.OutsideTemp != 18.5 {
+			t.Errorf("Expected OutsideTemp 18.5, got %f", response.Status.ClimateDetails.OutsideTemp)
 		}
 
 		// Verify charging details - KEY FUNCTIONALITY
-		if !response.Data.Status.ChargingDetails.PluggedIn {
+		if !response.Status.ChargingDetails.PluggedIn {
 			t.Error("Expected PluggedIn true, got false")
 		}
 
-		if response.Data.Status.ChargingDetails.ChargingState != "charging" {
-			t.Errorf("Expected ChargingState 'charging', got %s", response.Data.Status.ChargingDetails.ChargingState)
+		if response.Status.ChargingDetails.ChargingState != "charging" {
+			t.Errorf("Expected ChargingState 'charging', got %s", response.Status.ChargingDetails.ChargingState)
 		}
 
-		if response.Data.Status.ChargingDetails.ChargerPower != 11000 {
-			t.Errorf("Expected ChargerPower 11000, got %f", response.Data.Status.ChargingDetails.ChargerPower)
+		if response.Status.ChargingDetails.ChargerPower != 11000 {
+			t.Errorf("Expected ChargerPower 11000, got %f", response.Status.ChargingDetails.ChargerPower)
 		}
 
-		if response.Data.Status.ChargingDetails.ChargerVoltage != 240 {
-			t.Errorf("Expected ChargerVoltage 240, got %d", response.Data.Status.ChargingDetails.ChargerVoltage)
+		if response.Status.ChargingDetails.ChargerVoltage != 240 {
+			t.Errorf("Expected ChargerVoltage 240, got %f", response.Status.ChargingDetails.ChargerVoltage)
 		}
 
-		if response.Data.Status.ChargingDetails.ChargeEnergyAdded != 5.2 {
-			t.Errorf("Expected ChargeEnergyAdded 5.2, got %f", response.Data.Status.ChargingDetails.ChargeEnergyAdded)
+		if response.Status.ChargingDetails.ChargeEnergyAdded != 5.2 {
+			t.Errorf("Expected ChargeEnergyAdded 5.2, got %f", response.Status.ChargingDetails.ChargeEnergyAdded)
+		}
+
+		// Verify physical status from database
+		if !response.Status.CarStatus.Locked.Valid || response.Status.CarStatus.Locked.Bool.Bool {
+			t.Error("Expected Locked false from database")
+		}
+
+		if response.Status.DrivingDetails.ShiftState.String != "P" {
+			t.Errorf("Expected ShiftState 'P', got %s", response.Status.DrivingDetails.ShiftState.String)
 		}
 
 		// Verify units
-		if response.Data.Units.UnitsLength != "km" {
-			t.Errorf("Expected UnitsLength 'km', got %s", response.Data.Units.UnitsLength)
+		if response.Units.UnitOfLength != "km" {
+			t.Errorf("Expected UnitsLength 'km', got %s", response.Units.UnitOfLength)
 		}
 	})
 
@@ -147,42 +161,51 @@ func TestCarStatusMapper_MapToResponse(t *testing.T) {
 		response := mapper.MapToResponse(data, "unknown")
 
 		// Verify defaults are applied
-		if response.Data.Car.CarID != 2 {
-			t.Errorf("Expected CarID 2, got %d", response.Data.Car.CarID)
+		if response.Car.CarID != 2 {
+			t.Errorf("Expected CarID 2, got %d", response.Car.CarID)
 		}
 
-		if string(response.Data.Car.CarName) != "" {
-			t.Errorf("Expected empty CarName, got %s", response.Data.Car.CarName)
+		if string(response.Car.CarName) != "" {
+			t.Errorf("Expected empty CarName, got %s", response.Car.CarName)
 		}
 
-		if response.Data.Status.DisplayName != "Car 2" {
-			t.Errorf("Expected DisplayName 'Car 2', got %s", response.Data.Status.DisplayName)
+		if response.Status.DisplayName != "Car 2" {
+			t.Errorf("Expected DisplayName 'Car 2', got %s", response.Status.DisplayName)
 		}
 
-		if response.Data.Status.State != "unknown" {
-			t.Errorf("Expected State 'unknown', got %s", response.Data.Status.State)
+		if response.Status.State != "unknown" {
+			t.Errorf("Expected State 'unknown', got %s", response.Status.State)
 		}
 
 		// Verify charging defaults
-		if response.Data.Status.ChargingDetails.PluggedIn {
+		if response.Status.ChargingDetails.PluggedIn {
 			t.Error("Expected PluggedIn false for null data, got true")
 		}
 
-		if response.Data.Status.ChargingDetails.ChargingState != "disconnected" {
-			t.Errorf("Expected ChargingState 'disconnected', got %s", response.Data.Status.ChargingDetails.ChargingState)
+		if response.Status.ChargingDetails.ChargingState != "disconnected" {
+			t.Errorf("Expected ChargingState 'disconnected', got %s", response.Status.ChargingDetails.ChargingState)
+		}
+
+		// Verify fields not persisted by TeslaMate are null instead of false
+		if response.Status.CarStatus.Locked.Valid || response.Status.CarStatus.SentryMode.Valid {
+			t.Error("Expected Locked and SentryMode to be null for null data")
+		}
+
+		if response.Status.DrivingDetails.Heading.Valid || response.Status.ChargingDetails.ChargeLimitSoc.Valid {
+			t.Error("Expected Heading and ChargeLimitSoc to be null for null data")
 		}
 
 		// Verify unit defaults
-		if response.Data.Units.UnitsLength != "km" {
-			t.Errorf("Expected default UnitsLength 'km', got %s", response.Data.Units.UnitsLength)
+		if response.Units.UnitOfLength != "km" {
+			t.Errorf("Expected default UnitsLength 'km', got %s", response.Units.UnitOfLength)
 		}
 
-		if response.Data.Units.UnitsPressure != "bar" {
-			t.Errorf("Expected default UnitsPressure 'bar', got %s", response.Data.Units.UnitsPressure)
+		if response.Units.UnitOfPressure != "bar" {
+			t.Errorf("Expected default UnitsPressure 'bar', got %s", response.Units.UnitOfPressure)
 		}
 
-		if response.Data.Units.UnitsTemperature != "C" {
-			t.Errorf("Expected default UnitsTemperature 'C', got %s", response.Data.Units.UnitsTemperature)
+		if response.Units.UnitOfTemperature != "C" {
+			t.Errorf("Expected default UnitsTemperature 'C', got %s", response.Units.UnitOfTemperature)
 		}
 	})
 }
@@ -192,63 +215,112 @@ func TestCarStatusMapper_ApplyUnitConversions(t *testing.T) {
 
 	t.Run("Miles conversion", func(t *testing.T) {
 		response := &CarStatusResponse{}
-		response.Data.Status.Odometer = 100.0
-		response.Data.Status.BatteryDetails.EstBatteryRange = 400.0
-		response.Data.Status.BatteryDetails.RatedBatteryRange = 420.0
-		response.Data.Status.BatteryDetails.IdealBatteryRange = 410.0
-		response.Data.Units.UnitsLength = "mi"
+		response.Status.Odometer = 100.0
+		response.Status.BatteryDetails.EstBatteryRange = 400.0
+		response.Status.BatteryDetails.RatedBatteryRange = 420.0
+		response.Status.BatteryDetails.IdealBatteryRange = 410.0
+		response.Units.UnitOfLength = "mi"
 
 		mapper.ApplyUnitConversions(response)
 
 		// Verify kilometers were converted to miles
 		expectedOdometer := 100.0 * 0.62137119223733 // 62.137...
-		if response.Data.Status.Odometer < expectedOdometer-0.01 || response.Data.Status.Odometer > expectedOdometer+0.01 {
-			t.Errorf("Expected Odometer ~62.14, got %f", response.Data.Status.Odometer)
+		if response.Status.Odometer < expectedOdometer-0.01 || response.Status.Odometer > expectedOdometer+0.01 {
+			t.Errorf("Expected Odometer ~62.14, got %f", response.Status.Odometer)
 		}
 
 		expectedRange := 400.0 * 0.62137119223733 // 248.548...
-		if response.Data.Status.BatteryDetails.EstBatteryRange < expectedRange-0.01 || response.Data.Status.BatteryDetails.EstBatteryRange > expectedRange+0.01 {
-			t.Errorf("Expected EstBatteryRange ~248.55, got %f", response.Data.Status.BatteryDetails.EstBatteryRange)
+		if response.Status.BatteryDetails.EstBatteryRange < expectedRange-0.01 || response.Status.BatteryDetails.EstBatteryRange > expectedRange+0.01 {
+			t.Errorf("Expected EstBatteryRange ~248.55, got %f", response.Status.BatteryDetails.EstBatteryRange)
+		}
+	})
+
+	t.Run("Psi conversion", func(t *testing.T) {
+		response := &CarStatusResponse{}
+		response.Status.TpmsDetails.TpmsPressureFl = 2.9
+		response.Units.UnitOfPressure = "psi"
+
+		mapper.ApplyUnitConversions(response)
+
+		expectedPressure := 2.9 * 14.503773800722 // 42.06...
+		if response.Status.TpmsDetails.TpmsPressureFl < expectedPressure-0.01 || response.Status.TpmsDetails.TpmsPressureFl > expectedPressure+0.01 {
+			t.Errorf("Expected TpmsPressureFl ~42.06, got %f", response.Status.TpmsDetails.TpmsPressureFl)
 		}
 	})
 
 	t.Run("Fahrenheit conversion", func(t *testing.T) {
 		response := &CarStatusResponse{}
-		response.Data.Status.ClimateDetails.InsideTemp = 20.0   // 20C = 68F
-		response.Data.Status.ClimateDetails.OutsideTemp = 0.0   // 0C = 32F
-		response.Data.Units.UnitsTemperature = "F"
+		response.Status.ClimateDetails.InsideTemp = 20.0 // 20C = 68F
+		response.Status.ClimateDetails.OutsideTemp = 0.0 // 0C = 32F
+		response.Units.UnitOfTemperature = "F"
 
 		mapper.ApplyUnitConversions(response)
 
 		// Verify Celsius was converted to Fahrenheit
-		if response.Data.Status.ClimateDetails.InsideTemp != 68.0 {
-			t.Errorf("Expected InsideTemp 68.0F, got %f", response.Data.Status.ClimateDetails.InsideTemp)
+		if response.Status.ClimateDetails.InsideTemp != 68.0 {
+			t.Errorf("Expected InsideTemp 68.0F, got %f", response.Status.ClimateDetails.InsideTemp)
 		}
 
-		if response.Data.Status.ClimateDetails.OutsideTemp != 32.0 {
-			t.Errorf("Expected OutsideTemp 32.0F, got %f", response.Data.Status.ClimateDetails.OutsideTemp)
+		if response.Status.ClimateDetails.OutsideTemp != 32.0 {
+			t.Errorf("Expected OutsideTemp 32.0F, got %f", response.Status.ClimateDetails.OutsideTemp)
 		}
 	})
 
 	t.Run("No conversion needed", func(t *testing.T) {
 		response := &CarStatusResponse{}
-		response.Data.Status.Odometer = 100.0
-		response.Data.Status.ClimateDetails.InsideTemp = 20.0
-		response.Data.Units.UnitsLength = "km"
-		response.Data.Units.UnitsTemperature = "C"
+		response.Status.Odometer = 100.0
+		response.Status.ClimateDetails.InsideTemp = 20.0
+		response.Units.UnitOfLength = "km"
+		response.Units.UnitOfTemperature = "C"
 
-		originalOdometer := response.Data.Status.Odometer
-		originalTemp := response.Data.Status.ClimateDetails.InsideTemp
+		originalOdometer := response.Status.Odometer
+		originalTemp := response.Status.ClimateDetails.InsideTemp
 
 		mapper.ApplyUnitConversions(response)
 
 		// Verify no changes were made
-		if response.Data.Status.Odometer != originalOdometer {
-			t.Errorf("Expected Odometer unchanged at %f, got %f", originalOdometer, response.Data.Status.Odometer)
+		if response.Status.Odometer != originalOdometer {
+			t.Errorf("Expected Odometer unchanged at %f, got %f", originalOdometer, response.Status.Odometer)
 		}
 
-		if response.Data.Status.ClimateDetails.InsideTemp != originalTemp {
-			t.Errorf("Expected InsideTemp unchanged at %f, got %f", originalTemp, response.Data.Status.ClimateDetails.InsideTemp)
+		if response.Status.ClimateDetails.InsideTemp != originalTemp {
+			t.Errorf("Expected InsideTemp unchanged at %f, got %f", originalTemp, response.Status.ClimateDetails.InsideTemp)
+		}
+	})
+}
+
+func TestCarStatusMapper_ApplyLocation(t *testing.T) {
+	mapper := NewCarStatusMapper()
+
+	t.Run("Geofence, address and distance to home", func(t *testing.T) {
+		response := &CarStatusResponse{}
+		response.Units.UnitOfLength = "mi"
+		mapper.ApplyLocation(response, &CarLocation{
+			Geofence:     &teslamatedb.ListGeofencesAtPositionRow{GeofenceID: 5, Name: "Office", Radius: 100, Distance: 40.2},
+			Address:      &teslamatedb.GetNearestAddressRow{AddressID: 11, City: pgtype.Text{String: "Berlin", Valid: true}},
+			HomeDistance: pgtype.Float8{Float64: 16093.44, Valid: true},
+		})
+		mapper.ApplyUnitConversions(response)
+
+		geodata := response.Status.CarGeodata
+		if geodata.Geofence != "Office" || !geodata.GeofenceID.Valid || geodata.GeofenceID.Int64 != 5 {
+			t.Errorf("Expected geofence 5 Office, got %d %s", geodata.GeofenceID.Int64, geodata.Geofence)
+		}
+		if geodata.Address == nil || geodata.Address.AddressID != 11 || geodata.Address.City.String != "Berlin" || geodata.Address.Road.Valid {
+			t.Errorf("Expected address 11 in Berlin without road, got %+v", geodata.Address)
+		}
+		if !geodata.DistanceToHome.Valid || math.Abs(geodata.DistanceToHome.Float64-10) > 0.001 {
+			t.Errorf("Expected distance to home of 10 mi, got %v", geodata.DistanceToHome)
+		}
+	})
+
+	t.Run("No location", func(t *testing.T) {
+		response := &CarStatusResponse{}
+		mapper.ApplyLocation(response, nil)
+
+		geodata := response.Status.CarGeodata
+		if geodata.Geofence != "" || geodata.GeofenceID.Valid || geodata.Address != nil || geodata.DistanceToHome.Valid {
+			t.Errorf("Expected empty location, got %+v", geodata)
 		}
 	})
 }
@@ -260,8 +332,8 @@ func TestCarStatusMapper_HelperMethods(t *testing.T) {
 		// Test with name
 		data1 := &CarStatusData{
 			CarID: 1,
-			Name:  sql.NullString{String: "My Tesla", Valid: true},
-			Model: sql.NullString{String: "Model S", Valid: true},
+			Name:  pgtype.Text{String: "My Tesla", Valid: true},
+			Model: pgtype.Text{String: "Model S", Valid: true},
 		}
 		displayName := mapper.getDisplayName(data1)
 		if displayName != "My Tesla" {
@@ -271,8 +343,8 @@ func TestCarStatusMapper_HelperMethods(t *testing.T) {
 		// Test with model only
 		data2 := &CarStatusData{
 			CarID: 2,
-			Name:  sql.NullString{Valid: false},
-			Model: sql.NullString{String: "Model 3", Valid: true},
+			Name:  pgtype.Text{Valid: false},
+			Model: pgtype.Text{String: "Model 3", Valid: true},
 		}
 		displayName = mapper.getDisplayName(data2)
 		if displayName != "Model 3" {
@@ -282,8 +354,8 @@ func TestCarStatusMapper_HelperMethods(t *testing.T) {
 		// Test with neither
 		data3 := &CarStatusData{
 			CarID: 3,
-			Name:  sql.NullString{Valid: false},
-			Model: sql.NullString{Valid: false},
+			Name:  pgtype.Text{Valid: false},
+			Model: pgtype.Text{Valid: false},
 		}
 		displayName = mapper.getDisplayName(data3)
 		if displayName != "Car 3" {
@@ -293,8 +365,8 @@ func TestCarStatusMapper_HelperMethods(t *testing.T) {
 
 	t.Run("getValue helpers", func(t *testing.T) {
 		// Test string values
-		validStr := sql.NullString{String: "test", Valid: true}
-		invalidStr := sql.NullString{Valid: false}
+		validStr := pgtype.Text{String: "test", Valid: true}
+		invalidStr := pgtype.Text{Valid: false}
 
 		if mapper.getStringValue(validStr) != "test" {
 			t.Errorf("Expected 'test', got %s", mapper.getStringValue(validStr))
@@ -305,8 +377,8 @@ func TestCarStatusMapper_HelperMethods(t *testing.T) {
 		}
 
 		// Test float values
-		validFloat := sql.NullFloat64{Float64: 123.45, Valid: true}
-		invalidFloat := sql.NullFloat64{Valid: false}
+		validFloat := pgtype.Float8{Float64: 123.45, Valid: true}
+		invalidFloat := pgtype.Float8{Valid: false}
 
 		if mapper.getFloat64Value(validFloat) != 123.45 {
 			t.Errorf("Expected 123.45, got %f", mapper.getFloat64Value(validFloat))
@@ -317,8 +389,8 @@ func TestCarStatusMapper_HelperMethods(t *testing.T) {
 		}
 
 		// Test bool values
-		validBool := sql.NullBool{Bool: true, Valid: true}
-		invalidBool := sql.NullBool{Valid: false}
+		validBool := pgtype.Bool{Bool: true, Valid: true}
+		invalidBool := pgtype.Bool{Valid: false}
 
 		if !mapper.getBoolValue(validBool) {
 			t.Error("Expected true, got false")
@@ -329,8 +401,8 @@ func TestCarStatusMapper_HelperMethods(t *testing.T) {
 		}
 
 		// Test int values
-		validInt := sql.NullInt32{Int32: 42, Valid: true}
-		invalidInt := sql.NullInt32{Valid: false}
+		validInt := pgtype.Int2{Int16: 42, Valid: true}
+		invalidInt := pgtype.Int2{Valid: false}
 
 		if mapper.getIntValue(validInt) != 42 {
 			t.Errorf("Expected 42, got %d", mapper.getIntValue(validInt))
@@ -340,4 +412,4 @@ func TestCarStatusMapper_HelperMethods(t *testing.T) {
 			t.Errorf("Expected 0, got %d", mapper.getIntValue(invalidInt))
 		}
 	})
-}
\ No newline at end of file
+}
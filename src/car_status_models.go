@@ -1,69 +1,18 @@
 package main
 
 import (
-	"database/sql"
 	"time"
+
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
 // CarStatusData holds raw database query results
-type CarStatusData struct {
-	// Car identification
-	CarID         int             `db:"id"`
-	Name          sql.NullString  `db:"name"`
-	Model         sql.NullString  `db:"model"`
-	TrimBadging   sql.NullString  `db:"trim_badging"`
-	ExteriorColor sql.NullString  `db:"exterior_color"`
-	WheelType     sql.NullString  `db:"wheel_type"`
-	SpoilerType   sql.NullString  `db:"spoiler_type"`
-	Vin           sql.NullString  `db:"vin"`
-
-	// Position and battery data
-	PositionDate       sql.NullTime    `db:"position_date"`
-	Latitude           sql.NullFloat64 `db:"latitude"`
-	Longitude          sql.NullFloat64 `db:"longitude"`
-	Speed              sql.NullInt32   `db:"speed"`
-	Power              sql.NullInt32   `db:"power"`
-	Odometer           sql.NullFloat64 `db:"odometer"`
-	BatteryLevel       sql.NullInt32   `db:"battery_level"`
-	UsableBatteryLevel sql.NullInt32   `db:"usable_battery_level"`
-	IdealBatteryRange  sql.NullFloat64 `db:"ideal_battery_range_km"`
-	EstBatteryRange    sql.NullFloat64 `db:"est_battery_range_km"`
-	RatedBatteryRange  sql.NullFloat64 `db:"rated_battery_range_km"`
-	Elevation          sql.NullInt32   `db:"elevation"`
-	Heading            sql.NullInt32   `db:"heading"`
-
-	// Climate data
-	OutsideTemp sql.NullFloat64 `db:"outside_temp"`
-	InsideTemp  sql.NullFloat64 `db:"inside_temp"`
-	IsClimateOn sql.NullBool    `db:"is_climate_on"`
-
-	// State information
-	State      sql.NullString `db:"state"`
-	StateSince sql.NullTime   `db:"state_since"`
-
-	// Charging information
-	IsCharging             sql.NullBool    `db:"is_charging"`
-	ChargingState          sql.NullString  `db:"charging_state"`
-	ChargerPower           sql.NullInt32   `db:"charger_power"`
-	ChargerVoltage         sql.NullInt32   `db:"charger_voltage"`
-	ChargerPhases          sql.NullInt32   `db:"charger_phases"`
-	ChargerActualCurrent   sql.NullInt32   `db:"charger_actual_current"`
-	ChargeEnergyAdded      sql.NullFloat64 `db:"charge_energy_added"`
-
-	// TPMS data
-	TpmsPressureFl sql.NullFloat64 `db:"tpms_pressure_fl"`
-	TpmsPressureFr sql.NullFloat64 `db:"tpms_pressure_fr"`
-	TpmsPressureRl sql.NullFloat64 `db:"tpms_pressure_rl"`
-	TpmsPressureRr sql.NullFloat64 `db:"tpms_pressure_rr"`
-
-	// Settings
-	UnitOfLength      sql.NullString `db:"unit_of_length"`
-	UnitOfTemperature sql.NullString `db:"unit_of_temperature"`
-}
+type CarStatusData teslamatedb.GetCarStatusRow
 
 // API Response structures matching the provided format
 type Units struct {
 	UnitOfLength      string `json:"unit_of_length"`
+	UnitOfPressure    string `json:"unit_of_pressure"`
 	UnitOfTemperature string `json:"unit_of_temperature"`
 }
 
@@ -75,50 +24,77 @@ type TpmDetails struct {
 }
 
 type DrivingDetails struct {
-	Elevation  int    `json:"elevation"`
-	Heading    int    `json:"heading"`
-	Power      int    `json:"power"`
-	ShiftState string `json:"shift_state"`
-	Speed      int    `json:"speed"`
+	Elevation  int       `json:"elevation"`
+	Heading    NullInt64 `json:"heading"`
+	Power      int       `json:"power"`
+	ShiftState NullText  `json:"shift_state"`
+	Speed      int       `json:"speed"`
 }
 
 type ClimateDetails struct {
-	InsideTemp        float64 `json:"inside_temp"`
-	IsClimateOn       bool    `json:"is_climate_on"`
-	IsPreconditioning bool    `json:"is_preconditioning"`
-	OutsideTemp       float64 `json:"outside_temp"`
+	DriverTempSetting    NullFloat64 `json:"driver_temp_setting"`
+	FanStatus            NullInt64   `json:"fan_status"`
+	InsideTemp           float64     `json:"inside_temp"`
+	IsClimateOn          bool        `json:"is_climate_on"`
+	IsFrontDefrosterOn   NullBool    `json:"is_front_defroster_on"`
+	IsPreconditioning    NullBool    `json:"is_preconditioning"`
+	IsRearDefrosterOn    NullBool    `json:"is_rear_defroster_on"`
+	OutsideTemp          float64     `json:"outside_temp"`
+	PassengerTempSetting NullFloat64 `json:"passenger_temp_setting"`
 }
 
 type ChargingDetails struct {
-	ChargeCurrentRequest       float32   `json:"charge_current_request"`
-	ChargeCurrentRequestMax    float32   `json:"charge_current_request_max"`
-	ChargeEnergyAdded          float32   `json:"charge_energy_added"`
-	ChargeLimitSoc             float32   `json:"charge_limit_soc"`
-	ChargePortDoorOpen         bool      `json:"charge_port_door_open"`
-	ChargerActualCurrent       float32   `json:"charger_actual_current"`
-	ChargerPhases              int       `json:"charger_phases"`
-	ChargerPower               float32   `json:"charger_power"`
-	ChargerVoltage             float32   `json:"charger_voltage"`
-	PluggedIn                  bool      `json:"plugged_in"`
-	ScheduledChargingStartTime time.Time `json:"scheduled_charging_start_time"`
-	TimeToFullCharge           float32   `json:"time_to_full_charge"`
+	ChargeCurrentRequest       float32     `json:"charge_current_request"`
+	ChargeCurrentRequestMax    float32     `json:"charge_current_request_max"`
+	ChargeEnergyAdded          float32     `json:"charge_energy_added"`
+	ChargeLimitSoc             NullInt64   `json:"charge_limit_soc"`
+	ChargePortDoorOpen         bool        `json:"charge_port_door_open"`
+	ChargerActualCurrent       float32     `json:"charger_actual_current"`
+	ChargerPhases              int         `json:"charger_phases"`
+	ChargerPilotCurrent        NullInt64   `json:"charger_pilot_current"`
+	ChargerPower               float32     `json:"charger_power"`
+	ChargerVoltage             float32     `json:"charger_voltage"`
+	ChargingState              string      `json:"charging_state"`
+	ConnChargeCable            NullText    `json:"conn_charge_cable"`
+	FastChargerBrand           NullText    `json:"fast_charger_brand"`
+	FastChargerPresent         NullBool    `json:"fast_charger_present"`
+	FastChargerType            NullText    `json:"fast_charger_type"`
+	PluggedIn                  bool        `json:"plugged_in"`
+	ScheduledChargingStartTime NullTime    `json:"scheduled_charging_start_time"`
+	TimeToFullCharge           NullFloat64 `json:"time_to_full_charge"`
 }
 
 type PhysicalStatus struct {
-	DoorsOpen     bool `json:"doors_open"`
-	FrunkOpen     bool `json:"frunk_open"`
-	Healthy       bool `json:"healthy"`
-	IsUserPresent bool `json:"is_user_present"`
-	Locked        bool `json:"locked"`
-	SentryMode    bool `json:"sentry_mode"`
-	TrunkOpen     bool `json:"trunk_open"`
-	WindowsOpen   bool `json:"windows_open"`
+	DoorsOpen     NullBool `json:"doors_open"`
+	FrunkOpen     NullBool `json:"frunk_open"`
+	Healthy       bool     `json:"healthy"`
+	IsUserPresent NullBool `json:"is_user_present"`
+	Locked        NullBool `json:"locked"`
+	SentryMode    NullBool `json:"sentry_mode"`
+	TrunkOpen     NullBool `json:"trunk_open"`
+	WindowsOpen   NullBool `json:"windows_open"`
 }
 
 type GeoData struct {
-	Geofence  string  `json:"geofence"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Address        *GeoAddress `json:"address"`
+	DistanceToHome NullFloat64 `json:"distance_to_home"`
+	Geofence       string      `json:"geofence"`
+	GeofenceID     NullInt64   `json:"geofence_id"`
+	Latitude       float64     `json:"latitude"`
+	Longitude      float64     `json:"longitude"`
+}
+
+type GeoAddress struct {
+	AddressID     int      `json:"address_id"`
+	City          NullText `json:"city"`
+	Country       NullText `json:"country"`
+	DisplayName   NullText `json:"display_name"`
+	HouseNumber   NullText `json:"house_number"`
+	Name          NullText `json:"name"`
+	Neighbourhood NullText `json:"neighbourhood"`
+	Postcode      NullText `json:"postcode"`
+	Road          NullText `json:"road"`
+	State         NullText `json:"state"`
 }
 
 type Exterior struct {
@@ -133,11 +109,14 @@ type Details struct {
 }
 
 type BatteryDetails struct {
-	BatteryLevel       int     `json:"battery_level"`
-	EstBatteryRange    float64 `json:"est_battery_range"`
-	IdealBatteryRange  float64 `json:"ideal_battery_range"`
-	RatedBatteryRange  float64 `json:"rated_battery_range"`
-	UsableBatteryLevel int     `json:"usable_battery_level"`
+	BatteryHeater        NullBool `json:"battery_heater"`
+	BatteryHeaterNoPower NullBool `json:"battery_heater_no_power"`
+	BatteryHeaterOn      NullBool `json:"battery_heater_on"`
+	BatteryLevel         int      `json:"battery_level"`
+	EstBatteryRange      float64  `json:"est_battery_range"`
+	IdealBatteryRange    float64  `json:"ideal_battery_range"`
+	RatedBatteryRange    float64  `json:"rated_battery_range"`
+	UsableBatteryLevel   int      `json:"usable_battery_level"`
 }
 
 type Car struct {
@@ -176,4 +155,4 @@ type CarStatusResponse struct {
 
 type genericResponse[T any] struct {
 	Data T `json:"data"`
-}
\ No newline at end of file
+}
@@ -1,153 +1,121 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"time"
-)
 
-const carStatusQuery = `
-	SELECT 
-		c.id,
-		c.name,
-		c.model,
-		c.trim_badging,
-		c.exterior_color,
-		c.wheel_type,
-		c.spoiler_type,
-		c.vin,
-		-- Latest position data
-		p.date AS position_date,
-		p.latitude,
-		p.longitude,
-		p.speed,
-		p.power,
-		p.odometer,
-		p.battery_level,
-		p.usable_battery_level,
-		p.ideal_battery_range_km,
-		p.est_battery_range_km,
-		p.rated_battery_range_km,
-		p.outside_temp,
-		p.inside_temp,
-		p.is_climate_on,
-		p.elevation,
-		p.tpms_pressure_fl,
-		p.tpms_pressure_fr,
-		p.tpms_pressure_rl,
-		p.tpms_pressure_rr,
-		-- Latest state information
-		s.state,
-		s.start_date AS state_since,
-		-- Check for active charging process
-		CASE 
-			WHEN cp.id IS NOT NULL AND cp.end_date IS NULL THEN true
-			ELSE false
-		END AS is_charging,
-		CASE 
-			WHEN cp.id IS NOT NULL AND cp.end_date IS NULL THEN 'charging'
-			ELSE 'disconnected'
-		END AS charging_state,
-		-- Latest charge data if charging
-		ch.charger_power,
-		ch.charger_voltage,
-		ch.charger_phases,
-		ch.charger_actual_current,
-		ch.charge_energy_added,
-		-- Settings
-		(SELECT unit_of_length FROM settings LIMIT 1) AS unit_of_length,
-		(SELECT unit_of_temperature FROM settings LIMIT 1) AS unit_of_temperature
-	FROM cars c
-	LEFT JOIN positions p ON c.id = p.car_id AND p.date = (
-		SELECT MAX(date) FROM positions p2 WHERE p2.car_id = c.id
-	)
-	LEFT JOIN states s ON c.id = s.car_id AND s.start_date = (
-		SELECT MAX(start_date) FROM states s2 WHERE s2.car_id = c.id
-	)
-	LEFT JOIN charging_processes cp ON c.id = cp.car_id AND cp.end_date IS NULL
-	LEFT JOIN charges ch ON cp.id = ch.charging_process_id AND ch.date = (
-		SELECT MAX(date) FROM charges ch2 WHERE ch2.charging_process_id = cp.id
-	)
-	WHERE c.id = $1`
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
 
 // CarStatusService handles car status operations
 type CarStatusService struct {
-	db *sql.DB
+	queries teslamatedb.Querier
 }
 
-func NewCarStatusService(database *sql.DB) *CarStatusService {
-	return &CarStatusService{db: database}
+func NewCarStatusService(database teslamatedb.DBTX) *CarStatusService {
+	return &CarStatusService{queries: teslamatedb.New(instrumentDB(database))}
 }
 
 // GetCarStatus retrieves comprehensive car status from database
 func (s *CarStatusService) GetCarStatus(carID int) (*CarStatusData, error) {
+	return s.GetCarStatusContext(context.Background(), carID)
+}
+
+// GetCarStatusContext is GetCarStatus with a context, used to label the queries of a request
+func (s *CarStatusService) GetCarStatusContext(ctx context.Context, carID int) (*CarStatusData, error) {
 	// Verify car exists first
-	var exists bool
-	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM cars WHERE id=$1)", carID).Scan(&exists)
+	exists, err := s.queries.CarExists(ctx, int16(carID))
 	if err != nil {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
 	if !exists {
-		return nil, fmt.Errorf("car with ID %d does not exist", carID)
+		return nil, errNotFound("car with ID %d does not exist", carID)
 	}
 
 	// Query comprehensive car status
-	var data CarStatusData
-	err = s.db.QueryRow(carStatusQuery, carID).Scan(
-		&data.CarID,
-		&data.Name,
-		&data.Model,
-		&data.TrimBadging,
-		&data.ExteriorColor,
-		&data.WheelType,
-		&data.SpoilerType,
-		&data.Vin,
-		&data.PositionDate,
-		&data.Latitude,
-		&data.Longitude,
-		&data.Speed,
-		&data.Power,
-		&data.Odometer,
-		&data.BatteryLevel,
-		&data.UsableBatteryLevel,
-		&data.IdealBatteryRange,
-		&data.EstBatteryRange,
-		&data.RatedBatteryRange,
-		&data.OutsideTemp,
-		&data.InsideTemp,
-		&data.IsClimateOn,
-		&data.Elevation,
-		&data.TpmsPressureFl,
-		&data.TpmsPressureFr,
-		&data.TpmsPressureRl,
-		&data.TpmsPressureRr,
-		&data.State,
-		&data.StateSince,
-		&data.IsCharging,
-		&data.ChargingState,
-		&data.ChargerPower,
-		&data.ChargerVoltage,
-		&data.ChargerPhases,
-		&data.ChargerActualCurrent,
-		&data.ChargeEnergyAdded,
-		&data.UnitOfLength,
-		&data.UnitOfTemperature,
-	)
-
+	row, err := s.queries.GetCarStatus(ctx, int16(carID))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("no data available for car ID %d", carID)
+		if err == pgx.ErrNoRows {
+			return nil, errNotFound("no data available for car ID %d", carID)
 		}
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
 
+	data := CarStatusData(row)
 	return &data, nil
 }
 
+// CarStatusFingerprint identifies the newest rows backing a car status, so
+// changes can be detected without running the full GetCarStatus query
+type CarStatusFingerprint teslamatedb.GetCarStatusFingerprintRow
+
+// GetCarStatusFingerprint retrieves the latest positions, states and charging
+// markers of a car
+func (s *CarStatusService) GetCarStatusFingerprint(carID int) (CarStatusFingerprint, error) {
+	fp, err := s.queries.GetCarStatusFingerprint(context.Background(), int16(carID))
+	if err != nil {
+		return CarStatusFingerprint{}, fmt.Errorf("query failed: %w", err)
+	}
+	return CarStatusFingerprint(fp), nil
+}
+
+// CarLocation is the geofence and known address at the latest position of a car
+type CarLocation struct {
+	Geofence     *teslamatedb.ListGeofencesAtPositionRow
+	Address      *teslamatedb.GetNearestAddressRow
+	HomeDistance pgtype.Float8 // meters to the center of the home geofence
+}
+
+// GetCarLocation resolves the geofence containing the latest position of a car (the one with
+// the nearest center if geofences overlap), the nearest known address within about 1 km and
+// the distance to the geofence set as HOME_GEOFENCE_ID
+func (s *CarStatusService) GetCarLocation(ctx context.Context, data *CarStatusData) (*CarLocation, error) {
+	location := &CarLocation{}
+	if !data.Latitude.Valid || !data.Longitude.Valid {
+		return location, nil
+	}
+
+	homeGeofenceID := int32(getEnvAsInt("HOME_GEOFENCE_ID", 0))
+	geofences, err := s.queries.ListGeofencesAtPosition(ctx, teslamatedb.ListGeofencesAtPositionParams{
+		Latitude:       data.Latitude.Float64,
+		Longitude:      data.Longitude.Float64,
+		HomeGeofenceID: homeGeofenceID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("geofence query failed: %w", err)
+	}
+	for i, geofence := range geofences {
+		if location.Geofence == nil && geofence.Distance <= float64(geofence.Radius) {
+			location.Geofence = &geofences[i]
+		}
+		if homeGeofenceID != 0 && geofence.GeofenceID == homeGeofenceID {
+			location.HomeDistance = pgtype.Float8{Float64: geofence.Distance, Valid: true}
+		}
+	}
+
+	address, err := s.queries.GetNearestAddress(ctx, teslamatedb.GetNearestAddressParams{
+		Latitude:  data.Latitude.Float64,
+		Longitude: data.Longitude.Float64,
+	})
+	switch err {
+	case nil:
+		location.Address = &address
+	case pgx.ErrNoRows:
+		// no known address close to the car
+	default:
+		return nil, fmt.Errorf("address query failed: %w", err)
+	}
+
+	return location, nil
+}
+
 // DetermineVehicleState calculates vehicle state from available data
 func (s *CarStatusService) DetermineVehicleState(data *CarStatusData) string {
-	if data.State.Valid && data.State.String != "" {
-		return data.State.String
+	if data.State.Valid && data.State.StatesStatus != "" {
+		return string(data.State.StatesStatus)
 	}
 
 	// Fallback: determine state from position timestamp
@@ -164,4 +132,4 @@ func (s *CarStatusService) DetermineVehicleState(data *CarStatusData) string {
 	}
 
 	return "unknown"
-}
\ No newline at end of file
+}
@@ -1,22 +1,68 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"testing"
 	"time"
 
-	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pashagolub/pgxmock/v4"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
+// carStatusColumns lists the columns returned by GetCarStatus
+var carStatusColumns = []string{
+	"car_id", "name", "model", "trim_badging", "exterior_color", "wheel_type", "spoiler_type", "vin",
+	"position_date", "latitude", "longitude", "speed", "power", "odometer", "battery_level",
+	"usable_battery_level", "ideal_battery_range", "est_battery_range", "rated_battery_range",
+	"outside_temp", "inside_temp", "is_climate_on", "elevation",
+	"tpms_pressure_fl", "tpms_pressure_fr", "tpms_pressure_rl", "tpms_pressure_rr",
+	"driver_temp_setting", "passenger_temp_setting", "fan_status", "is_front_defroster_on", "is_rear_defroster_on",
+	"battery_heater", "battery_heater_on", "battery_heater_no_power",
+	"heading", "shift_state", "locked", "sentry_mode", "windows_open", "doors_open", "trunk_open", "frunk_open",
+	"is_user_present", "is_preconditioning", "charge_limit_soc", "time_to_full_charge",
+	"scheduled_charging_start_time", "charge_port_door_open",
+	"state", "state_since", "is_charging", "charging_state",
+	"charger_power", "charger_voltage", "charger_phases", "charger_actual_current", "charge_energy_added",
+	"charger_pilot_current", "conn_charge_cable", "fast_charger_present", "fast_charger_brand", "fast_charger_type",
+	"version",
+	"unit_of_length", "unit_of_pressure", "unit_of_temperature",
+}
+
+// carStatusRow returns a GetCarStatus row with the given values, all other columns are NULL
+func carStatusRow(values map[string]any) []any {
+	row := make([]any, len(carStatusColumns))
+	for i, column := range carStatusColumns {
+		row[i] = values[column]
+		// pgx scans integers into the nullable pgtype columns as int64
+		if v, ok := row[i].(int); ok {
+			row[i] = int64(v)
+		}
+	}
+	return row
+}
+
+// expectCarLocationQueries mocks the geofence and address queries of GetCarLocation, without
+// geofences or addresses around the position
+func expectCarLocationQueries(mock pgxmock.PgxPoolIface) {
+	mock.ExpectQuery("SELECT.*FROM geofences.*geofence_distances").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"geofence_id", "name", "radius", "distance"}))
+	mock.ExpectQuery("SELECT.*FROM addresses").
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"address_id", "display_name", "name", "house_number", "road", "neighbourhood", "city", "postcode", "state", "country"}))
+}
+
 func TestCarStatusService_GetCarStatus(t *testing.T) {
-	db, mock, err := sqlmock.New()
+	mock, err := pgxmock.NewPool()
 	if err != nil {
 		t.Fatalf("Failed to create mock database: %v", err)
 	}
-	defer db.Close()
+	defer mock.Close()
 
-	service := NewCarStatusService(db)
+	service := NewCarStatusService(mock)
 
 	t.Run("Car exists and has data", func(t *testing.T) {
 		carID := 1
@@ -24,34 +70,30 @@ func TestCarStatusService_GetCarStatus(t *testing.T) {
 
 		// Mock car existence check
 		mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
-			WithArgs(carID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+			WithArgs(int16(carID)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
 
 		// Mock main status query with all expected columns
-		rows := sqlmock.NewRows([]string{
-			"id", "name", "model", "trim_badging", "exterior_color", "wheel_type", "spoiler_type", "vin",
-			"position_date", "latitude", "longitude", "speed", "power", "odometer", "battery_level",
-			"usable_battery_level", "ideal_battery_range_km", "est_battery_range_km", "rated_battery_range_km",
-			"outside_temp", "inside_temp", "is_climate_on",
-			"state", "state_since", "is_charging", "charging_state",
-			"charger_power", "charger_voltage", "charger_phases", "charger_actual_current", "charge_energy_added",
-			"unit_of_length", "unit_of_pressure", "unit_of_temperature",
-		}).AddRow(
-			1, "Test Car", "Model 3", "Performance", "Red", "Sport", "None", "5YJ3E1EA4JF123456",
-			now, 37.7749, -122.4194, 65, 150, 12345.6, 85,
-			83, 400.5, 380.2, 420.8,
-			18.5, 22.3, true,
-			"online", now, true, "charging",
-			11000, 240, 3, 45, 5.2,
-			"km", "bar", "C",
-		)
+		rows := pgxmock.NewRows(carStatusColumns).AddRow(carStatusRow(map[string]any{
+			"car_id": 1, "name": "Test Car", "model": "Model 3", "trim_badging": "Performance",
+			"exterior_color": "Red", "wheel_type": "Sport", "spoiler_type": "None", "vin": "5YJ3E1EA4JF123456",
+			"position_date": now, "latitude": 37.7749, "longitude": -122.4194, "speed": 65, "power": 150,
+			"odometer": 12345.6, "battery_level": 85, "usable_battery_level": 83,
+			"ideal_battery_range": 400.5, "est_battery_range": 380.2, "rated_battery_range": 420.8,
+			"outside_temp": 18.5, "inside_temp": 22.3, "is_climate_on": true,
+			"state": "online", "state_since": now, "is_charging": true, "charging_state": "charging",
+			"charger_power": 11000, "charger_voltage": 240, "charger_phases": 3, "charger_actual_current": 45,
+			"charge_energy_added": 5.2,
+			"locked":              true, "sentry_mode": false,
+			"unit_of_length": "km", "unit_of_pressure": "bar", "unit_of_temperature": "C",
+		})...)
 
 		mock.ExpectQuery("SELECT.*FROM cars c.*WHERE c.id = \\$1").
-			WithArgs(carID).
+			WithArgs(int16(carID)).
 			WillReturnRows(rows)
 
 		result, err := service.GetCarStatus(carID)
-		
+
 		if err != nil {
 			t.Errorf("Expected no error, got %v", err)
 		}
@@ -69,12 +111,20 @@ func TestCarStatusService_GetCarStatus(t *testing.T) {
 			t.Errorf("Expected name 'Test Car', got %s", result.Name.String)
 		}
 
-		if !result.IsCharging.Bool {
+		if !result.IsCharging {
 			t.Error("Expected car to be charging")
 		}
 
-		if result.ChargingState.String != "charging" {
-			t.Errorf("Expected charging state 'charging', got %s", result.ChargingState.String)
+		if result.ChargingState != "charging" {
+			t.Errorf("Expected charging state 'charging', got %s", result.ChargingState)
+		}
+
+		if !result.Locked.Valid || !result.Locked.Bool {
+			t.Error("Expected car to be locked")
+		}
+
+		if result.DoorsOpen.Valid {
+			t.Error("Expected doors_open to be NULL when not persisted")
 		}
 
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -87,8 +137,8 @@ func TestCarStatusService_GetCarStatus(t *testing.T) {
 
 		// Mock car existence check returning false
 		mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
-			WithArgs(carID).
-			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+			WithArgs(int16(carID)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
 
 		result, err := service.GetCarStatus(carID)
 
@@ -105,6 +155,10 @@ func TestCarStatusService_GetCarStatus(t *testing.T) {
 			t.Errorf("Expected error '%s', got '%s'", expectedError, err.Error())
 		}
 
+		if !errors.Is(err, pgx.ErrNoRows) {
+			t.Errorf("Expected error to match pgx.ErrNoRows, got '%s'", err.Error())
+		}
+
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("Unfulfilled expectations: %s", err)
 		}
@@ -115,7 +169,7 @@ func TestCarStatusService_GetCarStatus(t *testing.T) {
 		expectedErr := errors.New("database connection failed")
 
 		mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
-			WithArgs(carID).
+			WithArgs(int16(carID)).
 			WillReturnError(expectedErr)
 
 		result, err := service.GetCarStatus(carID)
@@ -139,7 +193,7 @@ func TestCarStatusService_DetermineVehicleState(t *testing.T) {
 
 	t.Run("State from database", func(t *testing.T) {
 		data := &CarStatusData{
-			State: sql.NullString{String: "asleep", Valid: true},
+			State: teslamatedb.NullStatesStatus{StatesStatus: teslamatedb.StatesStatusAsleep, Valid: true},
 		}
 
 		state := service.DetermineVehicleState(data)
@@ -152,8 +206,8 @@ func TestCarStatusService_DetermineVehicleState(t *testing.T) {
 	t.Run("State from position timestamp - online", func(t *testing.T) {
 		recentTime := time.Now().Add(-2 * time.Minute)
 		data := &CarStatusData{
-			State:        sql.NullString{Valid: false},
-			PositionDate: sql.NullTime{Time: recentTime, Valid: true},
+			State:        teslamatedb.NullStatesStatus{},
+			PositionDate: pgtype.Timestamp{Time: recentTime, Valid: true},
 		}
 
 		state := service.DetermineVehicleState(data)
@@ -166,8 +220,8 @@ func TestCarStatusService_DetermineVehicleState(t *testing.T) {
 	t.Run("State from position timestamp - asleep", func(t *testing.T) {
 		oldTime := time.Now().Add(-15 * time.Minute)
 		data := &CarStatusData{
-			State:        sql.NullString{Valid: false},
-			PositionDate: sql.NullTime{Time: oldTime, Valid: true},
+			State:        teslamatedb.NullStatesStatus{},
+			PositionDate: pgtype.Timestamp{Time: oldTime, Valid: true},
 		}
 
 		state := service.DetermineVehicleState(data)
@@ -180,8 +234,8 @@ func TestCarStatusService_DetermineVehicleState(t *testing.T) {
 	t.Run("State from position timestamp - offline", func(t *testing.T) {
 		veryOldTime := time.Now().Add(-45 * time.Minute)
 		data := &CarStatusData{
-			State:        sql.NullString{Valid: false},
-			PositionDate: sql.NullTime{Time: veryOldTime, Valid: true},
+			State:        teslamatedb.NullStatesStatus{},
+			PositionDate: pgtype.Timestamp{Time: veryOldTime, Valid: true},
 		}
 
 		state := service.DetermineVehicleState(data)
@@ -193,8 +247,8 @@ func TestCarStatusService_DetermineVehicleState(t *testing.T) {
 
 	t.Run("No state data available", func(t *testing.T) {
 		data := &CarStatusData{
-			State:        sql.NullString{Valid: false},
-			PositionDate: sql.NullTime{Valid: false},
+			State:        teslamatedb.NullStatesStatus{},
+			PositionDate: pgtype.Timestamp{Valid: false},
 		}
 
 		state := service.DetermineVehicleState(data)
@@ -203,4 +257,82 @@ func TestCarStatusService_DetermineVehicleState(t *testing.T) {
 			t.Errorf("Expected state 'unknown', got '%s'", state)
 		}
 	})
-}
\ No newline at end of file
+}
+
+func TestCarStatusService_GetCarLocation(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	service := NewCarStatusService(mock)
+	data := &CarStatusData{
+		Latitude:  pgtype.Float8{Float64: 52.520008, Valid: true},
+		Longitude: pgtype.Float8{Float64: 13.404954, Valid: true},
+	}
+	geofenceColumns := []string{"geofence_id", "name", "radius", "distance"}
+	addressColumns := []string{"address_id", "display_name", "name", "house_number", "road", "neighbourhood", "city", "postcode", "state", "country"}
+
+	t.Run("Inside geofence with home and address", func(t *testing.T) {
+		t.Setenv("HOME_GEOFENCE_ID", "7")
+
+		// nearest geofence (3) doesn't contain the position, 5 does and 7 is home
+		mock.ExpectQuery("SELECT.*FROM geofences.*geofence_distances").
+			WithArgs(52.520008, 13.404954, int32(7)).
+			WillReturnRows(pgxmock.NewRows(geofenceColumns).
+				AddRow(3, "Parking", 20, 35.5).
+				AddRow(5, "Office", 100, 40.2).
+				AddRow(7, "Home", 50, 12500.0))
+		mock.ExpectQuery("SELECT.*FROM addresses").
+			WithArgs(52.520008, 13.404954).
+			WillReturnRows(pgxmock.NewRows(addressColumns).
+				AddRow(11, "Alexanderplatz 1, Berlin", nil, "1", "Alexanderplatz", "Mitte", "Berlin", "10178", "Berlin", "Germany"))
+
+		location, err := service.GetCarLocation(context.Background(), data)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if location.Geofence == nil || location.Geofence.GeofenceID != 5 {
+			t.Errorf("Expected geofence 5, got %v", location.Geofence)
+		}
+		if !location.HomeDistance.Valid || location.HomeDistance.Float64 != 12500 {
+			t.Errorf("Expected home distance 12500, got %v", location.HomeDistance)
+		}
+		if location.Address == nil || location.Address.AddressID != 11 {
+			t.Errorf("Expected address 11, got %v", location.Address)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Outside of geofences without home", func(t *testing.T) {
+		mock.ExpectQuery("SELECT.*FROM geofences.*geofence_distances").
+			WithArgs(52.520008, 13.404954, int32(0)).
+			WillReturnRows(pgxmock.NewRows(geofenceColumns))
+		mock.ExpectQuery("SELECT.*FROM addresses").
+			WithArgs(52.520008, 13.404954).
+			WillReturnRows(pgxmock.NewRows(addressColumns))
+
+		location, err := service.GetCarLocation(context.Background(), data)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if location.Geofence != nil || location.Address != nil || location.HomeDistance.Valid {
+			t.Errorf("Expected empty location, got %+v", location)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("No position", func(t *testing.T) {
+		location, err := service.GetCarLocation(context.Background(), &CarStatusData{})
+		if err != nil || location.Geofence != nil || location.Address != nil {
+			t.Errorf("Expected empty location without queries, got %+v, %v", location, err)
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// carStatusStreamHistory is the number of events kept per car for Last-Event-ID resume
+	carStatusStreamHistory = 32
+
+	// carStatusStreamGrace is how long a car is still watched after its last subscriber left,
+	// so a client reconnecting with Last-Event-ID gets the events it missed
+	carStatusStreamGrace = time.Minute
+)
+
+// CarStatusEvent is a status snapshot pushed to stream subscribers
+type CarStatusEvent struct {
+	ID       int64
+	Response *CarStatusResponse
+}
+
+// carStatusStream holds the subscribers and recent events of a single car
+type carStatusStream struct {
+	subscribers map[chan CarStatusEvent]struct{}
+	history     []CarStatusEvent
+	fingerprint CarStatusFingerprint
+	stop        chan struct{}
+	idle        *time.Timer // stops the stream once the grace period without subscribers is over
+}
+
+// CarStatusBroker watches the database for car status changes and fans them out
+// to subscribers, so every car is polled once regardless of the number of clients
+type CarStatusBroker struct {
+	service  *CarStatusService
+	mapper   *CarStatusMapper
+	interval time.Duration
+	grace    time.Duration // the stream stops right away when the last subscriber leaves if zero
+
+	mu     sync.Mutex
+	feed   *ChangeFeed
+	lastID int64
+	cars   map[int]*carStatusStream
+}
+
+func NewCarStatusBroker(service *CarStatusService, mapper *CarStatusMapper, interval time.Duration) *CarStatusBroker {
+	return &CarStatusBroker{
+		service:  service,
+		mapper:   mapper,
+		interval: interval,
+		grace:    carStatusStreamGrace,
+		cars:     make(map[int]*carStatusStream),
+	}
+}
+
+// Subscribe registers a subscriber for a car and returns the events it has to
+// send right away (missed events since lastEventID, or the latest snapshot),
+// the channel for upcoming events and a function to unsubscribe
+func (b *CarStatusBroker) Subscribe(carID int, lastEventID string) ([]CarStatusEvent, <-chan CarStatusEvent, func(), error) {
+	var (
+		fingerprint CarStatusFingerprint
+		response    *CarStatusResponse
+		err         error
+	)
+
+	for {
+		b.mu.Lock()
+		stream, ok := b.cars[carID]
+		if !ok && response != nil {
+			// first subscriber of the car starts watching it
+			stream = &carStatusStream{
+				subscribers: make(map[chan CarStatusEvent]struct{}),
+				history:     []CarStatusEvent{{ID: b.nextID(), Response: response}},
+				fingerprint: fingerprint,
+				stop:        make(chan struct{}),
+			}
+			b.cars[carID] = stream
+			go b.watch(carID, stream)
+			ok = true
+		}
+		if ok {
+			if stream.idle != nil {
+				stream.idle.Stop()
+				stream.idle = nil
+			}
+			ch := make(chan CarStatusEvent, 8)
+			stream.subscribers[ch] = struct{}{}
+			events := eventsSince(stream.history, lastEventID)
+			b.mu.Unlock()
+			return events, ch, func() { b.unsubscribe(carID, stream, ch) }, nil
+		}
+		b.mu.Unlock()
+
+		// loading the initial snapshot outside of the lock, which also validates the car
+		if fingerprint, err = b.service.GetCarStatusFingerprint(carID); err != nil {
+			return nil, nil, nil, err
+		}
+		if response, err = b.snapshot(carID); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+}
+
+// unsubscribe removes a subscriber and stops watching the car once nobody is left for the grace period,
+// the history is kept until then so a reconnecting client can resume
+func (b *CarStatusBroker) unsubscribe(carID int, stream *carStatusStream, ch chan CarStatusEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := stream.subscribers[ch]; !ok {
+		return
+	}
+	delete(stream.subscribers, ch)
+	if len(stream.subscribers) > 0 || b.cars[carID] != stream {
+		return
+	}
+	if b.grace <= 0 {
+		b.stop(carID, stream)
+		return
+	}
+	stream.idle = time.AfterFunc(b.grace, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if len(stream.subscribers) == 0 && b.cars[carID] == stream {
+			b.stop(carID, stream)
+		}
+	})
+}
+
+// stop stops watching a car and drops its history (b.mu needs to be held)
+func (b *CarStatusBroker) stop(carID int, stream *carStatusStream) {
+	close(stream.stop)
+	delete(b.cars, carID)
+}
+
+// UseChangeFeed lets the broker check a car as soon as the change feed reports
+// new rows for it, polling every interval stays in place as fallback
+func (b *CarStatusBroker) UseChangeFeed(feed *ChangeFeed) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.feed = feed
+}
+
+// watch polls the fingerprint of a car until the stream is stopped
+func (b *CarStatusBroker) watch(carID int, stream *carStatusStream) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	// without change feed, changes only arrive by polling
+	var changes <-chan ChangeEvent
+	b.mu.Lock()
+	feed := b.feed
+	b.mu.Unlock()
+	if feed != nil {
+		var unsubscribe func()
+		changes, unsubscribe = feed.Subscribe("positions", "states", "charging_processes", "charges")
+		defer unsubscribe()
+	}
+
+	for {
+		select {
+		case <-stream.stop:
+			return
+		case <-ticker.C:
+			b.poll(carID, stream)
+		case event := <-changes:
+			if event.CarID == carID || event.Operation == ChangeEventReconnect {
+				drainChangeEvents(changes)
+				b.poll(carID, stream)
+			}
+		}
+	}
+}
+
+// drainChangeEvents discards queued events, so a burst of rows results in a single poll
+func drainChangeEvents(changes <-chan ChangeEvent) {
+	for {
+		select {
+		case <-changes:
+		default:
+			return
+		}
+	}
+}
+
+// poll publishes a new snapshot when the fingerprint of a car has changed
+func (b *CarStatusBroker) poll(carID int, stream *carStatusStream) {
+	fingerprint, err := b.service.GetCarStatusFingerprint(carID)
+	if err != nil {
+		log.Printf("[warning] CarStatusBroker - unable to check status of car %d: %s", carID, err)
+		return
+	}
+
+	b.mu.Lock()
+	unchanged := fingerprint == stream.fingerprint
+	b.mu.Unlock()
+	if unchanged {
+		return
+	}
+
+	response, err := b.snapshot(carID)
+	if err != nil {
+		log.Printf("[warning] CarStatusBroker - unable to load status of car %d: %s", carID, err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream.fingerprint = fingerprint
+	event := CarStatusEvent{ID: b.nextID(), Response: response}
+	stream.history = append(stream.history, event)
+	if len(stream.history) > carStatusStreamHistory {
+		stream.history = stream.history[len(stream.history)-carStatusStreamHistory:]
+	}
+
+	for ch := range stream.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// slow subscriber, it will catch up with the next event
+			log.Printf("[warning] CarStatusBroker - dropped event %d of car %d for slow subscriber", event.ID, carID)
+		}
+	}
+}
+
+// snapshot builds the same response as the /status endpoint
+func (b *CarStatusBroker) snapshot(carID int) (*CarStatusResponse, error) {
+	statusData, err := b.service.GetCarStatus(carID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := b.mapper.MapToResponse(statusData, b.service.DetermineVehicleState(statusData))
+	location, err := b.service.GetCarLocation(context.Background(), statusData)
+	if err != nil {
+		log.Printf("[warning] CarStatusBroker - unable to resolve location of car %d: %s", carID, err)
+	}
+	b.mapper.ApplyLocation(response, location)
+	b.mapper.ApplyUnitConversions(response)
+
+	if gin.IsDebugging() {
+		log.Printf("[debug] CarStatusBroker - loaded new status snapshot of car %d", carID)
+	}
+	return response, nil
+}
+
+// nextID returns a new event id, based on the current time so ids keep growing across restarts
+// (b.mu needs to be held)
+func (b *CarStatusBroker) nextID() int64 {
+	id := time.Now().UnixMilli()
+	if id <= b.lastID {
+		id = b.lastID + 1
+	}
+	b.lastID = id
+	return id
+}
+
+// eventsSince returns the events after lastEventID, or only the latest event
+// if lastEventID is empty or no longer part of the history
+func eventsSince(history []CarStatusEvent, lastEventID string) []CarStatusEvent {
+	if len(history) == 0 {
+		return nil
+	}
+	if id, err := strconv.ParseInt(lastEventID, 10, 64); err == nil {
+		for i, event := range history {
+			if event.ID == id {
+				return history[i+1:]
+			}
+		}
+	}
+	return history[len(history)-1:]
+}
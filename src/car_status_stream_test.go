@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+// expectCarStatusQueries mocks the existence check and main status query of GetCarStatus
+func expectCarStatusQueries(mock pgxmock.PgxPoolIface, carID int, batteryLevel int) {
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
+		WithArgs(int16(carID)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+
+	now := time.Now()
+	rows := pgxmock.NewRows(carStatusColumns).AddRow(carStatusRow(map[string]any{
+		"car_id": carID, "name": "Test Tesla", "model": "Model 3",
+		"position_date": now, "latitude": 37.7749, "longitude": -122.4194, "odometer": 12345.6,
+		"battery_level": batteryLevel, "usable_battery_level": batteryLevel,
+		"state": "online", "state_since": now, "is_charging": false, "charging_state": "disconnected",
+		"unit_of_length": "km", "unit_of_pressure": "bar", "unit_of_temperature": "C",
+	})...)
+	mock.ExpectQuery("SELECT.*FROM cars c.*WHERE c.id = \\$1").
+		WithArgs(int16(carID)).
+		WillReturnRows(rows)
+	expectCarLocationQueries(mock)
+}
+
+// expectCarStatusFingerprint mocks the fingerprint query of GetCarStatusFingerprint
+func expectCarStatusFingerprint(mock pgxmock.PgxPoolIface, carID int, positionDate time.Time) {
+	mock.ExpectQuery("SELECT.*FROM positions WHERE car_id = \\$1").
+		WithArgs(int16(carID)).
+		WillReturnRows(pgxmock.NewRows([]string{"position_date", "state_since", "charging_process_id", "charge_date"}).
+			AddRow(positionDate, positionDate, int64(0), time.Unix(0, 0)))
+}
+
+func TestEventsSince(t *testing.T) {
+	history := []CarStatusEvent{{ID: 10}, {ID: 11}, {ID: 12}}
+
+	t.Run("No Last-Event-ID returns latest event", func(t *testing.T) {
+		events := eventsSince(history, "")
+		if len(events) != 1 || events[0].ID != 12 {
+			t.Errorf("Expected only event 12, got %v", events)
+		}
+	})
+
+	t.Run("Known Last-Event-ID returns missed events", func(t *testing.T) {
+		events := eventsSince(history, "10")
+		if len(events) != 2 || events[0].ID != 11 || events[1].ID != 12 {
+			t.Errorf("Expected events 11 and 12, got %v", events)
+		}
+	})
+
+	t.Run("Latest Last-Event-ID returns nothing", func(t *testing.T) {
+		if events := eventsSince(history, "12"); len(events) != 0 {
+			t.Errorf("Expected no events, got %v", events)
+		}
+	})
+
+	t.Run("Unknown Last-Event-ID returns latest event", func(t *testing.T) {
+		events := eventsSince(history, "3")
+		if len(events) != 1 || events[0].ID != 12 {
+			t.Errorf("Expected only event 12, got %v", events)
+		}
+	})
+}
+
+func TestCarStatusBroker(t *testing.T) {
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	broker := NewCarStatusBroker(NewCarStatusService(mock), NewCarStatusMapper(), time.Hour)
+	firstPosition := time.Now().Add(-time.Minute)
+
+	t.Run("Unknown car", func(t *testing.T) {
+		expectCarStatusFingerprint(mock, 999, firstPosition)
+		mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
+			WithArgs(int16(999)).
+			WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+
+		if _, _, _, err := broker.Subscribe(999, ""); err == nil {
+			t.Error("Expected error for non-existent car, got nil")
+		}
+		if len(broker.cars) != 0 {
+			t.Error("Expected no stream to be started for non-existent car")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Subscribe and receive changes", func(t *testing.T) {
+		expectCarStatusFingerprint(mock, 1, firstPosition)
+		expectCarStatusQueries(mock, 1, 80)
+
+		events, updates, unsubscribe, err := broker.Subscribe(1, "")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(events) != 1 || events[0].Response.Status.BatteryDetails.BatteryLevel != 80 {
+			t.Fatalf("Expected initial snapshot with battery level 80, got %v", events)
+		}
+
+		// second subscriber shares the stream without querying again
+		secondEvents, _, secondUnsubscribe, err := broker.Subscribe(1, "")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(secondEvents) != 1 || secondEvents[0].ID != events[0].ID {
+			t.Errorf("Expected second subscriber to get the same snapshot, got %v", secondEvents)
+		}
+
+		// unchanged fingerprint does not load the status again
+		expectCarStatusFingerprint(mock, 1, firstPosition)
+		broker.poll(1, broker.cars[1])
+		select {
+		case event := <-updates:
+			t.Errorf("Expected no event for unchanged data, got %v", event)
+		default:
+		}
+
+		// new position results in a new event
+		expectCarStatusFingerprint(mock, 1, time.Now())
+		expectCarStatusQueries(mock, 1, 79)
+		broker.poll(1, broker.cars[1])
+		select {
+		case event := <-updates:
+			if event.ID <= events[0].ID {
+				t.Errorf("Expected event id to grow, got %d after %d", event.ID, events[0].ID)
+			}
+			if event.Response.Status.BatteryDetails.BatteryLevel != 79 {
+				t.Errorf("Expected battery level 79, got %d", event.Response.Status.BatteryDetails.BatteryLevel)
+			}
+		default:
+			t.Error("Expected event for changed data")
+		}
+
+		// stream stops after the last subscriber leaves
+		unsubscribe()
+		if _, ok := broker.cars[1]; !ok {
+			t.Error("Expected stream to keep running for remaining subscriber")
+		}
+		secondUnsubscribe()
+		if _, ok := broker.cars[1]; !ok {
+			t.Fatal("Expected stream to keep its history for the grace period")
+		}
+
+		// a client reconnecting within the grace period resumes after its last event
+		resumed, _, resumedUnsubscribe, err := broker.Subscribe(1, strconv.FormatInt(events[0].ID, 10))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(resumed) != 1 || resumed[0].Response.Status.BatteryDetails.BatteryLevel != 79 {
+			t.Errorf("Expected the missed event with battery level 79, got %v", resumed)
+		}
+
+		// stream stops once the grace period is over
+		broker.grace = 10 * time.Millisecond
+		resumedUnsubscribe()
+		time.Sleep(50 * time.Millisecond)
+		broker.mu.Lock()
+		_, ok := broker.cars[1]
+		broker.mu.Unlock()
+		if ok {
+			t.Error("Expected stream to be stopped after the grace period without subscribers")
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestTeslaMateAPICarsStatusStreamV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	originalBroker := carStatusBroker
+	carStatusBroker = NewCarStatusBroker(NewCarStatusService(mock), NewCarStatusMapper(), time.Hour)
+	defer func() { carStatusBroker = originalBroker }()
+
+	expectCarStatusFingerprint(mock, 1, time.Now())
+	expectCarStatusQueries(mock, 1, 80)
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/status/stream", TeslaMateAPICarsStatusStreamV1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/api/v1/cars/1/status/stream", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/event-stream;charset=utf-8" {
+		t.Errorf("Expected event stream content type, got %s", contentType)
+	}
+
+	body := w.Body.String()
+	for _, expected := range []string{"id:", "event:status", `"car_id":1`, `"battery_level":80`} {
+		if !contains(body, expected) {
+			t.Errorf("Expected stream to contain '%s', but it didn't. Body: %s", expected, body)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
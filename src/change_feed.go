@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// changeFeedTables are the TeslaMate tables that notify about changed rows, charges have
+// no car_id and notify with the car of their charging process
+var changeFeedTables = []string{"positions", "states", "charging_processes", "charges", "drives", "updates"}
+
+// changeFeedChannelPattern restricts channel names to plain identifiers, since they are part of the trigger SQL
+var changeFeedChannelPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// ChangeEventReconnect is sent to all subscribers after the listener reconnected,
+// since notifications might have been missed in the meantime
+const ChangeEventReconnect = "RECONNECT"
+
+// changeFeed is nil unless CHANGE_FEED_ENABLE is true
+var (
+	changeFeed         *ChangeFeed
+	changeFeedListener *pq.Listener
+)
+
+// ChangeEvent describes a row written by TeslaMate
+type ChangeEvent struct {
+	Table     string `json:"table"`
+	Operation string `json:"operation"`
+	ID        int64  `json:"id"`
+	CarID     int    `json:"car_id"`
+}
+
+// ChangeFeed fans out database change notifications to in-process subscribers
+type ChangeFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan ChangeEvent][]string
+}
+
+func NewChangeFeed() *ChangeFeed {
+	return &ChangeFeed{subscribers: make(map[chan ChangeEvent][]string)}
+}
+
+// Subscribe returns a channel receiving changes of the given tables (all tables if none are given)
+// and a function to unsubscribe
+func (f *ChangeFeed) Subscribe(tables ...string) (<-chan ChangeEvent, func()) {
+	ch := make(chan ChangeEvent, 64)
+
+	f.mu.Lock()
+	f.subscribers[ch] = tables
+	f.mu.Unlock()
+
+	unsubscribe := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subscribers[ch]; ok {
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// Publish sends an event to every subscriber interested in its table
+func (f *ChangeFeed) Publish(event ChangeEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch, tables := range f.subscribers {
+		if event.Operation != ChangeEventReconnect && len(tables) > 0 && !checkArrayContainsString(tables, event.Table) {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			// slow subscriber, dropping event instead of blocking the listener
+			if gin.IsDebugging() {
+				log.Printf("[debug] ChangeFeed - dropped %s event of %s %d for slow subscriber", event.Operation, event.Table, event.ID)
+			}
+		}
+	}
+}
+
+// Listen publishes the notifications of a listener until it is closed
+func (f *ChangeFeed) Listen(notifications <-chan *pq.Notification) {
+	for notification := range notifications {
+		// pq sends nil after reconnecting
+		if notification == nil {
+			f.Publish(ChangeEvent{Operation: ChangeEventReconnect})
+			continue
+		}
+
+		var event ChangeEvent
+		if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+			log.Printf("[warning] ChangeFeed - unable to parse notification on %s: %s", notification.Channel, err)
+			continue
+		}
+		f.Publish(event)
+	}
+}
+
+// installChangeFeedTriggers creates the triggers notifying channel about changes of changeFeedTables
+func installChangeFeedTriggers(pool database, channel string) error {
+	if !changeFeedChannelPattern.MatchString(channel) {
+		return fmt.Errorf("invalid channel name: %s", channel)
+	}
+
+	ctx := context.Background()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		CREATE OR REPLACE FUNCTION teslamateapi_notify_change() RETURNS trigger AS $$
+		DECLARE
+			rec RECORD;
+			notify_car_id smallint;
+		BEGIN
+			IF TG_OP = 'DELETE' THEN
+				rec := OLD;
+			ELSE
+				rec := NEW;
+			END IF;
+			IF TG_TABLE_NAME = 'charges' THEN
+				SELECT charging_processes.car_id INTO notify_car_id FROM charging_processes WHERE charging_processes.id = rec.charging_process_id;
+			ELSE
+				notify_car_id := rec.car_id;
+			END IF;
+			PERFORM pg_notify(TG_ARGV[0], json_build_object(
+				'table', TG_TABLE_NAME,
+				'operation', TG_OP,
+				'id', rec.id,
+				'car_id', notify_car_id
+			)::text);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;`)
+	if err != nil {
+		return fmt.Errorf("creating trigger function failed: %w", err)
+	}
+
+	for _, table := range changeFeedTables {
+		_, err = tx.Exec(ctx, fmt.Sprintf(`DROP TRIGGER IF EXISTS teslamateapi_notify_change ON %s;`, table))
+		if err != nil {
+			return fmt.Errorf("dropping trigger on %s failed: %w", table, err)
+		}
+		_, err = tx.Exec(ctx, fmt.Sprintf(`
+			CREATE TRIGGER teslamateapi_notify_change
+			AFTER INSERT OR UPDATE OR DELETE ON %s
+			FOR EACH ROW EXECUTE FUNCTION teslamateapi_notify_change('%s');`, table, channel))
+		if err != nil {
+			return fmt.Errorf("creating trigger on %s failed: %w", table, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// initChangeFeed func
+func initChangeFeed() {
+	if !getEnvAsBool("CHANGE_FEED_ENABLE", false) {
+		return
+	}
+
+	channel := getEnv("CHANGE_FEED_CHANNEL", "teslamateapi_changes")
+	if !changeFeedChannelPattern.MatchString(channel) {
+		log.Println("[error] initChangeFeed - CHANGE_FEED_CHANNEL " + channel + " is invalid.. change feed disabled.")
+		return
+	}
+
+	// triggers are only installed on request, since they modify the TeslaMate database
+	if getEnvAsBool("CHANGE_FEED_INSTALL_TRIGGERS", false) {
+		if err := installChangeFeedTriggers(db, channel); err != nil {
+			log.Println("[error] initChangeFeed - unable to install triggers.. change feed disabled. Error:", err)
+			return
+		}
+		log.Println("[info] initChangeFeed - triggers installed for channel " + channel + ".")
+	} else {
+		log.Println("[info] initChangeFeed - CHANGE_FEED_INSTALL_TRIGGERS is not true.. expecting triggers to notify channel " + channel + ".")
+	}
+
+	changeFeedListener = pq.NewListener(getDBConnectionString(), 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Println("[warning] initChangeFeed - listener connection problem:", err)
+		}
+	})
+	if err := changeFeedListener.Listen(channel); err != nil {
+		log.Println("[error] initChangeFeed - unable to listen on channel " + channel + ".. change feed disabled. Error: " + err.Error())
+		changeFeedListener.Close()
+		changeFeedListener = nil
+		return
+	}
+
+	changeFeed = NewChangeFeed()
+	go changeFeed.Listen(changeFeedListener.Notify)
+	log.Println("[info] initChangeFeed - listening for changes on channel " + channel + ".")
+}
+
+// closeChangeFeed func
+func closeChangeFeed() {
+	if changeFeedListener != nil {
+		changeFeedListener.Close()
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+func TestChangeFeed_Listen(t *testing.T) {
+	feed := NewChangeFeed()
+
+	positions, unsubscribePositions := feed.Subscribe("positions")
+	defer unsubscribePositions()
+	all, unsubscribeAll := feed.Subscribe()
+	defer unsubscribeAll()
+
+	notifications := make(chan *pq.Notification, 3)
+	notifications <- &pq.Notification{Channel: "teslamateapi_changes", Extra: `{"table":"drives","operation":"UPDATE","id":42,"car_id":1}`}
+	notifications <- &pq.Notification{Channel: "teslamateapi_changes", Extra: `{"table":"positions","operation":"INSERT","id":7,"car_id":2}`}
+	notifications <- nil
+	close(notifications)
+
+	feed.Listen(notifications)
+
+	t.Run("Subscriber with table filter", func(t *testing.T) {
+		event := <-positions
+		if event.Table != "positions" || event.ID != 7 || event.CarID != 2 || event.Operation != "INSERT" {
+			t.Errorf("Expected positions insert 7 of car 2, got %+v", event)
+		}
+		if event = <-positions; event.Operation != ChangeEventReconnect {
+			t.Errorf("Expected reconnect event, got %+v", event)
+		}
+		select {
+		case event := <-positions:
+			t.Errorf("Expected no further events, got %+v", event)
+		default:
+		}
+	})
+
+	t.Run("Subscriber without table filter", func(t *testing.T) {
+		for _, expected := range []string{"drives", "positions", ""} {
+			if event := <-all; event.Table != expected {
+				t.Errorf("Expected event of table '%s', got %+v", expected, event)
+			}
+		}
+	})
+
+	t.Run("Unsubscribe closes channel", func(t *testing.T) {
+		unsubscribePositions()
+		if _, ok := <-positions; ok {
+			t.Error("Expected channel to be closed")
+		}
+		// publishing after unsubscribe must not panic
+		feed.Publish(ChangeEvent{Table: "positions"})
+	})
+}
+
+func TestInstallChangeFeedTriggers(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	t.Run("Triggers on all tables", func(t *testing.T) {
+		mock.ExpectBegin()
+		// charges have no car_id, their car is the one of the charging process
+		mock.ExpectExec("CREATE OR REPLACE FUNCTION teslamateapi_notify_change\\(\\)(.|\\s)*FROM charging_processes WHERE charging_processes.id = rec.charging_process_id").
+			WillReturnResult(pgxmock.NewResult("", 0))
+		for _, table := range changeFeedTables {
+			mock.ExpectExec("DROP TRIGGER IF EXISTS teslamateapi_notify_change ON " + table).
+				WillReturnResult(pgxmock.NewResult("", 0))
+			mock.ExpectExec("CREATE TRIGGER teslamateapi_notify_change.*ON " + table + ".*teslamateapi_notify_change\\('teslamateapi_changes'\\)").
+				WillReturnResult(pgxmock.NewResult("", 0))
+		}
+		mock.ExpectCommit()
+
+		if err := installChangeFeedTriggers(mock, "teslamateapi_changes"); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("Invalid channel name", func(t *testing.T) {
+		if err := installChangeFeedTriggers(mock, "changes'); DROP TABLE cars; --"); err == nil {
+			t.Error("Expected error for invalid channel name, got nil")
+		}
+	})
+}
+
+func TestCarStatusBroker_UseChangeFeed(t *testing.T) {
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	feed := NewChangeFeed()
+	broker := NewCarStatusBroker(NewCarStatusService(mock), NewCarStatusMapper(), time.Hour)
+	broker.UseChangeFeed(feed)
+
+	expectCarStatusFingerprint(mock, 1, time.Now().Add(-time.Minute))
+	expectCarStatusQueries(mock, 1, 80)
+	_, updates, unsubscribe, err := broker.Subscribe(1, "")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer unsubscribe()
+
+	// wait for the broker to subscribe to the feed
+	for i := 0; i < 100; i++ {
+		feed.mu.Lock()
+		subscribed := len(feed.subscribers) > 0
+		feed.mu.Unlock()
+		if subscribed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	expectCarStatusFingerprint(mock, 1, time.Now())
+	expectCarStatusQueries(mock, 1, 79)
+	feed.Publish(ChangeEvent{Table: "positions", Operation: "INSERT", ID: 1, CarID: 1})
+
+	select {
+	case event := <-updates:
+		if event.Response.Status.BatteryDetails.BatteryLevel != 79 {
+			t.Errorf("Expected battery level 79, got %d", event.Response.Status.BatteryDetails.BatteryLevel)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected event after change notification")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// metricsNamespace prefixes all metrics of TeslaMateApi
+const metricsNamespace = "teslamateapi"
+
+// metricsHandlerKey is the context key of the handler name used to label database queries
+type metricsHandlerKey struct{}
+
+var (
+	// metricsRegistry holds all metrics served by /metrics
+	metricsRegistry = prometheus.NewRegistry()
+
+	metricsHTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, gin route and status code.",
+	}, []string{"method", "route", "status"})
+
+	metricsHTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and gin route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	metricsDBDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by handler and query name.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"handler", "query"})
+
+	metricsCommands = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "command_requests_total",
+		Help:      "Commands forwarded to Tesla by command name and Tesla HTTP status (error if the request failed).",
+	}, []string{"command", "status"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metricsHTTPRequests,
+		metricsHTTPDuration,
+		metricsDBDuration,
+		metricsCommands,
+	)
+}
+
+// initMetrics func - registers the vehicle telemetry collector if ENABLE_METRICS is true
+func initMetrics() {
+	if !getEnvAsBool("ENABLE_METRICS", false) {
+		return
+	}
+	ttl := time.Duration(getEnvAsInt("METRICS_CACHE_TTL", 30)) * time.Second
+	metricsRegistry.MustRegister(NewVehicleCollector(NewCarStatusService(db), ttl))
+	log.Println("[info] initMetrics - serving metrics at /metrics, vehicle telemetry is cached for", ttl)
+}
+
+// metricsHandler serves all metrics of metricsRegistry
+var metricsHandler = gin.WrapH(promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+
+// metricsMiddleware func - gin middleware counting requests per route, the handler name
+// is added to the request context to label the database queries of the request
+func metricsMiddleware(c *gin.Context) {
+	handler := c.HandlerName()
+	handler = handler[strings.LastIndex(handler, ".")+1:]
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), metricsHandlerKey{}, handler))
+
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	metricsHTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	metricsHTTPDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+}
+
+// observeCommand func - counts a command forwarded to Tesla, status 0 means the request failed
+func observeCommand(command string, status int) {
+	label := "error"
+	if status != 0 {
+		label = strconv.Itoa(status)
+	}
+	metricsCommands.WithLabelValues(strings.TrimPrefix(command, "/command/"), label).Inc()
+}
+
+// instrumentedDB measures the latency of the queries run through the generated queries
+type instrumentedDB struct {
+	teslamatedb.DBTX
+}
+
+// instrumentDB func - wraps a database connection or transaction to observe metricsDBDuration
+func instrumentDB(db teslamatedb.DBTX) teslamatedb.DBTX {
+	return instrumentedDB{DBTX: db}
+}
+
+func (i instrumentedDB) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	defer observeQuery(ctx, query, time.Now())
+	return i.DBTX.Exec(ctx, query, args...)
+}
+
+func (i instrumentedDB) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	defer observeQuery(ctx, query, time.Now())
+	return i.DBTX.Query(ctx, query, args...)
+}
+
+func (i instrumentedDB) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	defer observeQuery(ctx, query, time.Now())
+	return i.DBTX.QueryRow(ctx, query, args...)
+}
+
+// observeQuery func - observes the latency of a query, labeled with the handler of the request
+// and the sqlc name of the query (or its first keyword, like FETCH for cursors)
+func observeQuery(ctx context.Context, query string, start time.Time) {
+	handler, ok := ctx.Value(metricsHandlerKey{}).(string)
+	if !ok {
+		handler = "background"
+	}
+	metricsDBDuration.WithLabelValues(handler, queryName(query)).Observe(time.Since(start).Seconds())
+}
+
+// queryName func - returns the name of a sqlc query, or the first keyword of other queries
+func queryName(query string) string {
+	if i := strings.Index(query, "-- name: "); i >= 0 {
+		if fields := strings.Fields(query[i+len("-- name: "):]); len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	if fields := strings.Fields(query); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "unknown"
+}
+
+// vehicleTelemetry is the cached status of a car exposed by VehicleCollector
+type vehicleTelemetry struct {
+	carID   string
+	carName string
+	state   string
+	data    *CarStatusData
+}
+
+var (
+	vehicleLabels = []string{"car_id", "car_name"}
+
+	vehicleBatteryLevelDesc = prometheus.NewDesc(metricsNamespace+"_vehicle_battery_level_percent", "Battery level of the car.", vehicleLabels, nil)
+	vehicleRangeDesc        = prometheus.NewDesc(metricsNamespace+"_vehicle_range_kilometers", "Estimated, rated and ideal range of the car.", append(vehicleLabels, "type"), nil)
+	vehicleOdometerDesc     = prometheus.NewDesc(metricsNamespace+"_vehicle_odometer_kilometers", "Odometer of the car.", vehicleLabels, nil)
+	vehicleStateDesc        = prometheus.NewDesc(metricsNamespace+"_vehicle_state", "State of the car, 1 for the current state.", append(vehicleLabels, "state"), nil)
+	vehicleChargerPowerDesc = prometheus.NewDesc(metricsNamespace+"_vehicle_charger_power_kilowatts", "Charger power of the ongoing charge, 0 if not charging.", vehicleLabels, nil)
+	vehicleTpmsDesc         = prometheus.NewDesc(metricsNamespace+"_vehicle_tpms_pressure_bar", "Tire pressure of the car.", append(vehicleLabels, "tire"), nil)
+	vehicleScrapeErrorsDesc = prometheus.NewDesc(metricsNamespace+"_vehicle_scrape_errors_total", "Failed refreshes of the vehicle telemetry.", nil, nil)
+)
+
+// vehicleStates are exposed for every car, so the state metric has a series per state
+var vehicleStates = []string{"online", "driving", "charging", "asleep", "offline", "updating", "suspended", "unknown"}
+
+// VehicleCollector exposes the status of all cars, the status is loaded at most once per ttl
+// so scrapes don't each run the GetCarStatus query
+type VehicleCollector struct {
+	service *CarStatusService
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	updated time.Time
+	cars    []vehicleTelemetry
+	errors  float64
+}
+
+func NewVehicleCollector(service *CarStatusService, ttl time.Duration) *VehicleCollector {
+	return &VehicleCollector{service: service, ttl: ttl}
+}
+
+// Describe implements prometheus.Collector
+func (v *VehicleCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- vehicleBatteryLevelDesc
+	ch <- vehicleRangeDesc
+	ch <- vehicleOdometerDesc
+	ch <- vehicleStateDesc
+	ch <- vehicleChargerPowerDesc
+	ch <- vehicleTpmsDesc
+	ch <- vehicleScrapeErrorsDesc
+}
+
+// Collect implements prometheus.Collector
+func (v *VehicleCollector) Collect(ch chan<- prometheus.Metric) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if time.Since(v.updated) >= v.ttl {
+		if cars, err := v.load(); err != nil {
+			// keep serving the last telemetry
+			v.errors++
+			log.Println("[warning] VehicleCollector - unable to load vehicle telemetry:", err)
+		} else {
+			v.cars = cars
+		}
+		v.updated = time.Now()
+	}
+
+	for _, car := range v.cars {
+		labels := []string{car.carID, car.carName}
+		gauge := func(desc *prometheus.Desc, value pgtype.Float8, extra ...string) {
+			if value.Valid {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value.Float64, append(labels, extra...)...)
+			}
+		}
+
+		gauge(vehicleBatteryLevelDesc, nullInt16ToNullFloat64(car.data.BatteryLevel))
+		gauge(vehicleRangeDesc, car.data.EstBatteryRange, "est")
+		gauge(vehicleRangeDesc, car.data.RatedBatteryRange, "rated")
+		gauge(vehicleRangeDesc, car.data.IdealBatteryRange, "ideal")
+		gauge(vehicleOdometerDesc, car.data.Odometer)
+		chargerPower := nullInt16ToNullFloat64(car.data.ChargerPower)
+		if !chargerPower.Valid {
+			chargerPower = pgtype.Float8{Float64: 0, Valid: true}
+		}
+		gauge(vehicleChargerPowerDesc, chargerPower)
+		gauge(vehicleTpmsDesc, car.data.TpmsPressureFl, "fl")
+		gauge(vehicleTpmsDesc, car.data.TpmsPressureFr, "fr")
+		gauge(vehicleTpmsDesc, car.data.TpmsPressureRl, "rl")
+		gauge(vehicleTpmsDesc, car.data.TpmsPressureRr, "rr")
+
+		states := vehicleStates
+		if !checkArrayContainsString(states, car.state) {
+			states = append(states[:len(states):len(states)], car.state)
+		}
+		for _, state := range states {
+			value := 0.0
+			if state == car.state {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(vehicleStateDesc, prometheus.GaugeValue, value, append(labels, state)...)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(vehicleScrapeErrorsDesc, prometheus.CounterValue, v.errors)
+}
+
+// load func - loads the status of all cars
+func (v *VehicleCollector) load() ([]vehicleTelemetry, error) {
+	ctx := context.WithValue(context.Background(), metricsHandlerKey{}, "VehicleCollector")
+	cars, err := v.service.queries.ListCarIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	telemetry := make([]vehicleTelemetry, 0, len(cars))
+	for _, car := range cars {
+		data, err := v.service.GetCarStatusContext(ctx, int(car.ID))
+		if err != nil {
+			return nil, err
+		}
+		telemetry = append(telemetry, vehicleTelemetry{
+			carID:   strconv.Itoa(int(car.ID)),
+			carName: car.Name.String,
+			state:   v.service.DetermineVehicleState(data),
+			data:    data,
+		})
+	}
+	return telemetry, nil
+}
+
+// nullInt16ToNullFloat64 converts a smallint column for a gauge
+func nullInt16ToNullFloat64(ni pgtype.Int2) pgtype.Float8 {
+	return pgtype.Float8{Float64: float64(ni.Int16), Valid: ni.Valid}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueryName(t *testing.T) {
+	tests := map[string]string{
+		"-- name: ListDrives :many\nSELECT 1":                                      "ListDrives",
+		"DECLARE export_drives NO SCROLL CURSOR FOR -- name: ExportDrives :many\n": "ExportDrives",
+		"FETCH FORWARD 500 FROM export_drives":                                     "FETCH",
+		"  ":                                                                       "unknown",
+	}
+	for query, expected := range tests {
+		if name := queryName(query); name != expected {
+			t.Errorf("Expected %s for %q, got %s", expected, query, name)
+		}
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ENABLE_METRICS", "true")
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	originalDB := db
+	db = mock
+	defer func() { db = originalDB }()
+
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
+		WithArgs(int16(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+
+	requests := testutil.ToFloat64(metricsHTTPRequests.WithLabelValues(http.MethodGet, "/api/v1/cars/:CarID/status", "200"))
+	queries := testutil.CollectAndCount(metricsDBDuration, metricsNamespace+"_db_query_duration_seconds")
+
+	router := newRouter()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/cars/1/status", nil))
+
+	if got := testutil.ToFloat64(metricsHTTPRequests.WithLabelValues(http.MethodGet, "/api/v1/cars/:CarID/status", "200")); got != requests+1 {
+		t.Errorf("Expected request to be counted once, got %f", got-requests)
+	}
+	if got := testutil.CollectAndCount(metricsDBDuration, metricsNamespace+"_db_query_duration_seconds"); got < queries || got == 0 {
+		t.Errorf("Expected query latency to be observed, got %d series", got)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	for _, expected := range []string{
+		`teslamateapi_http_requests_total{method="GET",route="/api/v1/cars/:CarID/status",status="200"}`,
+		`teslamateapi_db_query_duration_seconds_count{handler="TeslaMateAPICarsStatusV1",query="CarExists"}`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected metrics to contain %s", expected)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
+
+func TestVehicleCollector(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	collector := NewVehicleCollector(NewCarStatusService(mock), time.Minute)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	mock.ExpectQuery("SELECT.*FROM cars\\s+ORDER BY id").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name"}).AddRow(1, "Test Tesla"))
+	mock.ExpectQuery("SELECT EXISTS\\(SELECT 1 FROM cars WHERE id=\\$1\\)").
+		WithArgs(int16(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("SELECT.*FROM cars c.*WHERE c.id = \\$1").
+		WithArgs(int16(1)).
+		WillReturnRows(pgxmock.NewRows(carStatusColumns).AddRow(carStatusRow(map[string]any{
+			"car_id": 1, "name": "Test Tesla", "odometer": 12345.6, "battery_level": 80,
+			"rated_battery_range": 350.5, "tpms_pressure_fl": 2.9, "state": "asleep",
+		})...))
+
+	expected := `
+# HELP teslamateapi_vehicle_battery_level_percent Battery level of the car.
+# TYPE teslamateapi_vehicle_battery_level_percent gauge
+teslamateapi_vehicle_battery_level_percent{car_id="1",car_name="Test Tesla"} 80
+# HELP teslamateapi_vehicle_range_kilometers Estimated, rated and ideal range of the car.
+# TYPE teslamateapi_vehicle_range_kilometers gauge
+teslamateapi_vehicle_range_kilometers{car_id="1",car_name="Test Tesla",type="rated"} 350.5
+# HELP teslamateapi_vehicle_tpms_pressure_bar Tire pressure of the car.
+# TYPE teslamateapi_vehicle_tpms_pressure_bar gauge
+teslamateapi_vehicle_tpms_pressure_bar{car_id="1",car_name="Test Tesla",tire="fl"} 2.9
+`
+	names := []string{
+		"teslamateapi_vehicle_battery_level_percent",
+		"teslamateapi_vehicle_range_kilometers",
+		"teslamateapi_vehicle_tpms_pressure_bar",
+	}
+
+	// the second scrape is served from the cache without querying again
+	for i := 0; i < 2; i++ {
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), names...); err != nil {
+			t.Errorf("Unexpected metrics on scrape %d: %s", i+1, err)
+		}
+	}
+
+	metrics, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for _, family := range metrics {
+		if family.GetName() != "teslamateapi_vehicle_state" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			state := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "state" {
+					state = label.GetValue()
+				}
+			}
+			if value := metric.GetGauge().GetValue(); (state == "asleep") != (value == 1) {
+				t.Errorf("Expected only asleep to be 1, got %s = %f", state, value)
+			}
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %s", err)
+	}
+}
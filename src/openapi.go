@@ -0,0 +1,578 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIOperation documents an endpoint registered by registerAPIRoutes
+type openAPIOperation struct {
+	Method       string   // http method
+	Path         string   // gin path below /api/v1 and /api/v2
+	Summary      string   // short description of the endpoint
+	Tag          string   // group of the endpoint
+	Parameters   []string // names of components/parameters
+	Auth         bool     // endpoint requires API_TOKEN
+	RequestBody  string   // components/schemas of the request body of an Auth endpoint, any object if empty
+	Response     string   // components/schemas of the 200 response
+	Status       int      // status code of the successful response, 200 if zero
+	ContentTypes []string // content types of the 200 response, application/json if empty
+	LegacyErrors []int    // status codes besides 200 that v1 returns errors with, v2 as well
+}
+
+// openAPIOperations documents all endpoints of /api/v1 and /api/v2
+var openAPIOperations = []openAPIOperation{
+	{Method: http.MethodGet, Path: "/auth/lockouts", Summary: "List clients slowed down or locked out after failed authentications, needs the admin scope", Tag: "auth", Auth: true, Response: "AuthLockoutsResponse", LegacyErrors: []int{http.StatusUnauthorized, http.StatusForbidden}},
+	{Method: http.MethodGet, Path: "/cars", Summary: "List all cars", Tag: "cars", Response: "CarsResponse"},
+	{Method: http.MethodGet, Path: "/cars/:CarID", Summary: "Get a car", Tag: "cars", Parameters: []string{"CarID"}, Response: "CarsResponse"},
+	{Method: http.MethodGet, Path: "/cars/:CarID/battery/health", Summary: "Estimate battery capacity and degradation by month", Tag: "battery", Parameters: []string{"CarID"}, Response: "BatteryHealthResponse"},
+	{Method: http.MethodGet, Path: "/cars/:CarID/charges", Summary: "List charges of a car", Tag: "charges", Parameters: []string{"CarID", "page", "show", "startDate", "endDate"}, Response: "ChargesResponse"},
+	{Method: http.MethodGet, Path: "/cars/:CarID/charges/export", Summary: "Export all charges of a car as CSV or NDJSON", Tag: "charges", Parameters: []string{"CarID", "startDate", "endDate", "bulkFormat"}, Response: "ChargesExport", ContentTypes: []string{"text/csv", "application/x-ndjson"}, LegacyErrors: []int{http.StatusBadRequest}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/charges/:ChargeID", Summary: "Get a charge with its charge details", Tag: "charges", Parameters: []string{"CarID", "ChargeID"}, Response: "ChargeResponse"},
+	{Method: http.MethodPatch, Path: "/cars/:CarID/charges/:ChargeID", Summary: "Edit the cost of a charge, needs ENABLE_EDITS", Tag: "charges", Parameters: []string{"CarID", "ChargeID"}, Auth: true, RequestBody: "ChargeEdit", Response: "ChargeEditResponse", LegacyErrors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/charges/:ChargeID/curve", Summary: "Get the charge curve of a charge by battery level", Tag: "charges", Parameters: []string{"CarID", "ChargeID", "fast_charger_type"}, Response: "ChargeCurveResponse"},
+	{Method: http.MethodGet, Path: "/cars/:CarID/charges/:ChargeID/cost", Summary: "Calculate the cost of a charge with the time-of-use tariffs of TARIFFS_CONFIG", Tag: "charges", Parameters: []string{"CarID", "ChargeID"}, Response: "ChargeCostResponse", LegacyErrors: []int{http.StatusNotFound}},
+	{Method: http.MethodPut, Path: "/cars/:CarID/charges/:ChargeID/cost", Summary: "Calculate the cost of a charge and write it to TeslaMate, needs TARIFFS_WRITE_COST", Tag: "charges", Parameters: []string{"CarID", "ChargeID"}, Auth: true, Response: "ChargeCostResponse", LegacyErrors: []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/command", Summary: "List enabled commands", Tag: "commands", Parameters: []string{"CarID"}, Response: "EnabledCommandsResponse", LegacyErrors: []int{http.StatusForbidden}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/commands", Summary: "List enabled commands", Tag: "commands", Parameters: []string{"CarID"}, Response: "EnabledCommandsResponse", LegacyErrors: []int{http.StatusForbidden}},
+	{Method: http.MethodPost, Path: "/cars/:CarID/command/:Command", Summary: "Send a command to the car through the Tesla API, the body has to match the schema of the command", Tag: "commands", Parameters: []string{"CarID", "Command"}, Auth: true, Response: "TeslaResponse", LegacyErrors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusInternalServerError}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/drives", Summary: "List drives of a car", Tag: "drives", Parameters: []string{"CarID", "page", "show", "startDate", "endDate"}, Response: "DrivesResponse"},
+	{Method: http.MethodGet, Path: "/cars/:CarID/drives/export", Summary: "Export all drives of a car as CSV or NDJSON", Tag: "drives", Parameters: []string{"CarID", "startDate", "endDate", "bulkFormat"}, Response: "DrivesExport", ContentTypes: []string{"text/csv", "application/x-ndjson"}, LegacyErrors: []int{http.StatusBadRequest}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/drives/:DriveID", Summary: "Get a drive with its positions", Tag: "drives", Parameters: []string{"CarID", "DriveID", "simplify", "max_points", "encoding"}, Response: "DriveResponse", LegacyErrors: []int{http.StatusBadRequest}},
+	{Method: http.MethodPatch, Path: "/cars/:CarID/drives/:DriveID", Summary: "Edit the start and end geofence of a drive, needs ENABLE_EDITS", Tag: "drives", Parameters: []string{"CarID", "DriveID"}, Auth: true, RequestBody: "DriveEdit", Response: "DriveEditResponse", LegacyErrors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/drives/:DriveID/export", Summary: "Export the track of a drive as GPX, KML or GeoJSON", Tag: "drives", Parameters: []string{"CarID", "DriveID", "format"}, Response: "DriveExport", ContentTypes: []string{"application/gpx+xml", "application/vnd.google-earth.kml+xml", "application/geo+json"}, LegacyErrors: []int{http.StatusBadRequest}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/logging", Summary: "List enabled logging commands", Tag: "commands", Parameters: []string{"CarID"}, Response: "EnabledCommandsResponse", LegacyErrors: []int{http.StatusForbidden}},
+	{Method: http.MethodPut, Path: "/cars/:CarID/logging/:Command", Summary: "Resume or suspend logging in TeslaMate", Tag: "commands", Parameters: []string{"CarID", "Command"}, Auth: true, Response: "TeslaResponse", LegacyErrors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusInternalServerError}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/status", Summary: "Get the current status of a car", Tag: "status", Parameters: []string{"CarID"}, Response: "StatusResponse"},
+	{Method: http.MethodGet, Path: "/cars/:CarID/status/stream", Summary: "Stream the status of a car as Server-Sent Events", Tag: "status", Parameters: []string{"CarID", "Last-Event-ID"}, Response: "StatusStream", ContentTypes: []string{"text/event-stream"}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/stats", Summary: "Get statistics of drives and charges per day, week, month or year in TZ", Tag: "stats", Parameters: []string{"CarID", "period", "page", "show", "startDate", "endDate"}, Response: "StatsResponse", LegacyErrors: []int{http.StatusBadRequest}},
+	{Method: http.MethodGet, Path: "/cars/:CarID/updates", Summary: "List software updates of a car", Tag: "updates", Parameters: []string{"CarID", "page", "show"}, Response: "UpdatesResponse"},
+	{Method: http.MethodGet, Path: "/cars/:CarID/vampire-drain", Summary: "List idle periods between drives with the range and energy lost", Tag: "drives", Parameters: []string{"CarID", "page", "show", "startDate", "endDate", "min_duration"}, Response: "VampireDrainResponse", LegacyErrors: []int{http.StatusBadRequest}},
+	{Method: http.MethodPost, Path: "/cars/:CarID/wake_up", Summary: "Wake up the car through the Tesla API", Tag: "commands", Parameters: []string{"CarID"}, Auth: true, Response: "TeslaResponse", LegacyErrors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity, http.StatusTooManyRequests, http.StatusInternalServerError}},
+	{Method: http.MethodGet, Path: "/geofences", Summary: "List all geofences", Tag: "geofences", Parameters: []string{"page", "show"}, Response: "GeofencesResponse"},
+	{Method: http.MethodPost, Path: "/geofences", Summary: "Create a geofence, needs ENABLE_EDITS", Tag: "geofences", Auth: true, RequestBody: "GeofenceRequest", Response: "GeofenceEditResponse", Status: http.StatusCreated, LegacyErrors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden}},
+	{Method: http.MethodGet, Path: "/geofences/:GeofenceID", Summary: "Get a geofence", Tag: "geofences", Parameters: []string{"GeofenceID"}, Response: "GeofenceResponse"},
+	{Method: http.MethodPut, Path: "/geofences/:GeofenceID", Summary: "Replace a geofence, needs ENABLE_EDITS", Tag: "geofences", Parameters: []string{"GeofenceID"}, Auth: true, RequestBody: "GeofenceRequest", Response: "GeofenceEditResponse", LegacyErrors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound}},
+	{Method: http.MethodDelete, Path: "/geofences/:GeofenceID", Summary: "Delete a geofence, needs ENABLE_EDITS", Tag: "geofences", Parameters: []string{"GeofenceID"}, Auth: true, Response: "GeofenceEditResponse", LegacyErrors: []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusConflict}},
+	{Method: http.MethodGet, Path: "/geofences/:GeofenceID/visits", Summary: "List drives ending and charges inside of a geofence", Tag: "geofences", Parameters: []string{"GeofenceID", "page", "show", "startDate", "endDate"}, Response: "GeofenceVisitsResponse", LegacyErrors: []int{http.StatusBadRequest}},
+	{Method: http.MethodGet, Path: "/globalsettings", Summary: "Get the TeslaMate settings", Tag: "settings", Response: "GlobalSettingsResponse"},
+}
+
+// openAPIOtherOperations documents the endpoints outside of /api/v1 and /api/v2
+var openAPIOtherOperations = []openAPIOperation{
+	{Method: http.MethodGet, Path: "/", Summary: "Check that TeslaMateApi is running", Tag: "health", Response: "MessageResponse"},
+	{Method: http.MethodGet, Path: "/api/", Summary: "Check that TeslaMateApi is running", Tag: "health", Response: "MessageResponse"},
+	{Method: http.MethodGet, Path: "/api/v1/", Summary: "Check that TeslaMateApi v1 is running", Tag: "health", Response: "MessageResponse"},
+	{Method: http.MethodGet, Path: "/api/v2/", Summary: "Check that TeslaMateApi v2 is running", Tag: "health", Response: "MessageResponse"},
+	{Method: http.MethodGet, Path: "/api/ping", Summary: "Ping TeslaMateApi", Tag: "health", Response: "MessageResponse"},
+	{Method: http.MethodGet, Path: "/api/healthz", Summary: "Liveness probe", Tag: "health", Response: "HealthResponse"},
+	{Method: http.MethodGet, Path: "/api/readyz", Summary: "Readiness probe", Tag: "health", Response: "HealthResponse"},
+	{Method: http.MethodGet, Path: "/api/openapi.json", Summary: "Get this OpenAPI document", Tag: "documentation", Response: "OpenAPIDocument"},
+	{Method: http.MethodGet, Path: "/api/docs", Summary: "Browse this OpenAPI document", Tag: "documentation", Response: "HTMLPage", ContentTypes: []string{"text/html"}},
+	{Method: http.MethodGet, Path: "/api/docs/redoc.standalone.js", Summary: "Get the Redoc script of the documentation page", Tag: "documentation", Response: "JavaScript", ContentTypes: []string{"application/javascript"}},
+	{Method: http.MethodGet, Path: "/metrics", Summary: "Get the metrics in the Prometheus text format, needs ENABLE_METRICS and the read:metrics scope", Tag: "metrics", Auth: true, Response: "MetricsText", ContentTypes: []string{"text/plain"}},
+}
+
+// openAPIParameters are the components/parameters used by the operations
+var openAPIParameters = map[string]gin.H{
+	"CarID":         openAPIParameter("CarID", "path", "ID of the car in TeslaMate", gin.H{"type": "integer", "minimum": 1}),
+	"ChargeID":      openAPIParameter("ChargeID", "path", "ID of the charging process in TeslaMate", gin.H{"type": "integer"}),
+	"DriveID":       openAPIParameter("DriveID", "path", "ID of the drive in TeslaMate", gin.H{"type": "integer"}),
+	"Command":       openAPIParameter("Command", "path", "command to send, has to be allowed by COMMANDS_* or the commands allow list", gin.H{"type": "string"}),
+	"page":          openAPIParameter("page", "query", "page of the results", gin.H{"type": "integer", "minimum": 1, "default": 1}),
+	"show":          openAPIParameter("show", "query", "number of results per page", gin.H{"type": "integer", "minimum": 1, "default": 100}),
+	"startDate":     openAPIParameter("startDate", "query", "only return results starting at or after this date (RFC3339, or local time in TZ without offset)", gin.H{"type": "string", "format": "date-time"}),
+	"endDate":       openAPIParameter("endDate", "query", "only return results ending at or before this date (RFC3339, or local time in TZ without offset)", gin.H{"type": "string", "format": "date-time"}),
+	"format":        openAPIParameter("format", "query", "format of the export", gin.H{"type": "string", "enum": []string{"gpx", "kml", "geojson"}, "default": "gpx"}),
+	"bulkFormat":    openAPIParameter("format", "query", "format of the export, csv with a header row or newline-delimited json", gin.H{"type": "string", "enum": []string{"csv", "ndjson"}, "default": "csv"}),
+	"simplify":      openAPIParameter("simplify", "query", "drop positions closer than this many meters to the simplified track, sharp speed and power changes are kept", gin.H{"type": "number", "minimum": 0}),
+	"max_points":    openAPIParameter("max_points", "query", "maximum number of positions, keeping the most important ones for the shape of the track", gin.H{"type": "integer", "minimum": 2}),
+	"encoding":      openAPIParameter("encoding", "query", "polyline returns the positions as encoded polyline with per-point arrays in track instead of drive_details", gin.H{"type": "string", "enum": []string{"polyline"}}),
+	"Last-Event-ID": openAPIParameter("Last-Event-ID", "header", "id of the last received event to resume the stream", gin.H{"type": "string"}),
+
+	// geofences
+	"GeofenceID": openAPIParameter("GeofenceID", "path", "ID of the geofence in TeslaMate", gin.H{"type": "integer"}),
+
+	// stats
+	"period": openAPIParameter("period", "query", "period of the statistics buckets in TZ, weeks start on monday", gin.H{"type": "string", "enum": []string{"day", "week", "month", "year"}, "default": "month"}),
+
+	// vampire drain
+	"min_duration": openAPIParameter("min_duration", "query", "minimum duration of an idle period in minutes", gin.H{"type": "integer", "minimum": 0, "default": 60}),
+
+	// charge curve
+	"fast_charger_type": openAPIParameter("fast_charger_type", "query", "also return the curve of all fast charges of the car at this fast charger type", gin.H{"type": "string"}),
+}
+
+// openAPISchemas are the components/schemas of the responses, the handlers declare their structs inline
+var openAPISchemas = map[string]gin.H{
+	// cars
+	"CarsResponse":     openAPIObject("data:CarsData"),
+	"CarsData":         openAPIObject("cars:[]CarInfo?"),
+	"CarInfo":          openAPIObject("car_id:integer", "name:string", "car_details:CarDetails", "car_exterior:CarExterior", "car_settings:CarSettings", "teslamate_details:TeslaMateDetails", "teslamate_stats:TeslaMateStats"),
+	"CarDetails":       openAPIObject("eid:integer", "vid:integer", "vin:string", "model:string", "trim_badging:string", "efficiency:number?"),
+	"CarExterior":      openAPIObject("exterior_color:string", "spoiler_type:string", "wheel_type:string"),
+	"CarSettings":      openAPIObject("suspend_min:integer", "suspend_after_idle_min:integer", "req_not_unlocked:boolean", "free_supercharging:boolean", "use_streaming_api:boolean"),
+	"TeslaMateDetails": openAPIObject("inserted_at:date-time", "updated_at:date-time"),
+	"TeslaMateStats":   openAPIObject("total_charges:integer", "total_drives:integer", "total_updates:integer"),
+
+	// shared by charges, drives and updates
+	"Car":   openAPIObject("car_id:integer", "car_name:string"),
+	"Units": openAPIObject("unit_of_length:string", "unit_of_temperature:string"),
+
+	// battery
+	"BatteryHealthResponse": openAPIObject("data:BatteryHealthData"),
+	"BatteryHealthData":     openAPIObject("car:Car", "battery_health:BatteryHealth", "units:BatteryHealthUnits"),
+	"BatteryHealth":         openAPIObject("method:string", "first_month:string", "last_month:string", "original_capacity:number?", "current_capacity:number?", "degradation_percent:number?", "degradation_percent_per_year:number?", "months:[]BatteryHealthMonth"),
+	"BatteryHealthMonth":    openAPIObject("month:string", "charges:integer", "estimated_capacity:number?", "estimated_capacity_by_energy:number?", "projected_full_range:number?"),
+	"BatteryHealthUnits":    openAPIObject("unit_of_length:string"),
+
+	// charges
+	"ChargesResponse":      openAPIObject("data:ChargesData"),
+	"ChargesData":          openAPIObject("car:Car", "charges:[]Charge?", "units:Units"),
+	"ChargeResponse":       openAPIObject("data:ChargeData"),
+	"ChargeData":           openAPIObject("car:Car", "charge:ChargeWithDetails", "units:Units"),
+	"Charge":               openAPIObject(chargeFields...),
+	"ChargeWithDetails":    openAPIObject(append(chargeFields, "charge_details:[]ChargeDetails?")...),
+	"ChargeBatteryDetails": openAPIObject("start_battery_level:integer", "end_battery_level:integer"),
+	"ChargeRange":          openAPIObject("start_range:number", "end_range:number"),
+	"ChargeDetails":        openAPIObject("detail_id:integer", "date:date-time", "battery_level:integer", "usable_battery_level:integer", "charge_energy_added:number", "not_enough_power_to_heat:boolean?", "charger_details:ChargerDetails", "battery_info:ChargeBatteryInfo", "conn_charge_cable:string", "fast_charger_info:FastChargerInfo", "outside_temp:number"),
+	"ChargerDetails":       openAPIObject("charger_actual_current:integer", "charger_phases:integer", "charger_pilot_current:integer", "charger_power:integer", "charger_voltage:integer"),
+	"ChargeBatteryInfo":    openAPIObject("ideal_battery_range:number", "rated_battery_range:number", "battery_heater:boolean", "battery_heater_on:boolean", "battery_heater_no_power:boolean?"),
+	"FastChargerInfo":      openAPIObject("fast_charger_present:boolean", "fast_charger_brand:string", "fast_charger_type:string"),
+
+	// charge curve
+	"ChargeCurveResponse":  openAPIObject("data:ChargeCurveData"),
+	"ChargeCurveData":      openAPIObject("car:Car", "charge:ChargeCurveCharge", "curve:[]ChargeCurvePoint", "aggregate_curve?:ChargeCurveAggregate"),
+	"ChargeCurveCharge":    openAPIObject("charge_id:integer", "start_date:date-time", "end_date:date-time", "address:string", "charge_energy_added:number"),
+	"ChargeCurvePoint":     openAPIObject("battery_level:integer", "sessions:integer", "samples:integer", "charger_power:ChargeCurveValues", "charger_actual_current:ChargeCurveValues", "charger_voltage:ChargeCurveValues"),
+	"ChargeCurveValues":    openAPIObject("min:number", "avg:number", "max:number"),
+	"ChargeCurveAggregate": openAPIObject("fast_charger_type:string", "curve:[]ChargeCurvePoint"),
+
+	// edits
+	"ChargeEdit":         openAPIObject("cost?:number?"),
+	"ChargeEditResponse": openAPIObject("data:ChargeEditData"),
+	"ChargeEditData":     openAPIObject("car:EditCar", "charge:ChargeEditCharge", "changes:EditChanges"),
+	"ChargeEditCharge":   openAPIObject("charge_id:integer", "cost:number?"),
+	"DriveEdit":          openAPIObject("start_geofence_id?:integer?", "end_geofence_id?:integer?"),
+	"DriveEditResponse":  openAPIObject("data:DriveEditData"),
+	"DriveEditData":      openAPIObject("car:EditCar", "drive:DriveEditDrive", "changes:EditChanges"),
+	"DriveEditDrive":     openAPIObject("drive_id:integer", "start_geofence_id:integer?", "end_geofence_id:integer?"),
+	"EditCar":            openAPIObject("car_id:integer"),
+	"EditChanges":        {"type": "object", "description": "old and new value of every changed field", "additionalProperties": gin.H{"type": "object", "properties": gin.H{"old": gin.H{}, "new": gin.H{}}, "required": []string{"old", "new"}}},
+
+	// charge cost
+	"ChargeCostResponse": openAPIObject("data:ChargeCostData"),
+	"ChargeCostData":     openAPIObject("car:Car", "charge:ChargeCostCharge", "tariff_cost:TariffCost", "cost_written:boolean"),
+	"ChargeCostCharge":   openAPIObject("charge_id:integer", "start_date:date-time", "end_date:date-time", "geofence_id:integer?", "geofence_name:string", "charge_energy_added:number", "cost:number?"),
+	"TariffCost":         openAPISchemaOf(reflect.TypeOf(tariffCost{})),
+
+	// drives
+	"DrivesResponse":      openAPIObject("data:DrivesData"),
+	"DrivesData":          openAPIObject("car:Car", "drives:[]Drive?", "units:Units"),
+	"DriveResponse":       openAPIObject("data:DriveData"),
+	"DriveData":           openAPIObject("car:Car", "drive:DriveWithDetails", "units:Units"),
+	"Drive":               openAPIObject(driveFields...),
+	"DriveWithDetails":    openAPIObject(append(driveFields, "drive_details:[]DriveDetails?", "track?:DriveTrack")...),
+	"DriveTrack":          openAPIObject("polyline:string", "date:[]date-time", "speed:[]integer", "power:[]integer", "odometer:[]number", "battery_level:[]integer", "elevation:[]Elevation"),
+	"Elevation":           {"type": []string{"integer", "null"}, "description": "elevation in meters, null if unknown"},
+	"OdometerDetails":     openAPIObject("odometer_start:number", "odometer_end:number", "odometer_distance:number"),
+	"DriveBatteryDetails": openAPIObject("start_usable_battery_level:integer", "start_battery_level:integer", "end_usable_battery_level:integer", "end_battery_level:integer", "reduced_range:boolean", "is_sufficiently_precise:boolean"),
+	"DriveRange":          openAPIObject("start_range:number", "end_range:number", "range_diff:number"),
+	"DriveDetails":        openAPIObject("detail_id:integer", "date:date-time", "latitude:number", "longitude:number", "speed:integer", "power:integer", "odometer:number", "battery_level:integer", "usable_battery_level:integer?", "elevation:integer?", "climate_info:DriveClimateInfo", "battery_info:DriveBatteryInfo"),
+	"DriveClimateInfo":    openAPIObject("inside_temp:number?", "outside_temp:number?", "is_climate_on:boolean?", "fan_status:integer?", "driver_temp_setting:number?", "passenger_temp_setting:number?", "is_rear_defroster_on:boolean?", "is_front_defroster_on:boolean?"),
+	"DriveExport":         {"type": "string", "description": "GPX 1.1, KML 2.2 or GeoJSON file of the drive, served as attachment"},
+	"DrivesExport":        {"type": "string", "description": "CSV or NDJSON file with one drive per row ordered by start_date, streamed as attachment"},
+	"ChargesExport":       {"type": "string", "description": "CSV or NDJSON file with one charge per row ordered by start_date, streamed as attachment"},
+	"DriveBatteryInfo":    openAPIObject("est_battery_range:number?", "ideal_battery_range:number?", "rated_battery_range:number?", "battery_heater:boolean?", "battery_heater_on:boolean?", "battery_heater_no_power:boolean?"),
+
+	// stats
+	"StatsResponse": openAPIObject("data:StatsData"),
+	"StatsData":     openAPIObject("car:Car", "period:string", "statistics:[]Statistic", "units:Units"),
+	"Statistic":     openAPIObject("period:string", "start_date:date-time", "drives:integer", "distance:number", "duration_min:integer", "duration_str:string", "energy_consumed:number?", "efficiency:number?", "charges:integer", "energy_charged:number", "cost:number?", "outside_temp_avg:number?"),
+
+	// vampire drain
+	"VampireDrainResponse": openAPIObject("data:VampireDrainData"),
+	"VampireDrainData":     openAPIObject("car:Car", "vampire_drain:[]VampireDrain", "units:VampireDrainUnits"),
+	"VampireDrain":         openAPIObject("start_date:date-time", "end_date:date-time", "duration_min:integer", "duration_str:string", "battery_details:ChargeBatteryDetails", "range_ideal:VampireDrainRange", "range_rated:VampireDrainRange", "energy_lost:number?", "avg_power:number?", "asleep_percent:number", "online_percent:number"),
+	"VampireDrainRange":    openAPIObject("start_range:number", "end_range:number", "range_lost:number"),
+	"VampireDrainUnits":    openAPIObject("unit_of_length:string"),
+
+	// updates
+	"UpdatesResponse": openAPIObject("data:UpdatesData"),
+	"UpdatesData":     openAPIObject("car:Car", "updates:[]Update?"),
+	"Update":          openAPIObject("update_id:integer", "start_date:date-time", "end_date:date-time", "version:string"),
+
+	// geofences
+	"GeofencesResponse":      openAPIObject("data:GeofencesData"),
+	"GeofencesData":          openAPIObject("geofences:[]Geofence"),
+	"GeofenceResponse":       openAPIObject("data:GeofenceData"),
+	"GeofenceData":           openAPIObject("geofence:Geofence"),
+	"Geofence":               openAPIObject("geofence_id:integer", "name:string", "latitude:number", "longitude:number", "radius:integer", "billing_type:string", "cost_per_unit:number?", "session_fee:number?", "inserted_at:date-time", "updated_at:date-time"),
+	"GeofenceRequest":        openAPIObject("name:string", "latitude:number", "longitude:number", "radius:integer", "billing_type?:string", "cost_per_unit?:number?", "session_fee?:number?"),
+	"GeofenceEditResponse":   openAPIObject("data:GeofenceEditData"),
+	"GeofenceEditData":       openAPIObject("geofence:Geofence", "changes:EditChanges"),
+	"GeofenceVisitsResponse": openAPIObject("data:GeofenceVisitsData"),
+	"GeofenceVisitsData":     openAPIObject("geofence:Geofence", "drives:[]GeofenceDriveVisit", "charges:[]GeofenceChargeVisit", "units:GeofenceVisitsUnits"),
+	"GeofenceVisitsUnits":    openAPIObject("unit_of_length:string"),
+	"GeofenceDriveVisit":     openAPIObject("drive_id:integer", "car_id:integer", "car_name:string?", "start_date:date-time", "end_date:date-time", "distance:number?", "duration_min:integer?"),
+	"GeofenceChargeVisit":    openAPIObject("charge_id:integer", "car_id:integer", "car_name:string?", "start_date:date-time", "end_date:date-time", "charge_energy_added:number", "cost:number?", "duration_min:integer?"),
+
+	// auth
+	"AuthLockoutsResponse": openAPIObject("data:AuthLockoutsData"),
+	"AuthLockoutsData":     openAPIObject("lockouts:[]AuthLockout"),
+	"AuthLockout":          openAPISchemaOf(reflect.TypeOf(AuthLockout{})),
+
+	// globalsettings
+	"GlobalSettingsResponse": openAPIObject("data:GlobalSettingsData"),
+	"GlobalSettingsData":     openAPIObject("settings:GlobalSettings"),
+	"GlobalSettings":         openAPIObject("setting_id:integer", "account_info:TeslaMateDetails", "teslamate_units:Units", "teslamate_webgui:TeslaMateWebGUI", "teslamate_urls:TeslaMateURLs"),
+	"TeslaMateWebGUI":        openAPIObject("preferred_range:string", "language:string"),
+	"TeslaMateURLs":          openAPIObject("base_url:string", "grafana_url:string"),
+
+	// status
+	"StatusResponse": openAPISchemaOf(reflect.TypeOf(genericResponse[CarStatusResponse]{})),
+	"StatusStream":   {"type": "string", "description": "`status` events with the StatusResponse as data, `heartbeat` events and the event id to resume with Last-Event-ID"},
+
+	// commands
+	"EnabledCommandsResponse": openAPIObject("enabled_commands:[]string?", "command_schemas?:CommandSchemas", "command_policies?:CommandPolicies"),
+	"CommandSchemas":          {"type": "object", "description": "JSON schema of the body of every enabled command", "additionalProperties": gin.H{"type": "object"}},
+	"CommandPolicies":         {"type": "object", "description": "policy of the commands of COMMANDS_ALLOWLIST with constraints", "additionalProperties": gin.H{"type": "object"}},
+	"TeslaResponse":           {"type": "object", "description": "response of the Tesla API or TeslaMate, returned with their status code", "additionalProperties": true},
+
+	// errors
+	"LegacyError":      openAPIObject("error:string"),
+	"APIErrorResponse": openAPISchemaOf(reflect.TypeOf(APIErrorResponse{})),
+
+	// others
+	"MessageResponse": {"type": "object", "properties": gin.H{"message": gin.H{"type": "string"}, "path": gin.H{"type": "string"}}, "required": []string{"message"}},
+	"HealthResponse":  {"type": "object", "properties": gin.H{"status": gin.H{"type": "string"}, "error": gin.H{"type": "string"}}},
+	"OpenAPIDocument": {"type": "object", "description": "OpenAPI 3.1 document", "additionalProperties": true},
+	"HTMLPage":        {"type": "string"},
+	"JavaScript":      {"type": "string"},
+	"MetricsText":     {"type": "string", "description": "metrics of requests, queries, commands and vehicle telemetry in the Prometheus text exposition format"},
+}
+
+// fields shared by the charges and charge endpoints
+var chargeFields = []string{"charge_id:integer", "start_date:date-time", "end_date:date-time", "address:string", "charge_energy_added:number", "charge_energy_used:number", "cost:number", "duration_min:integer", "duration_str:string", "battery_details:ChargeBatteryDetails", "range_ideal:ChargeRange", "range_rated:ChargeRange", "outside_temp_avg:number", "odometer:number", "latitude:number", "longitude:number"}
+
+// fields shared by the drives and drive endpoints
+var driveFields = []string{"drive_id:integer", "start_date:date-time", "end_date:date-time", "start_address:string", "end_address:string", "odometer_details:OdometerDetails", "duration_min:integer", "duration_str:string", "speed_max:integer", "speed_avg:number", "power_max:integer", "power_min:integer", "battery_details:DriveBatteryDetails", "range_ideal:DriveRange", "range_rated:DriveRange", "outside_temp_avg:number", "inside_temp_avg:number"}
+
+// openAPIParameter func - returns a components/parameters entry
+func openAPIParameter(name string, in string, description string, schema gin.H) gin.H {
+	return gin.H{"name": name, "in": in, "description": description, "required": in == "path", "schema": schema}
+}
+
+// openAPIObject func - returns an object schema of "name:type" fields
+//
+// type is integer, number, string, boolean, date-time or a components/schemas name,
+// prefixed with [] for arrays and suffixed with ? if the value can be null, names
+// suffixed with ? are fields that are left out of some responses
+func openAPIObject(fields ...string) gin.H {
+	properties := gin.H{}
+	required := []string{}
+	for _, field := range fields {
+		name, fieldType, _ := strings.Cut(field, ":")
+		if optional, ok := strings.CutSuffix(name, "?"); ok {
+			properties[optional] = openAPIType(fieldType)
+			continue
+		}
+		properties[name] = openAPIType(fieldType)
+		required = append(required, name)
+	}
+	return gin.H{"type": "object", "properties": properties, "required": required}
+}
+
+// openAPIType func - returns the schema of a type used by openAPIObject
+func openAPIType(fieldType string) gin.H {
+	nullable := strings.HasSuffix(fieldType, "?")
+	fieldType = strings.TrimSuffix(fieldType, "?")
+
+	var schema gin.H
+	switch {
+	case strings.HasPrefix(fieldType, "[]"):
+		schema = gin.H{"type": "array", "items": openAPIType(fieldType[2:])}
+		fieldType = "array"
+	case fieldType == "date-time":
+		schema = gin.H{"type": "string", "format": "date-time"}
+		fieldType = "string"
+	case fieldType == "integer", fieldType == "number", fieldType == "string", fieldType == "boolean":
+		schema = gin.H{"type": fieldType}
+	default:
+		schema = gin.H{"$ref": "#/components/schemas/" + fieldType}
+		if nullable {
+			return gin.H{"oneOf": []gin.H{schema, {"type": "null"}}}
+		}
+		return schema
+	}
+	if nullable {
+		schema["type"] = []string{fieldType, "null"}
+	}
+	return schema
+}
+
+// openAPISchemaOf func - returns the schema of a response struct based on its json tags
+func openAPISchemaOf(t reflect.Type) gin.H {
+	switch t {
+	case reflect.TypeOf(time.Time{}):
+		return gin.H{"type": "string", "format": "date-time"}
+	case reflect.TypeOf(NullInt64{}):
+		return gin.H{"type": []string{"integer", "null"}}
+	case reflect.TypeOf(NullFloat64{}):
+		return gin.H{"type": []string{"number", "null"}}
+	case reflect.TypeOf(NullBool{}):
+		return gin.H{"type": []string{"boolean", "null"}}
+	case reflect.TypeOf(NullText{}):
+		return gin.H{"type": []string{"string", "null"}}
+	case reflect.TypeOf(NullTime{}):
+		return gin.H{"type": []string{"string", "null"}, "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return openAPISchemaOf(t.Elem())
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gin.H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	case reflect.String:
+		return gin.H{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return gin.H{"type": []string{"array", "null"}, "items": openAPISchemaOf(t.Elem())}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": openAPISchemaOf(t.Elem())}
+	case reflect.Struct:
+		properties := gin.H{}
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = openAPISchemaOf(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return gin.H{"type": "object", "properties": properties, "required": required}
+	}
+	return gin.H{}
+}
+
+// openAPIPath func - converts a gin path to an OpenAPI path
+func openAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// openAPIResponse func - returns a response with the schema for all contentTypes
+func openAPIResponse(description string, schema gin.H, contentTypes ...string) gin.H {
+	content := gin.H{}
+	for _, contentType := range contentTypes {
+		content[contentType] = gin.H{"schema": schema}
+	}
+	return gin.H{"description": description, "content": content}
+}
+
+// openAPIRef func - returns a reference to components/schemas
+func openAPIRef(name string) gin.H {
+	return gin.H{"$ref": "#/components/schemas/" + name}
+}
+
+// openAPIOperationObject func - returns the OpenAPI operation of an endpoint, with the errors of the api version
+func openAPIOperationObject(op openAPIOperation, version string) gin.H {
+	contentTypes := op.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = []string{"application/json"}
+	}
+
+	operation := gin.H{
+		"summary":     op.Summary,
+		"operationId": version + strings.ToUpper(op.Method[:1]) + strings.ToLower(op.Method[1:]) + strings.NewReplacer("/", "_", ":", "", ".", "_").Replace(op.Path),
+		"tags":        []string{op.Tag},
+	}
+	if version != "" {
+		operation["tags"] = []string{version, op.Tag}
+	}
+	if len(op.Parameters) > 0 {
+		parameters := []gin.H{}
+		for _, name := range op.Parameters {
+			parameters = append(parameters, gin.H{"$ref": "#/components/parameters/" + name})
+		}
+		operation["parameters"] = parameters
+	}
+	if op.Auth {
+		operation["security"] = []gin.H{{"bearerAuth": []string{}}, {"tokenQuery": []string{}}}
+	}
+	if op.Auth && op.Method != http.MethodGet {
+		operation["requestBody"] = gin.H{"required": false, "content": gin.H{"application/json": gin.H{"schema": gin.H{"type": "object", "additionalProperties": true}}}}
+		if op.RequestBody != "" {
+			operation["requestBody"] = gin.H{"required": true, "content": gin.H{"application/json": gin.H{"schema": openAPIRef(op.RequestBody)}}}
+		}
+	}
+
+	status := "200"
+	if op.Status != 0 {
+		status = strconv.Itoa(op.Status)
+	}
+
+	responses := gin.H{}
+	switch version {
+	case "v1":
+		// v1 returns most errors with 200
+		success := openAPIResponse("successful response, or an error with the legacy error body", openAPIRef(op.Response), contentTypes...)
+		if content := success["content"].(gin.H); content["application/json"] != nil {
+			content["application/json"] = gin.H{"schema": gin.H{"oneOf": []gin.H{openAPIRef(op.Response), openAPIRef("LegacyError")}}}
+		} else {
+			content["application/json"] = gin.H{"schema": openAPIRef("LegacyError")}
+		}
+		responses[status] = success
+		for _, code := range op.LegacyErrors {
+			responses[strconv.Itoa(code)] = openAPIResponse(http.StatusText(code), openAPIRef("LegacyError"), "application/json")
+		}
+	case "v2":
+		responses[status] = openAPIResponse("successful response", openAPIRef(op.Response), contentTypes...)
+		for _, code := range append([]int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError, http.StatusServiceUnavailable}, op.LegacyErrors...) {
+			responses[strconv.Itoa(code)] = openAPIResponse(http.StatusText(code), openAPIRef("APIErrorResponse"), "application/json")
+		}
+	default:
+		responses[status] = openAPIResponse("successful response", openAPIRef(op.Response), contentTypes...)
+	}
+	operation["responses"] = responses
+
+	return operation
+}
+
+// openAPIDocument func - returns the OpenAPI 3.1 document of all endpoints
+func openAPIDocument() gin.H {
+	paths := map[string]gin.H{}
+	addOperation := func(path string, method string, operation gin.H) {
+		path = openAPIPath(path)
+		if paths[path] == nil {
+			paths[path] = gin.H{}
+		}
+		paths[path][strings.ToLower(method)] = operation
+	}
+
+	for _, op := range openAPIOperations {
+		addOperation("/api/v1"+op.Path, op.Method, openAPIOperationObject(op, "v1"))
+		addOperation("/api/v2"+op.Path, op.Method, openAPIOperationObject(op, "v2"))
+	}
+	for _, op := range openAPIOtherOperations {
+		addOperation(op.Path, op.Method, openAPIOperationObject(op, ""))
+	}
+	for _, path := range legacyV1Paths {
+		addOperation(path, http.MethodGet, gin.H{
+			"summary":     "Redirect to /api/v1" + path,
+			"operationId": "legacyGet" + strings.NewReplacer("/", "_", ":", "").Replace(path),
+			"tags":        []string{"legacy"},
+			"deprecated":  true,
+			"responses":   gin.H{"301": gin.H{"description": "moved permanently to /api/v1" + openAPIPath(path)}},
+		})
+	}
+
+	return gin.H{
+		"openapi": "3.1.0",
+		"info": gin.H{
+			"title":       "TeslaMateApi",
+			"description": "RESTful API to get data collected by self-hosted data logger TeslaMate in JSON. The /api/v1 endpoints return most errors with 200 and {\"error\": \"message\"}, the /api/v2 endpoints return errors with their status code.",
+			"version":     apiVersion,
+			"license":     gin.H{"name": "MIT", "identifier": "MIT"},
+		},
+		"servers": []gin.H{{"url": "/"}},
+		"paths":   paths,
+		"components": gin.H{
+			"schemas":    openAPISchemas,
+			"parameters": openAPIParameters,
+			"securitySchemes": gin.H{
+				"bearerAuth": gin.H{"type": "http", "scheme": "bearer", "description": "API_TOKEN, a token of API_TOKENS_FILE or a JWT validated with JWT_JWKS as Authorization: Bearer header, every endpoint needs a token if API_TOKENS_FILE or JWT_JWKS is set"},
+				"tokenQuery": gin.H{"type": "apiKey", "in": "query", "name": "token", "description": "API_TOKEN or a token of API_TOKENS_FILE as token query parameter, unless API_TOKEN_QUERY_DISABLE is set"},
+			},
+		},
+	}
+}
+
+// openAPISpec func - returns the OpenAPI document
+func openAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, openAPIDocument())
+}
+
+// redocVersion is the version of the Redoc bundle in the redoc directory
+const redocVersion = "2.1.5"
+
+// redocIntegrity is the Subresource Integrity hash of redoc.standalone.js of redocVersion, the
+// CDN is only used without the vendored bundle and once the hash is set, see redoc/README.md
+const redocIntegrity = ""
+
+// redocFiles holds the vendored Redoc bundle, see redoc/README.md
+//
+//go:embed redoc
+var redocFiles embed.FS
+
+// openAPIDocsPage is a Redoc page rendering /api/openapi.json with the script element %s
+const openAPIDocsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>TeslaMateApi</title>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+  </head>
+  <body>
+    <redoc spec-url="openapi.json"></redoc>
+    %s
+  </body>
+</html>
+`
+
+// redocBundle func - returns the vendored Redoc bundle, nil if it isn't part of the build
+func redocBundle() []byte {
+	bundle, err := redocFiles.ReadFile("redoc/redoc.standalone.js")
+	if err != nil {
+		return nil
+	}
+	return bundle
+}
+
+// openAPIDocs func - returns a page to browse the OpenAPI document, with the vendored Redoc bundle
+// or the same pinned version of the CDN checked against redocIntegrity if the bundle isn't vendored
+func openAPIDocs(c *gin.Context) {
+	script := `<script src="docs/redoc.standalone.js"></script>`
+	if redocBundle() == nil {
+		if redocIntegrity == "" {
+			// never running a script of the CDN that can't be verified
+			script = `<p>Redoc isn't part of this build, the OpenAPI document is available at <a href="openapi.json">openapi.json</a>.</p>`
+		} else {
+			script = fmt.Sprintf(`<script src="https://cdn.jsdelivr.net/npm/redoc@%s/bundles/redoc.standalone.js" integrity="%s" crossorigin="anonymous"></script>`, redocVersion, redocIntegrity)
+		}
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(fmt.Sprintf(openAPIDocsPage, script)))
+}
+
+// openAPIDocsScript func - returns the vendored Redoc bundle
+func openAPIDocsScript(c *gin.Context) {
+	bundle := redocBundle()
+	if bundle == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": "PAGE_NOT_FOUND", "message": "Page not found"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, "application/javascript; charset=utf-8", bundle)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// routes registered only with optional features are documented as well
+	t.Setenv("ENABLE_METRICS", "true")
+	router := newRouter()
+
+	// round trip through json, like clients get the document
+	req, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var document struct {
+		OpenAPI    string                               `json:"openapi"`
+		Paths      map[string]map[string]map[string]any `json:"paths"`
+		Components map[string]map[string]any            `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatalf("Failed to decode document: %s", err)
+	}
+
+	if document.OpenAPI != "3.1.0" {
+		t.Errorf("Expected OpenAPI 3.1.0, got '%s'", document.OpenAPI)
+	}
+
+	t.Run("Every route is documented", func(t *testing.T) {
+		for _, route := range router.Routes() {
+			if _, ok := document.Paths[openAPIPath(route.Path)][strings.ToLower(route.Method)]; !ok {
+				t.Errorf("Route %s %s is not documented in openAPIOperations", route.Method, route.Path)
+			}
+		}
+	})
+
+	t.Run("Every documented operation is a route", func(t *testing.T) {
+		routes := map[string]bool{}
+		for _, route := range router.Routes() {
+			routes[strings.ToLower(route.Method)+" "+openAPIPath(route.Path)] = true
+		}
+		for path, operations := range document.Paths {
+			for method := range operations {
+				if !routes[method+" "+path] {
+					t.Errorf("Documented operation %s %s is not a route", method, path)
+				}
+			}
+		}
+	})
+
+	t.Run("Every reference exists", func(t *testing.T) {
+		var walk func(v any)
+		walk = func(v any) {
+			switch v := v.(type) {
+			case map[string]any:
+				for key, value := range v {
+					if ref, ok := value.(string); ok && key == "$ref" {
+						parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+						if len(parts) != 2 || document.Components[parts[0]][parts[1]] == nil {
+							t.Errorf("Reference %s does not exist", ref)
+						}
+						continue
+					}
+					walk(value)
+				}
+			case []any:
+				for _, value := range v {
+					walk(value)
+				}
+			}
+		}
+		var raw map[string]any
+		_ = json.Unmarshal(w.Body.Bytes(), &raw)
+		walk(raw)
+	})
+
+	t.Run("Query parameters and auth schemes", func(t *testing.T) {
+		drives := document.Paths["/api/v1/cars/{CarID}/drives"]["get"]
+		body, _ := json.Marshal(drives["parameters"])
+		for _, name := range []string{"page", "show", "startDate", "endDate"} {
+			if !contains(string(body), `"#/components/parameters/`+name+`"`) {
+				t.Errorf("Expected drives to document %s, got %s", name, body)
+			}
+		}
+
+		command := document.Paths["/api/v2/cars/{CarID}/command/{Command}"]["post"]
+		if command["security"] == nil {
+			t.Error("Expected command endpoint to require authentication")
+		}
+		if document.Components["securitySchemes"]["bearerAuth"] == nil || document.Components["securitySchemes"]["tokenQuery"] == nil {
+			t.Errorf("Expected bearerAuth and tokenQuery security schemes, got %v", document.Components["securitySchemes"])
+		}
+	})
+}
+
+func TestOpenAPIDocs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := newRouter()
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	page, script := request("/api/docs"), request("/api/docs/redoc.standalone.js")
+	if redocBundle() == nil {
+		// without the vendored bundle the page only loads the pinned version, checked by its hash
+		body := page.Body.String()
+		if redocIntegrity == "" && contains(body, "<script") {
+			t.Errorf("Expected page without scripts, got %s", body)
+		}
+		if redocIntegrity != "" && (!contains(body, "redoc@"+redocVersion+"/") || !contains(body, `integrity="`+redocIntegrity+`" crossorigin="anonymous"`)) {
+			t.Errorf("Expected page with Redoc %s of the CDN checked by its hash, got %s", redocVersion, body)
+		}
+		if script.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for the Redoc bundle, got %d", script.Code)
+		}
+		return
+	}
+	if !contains(page.Body.String(), `src="docs/redoc.standalone.js"`) || script.Code != http.StatusOK {
+		t.Errorf("Expected page with the vendored Redoc bundle, got %s and status %d", page.Body.String(), script.Code)
+	}
+	if redocIntegrity != "" {
+		sum := sha512.Sum384(redocBundle())
+		if hash := "sha384-" + base64.StdEncoding.EncodeToString(sum[:]); hash != redocIntegrity {
+			t.Errorf("Expected redocIntegrity to be the hash %s of the vendored bundle, got %s", hash, redocIntegrity)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TeslaMateAPIAuthLockoutsV1 func
+func TeslaMateAPIAuthLockoutsV1(c *gin.Context) {
+
+	// Data struct - child of JSONData
+	type Data struct {
+		Lockouts []AuthLockout `json:"lockouts"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// the lockouts are only shown to an authenticated identity with the admin scope, even without API_TOKENS_FILE
+	identity, errorMessage := authenticate(c)
+	if identity == nil {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusUnauthorized, "TeslaMateAPIAuthLockoutsV1", errorMessage, "invalid or missing bearer token")
+		return
+	}
+	if !identity.hasScope(scopeAdmin) {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, "TeslaMateAPIAuthLockoutsV1", "You are not allowed to access this endpoint", "token "+identity.Name+" is missing scope "+scopeAdmin)
+		return
+	}
+
+	lockouts := []AuthLockout{}
+	if limiter, ok := c.Get(authLimiterKey); ok {
+		lockouts = limiter.(*AuthLimiter).Lockouts()
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPIAuthLockoutsV1", JSONData{Data: Data{Lockouts: lockouts}})
+}
@@ -76,98 +76,65 @@ func TeslaMateAPICarsV1(c *gin.Context) {
 	var CarsData []Cars
 
 	// getting data from database
-	query := `
-		SELECT
-			cars.id,
-			eid,
-			vid,
-			model,
-			efficiency,
-			inserted_at,
-			updated_at,
-			vin,
-			name,
-			trim_badging,
-			exterior_color,
-			spoiler_type,
-			wheel_type,
-			suspend_min,
-			suspend_after_idle_min,
-			req_not_unlocked,
-			free_supercharging,
-			use_streaming_api,
-			(SELECT COUNT(*) FROM charging_processes WHERE car_id=cars.id) as total_charges,
-			(SELECT COUNT(*) FROM drives WHERE car_id=cars.id) as total_drives,
-			(SELECT COUNT(*) FROM updates WHERE car_id=cars.id) as total_charges
-		FROM cars
-		LEFT JOIN car_settings ON cars.id = car_settings.id
-		ORDER BY id;`
-	rows, err := db.Query(query)
+	cars, err := queries.ListCars(c.Request.Context())
 
 	// checking for errors in query
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsV1", CarsError1, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsV1", CarsError1, err.Error())
 		return
 	}
 
-	// defer closing rows
-	defer rows.Close()
-
 	// looping through all results
-	for rows.Next() {
-
-		// creating car object based on struct
-		car := Cars{}
-
-		// scanning row and putting values into the car
-		err = rows.Scan(
-			&car.CarID,
-			&car.CarDetails.EID,
-			&car.CarDetails.VID,
-			&car.CarDetails.Model,
-			&car.CarDetails.Efficiency,
-			&car.TeslaMateDetails.InsertedAt,
-			&car.TeslaMateDetails.UpdatedAt,
-			&car.CarDetails.Vin,
-			&car.Name,
-			&car.CarDetails.TrimBadging,
-			&car.CarExterior.ExteriorColor,
-			&car.CarExterior.SpoilerType,
-			&car.CarExterior.WheelType,
-			&car.CarSettings.SuspendMin,
-			&car.CarSettings.SuspendAfterIdleMin,
-			&car.CarSettings.ReqNotUnlocked,
-			&car.CarSettings.FreeSupercharging,
-			&car.CarSettings.UseStreamingAPI,
-			&car.TeslaMateStats.TotalCharges,
-			&car.TeslaMateStats.TotalDrives,
-			&car.TeslaMateStats.TotalUpdates,
-		)
-
-		// checking for errors after scanning
-		if err != nil {
-			TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsV1", CarsError1, err.Error())
-			return
+	for _, row := range cars {
+
+		// tokens restricted to some cars only get these cars
+		if !identityAllowsCar(c, int(row.ID)) {
+			continue
 		}
 
 		// appending car to CarsData if CarID is 0 or is CarID matches car.CarID
-		if CarID == 0 && len(ParamCarID) == 0 || CarID != 0 && CarID == car.CarID {
-
-			// adjusting to timezone differences from UTC to be userspecific
-			car.TeslaMateDetails.InsertedAt = getTimeInTimeZone(car.TeslaMateDetails.InsertedAt)
-			car.TeslaMateDetails.UpdatedAt = getTimeInTimeZone(car.TeslaMateDetails.UpdatedAt)
+		if CarID == 0 && len(ParamCarID) == 0 || CarID != 0 && CarID == int(row.ID) {
+
+			// creating car object based on struct
+			car := Cars{
+				CarID: int(row.ID),
+				Name:  NullString(row.Name.String),
+				CarDetails: CarDetails{
+					EID:         row.Eid,
+					VID:         row.Vid,
+					Vin:         row.Vin.String,
+					Model:       row.Model.String,
+					TrimBadging: NullString(row.TrimBadging.String),
+					Efficiency:  NullFloat64{row.Efficiency},
+				},
+				CarExterior: CarExterior{
+					ExteriorColor: row.ExteriorColor.String,
+					SpoilerType:   row.SpoilerType.String,
+					WheelType:     row.WheelType.String,
+				},
+				CarSettings: CarSettings{
+					SuspendMin:          int(row.SuspendMin.Int32),
+					SuspendAfterIdleMin: int(row.SuspendAfterIdleMin.Int32),
+					ReqNotUnlocked:      row.ReqNotUnlocked.Bool,
+					FreeSupercharging:   row.FreeSupercharging.Bool,
+					UseStreamingAPI:     row.UseStreamingApi.Bool,
+				},
+				TeslaMateDetails: TeslaMateDetails{
+					// adjusting to timezone differences from UTC to be userspecific
+					InsertedAt: getTimeInTimeZone(row.InsertedAt),
+					UpdatedAt:  getTimeInTimeZone(row.UpdatedAt),
+				},
+				TeslaMateStats: TeslaMateStats{
+					TotalCharges: int(row.TotalCharges),
+					TotalDrives:  int(row.TotalDrives),
+					TotalUpdates: int(row.TotalUpdates),
+				},
+			}
 
 			CarsData = append(CarsData, car)
 		}
 	}
 
-	// checking for errors in the rows result
-	err = rows.Err()
-	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsV1", CarsError1, err.Error())
-		return
-	}
-
 	//
 	// build the data-blob
 	jsonData := JSONData{
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// batteryHealthMinBatteryLevelAdded - charges adding less battery level are too imprecise for the estimations
+const batteryHealthMinBatteryLevelAdded = 10
+
+// TeslaMateAPICarsBatteryHealthV1 func
+func TeslaMateAPICarsBatteryHealthV1(c *gin.Context) {
+
+	// define error messages
+	var CarsBatteryHealthError1 = "Unable to load battery health."
+
+	// getting CarID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+
+	// creating structs for /cars/<CarID>/battery/health
+	// Car struct - child of Data
+	type Car struct {
+		CarID   int        `json:"car_id"`   // smallint
+		CarName NullString `json:"car_name"` // text (nullable)
+	}
+	// Month struct - child of BatteryHealth
+	type Month struct {
+		Month                     string      `json:"month"`                        // string (YYYY-MM)
+		Charges                   int         `json:"charges"`                      // int
+		EstimatedCapacity         NullFloat64 `json:"estimated_capacity"`           // float64 (kWh)
+		EstimatedCapacityByEnergy NullFloat64 `json:"estimated_capacity_by_energy"` // float64 (kWh)
+		ProjectedFullRange        NullFloat64 `json:"projected_full_range"`         // float64
+	}
+	// BatteryHealth struct - child of Data
+	type BatteryHealth struct {
+		Method                    string     `json:"method"`                       // string
+		FirstMonth                NullString `json:"first_month"`                  // string (YYYY-MM)
+		LastMonth                 NullString `json:"last_month"`                   // string (YYYY-MM)
+		OriginalCapacity          *float64   `json:"original_capacity"`            // float64 (kWh, nullable)
+		CurrentCapacity           *float64   `json:"current_capacity"`             // float64 (kWh, nullable)
+		DegradationPercent        *float64   `json:"degradation_percent"`          // float64 (nullable)
+		DegradationPercentPerYear *float64   `json:"degradation_percent_per_year"` // float64 (nullable)
+		Months                    []Month    `json:"months"`                       // []Month
+	}
+	// TeslaMateUnits struct - child of Data
+	type TeslaMateUnits struct {
+		UnitsLength string `json:"unit_of_length"` // string
+	}
+	// Data struct - child of JSONData
+	type Data struct {
+		Car            Car            `json:"car"`
+		BatteryHealth  BatteryHealth  `json:"battery_health"`
+		TeslaMateUnits TeslaMateUnits `json:"units"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// creating required vars
+	var (
+		CarName          NullString
+		MonthsData       = []Month{}
+		UnitsLength      string
+		xRange, yRange   []float64
+		xEnergy, yEnergy []float64
+	)
+
+	// getting data from database
+	months, err := queries.ListBatteryHealth(c.Request.Context(), teslamatedb.ListBatteryHealthParams{
+		TimeZone:             getTimeZoneName(),
+		CarID:                int16(CarID),
+		MinBatteryLevelAdded: batteryHealthMinBatteryLevelAdded,
+	})
+
+	// checking for errors in query
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsBatteryHealthV1", CarsBatteryHealthError1, err.Error())
+		return
+	}
+
+	// looping through all results
+	for _, row := range months {
+		month := Month{
+			Month:                     row.Month.Format("2006-01"),
+			Charges:                   int(row.Charges),
+			EstimatedCapacity:         NullFloat64{row.EstimatedCapacity},
+			EstimatedCapacityByEnergy: NullFloat64{row.EstimatedCapacityByEnergy},
+			ProjectedFullRange:        NullFloat64{row.ProjectedFullRangeKm},
+		}
+		UnitsLength = row.UnitOfLength
+		CarName = NullString(row.CarName.String)
+
+		// converting values based of settings UnitsLength
+		if UnitsLength == "mi" {
+			month.ProjectedFullRange = kilometersToMilesNilSupport(month.ProjectedFullRange)
+		}
+
+		// months since the first month are the x of the regressions
+		x := float64(monthsBetween(months[0].Month, row.Month))
+		if row.EstimatedCapacity.Valid {
+			xRange, yRange = append(xRange, x), append(yRange, row.EstimatedCapacity.Float64)
+		}
+		if row.EstimatedCapacityByEnergy.Valid {
+			xEnergy, yEnergy = append(xEnergy, x), append(yEnergy, row.EstimatedCapacityByEnergy.Float64)
+		}
+
+		MonthsData = append(MonthsData, month)
+	}
+
+	batteryHealth := BatteryHealth{Method: "rated_range", Months: MonthsData}
+
+	// the capacity based on rated range needs the efficiency of the car, otherwise the energy added is used
+	xs, ys := xRange, yRange
+	if len(xRange) < 2 && len(xEnergy) >= 2 {
+		batteryHealth.Method = "energy_added"
+		xs, ys = xEnergy, yEnergy
+	}
+	if len(months) > 0 {
+		batteryHealth.FirstMonth = NullString(months[0].Month.Format("2006-01"))
+		batteryHealth.LastMonth = NullString(months[len(months)-1].Month.Format("2006-01"))
+	}
+
+	// degradation of the regression line between the first and the last month
+	if slope, intercept, ok := linearRegression(xs, ys); ok && intercept > 0 {
+		current := intercept + slope*float64(monthsBetween(months[0].Month, months[len(months)-1].Month))
+		degradation := (1 - current/intercept) * 100
+		degradationPerYear := -slope * 12 / intercept * 100
+		batteryHealth.OriginalCapacity = &intercept
+		batteryHealth.CurrentCapacity = &current
+		batteryHealth.DegradationPercent = &degradation
+		batteryHealth.DegradationPercentPerYear = &degradationPerYear
+	}
+
+	//
+	// build the data-blob
+	jsonData := JSONData{
+		Data{
+			Car: Car{
+				CarID:   CarID,
+				CarName: CarName,
+			},
+			BatteryHealth: batteryHealth,
+			TeslaMateUnits: TeslaMateUnits{
+				UnitsLength: UnitsLength,
+			},
+		},
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsBatteryHealthV1", jsonData)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeBatteryHealthQuerier returns fixed months, all other queries are not implemented
+type fakeBatteryHealthQuerier struct {
+	teslamatedb.Querier
+	params teslamatedb.ListBatteryHealthParams
+	rows   []teslamatedb.ListBatteryHealthRow
+}
+
+func (q *fakeBatteryHealthQuerier) ListBatteryHealth(ctx context.Context, arg teslamatedb.ListBatteryHealthParams) ([]teslamatedb.ListBatteryHealthRow, error) {
+	q.params = arg
+	return q.rows, nil
+}
+
+func TestTeslaMateAPICarsBatteryHealthV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("Europe/Berlin")
+	defer func() { appUsersTimezone, _ = time.LoadLocation("UTC") }()
+
+	nullFloat := func(f float64) pgtype.Float8 { return pgtype.Float8{Float64: f, Valid: true} }
+	fake := &fakeBatteryHealthQuerier{}
+	for i, capacity := range []float64{75, 74.7, 74.4, 74.1} {
+		fake.rows = append(fake.rows, teslamatedb.ListBatteryHealthRow{
+			Month:                     time.Date(2023, time.Month(11+i*2), 1, 0, 0, 0, 0, time.UTC),
+			Charges:                   10,
+			EstimatedCapacity:         nullFloat(capacity),
+			EstimatedCapacityByEnergy: nullFloat(capacity + 2),
+			ProjectedFullRangeKm:      nullFloat(500),
+			UnitOfLength:              "mi",
+			CarName:                   pgtype.Text{String: "Test Tesla", Valid: true},
+		})
+	}
+
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/battery/health", TeslaMateAPICarsBatteryHealthV1)
+
+	req, _ := http.NewRequest("GET", "/api/v1/cars/1/battery/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if fake.params.CarID != 1 || fake.params.TimeZone != "Europe/Berlin" || fake.params.MinBatteryLevelAdded != batteryHealthMinBatteryLevelAdded {
+		t.Errorf("Unexpected query parameters: %+v", fake.params)
+	}
+
+	var resp struct {
+		Data struct {
+			BatteryHealth struct {
+				Method                    string   `json:"method"`
+				FirstMonth                string   `json:"first_month"`
+				LastMonth                 string   `json:"last_month"`
+				OriginalCapacity          *float64 `json:"original_capacity"`
+				CurrentCapacity           *float64 `json:"current_capacity"`
+				DegradationPercent        *float64 `json:"degradation_percent"`
+				DegradationPercentPerYear *float64 `json:"degradation_percent_per_year"`
+				Months                    []struct {
+					Month              string   `json:"month"`
+					EstimatedCapacity  *float64 `json:"estimated_capacity"`
+					ProjectedFullRange *float64 `json:"projected_full_range"`
+				} `json:"months"`
+			} `json:"battery_health"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	health := resp.Data.BatteryHealth
+
+	if health.Method != "rated_range" || health.FirstMonth != "2023-11" || health.LastMonth != "2024-05" || len(health.Months) != 4 {
+		t.Errorf("Unexpected battery health: %+v", health)
+	}
+	if health.OriginalCapacity == nil || math.Abs(*health.OriginalCapacity-75) > 1e-9 || math.Abs(*health.CurrentCapacity-74.1) > 1e-9 {
+		t.Fatalf("Expected capacity from 75 to 74.1 kWh, got %v and %v", health.OriginalCapacity, health.CurrentCapacity)
+	}
+	if math.Abs(*health.DegradationPercent-1.2) > 1e-9 || math.Abs(*health.DegradationPercentPerYear-2.4) > 1e-9 {
+		t.Errorf("Expected 1.2%% degradation and 2.4%% per year, got %v and %v", *health.DegradationPercent, *health.DegradationPercentPerYear)
+	}
+	if *health.Months[0].ProjectedFullRange != kilometersToMiles(500) {
+		t.Errorf("Expected projected full range in miles, got %v", *health.Months[0].ProjectedFullRange)
+	}
+}
@@ -1,10 +1,11 @@
 package main
 
 import (
-	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
 // TeslaMateAPICarsChargesV1 func
@@ -23,12 +24,12 @@ func TeslaMateAPICarsChargesV1(c *gin.Context) {
 	// get startDate and endDate from query parameters
 	parsedStartDate, err := parseDateParam(c.Query("startDate"))
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesV1", CarsChargesError2, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsChargesV1", CarsChargesError2, err.Error())
 		return
 	}
 	parsedEndDate, err := parseDateParam(c.Query("endDate"))
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesV1", CarsChargesError2, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsChargesV1", CarsChargesError2, err.Error())
 		return
 	}
 
@@ -99,102 +100,52 @@ func TeslaMateAPICarsChargesV1(c *gin.Context) {
 	ResultPage = (ResultPage * ResultShow)
 
 	// getting data from database
-	query := `
-		SELECT
-			charging_processes.id AS charge_id,
-			start_date,
-			end_date,
-			COALESCE(geofence.name, CONCAT_WS(', ', COALESCE(address.name, nullif(CONCAT_WS(' ', address.road, address.house_number), '')), address.city)) AS address,
-			COALESCE(charge_energy_added, 0) AS charge_energy_added,
-			COALESCE(charge_energy_used, 0) AS charge_energy_used,
-			COALESCE(cost, 0) AS cost,
-			start_ideal_range_km AS start_ideal_range,
-			end_ideal_range_km AS end_ideal_range,
-			start_rated_range_km AS start_rated_range,
-			end_rated_range_km AS end_rated_range,
-			start_battery_level,
-			end_battery_level,
-			duration_min,
-			TO_CHAR((duration_min * INTERVAL '1 minute'), 'HH24:MI') as duration_str,
-			outside_temp_avg,
-			position.odometer as odometer,
-			position.latitude,
-			position.longitude,
-			(SELECT unit_of_length FROM settings LIMIT 1) as unit_of_length,
-			(SELECT unit_of_temperature FROM settings LIMIT 1) as unit_of_temperature,
-			cars.name
-		FROM charging_processes
-		LEFT JOIN cars ON car_id = cars.id
-		LEFT JOIN addresses address ON address_id = address.id
-		LEFT JOIN positions position ON position_id = position.id
-		LEFT JOIN geofences geofence ON geofence_id = geofence.id
-		WHERE charging_processes.car_id=$1 AND charging_processes.end_date IS NOT NULL`
-
-	// Parameters to be passed to the query
-	var queryParams []interface{}
-	queryParams = append(queryParams, CarID)
-	paramIndex := 2
-
-	// Add date filtering if provided
-	if parsedStartDate != "" {
-		query += fmt.Sprintf(" AND charging_processes.start_date >= $%d", paramIndex)
-		queryParams = append(queryParams, parsedStartDate)
-		paramIndex++
-	}
-	if parsedEndDate != "" {
-		query += fmt.Sprintf(" AND charging_processes.end_date <= $%d", paramIndex)
-		queryParams = append(queryParams, parsedEndDate)
-		paramIndex++
-	}
-
-	query += fmt.Sprintf(`
-        ORDER BY start_date DESC
-        LIMIT $%d OFFSET $%d;`, paramIndex, paramIndex+1)
-
-	queryParams = append(queryParams, ResultShow, ResultPage)
-
-	rows, err := db.Query(query, queryParams...)
+	charges, err := queries.ListCharges(c.Request.Context(), teslamatedb.ListChargesParams{
+		CarID:     int16(CarID),
+		StartDate: parsedStartDate,
+		EndDate:   parsedEndDate,
+		Limit:     int32(ResultShow),
+		Offset:    int32(ResultPage),
+	})
 
 	// checking for errors in query
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesV1", CarsChargesError1, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesV1", CarsChargesError1, err.Error())
 		return
 	}
 
-	// defer closing rows
-	defer rows.Close()
-
 	// looping through all results
-	for rows.Next() {
+	for _, row := range charges {
 
 		// creating charge object based on struct
-		charge := Charges{}
-
-		// scanning row and putting values into the charge
-		err = rows.Scan(
-			&charge.ChargeID,
-			&charge.StartDate,
-			&charge.EndDate,
-			&charge.Address,
-			&charge.ChargeEnergyAdded,
-			&charge.ChargeEnergyUsed,
-			&charge.Cost,
-			&charge.RangeIdeal.StartRange,
-			&charge.RangeIdeal.EndRange,
-			&charge.RangeRated.StartRange,
-			&charge.RangeRated.EndRange,
-			&charge.BatteryDetails.StartBatteryLevel,
-			&charge.BatteryDetails.EndBatteryLevel,
-			&charge.DurationMin,
-			&charge.DurationStr,
-			&charge.OutsideTempAvg,
-			&charge.Odometer,
-			&charge.Latitude,
-			&charge.Longitude,
-			&UnitsLength,
-			&UnitsTemperature,
-			&CarName,
-		)
+		charge := Charges{
+			ChargeID:          int(row.ChargeID),
+			Address:           row.Address,
+			ChargeEnergyAdded: row.ChargeEnergyAdded,
+			ChargeEnergyUsed:  row.ChargeEnergyUsed,
+			Cost:              row.Cost,
+			DurationMin:       int(row.DurationMin.Int16),
+			DurationStr:       row.DurationStr,
+			BatteryDetails: BatteryDetails{
+				StartBatteryLevel: int(row.StartBatteryLevel.Int16),
+				EndBatteryLevel:   int(row.EndBatteryLevel.Int16),
+			},
+			RangeIdeal: PreferredRange{
+				StartRange: row.StartIdealRange.Float64,
+				EndRange:   row.EndIdealRange.Float64,
+			},
+			RangeRated: PreferredRange{
+				StartRange: row.StartRatedRange.Float64,
+				EndRange:   row.EndRatedRange.Float64,
+			},
+			OutsideTempAvg: row.OutsideTempAvg.Float64,
+			Odometer:       row.Odometer.Float64,
+			Latitude:       row.Latitude.Float64,
+			Longitude:      row.Longitude.Float64,
+		}
+		UnitsLength = row.UnitOfLength
+		UnitsTemperature = row.UnitOfTemperature
+		CarName = NullString(row.CarName.String)
 
 		// converting values based of settings UnitsLength
 		if UnitsLength == "mi" {
@@ -210,26 +161,13 @@ func TeslaMateAPICarsChargesV1(c *gin.Context) {
 		}
 
 		// adjusting to timezone differences from UTC to be userspecific
-		charge.StartDate = getTimeInTimeZone(charge.StartDate)
-		charge.EndDate = getTimeInTimeZone(charge.EndDate)
-
-		// checking for errors after scanning
-		if err != nil {
-			TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesV1", CarsChargesError1, err.Error())
-			return
-		}
+		charge.StartDate = getTimeInTimeZone(row.StartDate)
+		charge.EndDate = getTimeInTimeZone(row.EndDate.Time)
 
 		// appending charge to ChargesData
 		ChargesData = append(ChargesData, charge)
 	}
 
-	// checking for errors in the rows result
-	err = rows.Err()
-	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesV1", CarsChargesError1, err.Error())
-		return
-	}
-
 	//
 	// build the data-blob
 	jsonData := JSONData{
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPICarsChargesBulkExportV1 func
+func TeslaMateAPICarsChargesBulkExportV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsChargesBulkExportError1 = "Unable to load charges."
+		CarsChargesBulkExportError2 = "Invalid date format."
+		CarsChargesBulkExportError3 = "Invalid export format."
+	)
+
+	// getting CarID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+
+	// getting format from query parameters
+	ExportFormat := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if _, ok := bulkExportFormats[ExportFormat]; !ok {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsChargesBulkExportV1", CarsChargesBulkExportError3, "format has to be csv or ndjson, got "+strconv.Quote(ExportFormat))
+		return
+	}
+
+	// get startDate and endDate from query parameters
+	parsedStartDate, err := parseDateParam(c.Query("startDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsChargesBulkExportV1", CarsChargesBulkExportError2, err.Error())
+		return
+	}
+	parsedEndDate, err := parseDateParam(c.Query("endDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsChargesBulkExportV1", CarsChargesBulkExportError2, err.Error())
+		return
+	}
+
+	// Charge struct - one row of the export, the fields of /cars/<CarID>/charges flattened
+	type Charge struct {
+		CarID             int     `json:"car_id"`
+		ChargeID          int     `json:"charge_id"`
+		StartDate         string  `json:"start_date"`
+		EndDate           string  `json:"end_date"`
+		Address           string  `json:"address"`
+		ChargeEnergyAdded float64 `json:"charge_energy_added"`
+		ChargeEnergyUsed  float64 `json:"charge_energy_used"`
+		Cost              float64 `json:"cost"`
+		DurationMin       int     `json:"duration_min"`
+		DurationStr       string  `json:"duration_str"`
+		StartBatteryLevel int     `json:"start_battery_level"`
+		EndBatteryLevel   int     `json:"end_battery_level"`
+		RangeIdealStart   float64 `json:"range_ideal_start"`
+		RangeIdealEnd     float64 `json:"range_ideal_end"`
+		RangeRatedStart   float64 `json:"range_rated_start"`
+		RangeRatedEnd     float64 `json:"range_rated_end"`
+		OutsideTempAvg    float64 `json:"outside_temp_avg"`
+		Odometer          float64 `json:"odometer"`
+		Latitude          float64 `json:"latitude"`
+		Longitude         float64 `json:"longitude"`
+		UnitsLength       string  `json:"unit_of_length"`
+		UnitsTemperature  string  `json:"unit_of_temperature"`
+	}
+
+	// cursors only exist within a transaction
+	tx, err := db.BeginTx(c.Request.Context(), pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesBulkExportV1", CarsChargesBulkExportError1, err.Error())
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+
+	writer := newBulkExportWriter[Charge](c, ExportFormat, fmt.Sprintf("teslamate-car%d-charges", CarID))
+
+	// streaming charges batch by batch
+	err = readExportCursor(c.Request.Context(), instrumentDB(tx), "export_charges", func(q *teslamatedb.Queries) ([]teslamatedb.ExportChargesRow, error) {
+		return q.ExportCharges(c.Request.Context(), teslamatedb.ExportChargesParams{
+			CarID:     int16(CarID),
+			StartDate: parsedStartDate,
+			EndDate:   parsedEndDate,
+		})
+	}, func(rows []teslamatedb.ExportChargesRow) error {
+		for _, row := range rows {
+			charge := Charge{
+				CarID:             CarID,
+				ChargeID:          int(row.ChargeID),
+				Address:           row.Address,
+				ChargeEnergyAdded: row.ChargeEnergyAdded,
+				ChargeEnergyUsed:  row.ChargeEnergyUsed,
+				Cost:              row.Cost,
+				DurationMin:       int(row.DurationMin.Int16),
+				DurationStr:       row.DurationStr,
+				StartBatteryLevel: int(row.StartBatteryLevel.Int16),
+				EndBatteryLevel:   int(row.EndBatteryLevel.Int16),
+				RangeIdealStart:   row.StartIdealRange.Float64,
+				RangeIdealEnd:     row.EndIdealRange.Float64,
+				RangeRatedStart:   row.StartRatedRange.Float64,
+				RangeRatedEnd:     row.EndRatedRange.Float64,
+				OutsideTempAvg:    row.OutsideTempAvg.Float64,
+				Odometer:          row.Odometer.Float64,
+				Latitude:          row.Latitude.Float64,
+				Longitude:         row.Longitude.Float64,
+				UnitsLength:       row.UnitOfLength,
+				UnitsTemperature:  row.UnitOfTemperature,
+			}
+
+			// converting values based of settings UnitsLength
+			if charge.UnitsLength == "mi" {
+				charge.RangeIdealStart = kilometersToMiles(charge.RangeIdealStart)
+				charge.RangeIdealEnd = kilometersToMiles(charge.RangeIdealEnd)
+				charge.RangeRatedStart = kilometersToMiles(charge.RangeRatedStart)
+				charge.RangeRatedEnd = kilometersToMiles(charge.RangeRatedEnd)
+				charge.Odometer = kilometersToMiles(charge.Odometer)
+			}
+			// converting values based of settings UnitsTemperature
+			if charge.UnitsTemperature == "F" {
+				charge.OutsideTempAvg = celsiusToFahrenheit(charge.OutsideTempAvg)
+			}
+
+			// adjusting to timezone differences from UTC to be userspecific
+			charge.StartDate = getTimeInTimeZone(row.StartDate)
+			charge.EndDate = getTimeInTimeZone(row.EndDate.Time)
+
+			if err := writer.Write(charge); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+
+	// checking for errors, once streaming started the response can only be cut short
+	if err != nil {
+		if !writer.started {
+			TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesBulkExportV1", CarsChargesBulkExportError1, err.Error())
+			return
+		}
+		log.Println("[error] TeslaMateAPICarsChargesBulkExportV1 - (" + c.Request.RequestURI + "). " + CarsChargesBulkExportError1 + "; " + err.Error())
+		return
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		log.Println("[warning] TeslaMateAPICarsChargesBulkExportV1 - (" + c.Request.RequestURI + "). unable to commit transaction; " + err.Error())
+	}
+	log.Println("[info] TeslaMateAPICarsChargesBulkExportV1 - (" + c.Request.RequestURI + ") executed successfully.")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var exportChargesColumns = []string{
+	"charge_id", "start_date", "end_date", "address", "charge_energy_added", "charge_energy_used", "cost",
+	"start_ideal_range", "end_ideal_range", "start_rated_range", "end_rated_range",
+	"start_battery_level", "end_battery_level", "duration_min", "duration_str", "outside_temp_avg",
+	"odometer", "latitude", "longitude", "unit_of_length", "unit_of_temperature", "car_name",
+}
+
+// exportChargesRows returns n charges starting with charge id first
+func exportChargesRows(first int, n int, unitOfLength string, unitOfTemperature string) *pgxmock.Rows {
+	rows := pgxmock.NewRows(exportChargesColumns)
+	startDate := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		rows.AddRow([]any{
+			int64(first + i), startDate, startDate.Add(90 * time.Minute), "Home", 30.5, 33.0, 7.5,
+			100.0, 300.0, 110.0, 310.0,
+			int64(20), int64(80), int64(90), "01:30", 10.0,
+			1000.0, 52.52, 13.405, unitOfLength, unitOfTemperature, "Test Tesla",
+		}...)
+	}
+	return rows
+}
+
+func TestTeslaMateAPICarsChargesBulkExportV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	originalDB := db
+	db = mock
+	defer func() { db = originalDB }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/charges/export", TeslaMateAPICarsChargesBulkExportV1)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("CSV in batches", func(t *testing.T) {
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("DECLARE export_charges NO SCROLL CURSOR FOR -- name: ExportCharges").
+			WithArgs(int16(2), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM export_charges").WillReturnRows(exportChargesRows(1, exportCursorBatchSize, "mi", "F"))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM export_charges").WillReturnRows(exportChargesRows(501, 1, "mi", "F"))
+		mock.ExpectExec("CLOSE export_charges").WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectCommit()
+
+		w := request("/api/v1/cars/2/charges/export?endDate=2024-06-01T00:00:00Z")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="teslamate-car2-charges.csv"` {
+			t.Errorf("Unexpected Content-Disposition '%s'", cd)
+		}
+
+		records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		if err != nil {
+			t.Fatalf("Invalid CSV: %v", err)
+		}
+		if len(records) != 1+exportCursorBatchSize+1 {
+			t.Fatalf("Expected header and %d charges, got %d records", exportCursorBatchSize+1, len(records))
+		}
+		column := map[string]int{}
+		for i, name := range records[0] {
+			column[name] = i
+		}
+		last := records[len(records)-1]
+		for name, expected := range map[string]string{
+			"car_id":              "2",
+			"charge_id":           "501",
+			"end_date":            "2024-05-01T19:30:00Z",
+			"address":             "Home",
+			"charge_energy_added": "30.5",
+			"duration_str":        "01:30",
+			"odometer":            "621.37119223733",
+			"outside_temp_avg":    "50",
+			"unit_of_temperature": "F",
+		} {
+			if got := last[column[name]]; got != expected {
+				t.Errorf("Expected %s to be '%s', got '%s'", name, expected, got)
+			}
+		}
+	})
+
+	t.Run("NDJSON rows", func(t *testing.T) {
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("DECLARE export_charges").WithArgs(int16(2), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM export_charges").WillReturnRows(exportChargesRows(3, 2, "km", "C"))
+		mock.ExpectExec("CLOSE export_charges").WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectCommit()
+
+		w := request("/api/v1/cars/2/charges/export?format=ndjson")
+		if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Expected application/x-ndjson, got '%s'", ct)
+		}
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines, got %d: %s", len(lines), w.Body.String())
+		}
+		var charge map[string]any
+		if err := json.Unmarshal([]byte(lines[1]), &charge); err != nil {
+			t.Fatalf("Invalid JSON line: %v", err)
+		}
+		if charge["charge_id"] != float64(4) || charge["range_rated_end"] != 310.0 || charge["latitude"] != 52.52 {
+			t.Errorf("Unexpected charge: %v", charge)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("Error before streaming", func(t *testing.T) {
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("DECLARE export_charges").WithArgs(int16(2), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(errors.New("boom"))
+		mock.ExpectRollback()
+
+		w := request("/api/v1/cars/2/charges/export")
+		if body := w.Body.String(); body != `{"error":"Unable to load charges."}` {
+			t.Errorf("Expected legacy error body, got: %s", body)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		w := request("/api/v1/cars/2/charges/export?startDate=yesterday")
+		if body := w.Body.String(); body != `{"error":"Invalid date format."}` {
+			t.Errorf("Expected legacy error body, got: %s", body)
+		}
+	})
+}
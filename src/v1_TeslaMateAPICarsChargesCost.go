@@ -0,0 +1,159 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPICarsChargesCostV1 func
+func TeslaMateAPICarsChargesCostV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsChargesCostError1 = "Unable to load charge."
+		CarsChargesCostError2 = "Unable to load charge details."
+		CarsChargesCostError3 = "No tariffs configured."
+		CarsChargesCostError4 = "Unable to write charge cost."
+		CarsChargesCostError5 = "Charge is not covered by the tariffs."
+	)
+
+	// writing the cost needs TARIFFS_WRITE_COST and authentication
+	if c.Request.Method == http.MethodPut {
+		if !getEnvAsBool("TARIFFS_WRITE_COST", false) {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, "TeslaMateAPICarsChargesCostV1", "You are not allowed to write charge costs", "TARIFFS_WRITE_COST is not true")
+			return
+		}
+		validToken, errorMessage := validateAuthToken(c)
+		if !validToken {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusUnauthorized, "TeslaMateAPICarsChargesCostV1", errorMessage, "invalid or missing bearer token")
+			return
+		}
+	}
+
+	if len(tariffs) == 0 {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsChargesCostV1", CarsChargesCostError3, "TARIFFS_CONFIG is not set or contains no tariffs")
+		return
+	}
+
+	// getting CarID and ChargeID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+	ChargeID := convertStringToInteger(c.Param("ChargeID"))
+
+	// creating structs for /cars/<CarID>/charges/<ChargeID>/cost
+	// Car struct - child of Data
+	type Car struct {
+		CarID   int        `json:"car_id"`   // smallint
+		CarName NullString `json:"car_name"` // text (nullable)
+	}
+	// Charge struct - child of Data
+	type Charge struct {
+		ChargeID          int        `json:"charge_id"`           // int
+		StartDate         string     `json:"start_date"`          // string
+		EndDate           string     `json:"end_date"`            // string
+		GeofenceID        *int       `json:"geofence_id"`         // int (nullable)
+		GeofenceName      NullString `json:"geofence_name"`       // string (nullable)
+		ChargeEnergyAdded float64    `json:"charge_energy_added"` // float64
+		Cost              *float64   `json:"cost"`                // float64 (nullable)
+	}
+	// Data struct - child of JSONData
+	type Data struct {
+		Car         Car        `json:"car"`
+		Charge      Charge     `json:"charge"`
+		TariffCost  tariffCost `json:"tariff_cost"`
+		CostWritten bool       `json:"cost_written"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// getting data from database
+	row, err := queries.GetChargeTariffDetails(c.Request.Context(), teslamatedb.GetChargeTariffDetailsParams{
+		CarID: int16(CarID),
+		ID:    int32(ChargeID),
+	})
+
+	switch err {
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsChargesCostV1", "No rows were returned!", err.Error())
+		return
+	case nil:
+		// nothing wrong.. continuing
+		break
+	default:
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesCostV1", CarsChargesCostError1, err.Error())
+		return
+	}
+
+	// getting the energy added over time of the charge
+	details, err := queries.ListChargeDetails(c.Request.Context(), int32(ChargeID))
+
+	// checking for errors in query
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesCostV1", CarsChargesCostError2, err.Error())
+		return
+	}
+
+	// the charge starts without energy added, a charge without details is priced at its start
+	samples := []tariffSample{{Date: row.StartDate}}
+	for _, detail := range details {
+		samples = append(samples, tariffSample{Date: detail.Date, EnergyAdded: detail.ChargeEnergyAdded})
+	}
+	if len(details) == 0 {
+		samples = append(samples, tariffSample{Date: row.StartDate, EnergyAdded: row.ChargeEnergyAdded})
+	}
+
+	jsonData := JSONData{
+		Data{
+			Car: Car{
+				CarID:   CarID,
+				CarName: NullString(row.CarName.String),
+			},
+			Charge: Charge{
+				ChargeID:          int(row.ChargeID),
+				StartDate:         getTimeInTimeZone(row.StartDate),
+				EndDate:           getTimeInTimeZone(row.EndDate.Time),
+				GeofenceName:      NullString(row.GeofenceName.String),
+				ChargeEnergyAdded: row.ChargeEnergyAdded,
+			},
+			TariffCost: calculateChargeCost(tariffs, int(row.GeofenceID.Int32), row.StartDate, samples, appUsersTimezone),
+		},
+	}
+	if row.GeofenceID.Valid {
+		geofenceID := int(row.GeofenceID.Int32)
+		jsonData.Data.Charge.GeofenceID = &geofenceID
+	}
+	if row.Cost.Valid {
+		jsonData.Data.Charge.Cost = &row.Cost.Float64
+	}
+
+	// writing the calculated cost back to TeslaMate
+	if c.Request.Method == http.MethodPut {
+		tariffCost := jsonData.Data.TariffCost
+		if tariffCost.Tariff == "" || tariffCost.UnpricedEnergy > 0 {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusUnprocessableEntity, "TeslaMateAPICarsChargesCostV1", CarsChargesCostError5, "the charge has energy without a matching tariff")
+			return
+		}
+
+		updated, err := queries.UpdateChargeCost(c.Request.Context(), teslamatedb.UpdateChargeCostParams{
+			Cost:  pgtype.Float8{Float64: tariffCost.Cost, Valid: true},
+			CarID: int16(CarID),
+			ID:    int32(ChargeID),
+		})
+		if err != nil {
+			TeslaMateAPIHandleOtherErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesCostV1", CarsChargesCostError4, err.Error())
+			return
+		}
+		writeAuditRecord(c, CarID, "charge", ChargeID, map[string]auditChange{"cost": {Old: auditValue(updated.OldCost), New: auditValue(updated.NewCost)}})
+		jsonData.Data.Charge.Cost = &tariffCost.Cost
+		jsonData.Data.CostWritten = true
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsChargesCostV1", jsonData)
+}
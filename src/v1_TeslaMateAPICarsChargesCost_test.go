@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeChargeCostQuerier returns charge 7 at geofence 3 adding 10 kWh, all other queries are not implemented
+type fakeChargeCostQuerier struct {
+	teslamatedb.Querier
+	updateParams *teslamatedb.UpdateChargeCostParams
+}
+
+func (q *fakeChargeCostQuerier) GetChargeTariffDetails(ctx context.Context, arg teslamatedb.GetChargeTariffDetailsParams) (teslamatedb.GetChargeTariffDetailsRow, error) {
+	if arg.ID != 7 {
+		return teslamatedb.GetChargeTariffDetailsRow{}, pgx.ErrNoRows
+	}
+	return teslamatedb.GetChargeTariffDetailsRow{
+		ChargeID:          7,
+		StartDate:         time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC),
+		EndDate:           pgtype.Timestamp{Time: time.Date(2024, 5, 1, 21, 0, 0, 0, time.UTC), Valid: true},
+		GeofenceID:        pgtype.Int4{Int32: 3, Valid: true},
+		GeofenceName:      pgtype.Text{String: "Home", Valid: true},
+		ChargeEnergyAdded: 10,
+		CarName:           pgtype.Text{String: "Test Tesla", Valid: true},
+	}, nil
+}
+
+func (q *fakeChargeCostQuerier) ListChargeDetails(ctx context.Context, chargingProcessID int32) ([]teslamatedb.ListChargeDetailsRow, error) {
+	return []teslamatedb.ListChargeDetailsRow{
+		{Date: time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC), ChargeEnergyAdded: 5},
+		{Date: time.Date(2024, 5, 1, 21, 0, 0, 0, time.UTC), ChargeEnergyAdded: 10},
+	}, nil
+}
+
+func (q *fakeChargeCostQuerier) UpdateChargeCost(ctx context.Context, arg teslamatedb.UpdateChargeCostParams) (teslamatedb.UpdateChargeCostRow, error) {
+	q.updateParams = &arg
+	return teslamatedb.UpdateChargeCostRow{NewCost: arg.Cost}, nil
+}
+
+func TestTeslaMateAPICarsChargesCostV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("Europe/Berlin")
+	defer func() { appUsersTimezone, _ = time.LoadLocation("UTC") }()
+
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalTariffs, originalSecret := tariffs, envTokenSecret
+	defer func() { tariffs, envTokenSecret = originalTariffs, originalSecret }()
+	tariffs, _ = parseTariffs([]byte(testTariffs), appUsersTimezone)
+	setEnvToken(envToken)
+
+	fake := &fakeChargeCostQuerier{}
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/charges/:ChargeID/cost", TeslaMateAPICarsChargesCostV1)
+	router.PUT("/api/v1/cars/:CarID/charges/:ChargeID/cost", TeslaMateAPICarsChargesCostV1)
+
+	request := func(method string, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+envToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	var resp struct {
+		Data struct {
+			Charge struct {
+				GeofenceID *int     `json:"geofence_id"`
+				Cost       *float64 `json:"cost"`
+			} `json:"charge"`
+			TariffCost  tariffCost `json:"tariff_cost"`
+			CostWritten bool       `json:"cost_written"`
+		} `json:"data"`
+	}
+
+	t.Run("Cost breakdown", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/cars/1/charges/7/cost")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		// 21:00 to 23:00 in Berlin, the night rate starts at 22:00
+		if cost := resp.Data.TariffCost; cost.Tariff != "home" || len(cost.Windows) != 2 || cost.Cost != 2.5 {
+			t.Errorf("Unexpected tariff cost: %+v", cost)
+		}
+		if resp.Data.Charge.GeofenceID == nil || *resp.Data.Charge.GeofenceID != 3 || resp.Data.Charge.Cost != nil || resp.Data.CostWritten {
+			t.Errorf("Unexpected charge: %+v", resp.Data.Charge)
+		}
+		if fake.updateParams != nil {
+			t.Errorf("Expected no cost to be written")
+		}
+	})
+
+	t.Run("Writing needs TARIFFS_WRITE_COST", func(t *testing.T) {
+		w := request(http.MethodPut, "/api/v1/cars/1/charges/7/cost")
+		if w.Code != http.StatusForbidden || fake.updateParams != nil {
+			t.Errorf("Expected status 403 without writing, got %d", w.Code)
+		}
+	})
+
+	t.Run("Write cost", func(t *testing.T) {
+		t.Setenv("TARIFFS_WRITE_COST", "true")
+		w := request(http.MethodPut, "/api/v1/cars/1/charges/7/cost")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if fake.updateParams == nil || fake.updateParams.Cost.Float64 != 2.5 || fake.updateParams.CarID != 1 || fake.updateParams.ID != 7 {
+			t.Errorf("Unexpected update: %+v", fake.updateParams)
+		}
+		if !resp.Data.CostWritten || resp.Data.Charge.Cost == nil || *resp.Data.Charge.Cost != 2.5 {
+			t.Errorf("Expected the written cost in the response, got %+v", resp.Data)
+		}
+	})
+
+	t.Run("Writing needs authentication", func(t *testing.T) {
+		t.Setenv("TARIFFS_WRITE_COST", "true")
+		req, _ := http.NewRequest(http.MethodPut, "/api/v1/cars/1/charges/7/cost", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
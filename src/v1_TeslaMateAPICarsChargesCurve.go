@@ -0,0 +1,159 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPICarsChargesCurveV1 func
+func TeslaMateAPICarsChargesCurveV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsChargesCurveError1 = "Unable to load charge."
+		CarsChargesCurveError2 = "Unable to load charge curve."
+	)
+
+	// getting CarID and ChargeID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+	ChargeID := convertStringToInteger(c.Param("ChargeID"))
+	// fast charger type to compare the charge with
+	FastChargerType := c.Query("fast_charger_type")
+
+	// creating structs for /cars/<CarID>/charges/<ChargeID>/curve
+	// Car struct - child of Data
+	type Car struct {
+		CarID   int        `json:"car_id"`   // smallint
+		CarName NullString `json:"car_name"` // text (nullable)
+	}
+	// Charge struct - child of Data
+	type Charge struct {
+		ChargeID          int     `json:"charge_id"`           // int
+		StartDate         string  `json:"start_date"`          // string
+		EndDate           string  `json:"end_date"`            // string
+		Address           string  `json:"address"`             // string
+		ChargeEnergyAdded float64 `json:"charge_energy_added"` // float64
+	}
+	// CurveValues struct - child of CurvePoint
+	type CurveValues struct {
+		Min float64 `json:"min"` // float64
+		Avg float64 `json:"avg"` // float64
+		Max float64 `json:"max"` // float64
+	}
+	// CurvePoint struct - child of Data and AggregateCurve
+	type CurvePoint struct {
+		BatteryLevel         int         `json:"battery_level"`          // int
+		Sessions             int         `json:"sessions"`               // int
+		Samples              int         `json:"samples"`                // int
+		ChargerPower         CurveValues `json:"charger_power"`          // CurveValues (kW)
+		ChargerActualCurrent CurveValues `json:"charger_actual_current"` // CurveValues (A)
+		ChargerVoltage       CurveValues `json:"charger_voltage"`        // CurveValues (V)
+	}
+	// AggregateCurve struct - child of Data
+	type AggregateCurve struct {
+		FastChargerType string       `json:"fast_charger_type"` // string
+		Curve           []CurvePoint `json:"curve"`             // []CurvePoint
+	}
+	// Data struct - child of JSONData
+	type Data struct {
+		Car            Car             `json:"car"`
+		Charge         Charge          `json:"charge"`
+		Curve          []CurvePoint    `json:"curve"`
+		AggregateCurve *AggregateCurve `json:"aggregate_curve,omitempty"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// toCurve func - converts the rows of ListChargeCurve and ListFastChargeCurve
+	toCurve := func(rows []teslamatedb.ListChargeCurveRow) []CurvePoint {
+		curve := []CurvePoint{}
+		for _, row := range rows {
+			curve = append(curve, CurvePoint{
+				BatteryLevel:         int(row.BatteryLevel),
+				Sessions:             int(row.Sessions),
+				Samples:              int(row.Samples),
+				ChargerPower:         CurveValues{row.ChargerPowerMin, row.ChargerPowerAvg, row.ChargerPowerMax},
+				ChargerActualCurrent: CurveValues{row.ChargerActualCurrentMin, row.ChargerActualCurrentAvg, row.ChargerActualCurrentMax},
+				ChargerVoltage:       CurveValues{row.ChargerVoltageMin, row.ChargerVoltageAvg, row.ChargerVoltageMax},
+			})
+		}
+		return curve
+	}
+
+	// getting data from database
+	row, err := queries.GetCharge(c.Request.Context(), teslamatedb.GetChargeParams{
+		CarID: int16(CarID),
+		ID:    int32(ChargeID),
+	})
+
+	switch err {
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsChargesCurveV1", "No rows were returned!", err.Error())
+		return
+	case nil:
+		// nothing wrong.. continuing
+		break
+	default:
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesCurveV1", CarsChargesCurveError1, err.Error())
+		return
+	}
+
+	// getting the curve of the charge from database
+	curve, err := queries.ListChargeCurve(c.Request.Context(), int32(ChargeID))
+
+	// checking for errors in query
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesCurveV1", CarsChargesCurveError2, err.Error())
+		return
+	}
+
+	jsonData := JSONData{
+		Data{
+			Car: Car{
+				CarID:   CarID,
+				CarName: NullString(row.CarName.String),
+			},
+			Charge: Charge{
+				ChargeID:          int(row.ChargeID),
+				StartDate:         getTimeInTimeZone(row.StartDate),
+				EndDate:           getTimeInTimeZone(row.EndDate.Time),
+				Address:           row.Address,
+				ChargeEnergyAdded: row.ChargeEnergyAdded,
+			},
+			Curve: toCurve(curve),
+		},
+	}
+
+	// getting the curve of all fast charges of the car at the fast charger type
+	if FastChargerType != "" {
+		aggregate, err := queries.ListFastChargeCurve(c.Request.Context(), teslamatedb.ListFastChargeCurveParams{
+			CarID:           int16(CarID),
+			FastChargerType: pgtype.Text{String: FastChargerType, Valid: true},
+		})
+
+		// checking for errors in query
+		if err != nil {
+			TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesCurveV1", CarsChargesCurveError2, err.Error())
+			return
+		}
+
+		rows := make([]teslamatedb.ListChargeCurveRow, len(aggregate))
+		for i, row := range aggregate {
+			rows[i] = teslamatedb.ListChargeCurveRow(row)
+		}
+		jsonData.Data.AggregateCurve = &AggregateCurve{
+			FastChargerType: FastChargerType,
+			Curve:           toCurve(rows),
+		}
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsChargesCurveV1", jsonData)
+}
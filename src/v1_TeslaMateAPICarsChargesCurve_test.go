@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeChargeCurveQuerier returns charge 7 with a curve of two battery levels, all other queries are not implemented
+type fakeChargeCurveQuerier struct {
+	teslamatedb.Querier
+	fastChargeParams teslamatedb.ListFastChargeCurveParams
+}
+
+func (q *fakeChargeCurveQuerier) GetCharge(ctx context.Context, arg teslamatedb.GetChargeParams) (teslamatedb.GetChargeRow, error) {
+	if arg.ID != 7 {
+		return teslamatedb.GetChargeRow{}, pgx.ErrNoRows
+	}
+	startDate := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	return teslamatedb.GetChargeRow{
+		ChargeID:          7,
+		StartDate:         startDate,
+		EndDate:           pgtype.Timestamp{Time: startDate.Add(30 * time.Minute), Valid: true},
+		Address:           "Supercharger",
+		ChargeEnergyAdded: 42.5,
+		CarName:           pgtype.Text{String: "Test Tesla", Valid: true},
+	}, nil
+}
+
+func (q *fakeChargeCurveQuerier) ListChargeCurve(ctx context.Context, chargingProcessID int32) ([]teslamatedb.ListChargeCurveRow, error) {
+	return []teslamatedb.ListChargeCurveRow{
+		{BatteryLevel: 10, Sessions: 1, Samples: 3, ChargerPowerMin: 140, ChargerPowerAvg: 145, ChargerPowerMax: 150, ChargerActualCurrentMin: 350, ChargerActualCurrentAvg: 360, ChargerActualCurrentMax: 370, ChargerVoltageMin: 390, ChargerVoltageAvg: 395, ChargerVoltageMax: 400},
+		{BatteryLevel: 11, Sessions: 1, Samples: 2, ChargerPowerMin: 148, ChargerPowerAvg: 149, ChargerPowerMax: 150},
+	}, nil
+}
+
+func (q *fakeChargeCurveQuerier) ListFastChargeCurve(ctx context.Context, arg teslamatedb.ListFastChargeCurveParams) ([]teslamatedb.ListFastChargeCurveRow, error) {
+	q.fastChargeParams = arg
+	return []teslamatedb.ListFastChargeCurveRow{
+		{BatteryLevel: 10, Sessions: 4, Samples: 12, ChargerPowerMin: 90, ChargerPowerAvg: 120, ChargerPowerMax: 150},
+	}, nil
+}
+
+func TestTeslaMateAPICarsChargesCurveV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	fake := &fakeChargeCurveQuerier{}
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/charges/:ChargeID/curve", TeslaMateAPICarsChargesCurveV1)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	type curvePoint struct {
+		BatteryLevel int `json:"battery_level"`
+		Sessions     int `json:"sessions"`
+		ChargerPower struct {
+			Min, Avg, Max float64
+		} `json:"charger_power"`
+	}
+	var resp struct {
+		Data struct {
+			Charge struct {
+				ChargeID  int    `json:"charge_id"`
+				StartDate string `json:"start_date"`
+			} `json:"charge"`
+			Curve          []curvePoint `json:"curve"`
+			AggregateCurve *struct {
+				FastChargerType string       `json:"fast_charger_type"`
+				Curve           []curvePoint `json:"curve"`
+			} `json:"aggregate_curve"`
+		} `json:"data"`
+	}
+
+	t.Run("Charge curve", func(t *testing.T) {
+		w := request("/api/v1/cars/1/charges/7/curve")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if resp.Data.Charge.ChargeID != 7 || resp.Data.Charge.StartDate != "2024-05-01T08:00:00Z" {
+			t.Errorf("Unexpected charge: %+v", resp.Data.Charge)
+		}
+		if len(resp.Data.Curve) != 2 || resp.Data.Curve[0].BatteryLevel != 10 || resp.Data.Curve[0].ChargerPower.Avg != 145 {
+			t.Errorf("Unexpected curve: %+v", resp.Data.Curve)
+		}
+		if resp.Data.AggregateCurve != nil || contains(w.Body.String(), "aggregate_curve") {
+			t.Errorf("Expected no aggregate curve without fast_charger_type")
+		}
+	})
+
+	t.Run("Aggregate curve", func(t *testing.T) {
+		w := request("/api/v1/cars/1/charges/7/curve?fast_charger_type=Tesla")
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if fake.fastChargeParams.CarID != 1 || fake.fastChargeParams.FastChargerType.String != "Tesla" {
+			t.Errorf("Unexpected query parameters: %+v", fake.fastChargeParams)
+		}
+		aggregate := resp.Data.AggregateCurve
+		if aggregate == nil || aggregate.FastChargerType != "Tesla" || len(aggregate.Curve) != 1 || aggregate.Curve[0].Sessions != 4 {
+			t.Errorf("Unexpected aggregate curve: %+v", aggregate)
+		}
+	})
+
+	t.Run("Charge not found", func(t *testing.T) {
+		w := request("/api/v1/cars/1/charges/8/curve")
+		if body := w.Body.String(); body != `{"error":"No rows were returned!"}` {
+			t.Errorf("Expected legacy error body, got: %s", body)
+		}
+	})
+}
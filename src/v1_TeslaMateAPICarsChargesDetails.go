@@ -1,10 +1,12 @@
 package main
 
 import (
-	"database/sql"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
 // TeslaMateAPICarsChargesDetailsV1 func
@@ -118,78 +120,53 @@ func TeslaMateAPICarsChargesDetailsV1(c *gin.Context) {
 	)
 
 	// getting data from database
-	query := `
-		SELECT
-			charging_processes.id AS charge_id,
-			start_date,
-			end_date,
-			COALESCE(geofence.name, CONCAT_WS(', ', COALESCE(address.name, nullif(CONCAT_WS(' ', address.road, address.house_number), '')), address.city)) AS address,
-			COALESCE(charging_processes.charge_energy_added, 0) AS charge_energy_added,
-			COALESCE(charge_energy_used, 0) AS charge_energy_used,
-			COALESCE(cost, 0) AS cost,
-			start_ideal_range_km AS start_ideal_range,
-			end_ideal_range_km AS end_ideal_range,
-			start_rated_range_km AS start_rated_range,
-			end_rated_range_km AS end_rated_range,
-			start_battery_level,
-			end_battery_level,
-			duration_min,
-			TO_CHAR((duration_min * INTERVAL '1 minute'), 'HH24:MI') as duration_str,
-			outside_temp_avg,
-			position.odometer as odometer,
-			position.latitude,
-			position.longitude,
-			(SELECT unit_of_length FROM settings LIMIT 1) as unit_of_length,
-			(SELECT unit_of_temperature FROM settings LIMIT 1) as unit_of_temperature,
-			cars.name
-		FROM charging_processes
-		LEFT JOIN cars ON car_id = cars.id
-		LEFT JOIN addresses address ON address_id = address.id
-		LEFT JOIN positions position ON position_id = position.id
-		LEFT JOIN geofences geofence ON geofence_id = geofence.id
-		LEFT JOIN charges ON charging_processes.id = charges.id
-		WHERE charging_processes.car_id=$1 AND charging_processes.id=$2 AND charging_processes.end_date IS NOT NULL
-		ORDER BY start_date DESC;`
-	row := db.QueryRow(query, CarID, ChargeID)
-
-	// scanning row and putting values into the charge
-	err := row.Scan(
-		&charge.ChargeID,
-		&charge.StartDate,
-		&charge.EndDate,
-		&charge.Address,
-		&charge.ChargeEnergyAdded,
-		&charge.ChargeEnergyUsed,
-		&charge.Cost,
-		&charge.RangeIdeal.StartRange,
-		&charge.RangeIdeal.EndRange,
-		&charge.RangeRated.StartRange,
-		&charge.RangeRated.EndRange,
-		&charge.BatteryDetails.StartBatteryLevel,
-		&charge.BatteryDetails.EndBatteryLevel,
-		&charge.DurationMin,
-		&charge.DurationStr,
-		&charge.OutsideTempAvg,
-		&charge.Odometer,
-		&charge.Latitude,
-		&charge.Longitude,
-		&UnitsLength,
-		&UnitsTemperature,
-		&CarName,
-	)
+	row, err := queries.GetCharge(c.Request.Context(), teslamatedb.GetChargeParams{
+		CarID: int16(CarID),
+		ID:    int32(ChargeID),
+	})
 
 	switch err {
-	case sql.ErrNoRows:
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesDetailsV1", "No rows were returned!", err.Error())
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsChargesDetailsV1", "No rows were returned!", err.Error())
 		return
 	case nil:
 		// nothing wrong.. continuing
 		break
 	default:
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesDetailsV1", CarsChargesDetailsError1, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesDetailsV1", CarsChargesDetailsError1, err.Error())
 		return
 	}
 
+	// putting values into the charge
+	charge = Charge{
+		ChargeID:          int(row.ChargeID),
+		Address:           row.Address,
+		ChargeEnergyAdded: row.ChargeEnergyAdded,
+		ChargeEnergyUsed:  row.ChargeEnergyUsed,
+		Cost:              row.Cost,
+		DurationMin:       int(row.DurationMin.Int16),
+		DurationStr:       row.DurationStr,
+		BatteryDetails: BatteryDetails{
+			StartBatteryLevel: int(row.StartBatteryLevel.Int16),
+			EndBatteryLevel:   int(row.EndBatteryLevel.Int16),
+		},
+		RangeIdeal: PreferredRange{
+			StartRange: row.StartIdealRange.Float64,
+			EndRange:   row.EndIdealRange.Float64,
+		},
+		RangeRated: PreferredRange{
+			StartRange: row.StartRatedRange.Float64,
+			EndRange:   row.EndRatedRange.Float64,
+		},
+		OutsideTempAvg: row.OutsideTempAvg.Float64,
+		Odometer:       row.Odometer.Float64,
+		Latitude:       row.Latitude.Float64,
+		Longitude:      row.Longitude.Float64,
+	}
+	UnitsLength = row.UnitOfLength
+	UnitsTemperature = row.UnitOfTemperature
+	CarName = NullString(row.CarName.String)
+
 	// converting values based of settings UnitsLength
 	if UnitsLength == "mi" {
 		charge.RangeIdeal.StartRange = kilometersToMiles(charge.RangeIdeal.StartRange)
@@ -204,77 +181,50 @@ func TeslaMateAPICarsChargesDetailsV1(c *gin.Context) {
 	}
 
 	// adjusting to timezone differences from UTC to be userspecific
-	charge.StartDate = getTimeInTimeZone(charge.StartDate)
-	charge.EndDate = getTimeInTimeZone(charge.EndDate)
+	charge.StartDate = getTimeInTimeZone(row.StartDate)
+	charge.EndDate = getTimeInTimeZone(row.EndDate.Time)
 
 	// getting detailed charge data from database
-	query = `
- 			SELECT
-				id AS detail_id,
-				date,
-				battery_level,
-				usable_battery_level,
-				charge_energy_added,
-				not_enough_power_to_heat,
-				COALESCE(charger_actual_current, 0) as charger_actual_current,
-				COALESCE(charger_phases, 0) AS charger_phases,
-				COALESCE(charger_pilot_current, 0) as charger_pilot_current,
-				COALESCE(charger_power, 0) as charger_power,
-				COALESCE(charger_voltage, 0) as charger_voltage,
-				ideal_battery_range_km AS ideal_battery_range,
-				rated_battery_range_km AS rated_battery_range,
-				battery_heater,
-				battery_heater_on,
-				battery_heater_no_power,
-				conn_charge_cable,
-				fast_charger_present,
-				fast_charger_brand,
-				fast_charger_type,
-				outside_temp
-			FROM charges
-			WHERE charging_process_id=$1
-			ORDER BY id ASC;`
-	rows, err := db.Query(query, ChargeID)
+	details, err := queries.ListChargeDetails(c.Request.Context(), int32(ChargeID))
 
 	// checking for errors in query
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesDetailsV1", CarsChargesDetailsError2, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesDetailsV1", CarsChargesDetailsError2, err.Error())
 		return
 	}
 
-	// defer closing rows
-	defer rows.Close()
-
 	// looping through all results
-	for rows.Next() {
+	for _, detail := range details {
 
 		// creating chargedetails object based on struct
-		chargedetails := ChargeDetails{}
-
-		// scanning row and putting values into the drive
-		err = rows.Scan(
-			&chargedetails.DetailID,
-			&chargedetails.Date,
-			&chargedetails.BatteryLevel,
-			&chargedetails.UsableBatteryLevel,
-			&chargedetails.ChargeEnergyAdded,
-			&chargedetails.NotEnoughPowerToHeat,
-			&chargedetails.ChargerDetails.ChargerActualCurrent,
-			&chargedetails.ChargerDetails.ChargerPhases,
-			&chargedetails.ChargerDetails.ChargerPilotCurrent,
-			&chargedetails.ChargerDetails.ChargerPower,
-			&chargedetails.ChargerDetails.ChargerVoltage,
-			&chargedetails.BatteryInfo.IdealBatteryRange,
-			&chargedetails.BatteryInfo.RatedBatteryRange,
-			&chargedetails.BatteryInfo.BatteryHeater,
-			&chargedetails.BatteryInfo.BatteryHeaterOn,
-			&chargedetails.BatteryInfo.BatteryHeaterNoPower,
-			&chargedetails.ConnChargeCable,
-			&chargedetails.FastChargerInfo.FastChargerPresent,
-			&chargedetails.FastChargerInfo.FastChargerBrand,
-			&chargedetails.FastChargerInfo.FastChargerType,
-			&chargedetails.OutsideTemp,
-		)
+		chargedetails := ChargeDetails{
+			DetailID:             int(detail.DetailID),
+			BatteryLevel:         int(detail.BatteryLevel.Int16),
+			UsableBatteryLevel:   int(detail.UsableBatteryLevel.Int16),
+			ChargeEnergyAdded:    detail.ChargeEnergyAdded,
+			NotEnoughPowerToHeat: NullBool{detail.NotEnoughPowerToHeat},
+			ChargerDetails: ChargerDetails{
+				ChargerActualCurrent: int(detail.ChargerActualCurrent),
+				ChargerPhases:        int(detail.ChargerPhases),
+				ChargerPilotCurrent:  int(detail.ChargerPilotCurrent),
+				ChargerPower:         int(detail.ChargerPower),
+				ChargerVoltage:       int(detail.ChargerVoltage),
+			},
+			BatteryInfo: BatteryInfo{
+				IdealBatteryRange:    detail.IdealBatteryRange.Float64,
+				RatedBatteryRange:    detail.RatedBatteryRange.Float64,
+				BatteryHeater:        detail.BatteryHeater.Bool,
+				BatteryHeaterOn:      detail.BatteryHeaterOn.Bool,
+				BatteryHeaterNoPower: NullBool{detail.BatteryHeaterNoPower},
+			},
+			ConnChargeCable: detail.ConnChargeCable.String,
+			FastChargerInfo: FastChargerInfo{
+				FastChargerPresent: detail.FastChargerPresent.Bool,
+				FastChargerBrand:   NullString(detail.FastChargerBrand.String),
+				FastChargerType:    detail.FastChargerType.String,
+			},
+			OutsideTemp: detail.OutsideTemp.Float64,
+		}
 
 		// converting values based of settings UnitsLength
 		if UnitsLength == "mi" {
@@ -287,26 +237,13 @@ func TeslaMateAPICarsChargesDetailsV1(c *gin.Context) {
 			chargedetails.OutsideTemp = celsiusToFahrenheit(chargedetails.OutsideTemp)
 		}
 		// adjusting to timezone differences from UTC to be userspecific
-		chargedetails.Date = getTimeInTimeZone(chargedetails.Date)
-
-		// checking for errors after scanning
-		if err != nil {
-			TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesDetailsV1", CarsChargesDetailsError2, err.Error())
-			return
-		}
+		chargedetails.Date = getTimeInTimeZone(detail.Date)
 
 		// appending drive to ChargeData
 		ChargeDetailsData = append(ChargeDetailsData, chargedetails)
 		charge.ChargeDetails = ChargeDetailsData
 	}
 
-	// checking for errors in the rows result
-	err = rows.Err()
-	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsChargesDetailsV1", CarsChargesDetailsError2, err.Error())
-		return
-	}
-
 	//
 	// build the data-blob
 	jsonData := JSONData{
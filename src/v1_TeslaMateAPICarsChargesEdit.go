@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// chargeCostMax is the largest cost charging_processes.cost (numeric(6,2)) can store
+const chargeCostMax = 9999.99
+
+// TeslaMateAPICarsChargesEditV1 func
+func TeslaMateAPICarsChargesEditV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsChargesEditError1 = "Unable to update charge."
+		CarsChargesEditError2 = "Invalid charge update."
+	)
+
+	// edits need ENABLE_EDITS and authentication
+	if !checkEditPermission(c, "TeslaMateAPICarsChargesEditV1") {
+		return
+	}
+
+	// getting CarID and ChargeID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+	ChargeID := convertStringToInteger(c.Param("ChargeID"))
+
+	// creating structs for /cars/<CarID>/charges/<ChargeID>
+	// Car struct - child of Data
+	type Car struct {
+		CarID int `json:"car_id"` // smallint
+	}
+	// Charge struct - child of Data
+	type Charge struct {
+		ChargeID int      `json:"charge_id"` // int
+		Cost     *float64 `json:"cost"`      // float64 (nullable)
+	}
+	// Data struct - child of JSONData
+	type Data struct {
+		Car     Car                    `json:"car"`
+		Charge  Charge                 `json:"charge"`
+		Changes map[string]auditChange `json:"changes"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// validating the body, only the cost of a charge can be edited
+	fields, err := parsePatchBody(c, "cost")
+	if err != nil {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsChargesEditV1", CarsChargesEditError2, err.Error())
+		return
+	}
+	var cost pgtype.Float8
+	if !isJSONNull(fields["cost"]) {
+		if err := json.Unmarshal(fields["cost"], &cost.Float64); err != nil || cost.Float64 < 0 || cost.Float64 > chargeCostMax {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsChargesEditV1", CarsChargesEditError2, "cost has to be null or a number between 0 and 9999.99")
+			return
+		}
+		cost.Float64 = math.Round(cost.Float64*100) / 100
+		cost.Valid = true
+	}
+
+	// updating the charge in database
+	row, err := queries.UpdateChargeCost(c.Request.Context(), teslamatedb.UpdateChargeCostParams{
+		Cost:  cost,
+		CarID: int16(CarID),
+		ID:    int32(ChargeID),
+	})
+
+	switch err {
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsChargesEditV1", "No rows were returned!", err.Error())
+		return
+	case nil:
+		// nothing wrong.. continuing
+		break
+	default:
+		TeslaMateAPIHandleOtherErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsChargesEditV1", CarsChargesEditError1, err.Error())
+		return
+	}
+
+	changes := map[string]auditChange{"cost": {Old: auditValue(row.OldCost), New: auditValue(row.NewCost)}}
+	writeAuditRecord(c, CarID, "charge", ChargeID, changes)
+
+	jsonData := JSONData{
+		Data{
+			Car:     Car{CarID: CarID},
+			Charge:  Charge{ChargeID: ChargeID},
+			Changes: changes,
+		},
+	}
+	if row.NewCost.Valid {
+		jsonData.Data.Charge.Cost = &row.NewCost.Float64
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsChargesEditV1", jsonData)
+}
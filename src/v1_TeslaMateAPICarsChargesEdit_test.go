@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeChargeEditQuerier updates the cost of charge 7 that costs 12.5, all other queries are not implemented
+type fakeChargeEditQuerier struct {
+	teslamatedb.Querier
+	updateParams *teslamatedb.UpdateChargeCostParams
+}
+
+func (q *fakeChargeEditQuerier) UpdateChargeCost(ctx context.Context, arg teslamatedb.UpdateChargeCostParams) (teslamatedb.UpdateChargeCostRow, error) {
+	if arg.ID != 7 {
+		return teslamatedb.UpdateChargeCostRow{}, pgx.ErrNoRows
+	}
+	q.updateParams = &arg
+	return teslamatedb.UpdateChargeCostRow{OldCost: pgtype.Float8{Float64: 12.5, Valid: true}, NewCost: arg.Cost}, nil
+}
+
+func TestTeslaMateAPICarsChargesEditV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken(envToken)
+
+	fake := &fakeChargeEditQuerier{}
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.PATCH("/api/v1/cars/:CarID/charges/:ChargeID", TeslaMateAPICarsChargesEditV1)
+
+	request := func(path string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+envToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Edits need ENABLE_EDITS", func(t *testing.T) {
+		w := request("/api/v1/cars/1/charges/7", `{"cost": 10}`)
+		if w.Code != http.StatusForbidden || fake.updateParams != nil {
+			t.Errorf("Expected status 403 without update, got %d", w.Code)
+		}
+	})
+
+	t.Setenv("ENABLE_EDITS", "true")
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	t.Setenv("AUDIT_LOG", auditLog)
+
+	t.Run("Edit cost", func(t *testing.T) {
+		w := request("/api/v1/cars/1/charges/7", `{"cost": 10.456}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if fake.updateParams == nil || fake.updateParams.Cost != (pgtype.Float8{Float64: 10.46, Valid: true}) || fake.updateParams.CarID != 1 {
+			t.Errorf("Unexpected update: %+v", fake.updateParams)
+		}
+		if body := w.Body.String(); !contains(body, `"changes":{"cost":{"old":12.5,"new":10.46}}`) {
+			t.Errorf("Expected old and new cost in the response, got %s", body)
+		}
+
+		data, err := os.ReadFile(auditLog)
+		if err != nil {
+			t.Fatalf("Expected an audit log: %v", err)
+		}
+		var record auditRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			t.Fatalf("Invalid audit record: %v", err)
+		}
+		if record.Identity != "API_TOKEN" || record.Resource != "charge" || record.ResourceID != 7 || record.CarID != 1 || record.Changes["cost"].Old != 12.5 || record.Changes["cost"].New != 10.46 {
+			t.Errorf("Unexpected audit record: %+v", record)
+		}
+	})
+
+	t.Run("Clear cost", func(t *testing.T) {
+		w := request("/api/v1/cars/1/charges/7", `{"cost": null}`)
+		if w.Code != http.StatusOK || fake.updateParams.Cost.Valid {
+			t.Errorf("Expected the cost to be cleared, got %d and %+v", w.Code, fake.updateParams)
+		}
+	})
+
+	t.Run("Invalid updates", func(t *testing.T) {
+		for _, body := range []string{`{"cost": -1}`, `{"cost": "10"}`, `{"cost": 10000}`, `{"address": "home"}`, `{}`, `[]`} {
+			if w := request("/api/v1/cars/1/charges/7", body); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+			}
+		}
+	})
+
+	t.Run("Charge not found", func(t *testing.T) {
+		if w := request("/api/v1/cars/1/charges/8", `{"cost": 1}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
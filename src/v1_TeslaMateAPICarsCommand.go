@@ -1,14 +1,14 @@
 package main
 
 import (
-	"database/sql"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	_ "github.com/lib/pq"
 )
 
@@ -17,45 +17,42 @@ func TeslaMateAPICarsCommandV1(c *gin.Context) {
 
 	// creating required vars
 	var (
-		CarsCommandsError1                                 = "Unable to load cars."
-		TeslaAccessToken, TeslaVehicleID, TeslaEndpointUrl string
-		jsonData                                           map[string]interface{}
-		err                                                error
+		CarsCommandsError1               = "Unable to load cars."
+		TeslaAccessToken, TeslaVehicleID string
+		jsonData                         map[string]interface{}
+		err                              error
 	)
 
 	// check if commands are enabled.. if not we need to abort
 	if !getEnvAsBool("ENABLE_COMMANDS", false) {
-		log.Println("[warning] TeslaMateAPICarsCommandV1 ENABLE_COMMANDS is not true.. returning 403 forbidden.")
-		TeslaMateAPIHandleOtherResponse(c, http.StatusForbidden, "TeslaMateAPICarsCommandV1", gin.H{"error": "You are not allowed to access commands"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, "TeslaMateAPICarsCommandV1", "You are not allowed to access commands", "ENABLE_COMMANDS is not true")
 		return
 	}
 
 	// if request method is GET return list of commands
 	if c.Request.Method == http.MethodGet {
-		TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsCommandV1", gin.H{"enabled_commands": allowList})
+		TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsCommandV1", gin.H{"enabled_commands": commandAllowList.Commands(), "command_schemas": enabledCommandSchemas(), "command_policies": commandAllowList.Policies()})
 		return
 	}
 
 	// authentication for the endpoint
 	validToken, errorMessage := validateAuthToken(c)
 	if !validToken {
-		TeslaMateAPIHandleOtherResponse(c, http.StatusUnauthorized, "TeslaMateAPICarsCommandV1", gin.H{"error": errorMessage})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusUnauthorized, "TeslaMateAPICarsCommandV1", errorMessage, "invalid or missing bearer token")
 		return
 	}
 
 	// getting CarID param from URL and validating that it's not zero
 	CarID := convertStringToInteger(c.Param("CarID"))
 	if CarID == 0 {
-		log.Println("[error] TeslaMateAPICarsCommandV1 CarID is invalid (zero)!")
-		TeslaMateAPIHandleOtherResponse(c, http.StatusBadRequest, "TeslaMateAPICarsCommandV1", gin.H{"error": "CarID invalid"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsCommandV1", "CarID invalid", "CarID is invalid (zero)")
 		return
 	}
 
 	// getting request body to pass to Tesla
 	reqBody, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Println("[error] TeslaMateAPICarsCommandV1 error in first io.ReadAll", err)
-		TeslaMateAPIHandleOtherResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsCommandV1", gin.H{"error": "internal io reading error"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsCommandV1", "internal io reading error", err.Error())
 		return
 	}
 
@@ -66,78 +63,89 @@ func TeslaMateAPICarsCommandV1(c *gin.Context) {
 		command = "/wake_up"
 	}
 
-	if !checkArrayContainsString(allowList, command) {
-		log.Println("[warning] TeslaMateAPICarsCommandV1 command not allowed!")
-		TeslaMateAPIHandleOtherResponse(c, http.StatusUnauthorized, "TeslaMateAPICarsCommandV1", gin.H{"error": "unauthorized"})
+	// v1 keeps answering 401 for commands that aren't enabled, v2 answers 403 since the token is valid
+	if !commandAllowList.Allowed(command) {
+		handleErrorResponse(c, http.StatusUnauthorized, http.StatusForbidden, "TeslaMateAPICarsCommandV1", "unauthorized", "command "+command+" is not allowed")
 		return
 	}
 
+	// the token needs the command:<group> scope of the command
+	if !checkCommandScope(c, "TeslaMateAPICarsCommandV1", command) {
+		return
+	}
+
+	// the body has to match the schema of the command, so invalid parameters don't fail at Tesla
+	if fieldErrors := validateCommandBody(command, reqBody); len(fieldErrors) > 0 {
+		TeslaMateAPIHandleValidationErrorResponse(c, "TeslaMateAPICarsCommandV1", "invalid parameters for command "+command, fieldErrors)
+		return
+	}
+
+	// the scopes, time windows, parameters and invocations per hour of the allow list,
+	// the invocation only counts if Tesla accepted the command
+	release, ok := checkCommandPolicy(c, "TeslaMateAPICarsCommandV1", CarID, command, reqBody)
+	if !ok {
+		return
+	}
+	sent := false
+	defer func() {
+		if !sent {
+			release()
+		}
+	}()
+
 	// get TeslaVehicleID and TeslaAccessToken
-	query := `
-		SELECT
-			eid as TeslaVehicleID,
-			(SELECT access FROM tokens LIMIT 1) as TeslaAccessToken
-		FROM cars
-		WHERE id = $1
-		LIMIT 1;`
-	row := db.QueryRow(query, CarID)
-
-	err = row.Scan(
-		&TeslaVehicleID,
-		&TeslaAccessToken,
-	)
+	commandDetails, err := queries.GetCarCommandDetails(c.Request.Context(), int16(CarID))
+	TeslaVehicleID = strconv.FormatInt(commandDetails.Eid, 10)
+	TeslaAccessToken = string(commandDetails.AccessToken)
 
 	switch err {
-	case sql.ErrNoRows:
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsCommandV1", "No rows were returned!", err.Error())
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsCommandV1", "No rows were returned!", err.Error())
 		return
 	case nil:
 		// nothing wrong.. continuing
 		break
 	default:
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsCommandV1", CarsCommandsError1, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsCommandV1", CarsCommandsError1, err.Error())
 		return
 	}
 
 	// load ENCRYPTION_KEY environment variable
 	teslaMateEncryptionKey := getEnv("ENCRYPTION_KEY", "")
 	if teslaMateEncryptionKey == "" {
-		log.Println("[error] TeslaMateAPICarsCommandV1 can't get ENCRYPTION_KEY.. will fail to perform command.")
-		TeslaMateAPIHandleOtherResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsCommandV1", gin.H{"error": "missing ENCRYPTION_KEY env variable"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsCommandV1", "missing ENCRYPTION_KEY env variable", "ENCRYPTION_KEY is not set")
 		return
 	}
 
 	// decrypt access token
 	TeslaAccessToken = decryptAccessToken(TeslaAccessToken, teslaMateEncryptionKey)
 
-	switch getCarRegionAPI(TeslaAccessToken) {
-	case ChinaAPI:
-		TeslaEndpointUrl = getEnv("TESLA_API_HOST", "https://owner-api.vn.cloud.tesla.cn")
-	default:
-		TeslaEndpointUrl = getEnv("TESLA_API_HOST", "https://owner-api.teslamotors.com")
+	// send the command with the backend of the car
+	backend := commandBackendForCar(CarID)
+	if gin.IsDebugging() {
+		log.Printf("[debug] TeslaMateAPICarsCommandV1 - sending %s of car %d with the %s backend", command, CarID, backend.Name())
 	}
-
-	client := &http.Client{}
-	req, _ := http.NewRequest(http.MethodPost, TeslaEndpointUrl+"/api/1/vehicles/"+TeslaVehicleID+command, strings.NewReader(string(reqBody)))
-	req.Header.Set("Authorization", "Bearer "+TeslaAccessToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "TeslaMateApi/"+apiVersion+" (+https://github.com/tobiasehlert/teslamateapi)")
-	resp, err := client.Do(req)
+	resp, err := backend.Send(c.Request.Context(), CommandVehicle{
+		CarID:       CarID,
+		ID:          TeslaVehicleID,
+		VIN:         commandDetails.Vin.String,
+		AccessToken: TeslaAccessToken,
+	}, command, reqBody)
 
 	// check response error
 	if err != nil {
-		log.Println("[error] TeslaMateAPICarsCommandV1 error in http request to "+TeslaEndpointUrl, err)
-		TeslaMateAPIHandleOtherResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsCommandV1", gin.H{"error": "internal http request error"})
+		observeCommand(command, 0)
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsCommandV1", "internal http request error", err.Error())
 		return
 	}
 
 	defer resp.Body.Close()
-	defer client.CloseIdleConnections()
+	observeCommand(command, resp.StatusCode)
+	sent = resp.StatusCode >= 200 && resp.StatusCode < 300
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Println("[error] TeslaMateAPICarsCommandV1 error in second io.ReadAll:", err)
-		TeslaMateAPIHandleOtherResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsCommandV1", gin.H{"error": "internal io reading error"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsCommandV1", "internal io reading error", err.Error())
 		return
 	}
 	json.Unmarshal([]byte(respBody), &jsonData)
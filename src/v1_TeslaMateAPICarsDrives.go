@@ -1,10 +1,11 @@
 package main
 
 import (
-	"fmt"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
 // TeslaMateAPICarsDrivesV1 func
@@ -23,12 +24,12 @@ func TeslaMateAPICarsDrivesV1(c *gin.Context) {
 	// get startDate and endDate from query parameters
 	parsedStartDate, err := parseDateParam(c.Query("startDate"))
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesV1", CarsDrivesError2, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesV1", CarsDrivesError2, err.Error())
 		return
 	}
 	parsedEndDate, err := parseDateParam(c.Query("endDate"))
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesV1", CarsDrivesError2, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesV1", CarsDrivesError2, err.Error())
 		return
 	}
 
@@ -111,123 +112,63 @@ func TeslaMateAPICarsDrivesV1(c *gin.Context) {
 	ResultPage = (ResultPage * ResultShow)
 
 	// getting data from database
-	query := `
-		SELECT
-			drives.id AS drive_id,
-			start_date,
-			end_date,
-			COALESCE(start_geofence.name, CONCAT_WS(', ', COALESCE(start_address.name, nullif(CONCAT_WS(' ', start_address.road, start_address.house_number), '')), start_address.city)) AS start_address,
-			COALESCE(end_geofence.name, CONCAT_WS(', ', COALESCE(end_address.name, nullif(CONCAT_WS(' ', end_address.road, end_address.house_number), '')), end_address.city)) AS end_address,
-			start_km,
-			end_km,
-			distance,
-			duration_min,
-			TO_CHAR((duration_min * INTERVAL '1 minute'), 'HH24:MI') as duration_str,
-			speed_max,
-			COALESCE(distance / NULLIF(duration_min, 0) * 60, 0) AS speed_avg,
-			power_max,
-			power_min,
-			COALESCE(start_position.usable_battery_level, start_position.battery_level) as start_usable_battery_level,
-			start_position.battery_level as start_battery_level,
-			COALESCE(end_position.usable_battery_level, end_position.battery_level) as end_usable_battery_level,
-			end_position.battery_level as end_battery_level,
-			case when ( start_position.battery_level != start_position.usable_battery_level OR end_position.battery_level != end_position.usable_battery_level ) = true then true else false end  as reduced_range,
-			duration_min > 1 AND distance > 1 AND ( start_position.usable_battery_level IS NULL OR end_position.usable_battery_level IS NULL OR ( end_position.battery_level - end_position.usable_battery_level ) = 0 ) as is_sufficiently_precise,
-			start_ideal_range_km,
-			end_ideal_range_km,
-			COALESCE( NULLIF ( GREATEST ( start_ideal_range_km - end_ideal_range_km, 0 ), 0 ),0 ) as range_diff_ideal_km,
-			start_rated_range_km,
-			end_rated_range_km,
-			COALESCE( NULLIF ( GREATEST ( start_rated_range_km - end_rated_range_km, 0 ), 0 ),0 ) as range_diff_rated_km,
-			outside_temp_avg,
-			inside_temp_avg,
-			(SELECT unit_of_length FROM settings LIMIT 1) as unit_of_length,
-			(SELECT unit_of_temperature FROM settings LIMIT 1) as unit_of_temperature,
-			cars.name
-		FROM drives
-		LEFT JOIN cars ON car_id = cars.id
-		LEFT JOIN addresses start_address ON start_address_id = start_address.id
-		LEFT JOIN addresses end_address ON end_address_id = end_address.id
-		LEFT JOIN positions start_position ON start_position_id = start_position.id
-		LEFT JOIN positions end_position ON end_position_id = end_position.id
-		LEFT JOIN geofences start_geofence ON start_geofence_id = start_geofence.id
-		LEFT JOIN geofences end_geofence ON end_geofence_id = end_geofence.id
-		WHERE drives.car_id=$1 AND end_date IS NOT NULL`
-
-	// Parameters to be passed to the query
-	var queryParams []interface{}
-	queryParams = append(queryParams, CarID)
-	paramIndex := 2
-
-	// Add date filtering if provided
-	if parsedStartDate != "" {
-		query += fmt.Sprintf(" AND drives.start_date >= $%d", paramIndex)
-		queryParams = append(queryParams, parsedStartDate)
-		paramIndex++
-	}
-	if parsedEndDate != "" {
-		query += fmt.Sprintf(" AND drives.end_date <= $%d", paramIndex)
-		queryParams = append(queryParams, parsedEndDate)
-		paramIndex++
-	}
-
-	query += fmt.Sprintf(`
-        ORDER BY start_date DESC
-        LIMIT $%d OFFSET $%d;`, paramIndex, paramIndex+1)
-
-	queryParams = append(queryParams, ResultShow, ResultPage)
-
-	rows, err := db.Query(query, queryParams...)
+	drives, err := queries.ListDrives(c.Request.Context(), teslamatedb.ListDrivesParams{
+		CarID:     int16(CarID),
+		StartDate: parsedStartDate,
+		EndDate:   parsedEndDate,
+		Limit:     int32(ResultShow),
+		Offset:    int32(ResultPage),
+	})
 
 	// checking for errors in query
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesV1", CarsDrivesError1, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesV1", CarsDrivesError1, err.Error())
 		return
 	}
 
-	// defer closing rows
-	defer rows.Close()
-
 	// looping through all results
-	for rows.Next() {
+	for _, row := range drives {
 
 		// creating drive object based on struct
-		drive := Drives{}
-
-		// scanning row and putting values into the drive
-		err = rows.Scan(
-			&drive.DriveID,
-			&drive.StartDate,
-			&drive.EndDate,
-			&drive.StartAddress,
-			&drive.EndAddress,
-			&drive.OdometerDetails.OdometerStart,
-			&drive.OdometerDetails.OdometerEnd,
-			&drive.OdometerDetails.OdometerDistance,
-			&drive.DurationMin,
-			&drive.DurationStr,
-			&drive.SpeedMax,
-			&drive.SpeedAvg,
-			&drive.PowerMax,
-			&drive.PowerMin,
-			&drive.BatteryDetails.StartUsableBatteryLevel,
-			&drive.BatteryDetails.StartBatteryLevel,
-			&drive.BatteryDetails.EndUsableBatteryLevel,
-			&drive.BatteryDetails.EndBatteryLevel,
-			&drive.BatteryDetails.ReducedRange,
-			&drive.BatteryDetails.IsSufficientlyPrecise,
-			&drive.RangeIdeal.StartRange,
-			&drive.RangeIdeal.EndRange,
-			&drive.RangeIdeal.RangeDiff,
-			&drive.RangeRated.StartRange,
-			&drive.RangeRated.EndRange,
-			&drive.RangeRated.RangeDiff,
-			&drive.OutsideTempAvg,
-			&drive.InsideTempAvg,
-			&UnitsLength,
-			&UnitsTemperature,
-			&CarName,
-		)
+		drive := Drives{
+			DriveID:      int(row.DriveID),
+			StartAddress: row.StartAddress,
+			EndAddress:   row.EndAddress,
+			OdometerDetails: OdometerDetails{
+				OdometerStart:    row.StartKm.Float64,
+				OdometerEnd:      row.EndKm.Float64,
+				OdometerDistance: row.Distance.Float64,
+			},
+			DurationMin: int(row.DurationMin.Int16),
+			DurationStr: row.DurationStr,
+			SpeedMax:    int(row.SpeedMax.Int16),
+			SpeedAvg:    row.SpeedAvg,
+			PowerMax:    int(row.PowerMax.Int16),
+			PowerMin:    int(row.PowerMin.Int16),
+			BatteryDetails: BatteryDetails{
+				StartUsableBatteryLevel: int(row.StartUsableBatteryLevel.Int16),
+				StartBatteryLevel:       int(row.StartBatteryLevel.Int16),
+				EndUsableBatteryLevel:   int(row.EndUsableBatteryLevel.Int16),
+				EndBatteryLevel:         int(row.EndBatteryLevel.Int16),
+				ReducedRange:            row.ReducedRange,
+				IsSufficientlyPrecise:   row.IsSufficientlyPrecise,
+			},
+			RangeIdeal: PreferredRange{
+				StartRange: row.StartIdealRangeKm.Float64,
+				EndRange:   row.EndIdealRangeKm.Float64,
+				RangeDiff:  row.RangeDiffIdealKm,
+			},
+			RangeRated: PreferredRange{
+				StartRange: row.StartRatedRangeKm.Float64,
+				EndRange:   row.EndRatedRangeKm.Float64,
+				RangeDiff:  row.RangeDiffRatedKm,
+			},
+			OutsideTempAvg: row.OutsideTempAvg.Float64,
+			InsideTempAvg:  row.InsideTempAvg.Float64,
+		}
+		UnitsLength = row.UnitOfLength
+		UnitsTemperature = row.UnitOfTemperature
+		CarName = NullString(row.CarName.String)
 
 		// converting values based of settings UnitsLength
 		if UnitsLength == "mi" {
@@ -250,26 +191,13 @@ func TeslaMateAPICarsDrivesV1(c *gin.Context) {
 		}
 
 		// adjusting to timezone differences from UTC to be userspecific
-		drive.StartDate = getTimeInTimeZone(drive.StartDate)
-		drive.EndDate = getTimeInTimeZone(drive.EndDate)
-
-		// checking for errors after scanning
-		if err != nil {
-			TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesV1", CarsDrivesError1, err.Error())
-			return
-		}
+		drive.StartDate = getTimeInTimeZone(row.StartDate)
+		drive.EndDate = getTimeInTimeZone(row.EndDate.Time)
 
 		// appending drive to DrivesData
 		DrivesData = append(DrivesData, drive)
 	}
 
-	// checking for errors in the rows result
-	err = rows.Err()
-	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesV1", CarsDrivesError1, err.Error())
-		return
-	}
-
 	//
 	// build the data-blob
 	jsonData := JSONData{
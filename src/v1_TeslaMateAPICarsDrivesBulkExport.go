@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPICarsDrivesBulkExportV1 func
+func TeslaMateAPICarsDrivesBulkExportV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsDrivesBulkExportError1 = "Unable to load drives."
+		CarsDrivesBulkExportError2 = "Invalid date format."
+		CarsDrivesBulkExportError3 = "Invalid export format."
+	)
+
+	// getting CarID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+
+	// getting format from query parameters
+	ExportFormat := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if _, ok := bulkExportFormats[ExportFormat]; !ok {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesBulkExportV1", CarsDrivesBulkExportError3, "format has to be csv or ndjson, got "+strconv.Quote(ExportFormat))
+		return
+	}
+
+	// get startDate and endDate from query parameters
+	parsedStartDate, err := parseDateParam(c.Query("startDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesBulkExportV1", CarsDrivesBulkExportError2, err.Error())
+		return
+	}
+	parsedEndDate, err := parseDateParam(c.Query("endDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesBulkExportV1", CarsDrivesBulkExportError2, err.Error())
+		return
+	}
+
+	// Drive struct - one row of the export, the fields of /cars/<CarID>/drives flattened
+	type Drive struct {
+		CarID                   int     `json:"car_id"`
+		DriveID                 int     `json:"drive_id"`
+		StartDate               string  `json:"start_date"`
+		EndDate                 string  `json:"end_date"`
+		StartAddress            string  `json:"start_address"`
+		EndAddress              string  `json:"end_address"`
+		OdometerStart           float64 `json:"odometer_start"`
+		OdometerEnd             float64 `json:"odometer_end"`
+		OdometerDistance        float64 `json:"odometer_distance"`
+		DurationMin             int     `json:"duration_min"`
+		DurationStr             string  `json:"duration_str"`
+		SpeedMax                int     `json:"speed_max"`
+		SpeedAvg                float64 `json:"speed_avg"`
+		PowerMax                int     `json:"power_max"`
+		PowerMin                int     `json:"power_min"`
+		StartUsableBatteryLevel int     `json:"start_usable_battery_level"`
+		StartBatteryLevel       int     `json:"start_battery_level"`
+		EndUsableBatteryLevel   int     `json:"end_usable_battery_level"`
+		EndBatteryLevel         int     `json:"end_battery_level"`
+		ReducedRange            bool    `json:"reduced_range"`
+		IsSufficientlyPrecise   bool    `json:"is_sufficiently_precise"`
+		RangeIdealStart         float64 `json:"range_ideal_start"`
+		RangeIdealEnd           float64 `json:"range_ideal_end"`
+		RangeIdealDiff          float64 `json:"range_ideal_diff"`
+		RangeRatedStart         float64 `json:"range_rated_start"`
+		RangeRatedEnd           float64 `json:"range_rated_end"`
+		RangeRatedDiff          float64 `json:"range_rated_diff"`
+		OutsideTempAvg          float64 `json:"outside_temp_avg"`
+		InsideTempAvg           float64 `json:"inside_temp_avg"`
+		UnitsLength             string  `json:"unit_of_length"`
+		UnitsTemperature        string  `json:"unit_of_temperature"`
+	}
+
+	// cursors only exist within a transaction
+	tx, err := db.BeginTx(c.Request.Context(), pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesBulkExportV1", CarsDrivesBulkExportError1, err.Error())
+		return
+	}
+	defer tx.Rollback(c.Request.Context())
+
+	writer := newBulkExportWriter[Drive](c, ExportFormat, fmt.Sprintf("teslamate-car%d-drives", CarID))
+
+	// streaming drives batch by batch
+	err = readExportCursor(c.Request.Context(), instrumentDB(tx), "export_drives", func(q *teslamatedb.Queries) ([]teslamatedb.ExportDrivesRow, error) {
+		return q.ExportDrives(c.Request.Context(), teslamatedb.ExportDrivesParams{
+			CarID:     int16(CarID),
+			StartDate: parsedStartDate,
+			EndDate:   parsedEndDate,
+		})
+	}, func(rows []teslamatedb.ExportDrivesRow) error {
+		for _, row := range rows {
+			drive := Drive{
+				CarID:                   CarID,
+				DriveID:                 int(row.DriveID),
+				StartAddress:            row.StartAddress,
+				EndAddress:              row.EndAddress,
+				OdometerStart:           row.StartKm.Float64,
+				OdometerEnd:             row.EndKm.Float64,
+				OdometerDistance:        row.Distance.Float64,
+				DurationMin:             int(row.DurationMin.Int16),
+				DurationStr:             row.DurationStr,
+				SpeedMax:                int(row.SpeedMax.Int16),
+				SpeedAvg:                row.SpeedAvg,
+				PowerMax:                int(row.PowerMax.Int16),
+				PowerMin:                int(row.PowerMin.Int16),
+				StartUsableBatteryLevel: int(row.StartUsableBatteryLevel.Int16),
+				StartBatteryLevel:       int(row.StartBatteryLevel.Int16),
+				EndUsableBatteryLevel:   int(row.EndUsableBatteryLevel.Int16),
+				EndBatteryLevel:         int(row.EndBatteryLevel.Int16),
+				ReducedRange:            row.ReducedRange,
+				IsSufficientlyPrecise:   row.IsSufficientlyPrecise,
+				RangeIdealStart:         row.StartIdealRangeKm.Float64,
+				RangeIdealEnd:           row.EndIdealRangeKm.Float64,
+				RangeIdealDiff:          row.RangeDiffIdealKm,
+				RangeRatedStart:         row.StartRatedRangeKm.Float64,
+				RangeRatedEnd:           row.EndRatedRangeKm.Float64,
+				RangeRatedDiff:          row.RangeDiffRatedKm,
+				OutsideTempAvg:          row.OutsideTempAvg.Float64,
+				InsideTempAvg:           row.InsideTempAvg.Float64,
+				UnitsLength:             row.UnitOfLength,
+				UnitsTemperature:        row.UnitOfTemperature,
+			}
+
+			// converting values based of settings UnitsLength
+			if drive.UnitsLength == "mi" {
+				drive.OdometerStart = kilometersToMiles(drive.OdometerStart)
+				drive.OdometerEnd = kilometersToMiles(drive.OdometerEnd)
+				drive.OdometerDistance = kilometersToMiles(drive.OdometerDistance)
+				drive.SpeedMax = int(kilometersToMiles(float64(drive.SpeedMax)))
+				drive.SpeedAvg = kilometersToMiles(drive.SpeedAvg)
+				drive.RangeIdealStart = kilometersToMiles(drive.RangeIdealStart)
+				drive.RangeIdealEnd = kilometersToMiles(drive.RangeIdealEnd)
+				drive.RangeIdealDiff = kilometersToMiles(drive.RangeIdealDiff)
+				drive.RangeRatedStart = kilometersToMiles(drive.RangeRatedStart)
+				drive.RangeRatedEnd = kilometersToMiles(drive.RangeRatedEnd)
+				drive.RangeRatedDiff = kilometersToMiles(drive.RangeRatedDiff)
+			}
+			// converting values based of settings UnitsTemperature
+			if drive.UnitsTemperature == "F" {
+				drive.OutsideTempAvg = celsiusToFahrenheit(drive.OutsideTempAvg)
+				drive.InsideTempAvg = celsiusToFahrenheit(drive.InsideTempAvg)
+			}
+
+			// adjusting to timezone differences from UTC to be userspecific
+			drive.StartDate = getTimeInTimeZone(row.StartDate)
+			drive.EndDate = getTimeInTimeZone(row.EndDate.Time)
+
+			if err := writer.Write(drive); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+
+	// checking for errors, once streaming started the response can only be cut short
+	if err != nil {
+		if !writer.started {
+			TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesBulkExportV1", CarsDrivesBulkExportError1, err.Error())
+			return
+		}
+		log.Println("[error] TeslaMateAPICarsDrivesBulkExportV1 - (" + c.Request.RequestURI + "). " + CarsDrivesBulkExportError1 + "; " + err.Error())
+		return
+	}
+	if err := tx.Commit(c.Request.Context()); err != nil {
+		log.Println("[warning] TeslaMateAPICarsDrivesBulkExportV1 - (" + c.Request.RequestURI + "). unable to commit transaction; " + err.Error())
+	}
+	log.Println("[info] TeslaMateAPICarsDrivesBulkExportV1 - (" + c.Request.RequestURI + ") executed successfully.")
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+)
+
+var exportDrivesColumns = []string{
+	"drive_id", "start_date", "end_date", "start_address", "end_address", "start_km", "end_km", "distance",
+	"duration_min", "duration_str", "speed_max", "speed_avg", "power_max", "power_min",
+	"start_usable_battery_level", "start_battery_level", "end_usable_battery_level", "end_battery_level",
+	"reduced_range", "is_sufficiently_precise", "start_ideal_range_km", "end_ideal_range_km", "range_diff_ideal_km",
+	"start_rated_range_km", "end_rated_range_km", "range_diff_rated_km", "outside_temp_avg", "inside_temp_avg",
+	"unit_of_length", "unit_of_temperature", "car_name",
+}
+
+// exportDrivesRows returns n drives starting with drive id first
+func exportDrivesRows(first int, n int) *pgxmock.Rows {
+	rows := pgxmock.NewRows(exportDrivesColumns)
+	startDate := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		rows.AddRow([]any{
+			int64(first + i), startDate, startDate.Add(30 * time.Minute), "Home", "Work, Street 1", 1000.0, 1100.0, 100.0,
+			int64(30), "00:30", int64(120), 50.0, int64(150), int64(-50),
+			int64(80), int64(80), int64(60), int64(60),
+			false, true, 300.0, 200.0, 100.0,
+			310.0, 210.0, 100.0, 20.0, 22.0,
+			"mi", "F", "Test Tesla",
+		}...)
+	}
+	return rows
+}
+
+func TestTeslaMateAPICarsDrivesBulkExportV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("Failed to create mock database: %v", err)
+	}
+	defer mock.Close()
+
+	originalDB := db
+	db = mock
+	defer func() { db = originalDB }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/drives/export", TeslaMateAPICarsDrivesBulkExportV1)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("CSV in batches", func(t *testing.T) {
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("DECLARE export_drives NO SCROLL CURSOR FOR -- name: ExportDrives").
+			WithArgs(int16(1), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM export_drives").WillReturnRows(exportDrivesRows(1, exportCursorBatchSize))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM export_drives").WillReturnRows(exportDrivesRows(501, 2))
+		mock.ExpectExec("CLOSE export_drives").WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectCommit()
+
+		w := request("/api/v1/cars/1/drives/export?startDate=2024-05-01T00:00:00Z")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+			t.Errorf("Expected text/csv, got '%s'", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="teslamate-car1-drives.csv"` {
+			t.Errorf("Unexpected Content-Disposition '%s'", cd)
+		}
+
+		records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+		if err != nil {
+			t.Fatalf("Invalid CSV: %v", err)
+		}
+		if len(records) != 1+exportCursorBatchSize+2 {
+			t.Fatalf("Expected header and %d drives, got %d records", exportCursorBatchSize+2, len(records))
+		}
+		column := map[string]int{}
+		for i, name := range records[0] {
+			column[name] = i
+		}
+		last := records[len(records)-1]
+		for name, expected := range map[string]string{
+			"car_id":            "1",
+			"drive_id":          "502",
+			"start_date":        "2024-05-01T08:00:00Z",
+			"end_address":       "Work, Street 1",
+			"odometer_distance": "62.137119223732995",
+			"outside_temp_avg":  "68",
+			"reduced_range":     "false",
+			"unit_of_length":    "mi",
+		} {
+			if got := last[column[name]]; got != expected {
+				t.Errorf("Expected %s to be '%s', got '%s'", name, expected, got)
+			}
+		}
+	})
+
+	t.Run("NDJSON without drives", func(t *testing.T) {
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("DECLARE export_drives").WithArgs(int16(1), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM export_drives").WillReturnRows(pgxmock.NewRows(exportDrivesColumns))
+		mock.ExpectExec("CLOSE export_drives").WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectCommit()
+
+		w := request("/api/v1/cars/1/drives/export?format=ndjson")
+		if w.Code != http.StatusOK || w.Body.Len() != 0 {
+			t.Errorf("Expected empty 200 response, got %d: %s", w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("Expected application/x-ndjson, got '%s'", ct)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("NDJSON rows", func(t *testing.T) {
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("DECLARE export_drives").WithArgs(int16(1), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectQuery("FETCH FORWARD 500 FROM export_drives").WillReturnRows(exportDrivesRows(7, 2))
+		mock.ExpectExec("CLOSE export_drives").WillReturnResult(pgxmock.NewResult("", 0))
+		mock.ExpectCommit()
+
+		w := request("/api/v1/cars/1/drives/export?format=ndjson")
+		lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines, got %d: %s", len(lines), w.Body.String())
+		}
+		var drive map[string]any
+		if err := json.Unmarshal([]byte(lines[1]), &drive); err != nil {
+			t.Fatalf("Invalid JSON line: %v", err)
+		}
+		if drive["drive_id"] != float64(8) || drive["range_ideal_start"] != 186.41135767119897 {
+			t.Errorf("Unexpected drive: %v", drive)
+		}
+	})
+
+	t.Run("Error before streaming", func(t *testing.T) {
+		mock.ExpectBeginTx(pgx.TxOptions{AccessMode: pgx.ReadOnly})
+		mock.ExpectExec("DECLARE export_drives").WithArgs(int16(1), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnError(errors.New("boom"))
+		mock.ExpectRollback()
+
+		w := request("/api/v1/cars/1/drives/export")
+		if body := w.Body.String(); body != `{"error":"Unable to load drives."}` {
+			t.Errorf("Expected legacy error body, got: %s", body)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		w := request("/api/v1/cars/1/drives/export?format=xlsx")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
@@ -1,10 +1,16 @@
 package main
 
 import (
-	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
 // TeslaMateAPICarsDrivesDetailsV1 func
@@ -14,12 +20,30 @@ func TeslaMateAPICarsDrivesDetailsV1(c *gin.Context) {
 	var (
 		CarsDrivesDetailsError1 = "Unable to load drive."
 		CarsDrivesDetailsError2 = "Unable to load drive details."
+		CarsDrivesDetailsError3 = "Invalid track option."
 	)
 
 	// getting CarID and DriveID param from URL
 	CarID := convertStringToInteger(c.Param("CarID"))
 	DriveID := convertStringToInteger(c.Param("DriveID"))
 
+	// query options to simplify and encode the track of the drive
+	TrackSimplify, err := strconv.ParseFloat(c.DefaultQuery("simplify", "0"), 64)
+	if err != nil || TrackSimplify < 0 || math.IsInf(TrackSimplify, 0) || math.IsNaN(TrackSimplify) {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError3, "simplify has to be a tolerance in meters, got "+strconv.Quote(c.Query("simplify")))
+		return
+	}
+	TrackMaxPoints, err := strconv.Atoi(c.DefaultQuery("max_points", "0"))
+	if err != nil || TrackMaxPoints < 0 || TrackMaxPoints == 1 {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError3, "max_points has to be 2 or more, got "+strconv.Quote(c.Query("max_points")))
+		return
+	}
+	TrackEncoding := strings.ToLower(c.Query("encoding"))
+	if TrackEncoding != "" && TrackEncoding != "polyline" {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError3, "encoding has to be polyline, got "+strconv.Quote(TrackEncoding))
+		return
+	}
+
 	// creating structs for /cars/<CarID>/drives/<DriveID>
 	// Car struct - child of Data
 	type Car struct {
@@ -82,6 +106,16 @@ func TeslaMateAPICarsDrivesDetailsV1(c *gin.Context) {
 		ClimateInfo        ClimateInfo `json:"climate_info"`         // struct
 		BatteryInfo        BatteryInfo `json:"battery_info"`         // struct
 	}
+	// DriveTrack struct - child of Drive, the positions with encoding=polyline
+	type DriveTrack struct {
+		Polyline     string      `json:"polyline"`      // encoded polyline
+		Date         []string    `json:"date"`          // timestamp without time zone
+		Speed        []int       `json:"speed"`         // smallint
+		Power        []int       `json:"power"`         // smallint
+		Odometer     []float64   `json:"odometer"`      // double precision
+		BatteryLevel []int       `json:"battery_level"` // smallint
+		Elevation    []NullInt64 `json:"elevation"`     // smallint
+	}
 	// Drive struct - child of Data
 	type Drive struct {
 		DriveID         int             `json:"drive_id"`         // int
@@ -102,6 +136,7 @@ func TeslaMateAPICarsDrivesDetailsV1(c *gin.Context) {
 		OutsideTempAvg  float64         `json:"outside_temp_avg"` // float64
 		InsideTempAvg   float64         `json:"inside_temp_avg"`  // float64
 		DriveDetails    []DriveDetails  `json:"drive_details"`    // struct
+		DriveTrack      *DriveTrack     `json:"track,omitempty"`  // struct
 	}
 	// TeslaMateUnits struct - child of Data
 	type TeslaMateUnits struct {
@@ -124,101 +159,70 @@ func TeslaMateAPICarsDrivesDetailsV1(c *gin.Context) {
 		CarName                       NullString
 		drive                         Drive
 		DriveDetailsData              []DriveDetails
+		DriveTrackData                *DriveTrack
+		DriveTrackCoordinates         [][2]float64
 		UnitsLength, UnitsTemperature string
 	)
 
 	// getting data from database
-	query := `
-		SELECT
-			drives.id AS drive_id,
-			start_date,
-			end_date,
-			COALESCE(start_geofence.name, CONCAT_WS(', ', COALESCE(start_address.name, nullif(CONCAT_WS(' ', start_address.road, start_address.house_number), '')), start_address.city)) AS start_address,
-			COALESCE(end_geofence.name, CONCAT_WS(', ', COALESCE(end_address.name, nullif(CONCAT_WS(' ', end_address.road, end_address.house_number), '')), end_address.city)) AS end_address,
-			start_km,
-			end_km,
-			distance,
-			duration_min,
-			TO_CHAR((duration_min * INTERVAL '1 minute'), 'HH24:MI') as duration_str,
-			speed_max,
-			COALESCE(distance / NULLIF(duration_min, 0) * 60, 0) AS speed_avg,
-			power_max,
-			power_min,
-			COALESCE(start_position.usable_battery_level, start_position.battery_level) as start_usable_battery_level,
-			start_position.battery_level as start_battery_level,
-			COALESCE(end_position.usable_battery_level, end_position.battery_level) as end_usable_battery_level,
-			end_position.battery_level as end_battery_level,
-			case when ( start_position.battery_level != start_position.usable_battery_level OR end_position.battery_level != end_position.usable_battery_level ) = true then true else false end  as reduced_range,
-			duration_min > 1 AND distance > 1 AND ( start_position.usable_battery_level IS NULL OR end_position.usable_battery_level IS NULL OR ( end_position.battery_level - end_position.usable_battery_level ) = 0 ) as is_sufficiently_precise,
-			start_ideal_range_km,
-			end_ideal_range_km,
-			COALESCE( NULLIF ( GREATEST ( start_ideal_range_km - end_ideal_range_km, 0 ), 0 ),0 ) as range_diff_ideal_km,
-			start_rated_range_km,
-			end_rated_range_km,
-			COALESCE( NULLIF ( GREATEST ( start_rated_range_km - end_rated_range_km, 0 ), 0 ),0 ) as range_diff_rated_km,
-			outside_temp_avg,
-			inside_temp_avg,
-			(SELECT unit_of_length FROM settings LIMIT 1) as unit_of_length,
-			(SELECT unit_of_temperature FROM settings LIMIT 1) as unit_of_temperature,
-			cars.name
-		FROM drives
-		LEFT JOIN cars ON car_id = cars.id
-		LEFT JOIN addresses start_address ON start_address_id = start_address.id
-		LEFT JOIN addresses end_address ON end_address_id = end_address.id
-		LEFT JOIN positions start_position ON start_position_id = start_position.id
-		LEFT JOIN positions end_position ON end_position_id = end_position.id
-		LEFT JOIN geofences start_geofence ON start_geofence_id = start_geofence.id
-		LEFT JOIN geofences end_geofence ON end_geofence_id = end_geofence.id
-		WHERE drives.car_id=$1 AND end_date IS NOT NULL AND drives.id = $2;`
-	row := db.QueryRow(query, CarID, DriveID)
-
-	// scanning row and putting values into the drive
-	err := row.Scan(
-		&drive.DriveID,
-		&drive.StartDate,
-		&drive.EndDate,
-		&drive.StartAddress,
-		&drive.EndAddress,
-		&drive.OdometerDetails.OdometerStart,
-		&drive.OdometerDetails.OdometerEnd,
-		&drive.OdometerDetails.OdometerDistance,
-		&drive.DurationMin,
-		&drive.DurationStr,
-		&drive.SpeedMax,
-		&drive.SpeedAvg,
-		&drive.PowerMax,
-		&drive.PowerMin,
-		&drive.BatteryDetails.StartUsableBatteryLevel,
-		&drive.BatteryDetails.StartBatteryLevel,
-		&drive.BatteryDetails.EndUsableBatteryLevel,
-		&drive.BatteryDetails.EndBatteryLevel,
-		&drive.BatteryDetails.ReducedRange,
-		&drive.BatteryDetails.IsSufficientlyPrecise,
-		&drive.RangeIdeal.StartRange,
-		&drive.RangeIdeal.EndRange,
-		&drive.RangeIdeal.RangeDiff,
-		&drive.RangeRated.StartRange,
-		&drive.RangeRated.EndRange,
-		&drive.RangeRated.RangeDiff,
-		&drive.OutsideTempAvg,
-		&drive.InsideTempAvg,
-		&UnitsLength,
-		&UnitsTemperature,
-		&CarName,
-	)
+	row, err := queries.GetDrive(c.Request.Context(), teslamatedb.GetDriveParams{
+		CarID: int16(CarID),
+		ID:    int32(DriveID),
+	})
 
 	switch err {
-	case sql.ErrNoRows:
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesDetailsV1", "No rows were returned!", err.Error())
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsDrivesDetailsV1", "No rows were returned!", err.Error())
 		return
 	case nil:
 		// nothing wrong.. continuing
 		break
 	default:
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError1, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError1, err.Error())
 		return
 	}
 
+	// putting values into the drive
+	drive = Drive{
+		DriveID:      int(row.DriveID),
+		StartAddress: row.StartAddress,
+		EndAddress:   row.EndAddress,
+		OdometerDetails: OdometerDetails{
+			OdometerStart:    row.StartKm.Float64,
+			OdometerEnd:      row.EndKm.Float64,
+			OdometerDistance: row.Distance.Float64,
+		},
+		DurationMin: int(row.DurationMin.Int16),
+		DurationStr: row.DurationStr,
+		SpeedMax:    int(row.SpeedMax.Int16),
+		SpeedAvg:    row.SpeedAvg,
+		PowerMax:    int(row.PowerMax.Int16),
+		PowerMin:    int(row.PowerMin.Int16),
+		BatteryDetails: BatteryDetails{
+			StartUsableBatteryLevel: int(row.StartUsableBatteryLevel.Int16),
+			StartBatteryLevel:       int(row.StartBatteryLevel.Int16),
+			EndUsableBatteryLevel:   int(row.EndUsableBatteryLevel.Int16),
+			EndBatteryLevel:         int(row.EndBatteryLevel.Int16),
+			ReducedRange:            row.ReducedRange,
+			IsSufficientlyPrecise:   row.IsSufficientlyPrecise,
+		},
+		RangeIdeal: PreferredRange{
+			StartRange: row.StartIdealRangeKm.Float64,
+			EndRange:   row.EndIdealRangeKm.Float64,
+			RangeDiff:  row.RangeDiffIdealKm,
+		},
+		RangeRated: PreferredRange{
+			StartRange: row.StartRatedRangeKm.Float64,
+			EndRange:   row.EndRatedRangeKm.Float64,
+			RangeDiff:  row.RangeDiffRatedKm,
+		},
+		OutsideTempAvg: row.OutsideTempAvg.Float64,
+		InsideTempAvg:  row.InsideTempAvg.Float64,
+	}
+	UnitsLength = row.UnitOfLength
+	UnitsTemperature = row.UnitOfTemperature
+	CarName = NullString(row.CarName.String)
+
 	// converting values based of settings UnitsLength
 	if UnitsLength == "mi" {
 		drive.OdometerDetails.OdometerStart = kilometersToMiles(drive.OdometerDetails.OdometerStart)
@@ -239,83 +243,72 @@ func TeslaMateAPICarsDrivesDetailsV1(c *gin.Context) {
 		drive.InsideTempAvg = celsiusToFahrenheit(drive.InsideTempAvg)
 	}
 	// adjusting to timezone differences from UTC to be userspecific
-	drive.StartDate = getTimeInTimeZone(drive.StartDate)
-	drive.EndDate = getTimeInTimeZone(drive.EndDate)
+	drive.StartDate = getTimeInTimeZone(row.StartDate)
+	drive.EndDate = getTimeInTimeZone(row.EndDate.Time)
 
 	// getting detailed drive data from database
-	query = `
-		 			SELECT
-						id AS detail_id,
-						date,
-						latitude,
-						longitude,
-						COALESCE(speed, 0) AS speed,
-						power,
-						odometer,
-						battery_level,
-						usable_battery_level,
-						elevation,
-						inside_temp,
-						outside_temp,
-						is_climate_on,
-						fan_status,
-						driver_temp_setting,
-						passenger_temp_setting,
-						is_rear_defroster_on,
-						is_front_defroster_on,
-						est_battery_range_km,
-						ideal_battery_range_km,
-						rated_battery_range_km,
-						battery_heater,
-						battery_heater_on,
-						battery_heater_no_power
-		 			FROM positions
-		 			WHERE drive_id = $1
-		 			ORDER BY id ASC;`
-	rows, err := db.Query(query, DriveID)
+	positions, err := queries.ListDrivePositions(c.Request.Context(), pgtype.Int4{Int32: int32(DriveID), Valid: true})
 
 	// checking for errors in query
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError2, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError2, err.Error())
 		return
 	}
 
-	// defer closing rows
-	defer rows.Close()
+	// simplifying the track before converting the positions
+	if TrackSimplify > 0 || TrackMaxPoints > 0 {
+		points := make([]trackPoint, len(positions))
+		for i, position := range positions {
+			points[i] = trackPoint{
+				Latitude:  position.Latitude,
+				Longitude: position.Longitude,
+				Speed:     float64(position.Speed),
+				Power:     float64(position.Power.Int16),
+			}
+		}
+		var simplified []teslamatedb.ListDrivePositionsRow
+		for _, i := range simplifyTrack(points, TrackSimplify, TrackMaxPoints) {
+			simplified = append(simplified, positions[i])
+		}
+		positions = simplified
+	}
+	if TrackEncoding == "polyline" {
+		DriveTrackData = &DriveTrack{Date: []string{}, Speed: []int{}, Power: []int{}, Odometer: []float64{}, BatteryLevel: []int{}, Elevation: []NullInt64{}}
+	}
 
 	// looping through all results
-	for rows.Next() {
+	for _, position := range positions {
 
 		// creating drivedetails object based on struct
-		drivedetails := DriveDetails{}
-
-		// scanning row and putting values into the drive
-		err = rows.Scan(
-			&drivedetails.DetailID,
-			&drivedetails.Date,
-			&drivedetails.Latitude,
-			&drivedetails.Longitude,
-			&drivedetails.Speed,
-			&drivedetails.Power,
-			&drivedetails.Odometer,
-			&drivedetails.BatteryLevel,
-			&drivedetails.UsableBatteryLevel,
-			&drivedetails.Elevation,
-			&drivedetails.ClimateInfo.InsideTemp,
-			&drivedetails.ClimateInfo.OutsideTemp,
-			&drivedetails.ClimateInfo.IsClimateOn,
-			&drivedetails.ClimateInfo.FanStatus,
-			&drivedetails.ClimateInfo.DriverTempSetting,
-			&drivedetails.ClimateInfo.PassengerTempSetting,
-			&drivedetails.ClimateInfo.IsRearDefrosterOn,
-			&drivedetails.ClimateInfo.IsFrontDefrosterOn,
-			&drivedetails.BatteryInfo.EstBatteryRange,
-			&drivedetails.BatteryInfo.IdealBatteryRange,
-			&drivedetails.BatteryInfo.RatedBatteryRange,
-			&drivedetails.BatteryInfo.BatteryHeater,
-			&drivedetails.BatteryInfo.BatteryHeaterOn,
-			&drivedetails.BatteryInfo.BatteryHeaterNoPower,
-		)
+		drivedetails := DriveDetails{
+			DetailID:           int(position.DetailID),
+			Latitude:           position.Latitude,
+			Longitude:          position.Longitude,
+			Speed:              int(position.Speed),
+			Power:              int(position.Power.Int16),
+			Odometer:           position.Odometer.Float64,
+			BatteryLevel:       int(position.BatteryLevel.Int16),
+			UsableBatteryLevel: nullInt16ToNullInt64(position.UsableBatteryLevel),
+			Elevation:          nullInt16ToNullInt64(position.Elevation),
+			ClimateInfo: ClimateInfo{
+				InsideTemp:           NullFloat64{position.InsideTemp},
+				OutsideTemp:          NullFloat64{position.OutsideTemp},
+				IsClimateOn:          NullBool{position.IsClimateOn},
+				FanStatus:            nullInt32ToNullInt64(position.FanStatus),
+				DriverTempSetting:    NullFloat64{position.DriverTempSetting},
+				PassengerTempSetting: NullFloat64{position.PassengerTempSetting},
+				IsRearDefrosterOn:    NullBool{position.IsRearDefrosterOn},
+				IsFrontDefrosterOn:   NullBool{position.IsFrontDefrosterOn},
+			},
+			BatteryInfo: BatteryInfo{
+				EstBatteryRange:      NullFloat64{position.EstBatteryRangeKm},
+				IdealBatteryRange:    NullFloat64{position.IdealBatteryRangeKm},
+				RatedBatteryRange:    NullFloat64{position.RatedBatteryRangeKm},
+				BatteryHeater:        NullBool{position.BatteryHeater},
+				BatteryHeaterOn:      NullBool{position.BatteryHeaterOn},
+				BatteryHeaterNoPower: NullBool{position.BatteryHeaterNoPower},
+			},
+		}
 
 		// converting values based of settings UnitsLength
 		if UnitsLength == "mi" {
@@ -333,24 +326,27 @@ func TeslaMateAPICarsDrivesDetailsV1(c *gin.Context) {
 			drivedetails.ClimateInfo.PassengerTempSetting = celsiusToFahrenheitNilSupport(drivedetails.ClimateInfo.PassengerTempSetting)
 		}
 		// adjusting to timezone differences from UTC to be userspecific
-		drivedetails.Date = getTimeInTimeZone(drivedetails.Date)
+		drivedetails.Date = getTimeInTimeZone(position.Date)
 
-		// checking for errors after scanning
-		if err != nil {
-			TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError2, err.Error())
-			return
+		// appending position to the per-point arrays of the track
+		if DriveTrackData != nil {
+			DriveTrackCoordinates = append(DriveTrackCoordinates, [2]float64{drivedetails.Latitude, drivedetails.Longitude})
+			DriveTrackData.Date = append(DriveTrackData.Date, drivedetails.Date)
+			DriveTrackData.Speed = append(DriveTrackData.Speed, drivedetails.Speed)
+			DriveTrackData.Power = append(DriveTrackData.Power, drivedetails.Power)
+			DriveTrackData.Odometer = append(DriveTrackData.Odometer, drivedetails.Odometer)
+			DriveTrackData.BatteryLevel = append(DriveTrackData.BatteryLevel, drivedetails.BatteryLevel)
+			DriveTrackData.Elevation = append(DriveTrackData.Elevation, drivedetails.Elevation)
+			continue
 		}
 
 		// appending drive to drive
 		DriveDetailsData = append(DriveDetailsData, drivedetails)
 		drive.DriveDetails = DriveDetailsData
 	}
-
-	// checking for errors in the rows result
-	err = rows.Err()
-	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsDrivesDetailsV1", CarsDrivesDetailsError2, err.Error())
-		return
+	if DriveTrackData != nil {
+		DriveTrackData.Polyline = encodePolyline(DriveTrackCoordinates)
+		drive.DriveTrack = DriveTrackData
 	}
 
 	//
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTeslaMateAPICarsDrivesDetailsV1Track(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	originalQueries := queries
+	queries = &fakeDriveExportQuerier{}
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/drives/:DriveID", TeslaMateAPICarsDrivesDetailsV1)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Positions by default", func(t *testing.T) {
+		w := request("/api/v1/cars/1/drives/42")
+		if !contains(w.Body.String(), `"detail_id":2`) || contains(w.Body.String(), `"track"`) {
+			t.Errorf("Expected drive_details without track, got: %s", w.Body.String())
+		}
+	})
+
+	t.Run("Encoded polyline", func(t *testing.T) {
+		w := request("/api/v1/cars/1/drives/42?encoding=polyline&max_points=2")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var resp struct {
+			Data struct {
+				Drive struct {
+					DriveDetails []any `json:"drive_details"`
+					Track        struct {
+						Polyline  string   `json:"polyline"`
+						Date      []string `json:"date"`
+						Speed     []int    `json:"speed"`
+						Power     []int    `json:"power"`
+						Elevation []*int   `json:"elevation"`
+					} `json:"track"`
+				} `json:"drive"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		drive := resp.Data.Drive
+		if drive.DriveDetails != nil {
+			t.Errorf("Expected no drive_details, got %v", drive.DriveDetails)
+		}
+		if drive.Track.Polyline != encodePolyline([][2]float64{{59.3293, 18.0686}, {59.3300, 18.0700}}) {
+			t.Errorf("Unexpected polyline '%s'", drive.Track.Polyline)
+		}
+		if len(drive.Track.Date) != 2 || drive.Track.Date[1] != "2024-05-01T08:01:00Z" || drive.Track.Speed[0] != 36 || drive.Track.Power[1] != -5 || *drive.Track.Elevation[1] != 22 {
+			t.Errorf("Unexpected per-point arrays: %+v", drive.Track)
+		}
+	})
+
+	for _, query := range []string{"simplify=-1", "simplify=abc", "max_points=1", "encoding=geojson"} {
+		t.Run("Invalid "+query, func(t *testing.T) {
+			w := request("/api/v1/cars/1/drives/42?" + query)
+			if w.Code != http.StatusBadRequest || w.Body.String() != `{"error":"Invalid track option."}` {
+				t.Errorf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPICarsDrivesEditV1 func
+func TeslaMateAPICarsDrivesEditV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsDrivesEditError1 = "Unable to update drive."
+		CarsDrivesEditError2 = "Invalid drive update."
+		CarsDrivesEditError3 = "Unable to load geofence."
+	)
+
+	// edits need ENABLE_EDITS and authentication
+	if !checkEditPermission(c, "TeslaMateAPICarsDrivesEditV1") {
+		return
+	}
+
+	// getting CarID and DriveID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+	DriveID := convertStringToInteger(c.Param("DriveID"))
+
+	// creating structs for /cars/<CarID>/drives/<DriveID>
+	// Car struct - child of Data
+	type Car struct {
+		CarID int `json:"car_id"` // smallint
+	}
+	// Drive struct - child of Data
+	type Drive struct {
+		DriveID         int  `json:"drive_id"`          // int
+		StartGeofenceID *int `json:"start_geofence_id"` // int (nullable)
+		EndGeofenceID   *int `json:"end_geofence_id"`   // int (nullable)
+	}
+	// Data struct - child of JSONData
+	type Data struct {
+		Car     Car                    `json:"car"`
+		Drive   Drive                  `json:"drive"`
+		Changes map[string]auditChange `json:"changes"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// validating the body, the geofences of a drive can be edited like TeslaMate does when geofences change
+	fields, err := parsePatchBody(c, "start_geofence_id", "end_geofence_id")
+	if err != nil {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesEditV1", CarsDrivesEditError2, err.Error())
+		return
+	}
+	params := teslamatedb.UpdateDriveGeofencesParams{
+		CarID: int16(CarID),
+		ID:    int32(DriveID),
+	}
+	for name, target := range map[string]*pgtype.Int4{"start_geofence_id": &params.StartGeofenceID, "end_geofence_id": &params.EndGeofenceID} {
+		value, ok := fields[name]
+		if !ok || isJSONNull(value) {
+			continue
+		}
+		if err := json.Unmarshal(value, &target.Int32); err != nil || target.Int32 <= 0 {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesEditV1", CarsDrivesEditError2, name+" has to be null or the id of a geofence")
+			return
+		}
+		exists, err := queries.GeofenceExists(c.Request.Context(), target.Int32)
+		if err != nil {
+			TeslaMateAPIHandleOtherErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesEditV1", CarsDrivesEditError3, err.Error())
+			return
+		}
+		if !exists {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesEditV1", CarsDrivesEditError2, name+" is not the id of a geofence")
+			return
+		}
+		target.Valid = true
+	}
+	_, params.SetStartGeofence = fields["start_geofence_id"]
+	_, params.SetEndGeofence = fields["end_geofence_id"]
+
+	// updating the drive in database
+	row, err := queries.UpdateDriveGeofences(c.Request.Context(), params)
+
+	switch err {
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsDrivesEditV1", "No rows were returned!", err.Error())
+		return
+	case nil:
+		// nothing wrong.. continuing
+		break
+	default:
+		TeslaMateAPIHandleOtherErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesEditV1", CarsDrivesEditError1, err.Error())
+		return
+	}
+
+	// only the fields of the body are changes
+	changes := map[string]auditChange{}
+	if params.SetStartGeofence {
+		changes["start_geofence_id"] = auditChange{Old: auditValue(row.OldStartGeofenceID), New: auditValue(row.NewStartGeofenceID)}
+	}
+	if params.SetEndGeofence {
+		changes["end_geofence_id"] = auditChange{Old: auditValue(row.OldEndGeofenceID), New: auditValue(row.NewEndGeofenceID)}
+	}
+	writeAuditRecord(c, CarID, "drive", DriveID, changes)
+
+	jsonData := JSONData{
+		Data{
+			Car:     Car{CarID: CarID},
+			Drive:   Drive{DriveID: DriveID},
+			Changes: changes,
+		},
+	}
+	if row.NewStartGeofenceID.Valid {
+		startGeofenceID := int(row.NewStartGeofenceID.Int32)
+		jsonData.Data.Drive.StartGeofenceID = &startGeofenceID
+	}
+	if row.NewEndGeofenceID.Valid {
+		endGeofenceID := int(row.NewEndGeofenceID.Int32)
+		jsonData.Data.Drive.EndGeofenceID = &endGeofenceID
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsDrivesEditV1", jsonData)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeDriveEditQuerier updates the geofences of a drive starting at geofence 1, only geofences 1 and 2 exist
+type fakeDriveEditQuerier struct {
+	teslamatedb.Querier
+	updateParams *teslamatedb.UpdateDriveGeofencesParams
+}
+
+func (q *fakeDriveEditQuerier) GeofenceExists(ctx context.Context, id int32) (bool, error) {
+	return id == 1 || id == 2, nil
+}
+
+func (q *fakeDriveEditQuerier) UpdateDriveGeofences(ctx context.Context, arg teslamatedb.UpdateDriveGeofencesParams) (teslamatedb.UpdateDriveGeofencesRow, error) {
+	q.updateParams = &arg
+	row := teslamatedb.UpdateDriveGeofencesRow{OldStartGeofenceID: pgtype.Int4{Int32: 1, Valid: true}}
+	row.NewStartGeofenceID, row.NewEndGeofenceID = row.OldStartGeofenceID, row.OldEndGeofenceID
+	if arg.SetStartGeofence {
+		row.NewStartGeofenceID = arg.StartGeofenceID
+	}
+	if arg.SetEndGeofence {
+		row.NewEndGeofenceID = arg.EndGeofenceID
+	}
+	return row, nil
+}
+
+func TestTeslaMateAPICarsDrivesEditV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ENABLE_EDITS", "true")
+
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken(envToken)
+
+	fake := &fakeDriveEditQuerier{}
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.PATCH("/api/v1/cars/:CarID/drives/:DriveID", TeslaMateAPICarsDrivesEditV1)
+
+	request := func(body string, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPatch, "/api/v1/cars/1/drives/5", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Edit end geofence", func(t *testing.T) {
+		w := request(`{"end_geofence_id": 2}`, envToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		params := fake.updateParams
+		if params == nil || params.SetStartGeofence || !params.SetEndGeofence || params.EndGeofenceID != (pgtype.Int4{Int32: 2, Valid: true}) || params.ID != 5 {
+			t.Errorf("Unexpected update: %+v", params)
+		}
+		body := w.Body.String()
+		if !contains(body, `"drive":{"drive_id":5,"start_geofence_id":1,"end_geofence_id":2}`) || !contains(body, `"changes":{"end_geofence_id":{"old":null,"new":2}}`) {
+			t.Errorf("Unexpected response: %s", body)
+		}
+	})
+
+	t.Run("Clear start geofence", func(t *testing.T) {
+		w := request(`{"start_geofence_id": null}`, envToken)
+		if w.Code != http.StatusOK || !fake.updateParams.SetStartGeofence || fake.updateParams.StartGeofenceID.Valid {
+			t.Errorf("Expected the start geofence to be cleared, got %d and %+v", w.Code, fake.updateParams)
+		}
+	})
+
+	t.Run("Invalid updates", func(t *testing.T) {
+		for _, body := range []string{`{"end_geofence_id": 3}`, `{"end_geofence_id": 1.5}`, `{"distance": 10}`} {
+			if w := request(body, envToken); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+			}
+		}
+	})
+
+	t.Run("Edits need authentication", func(t *testing.T) {
+		if w := request(`{"end_geofence_id": 2}`, "invalid"); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
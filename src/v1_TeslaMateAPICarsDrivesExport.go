@@ -0,0 +1,418 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// driveExport is the track of a drive rendered by the export formats
+type driveExport struct {
+	CarID        int
+	CarName      string
+	DriveID      int
+	StartDate    time.Time
+	EndDate      time.Time
+	StartAddress string
+	EndAddress   string
+	Positions    []teslamatedb.ListDrivePositionsRow
+}
+
+// driveExportFormats are the formats supported by /cars/<CarID>/drives/<DriveID>/export
+var driveExportFormats = map[string]struct {
+	ContentType string
+	Render      func(w io.Writer, d driveExport) error
+}{
+	"gpx":     {"application/gpx+xml", renderDriveGPX},
+	"kml":     {"application/vnd.google-earth.kml+xml", renderDriveKML},
+	"geojson": {"application/geo+json", renderDriveGeoJSON},
+}
+
+// TeslaMateAPICarsDrivesExportV1 func
+func TeslaMateAPICarsDrivesExportV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsDrivesExportError1 = "Unable to load drive."
+		CarsDrivesExportError2 = "Unable to load drive details."
+		CarsDrivesExportError3 = "Invalid export format."
+		CarsDrivesExportError4 = "Unable to export drive."
+	)
+
+	// getting CarID and DriveID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+	DriveID := convertStringToInteger(c.Param("DriveID"))
+
+	// getting format from query parameters
+	ExportFormat := strings.ToLower(c.DefaultQuery("format", "gpx"))
+	format, ok := driveExportFormats[ExportFormat]
+	if !ok {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsDrivesExportV1", CarsDrivesExportError3, "format has to be gpx, kml or geojson, got "+strconv.Quote(ExportFormat))
+		return
+	}
+
+	// getting data from database
+	row, err := queries.GetDrive(c.Request.Context(), teslamatedb.GetDriveParams{
+		CarID: int16(CarID),
+		ID:    int32(DriveID),
+	})
+
+	switch err {
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPICarsDrivesExportV1", "No rows were returned!", err.Error())
+		return
+	case nil:
+		// nothing wrong.. continuing
+		break
+	default:
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesExportV1", CarsDrivesExportError1, err.Error())
+		return
+	}
+
+	// getting detailed drive data from database
+	positions, err := queries.ListDrivePositions(c.Request.Context(), pgtype.Int4{Int32: int32(DriveID), Valid: true})
+
+	// checking for errors in query
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsDrivesExportV1", CarsDrivesExportError2, err.Error())
+		return
+	}
+
+	drive := driveExport{
+		CarID:        CarID,
+		CarName:      row.CarName.String,
+		DriveID:      int(row.DriveID),
+		StartDate:    row.StartDate,
+		EndDate:      row.EndDate.Time,
+		StartAddress: row.StartAddress,
+		EndAddress:   row.EndAddress,
+		Positions:    positions,
+	}
+
+	// rendering the track before sending any header, so errors can still be returned
+	var export strings.Builder
+	if err := format.Render(&export, drive); err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsDrivesExportV1", CarsDrivesExportError4, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="teslamate-car%d-drive%d.%s"`, CarID, DriveID, ExportFormat))
+	c.Data(http.StatusOK, format.ContentType+"; charset=utf-8", []byte(export.String()))
+	log.Println("[info] TeslaMateAPICarsDrivesExportV1 - (" + c.Request.RequestURI + ") executed successfully.")
+}
+
+// name func - returns the name of the drive used in the exports
+func (d driveExport) name() string {
+	return fmt.Sprintf("Drive %d: %s - %s", d.DriveID, d.StartAddress, d.EndAddress)
+}
+
+// description func - returns the description of the drive used in the exports
+func (d driveExport) description() string {
+	return fmt.Sprintf("%s from %s to %s", d.CarName, getTimeInTimeZone(d.StartDate), getTimeInTimeZone(d.EndDate))
+}
+
+// hasElevation func - elevation is only part of the coordinates if all positions have one
+func (d driveExport) hasElevation() bool {
+	for _, position := range d.Positions {
+		if !position.Elevation.Valid {
+			return false
+		}
+	}
+	return len(d.Positions) > 0
+}
+
+// renderDriveGPX func - renders the drive as GPX 1.1 track, speed and outside temperature
+// use the Garmin TrackPointExtension, power and battery level a teslamate extension
+func renderDriveGPX(w io.Writer, d driveExport) error {
+	type TrackPointExtension struct {
+		OutsideTemp *float64 `xml:"gpxtpx:atemp,omitempty"` // degree Celsius
+		Speed       float64  `xml:"gpxtpx:speed"`           // meters per second
+	}
+	type Extensions struct {
+		TrackPointExtension TrackPointExtension `xml:"gpxtpx:TrackPointExtension"`
+		Power               *int16              `xml:"teslamate:power,omitempty"`         // kW
+		BatteryLevel        *int16              `xml:"teslamate:battery_level,omitempty"` // percent
+	}
+	type TrackPoint struct {
+		Lat        float64    `xml:"lat,attr"`
+		Lon        float64    `xml:"lon,attr"`
+		Elevation  *int16     `xml:"ele,omitempty"` // meters
+		Time       string     `xml:"time"`
+		Extensions Extensions `xml:"extensions"`
+	}
+	type Track struct {
+		Name        string       `xml:"name"`
+		Description string       `xml:"desc"`
+		Segment     []TrackPoint `xml:"trkseg>trkpt"`
+	}
+	type Metadata struct {
+		Name string `xml:"name"`
+		Time string `xml:"time"`
+	}
+	type GPX struct {
+		XMLName        xml.Name `xml:"gpx"`
+		Version        string   `xml:"version,attr"`
+		Creator        string   `xml:"creator,attr"`
+		Xmlns          string   `xml:"xmlns,attr"`
+		XmlnsGpxtpx    string   `xml:"xmlns:gpxtpx,attr"`
+		XmlnsTeslaMate string   `xml:"xmlns:teslamate,attr"`
+		Metadata       Metadata `xml:"metadata"`
+		Track          Track    `xml:"trk"`
+	}
+
+	gpx := GPX{
+		Version:        "1.1",
+		Creator:        "TeslaMateApi/" + apiVersion,
+		Xmlns:          "http://www.topografix.com/GPX/1/1",
+		XmlnsGpxtpx:    "http://www.garmin.com/xmlschemas/TrackPointExtension/v2",
+		XmlnsTeslaMate: "https://github.com/tobiasehlert/teslamateapi",
+		Metadata:       Metadata{Name: d.name(), Time: d.StartDate.UTC().Format(time.RFC3339)},
+		Track:          Track{Name: d.name(), Description: d.description(), Segment: []TrackPoint{}},
+	}
+	for _, position := range d.Positions {
+		gpx.Track.Segment = append(gpx.Track.Segment, TrackPoint{
+			Lat:       position.Latitude,
+			Lon:       position.Longitude,
+			Elevation: nullInt16Pointer(position.Elevation),
+			Time:      position.Date.UTC().Format(time.RFC3339),
+			Extensions: Extensions{
+				TrackPointExtension: TrackPointExtension{
+					OutsideTemp: nullFloat64Pointer(position.OutsideTemp),
+					Speed:       float64(position.Speed) / 3.6,
+				},
+				Power:        nullInt16Pointer(position.Power),
+				BatteryLevel: nullInt16Pointer(position.BatteryLevel),
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(gpx); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// renderDriveKML func - renders the drive as KML 2.2 gx:Track with speed, power
+// and battery level as extended data
+func renderDriveKML(w io.Writer, d driveExport) error {
+	type SimpleField struct {
+		Type        string `xml:"type,attr"`
+		Name        string `xml:"name,attr"`
+		DisplayName string `xml:"displayName"`
+	}
+	type Schema struct {
+		ID          string        `xml:"id,attr"`
+		SimpleField []SimpleField `xml:"gx:SimpleArrayField"`
+	}
+	type SimpleArrayData struct {
+		Name   string   `xml:"name,attr"`
+		Values []string `xml:"gx:value"`
+	}
+	type SchemaData struct {
+		SchemaURL       string            `xml:"schemaUrl,attr"`
+		SimpleArrayData []SimpleArrayData `xml:"gx:SimpleArrayData"`
+	}
+	type Track struct {
+		AltitudeMode string     `xml:"altitudeMode"`
+		When         []string   `xml:"when"`
+		Coord        []string   `xml:"gx:coord"`
+		SchemaData   SchemaData `xml:"ExtendedData>SchemaData"`
+	}
+	type Placemark struct {
+		Name        string `xml:"name"`
+		Description string `xml:"description"`
+		TimeSpan    struct {
+			Begin string `xml:"begin"`
+			End   string `xml:"end"`
+		} `xml:"TimeSpan"`
+		Track Track `xml:"gx:Track"`
+	}
+	type Document struct {
+		Name      string    `xml:"name"`
+		Schema    Schema    `xml:"Schema"`
+		Placemark Placemark `xml:"Placemark"`
+	}
+	type KML struct {
+		XMLName  xml.Name `xml:"kml"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		XmlnsGx  string   `xml:"xmlns:gx,attr"`
+		Document Document `xml:"Document"`
+	}
+
+	speed := SimpleArrayData{Name: "speed", Values: []string{}}
+	power := SimpleArrayData{Name: "power", Values: []string{}}
+	batteryLevel := SimpleArrayData{Name: "battery_level", Values: []string{}}
+	track := Track{AltitudeMode: "clampToGround", When: []string{}, Coord: []string{}}
+	withElevation := d.hasElevation()
+	if withElevation {
+		track.AltitudeMode = "absolute"
+	}
+	for _, position := range d.Positions {
+		altitude := 0
+		if withElevation {
+			altitude = int(position.Elevation.Int16)
+		}
+		coord := strconv.FormatFloat(position.Longitude, 'f', -1, 64) + " " + strconv.FormatFloat(position.Latitude, 'f', -1, 64) + " " + strconv.Itoa(altitude)
+		track.When = append(track.When, position.Date.UTC().Format(time.RFC3339))
+		track.Coord = append(track.Coord, coord)
+		speed.Values = append(speed.Values, strconv.Itoa(int(position.Speed)))
+		power.Values = append(power.Values, nullInt16String(position.Power))
+		batteryLevel.Values = append(batteryLevel.Values, nullInt16String(position.BatteryLevel))
+	}
+	track.SchemaData = SchemaData{SchemaURL: "#drive", SimpleArrayData: []SimpleArrayData{speed, power, batteryLevel}}
+
+	kml := KML{
+		Xmlns:   "http://www.opengis.net/kml/2.2",
+		XmlnsGx: "http://www.google.com/kml/ext/2.2",
+		Document: Document{
+			Name: d.name(),
+			Schema: Schema{ID: "drive", SimpleField: []SimpleField{
+				{Type: "int", Name: "speed", DisplayName: "Speed (km/h)"},
+				{Type: "int", Name: "power", DisplayName: "Power (kW)"},
+				{Type: "int", Name: "battery_level", DisplayName: "Battery level (%)"},
+			}},
+			Placemark: Placemark{Name: d.name(), Description: d.description(), Track: track},
+		},
+	}
+	kml.Document.Placemark.TimeSpan.Begin = d.StartDate.UTC().Format(time.RFC3339)
+	kml.Document.Placemark.TimeSpan.End = d.EndDate.UTC().Format(time.RFC3339)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(kml); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// renderDriveGeoJSON func - renders the drive as GeoJSON (RFC 7946) LineString feature, a Point for a single
+// position and without geometry for no positions, times, speed, power and battery level of the coordinates
+// are in coordinateProperties
+func renderDriveGeoJSON(w io.Writer, d driveExport) error {
+	type CoordinateProperties struct {
+		Times        []string    `json:"times"`
+		Elevation    []NullInt64 `json:"elevation"`     // meters
+		Speed        []int       `json:"speed"`         // km/h
+		Power        []NullInt64 `json:"power"`         // kW
+		BatteryLevel []NullInt64 `json:"battery_level"` // percent
+	}
+	type Properties struct {
+		CarID                int                  `json:"car_id"`
+		CarName              string               `json:"car_name"`
+		DriveID              int                  `json:"drive_id"`
+		Name                 string               `json:"name"`
+		StartDate            string               `json:"start_date"`
+		EndDate              string               `json:"end_date"`
+		StartAddress         string               `json:"start_address"`
+		EndAddress           string               `json:"end_address"`
+		CoordinateProperties CoordinateProperties `json:"coordinateProperties"`
+	}
+	type Geometry struct {
+		Type        string `json:"type"`
+		Coordinates any    `json:"coordinates"`
+	}
+	type Feature struct {
+		Type       string     `json:"type"`
+		Geometry   *Geometry  `json:"geometry"`
+		Properties Properties `json:"properties"`
+	}
+	type FeatureCollection struct {
+		Type     string    `json:"type"`
+		Features []Feature `json:"features"`
+	}
+
+	withElevation := d.hasElevation()
+
+	feature := Feature{
+		Type: "Feature",
+		Properties: Properties{
+			CarID:        d.CarID,
+			CarName:      d.CarName,
+			DriveID:      d.DriveID,
+			Name:         d.name(),
+			StartDate:    getTimeInTimeZone(d.StartDate),
+			EndDate:      getTimeInTimeZone(d.EndDate),
+			StartAddress: d.StartAddress,
+			EndAddress:   d.EndAddress,
+			CoordinateProperties: CoordinateProperties{
+				Times:        []string{},
+				Elevation:    []NullInt64{},
+				Speed:        []int{},
+				Power:        []NullInt64{},
+				BatteryLevel: []NullInt64{},
+			},
+		},
+	}
+	var coordinates [][]float64
+	for _, position := range d.Positions {
+		coordinate := []float64{position.Longitude, position.Latitude}
+		if withElevation {
+			coordinate = append(coordinate, float64(position.Elevation.Int16))
+		}
+		coordinates = append(coordinates, coordinate)
+
+		properties := &feature.Properties.CoordinateProperties
+		properties.Times = append(properties.Times, getTimeInTimeZone(position.Date))
+		properties.Elevation = append(properties.Elevation, nullInt16ToNullInt64(position.Elevation))
+		properties.Speed = append(properties.Speed, int(position.Speed))
+		properties.Power = append(properties.Power, nullInt16ToNullInt64(position.Power))
+		properties.BatteryLevel = append(properties.BatteryLevel, nullInt16ToNullInt64(position.BatteryLevel))
+	}
+
+	// a LineString needs at least two positions, without positions the geometry is null
+	if len(coordinates) == 1 {
+		feature.Geometry = &Geometry{Type: "Point", Coordinates: coordinates[0]}
+	} else if len(coordinates) > 1 {
+		feature.Geometry = &Geometry{Type: "LineString", Coordinates: coordinates}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(FeatureCollection{Type: "FeatureCollection", Features: []Feature{feature}})
+}
+
+// nullInt16Pointer func - returns nil for NULL, used for optional xml elements
+func nullInt16Pointer(n pgtype.Int2) *int16 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int16
+}
+
+// nullFloat64Pointer func - returns nil for NULL, used for optional xml elements
+func nullFloat64Pointer(n pgtype.Float8) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Float64
+}
+
+// nullInt16String func - returns an empty string for NULL, used for KML array data
+func nullInt16String(n pgtype.Int2) string {
+	if !n.Valid {
+		return ""
+	}
+	return strconv.Itoa(int(n.Int16))
+}
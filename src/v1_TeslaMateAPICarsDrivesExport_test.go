@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeDriveExportQuerier returns drive 42 with two positions, 43 with one and 44 without positions,
+// all other queries are not implemented
+type fakeDriveExportQuerier struct {
+	teslamatedb.Querier
+}
+
+func (q *fakeDriveExportQuerier) GetDrive(ctx context.Context, arg teslamatedb.GetDriveParams) (teslamatedb.GetDriveRow, error) {
+	if arg.ID < 42 || arg.ID > 44 {
+		return teslamatedb.GetDriveRow{}, pgx.ErrNoRows
+	}
+	startDate := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	return teslamatedb.GetDriveRow{
+		DriveID:      arg.ID,
+		StartDate:    startDate,
+		EndDate:      pgtype.Timestamp{Time: startDate.Add(time.Minute), Valid: true},
+		StartAddress: "Home",
+		EndAddress:   "Work",
+		CarName:      pgtype.Text{String: "Test Tesla", Valid: true},
+	}, nil
+}
+
+func (q *fakeDriveExportQuerier) ListDrivePositions(ctx context.Context, driveID pgtype.Int4) ([]teslamatedb.ListDrivePositionsRow, error) {
+	date := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	positions := []teslamatedb.ListDrivePositionsRow{
+		{DetailID: 1, Date: date, Latitude: 59.3293, Longitude: 18.0686, Speed: 36, Power: pgtype.Int2{Int16: 12, Valid: true}, Elevation: pgtype.Int2{Int16: 20, Valid: true}, OutsideTemp: pgtype.Float8{Float64: 15.5, Valid: true}},
+		{DetailID: 2, Date: date.Add(time.Minute), Latitude: 59.3300, Longitude: 18.0700, Speed: 0, Power: pgtype.Int2{Int16: -5, Valid: true}, Elevation: pgtype.Int2{Int16: 22, Valid: true}},
+	}
+	return positions[:44-driveID.Int32], nil
+}
+
+func TestTeslaMateAPICarsDrivesExportV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	originalQueries := queries
+	queries = &fakeDriveExportQuerier{}
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/drives/:DriveID/export", TeslaMateAPICarsDrivesExportV1)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("GPX", func(t *testing.T) {
+		w := request("/api/v1/cars/1/drives/42/export?format=gpx")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/gpx+xml; charset=utf-8" {
+			t.Errorf("Expected GPX content type, got '%s'", contentType)
+		}
+		if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="teslamate-car1-drive42.gpx"` {
+			t.Errorf("Unexpected Content-Disposition '%s'", disposition)
+		}
+
+		var gpx struct {
+			Points []struct {
+				Lat   float64 `xml:"lat,attr"`
+				Ele   int     `xml:"ele"`
+				Time  string  `xml:"time"`
+				Speed float64 `xml:"extensions>TrackPointExtension>speed"`
+				Power int     `xml:"extensions>power"`
+			} `xml:"trk>trkseg>trkpt"`
+		}
+		if err := xml.Unmarshal(w.Body.Bytes(), &gpx); err != nil {
+			t.Fatalf("Invalid GPX: %s", err)
+		}
+		if len(gpx.Points) != 2 {
+			t.Fatalf("Expected 2 track points, got %d", len(gpx.Points))
+		}
+		point := gpx.Points[0]
+		if point.Lat != 59.3293 || point.Ele != 20 || point.Time != "2024-05-01T08:00:00Z" || point.Speed != 10 || point.Power != 12 {
+			t.Errorf("Unexpected track point %+v", point)
+		}
+		for _, expected := range []string{`xmlns="http://www.topografix.com/GPX/1/1"`, `<gpxtpx:atemp>15.5</gpxtpx:atemp>`} {
+			if !contains(w.Body.String(), expected) {
+				t.Errorf("Expected GPX to contain '%s', got: %s", expected, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("KML", func(t *testing.T) {
+		w := request("/api/v1/cars/1/drives/42/export?format=kml")
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/vnd.google-earth.kml+xml; charset=utf-8" {
+			t.Errorf("Expected KML content type, got '%s'", contentType)
+		}
+
+		var kml struct {
+			When  []string `xml:"Document>Placemark>Track>when"`
+			Coord []string `xml:"Document>Placemark>Track>coord"`
+		}
+		if err := xml.Unmarshal(w.Body.Bytes(), &kml); err != nil {
+			t.Fatalf("Invalid KML: %s", err)
+		}
+		if len(kml.When) != 2 || kml.Coord[1] != "18.07 59.33 22" {
+			t.Errorf("Unexpected track %+v", kml)
+		}
+	})
+
+	t.Run("GeoJSON", func(t *testing.T) {
+		w := request("/api/v1/cars/1/drives/42/export?format=geojson")
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/geo+json; charset=utf-8" {
+			t.Errorf("Expected GeoJSON content type, got '%s'", contentType)
+		}
+
+		var geojson struct {
+			Type     string `json:"type"`
+			Features []struct {
+				Geometry struct {
+					Type        string      `json:"type"`
+					Coordinates [][]float64 `json:"coordinates"`
+				} `json:"geometry"`
+				Properties struct {
+					DriveID              int `json:"drive_id"`
+					CoordinateProperties struct {
+						Times []string `json:"times"`
+						Power []*int   `json:"power"`
+					} `json:"coordinateProperties"`
+				} `json:"properties"`
+			} `json:"features"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &geojson); err != nil {
+			t.Fatalf("Invalid GeoJSON: %s", err)
+		}
+		if geojson.Type != "FeatureCollection" || len(geojson.Features) != 1 {
+			t.Fatalf("Expected a FeatureCollection with one feature, got: %s", w.Body.String())
+		}
+		feature := geojson.Features[0]
+		if feature.Geometry.Type != "LineString" || len(feature.Geometry.Coordinates) != 2 {
+			t.Fatalf("Expected LineString with 2 coordinates, got %+v", feature.Geometry)
+		}
+		if coordinate := feature.Geometry.Coordinates[0]; coordinate[0] != 18.0686 || coordinate[1] != 59.3293 || coordinate[2] != 20 {
+			t.Errorf("Expected [lon, lat, elevation], got %v", coordinate)
+		}
+		if times := feature.Properties.CoordinateProperties.Times; len(times) != 2 || times[1] != "2024-05-01T08:01:00Z" {
+			t.Errorf("Unexpected times %v", times)
+		}
+		if power := feature.Properties.CoordinateProperties.Power; len(power) != 2 || *power[1] != -5 {
+			t.Errorf("Unexpected power %v", power)
+		}
+	})
+
+	t.Run("GeoJSON of short drives", func(t *testing.T) {
+		for path, expected := range map[string]string{
+			"/api/v1/cars/1/drives/43/export?format=geojson": `{"type":"Point","coordinates":[18.0686,59.3293,20]}`,
+			"/api/v1/cars/1/drives/44/export?format=geojson": `null`,
+		} {
+			var geojson struct {
+				Features []struct {
+					Geometry json.RawMessage `json:"geometry"`
+				} `json:"features"`
+			}
+			w := request(path)
+			if err := json.Unmarshal(w.Body.Bytes(), &geojson); err != nil || len(geojson.Features) != 1 {
+				t.Fatalf("Expected a FeatureCollection with one feature, got: %s", w.Body.String())
+			}
+			var geometry bytes.Buffer
+			if err := json.Compact(&geometry, geojson.Features[0].Geometry); err != nil || geometry.String() != expected {
+				t.Errorf("Expected geometry %s for %s, got %s", expected, path, geometry.String())
+			}
+		}
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		w := request("/api/v1/cars/1/drives/42/export?format=shp")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("Drive not found", func(t *testing.T) {
+		w := request("/api/v1/cars/1/drives/7/export?format=gpx")
+		if !contains(w.Body.String(), `"error":"No rows were returned!"`) {
+			t.Errorf("Expected legacy error, got: %s", w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeDrivesQuerier returns fixed drives, all other queries are not implemented
+type fakeDrivesQuerier struct {
+	teslamatedb.Querier
+	params teslamatedb.ListDrivesParams
+	rows   []teslamatedb.ListDrivesRow
+}
+
+func (q *fakeDrivesQuerier) ListDrives(ctx context.Context, arg teslamatedb.ListDrivesParams) ([]teslamatedb.ListDrivesRow, error) {
+	q.params = arg
+	return q.rows, nil
+}
+
+func TestTeslaMateAPICarsDrivesV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	startDate := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	fake := &fakeDrivesQuerier{rows: []teslamatedb.ListDrivesRow{{
+		DriveID:           42,
+		StartDate:         startDate,
+		EndDate:           pgtype.Timestamp{Time: startDate.Add(30 * time.Minute), Valid: true},
+		StartAddress:      "Home",
+		EndAddress:        "Work",
+		Distance:          pgtype.Float8{Float64: 100, Valid: true},
+		DurationMin:       pgtype.Int2{Int16: 30, Valid: true},
+		DurationStr:       "00:30",
+		StartIdealRangeKm: pgtype.Float8{Float64: 300, Valid: true},
+		UnitOfLength:      "mi",
+		UnitOfTemperature: "C",
+		CarName:           pgtype.Text{String: "Test Tesla", Valid: true},
+	}}}
+
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/drives", TeslaMateAPICarsDrivesV1)
+
+	req, _ := http.NewRequest("GET", "/api/v1/cars/1/drives?page=2&show=10&startDate=2024-05-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", w.Code)
+	}
+
+	t.Run("Query parameters", func(t *testing.T) {
+		if fake.params.CarID != 1 || fake.params.Limit != 10 || fake.params.Offset != 10 {
+			t.Errorf("Expected car 1 with limit 10 and offset 10, got %+v", fake.params)
+		}
+		if !fake.params.StartDate.Valid || !fake.params.StartDate.Time.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Expected start date 2024-05-01, got %+v", fake.params.StartDate)
+		}
+		if fake.params.EndDate.Valid {
+			t.Errorf("Expected no end date, got %+v", fake.params.EndDate)
+		}
+	})
+
+	t.Run("Response body", func(t *testing.T) {
+		body := w.Body.String()
+		for _, expected := range []string{
+			`"car_name":"Test Tesla"`,
+			`"drive_id":42`,
+			`"start_date":"2024-05-01T08:00:00Z"`,
+			`"odometer_distance":62.13711922373`,
+			`"start_range":186.41135767`,
+			`"unit_of_length":"mi"`,
+		} {
+			if !contains(body, expected) {
+				t.Errorf("Expected response to contain '%s', but it didn't. Body: %s", expected, body)
+			}
+		}
+	})
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -23,49 +22,59 @@ func TeslaMateAPICarsLoggingV1(c *gin.Context) {
 
 	// check if commands are enabled.. if not we need to abort
 	if !getEnvAsBool("ENABLE_COMMANDS", false) {
-		log.Println("[warning] TeslaMateAPICarsLoggingV1 ENABLE_COMMANDS is not true.. returning 403 forbidden.")
-		TeslaMateAPIHandleOtherResponse(c, http.StatusForbidden, "TeslaMateAPICarsLoggingV1", gin.H{"error": "You are not allowed to access logging commands"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusForbidden, "TeslaMateAPICarsLoggingV1", "You are not allowed to access logging commands", "ENABLE_COMMANDS is not true")
 		return
 	}
 
 	// if request method is GET return list of commands
 	if c.Request.Method == http.MethodGet {
-		TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsLoggingV1", gin.H{"enabled_commands": allowList})
+		TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsLoggingV1", gin.H{"enabled_commands": commandAllowList.Commands()})
 		return
 	}
 
 	// authentication for the endpoint
 	validToken, errorMessage := validateAuthToken(c)
 	if !validToken {
-		TeslaMateAPIHandleOtherResponse(c, http.StatusUnauthorized, "TeslaMateAPICarsLoggingV1", gin.H{"error": errorMessage})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusUnauthorized, "TeslaMateAPICarsLoggingV1", errorMessage, "invalid or missing bearer token")
 		return
 	}
 
 	// getting CarID param from URL and validating that it's not zero
 	CarID := convertStringToInteger(c.Param("CarID"))
 	if CarID == 0 {
-		log.Println("[error] TeslaMateAPICarsLoggingV1 CarID is invalid (zero)!")
-		TeslaMateAPIHandleOtherResponse(c, http.StatusBadRequest, "TeslaMateAPICarsLoggingV1", gin.H{"error": "CarID invalid"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsLoggingV1", "CarID invalid", "CarID is invalid (zero)")
 		return
 	}
 
 	// getting request body to pass to Tesla
 	reqBody, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		log.Println("[error] TeslaMateAPICarsLoggingV1 error in first io.ReadAll", err)
-		TeslaMateAPIHandleOtherResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsLoggingV1", gin.H{"error": "internal io reading error"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsLoggingV1", "internal io reading error", err.Error())
 		return
 	}
 
 	// getting :Command
 	command := ("/logging/" + c.Param("Command"))
 
-	if !checkArrayContainsString(allowList, command) {
-		log.Printf("[warning] TeslaMateAPICarsLoggingV1 command not allowed!")
-		TeslaMateAPIHandleOtherResponse(c, http.StatusUnauthorized, "TeslaMateAPICarsLoggingV1", gin.H{"error": "unauthorized"})
+	// v1 keeps answering 401 for commands that aren't enabled, v2 answers 403 since the token is valid
+	if !commandAllowList.Allowed(command) {
+		handleErrorResponse(c, http.StatusUnauthorized, http.StatusForbidden, "TeslaMateAPICarsLoggingV1", "unauthorized", "command "+command+" is not allowed")
 		return
 	}
 
+	// the scopes, time windows and invocations per hour of the allow list,
+	// the invocation only counts if TeslaMate accepted the command
+	release, ok := checkCommandPolicy(c, "TeslaMateAPICarsLoggingV1", CarID, command, reqBody)
+	if !ok {
+		return
+	}
+	sent := false
+	defer func() {
+		if !sent {
+			release()
+		}
+	}()
+
 	client := &http.Client{}
 	putURL := ""
 	if getEnvAsBool("TESLAMATE_SSL", false) {
@@ -80,18 +89,17 @@ func TeslaMateAPICarsLoggingV1(c *gin.Context) {
 
 	// check response error
 	if err != nil {
-		log.Println("[error] TeslaMateAPICarsLoggingV1 error in http request to http://teslamate:", err)
-		TeslaMateAPIHandleOtherResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsLoggingV1", gin.H{"error": "internal http request error"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsLoggingV1", "internal http request error", err.Error())
 		return
 	}
 
 	defer resp.Body.Close()
 	defer client.CloseIdleConnections()
+	sent = resp.StatusCode >= 200 && resp.StatusCode < 300
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Println("[error] TeslaMateAPICarsLoggingV1 error in second io.ReadAll:", err)
-		TeslaMateAPIHandleOtherResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsLoggingV1", gin.H{"error": "internal io reading error"})
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusInternalServerError, "TeslaMateAPICarsLoggingV1", "internal io reading error", err.Error())
 		return
 	}
 	json.Unmarshal([]byte(respBody), &jsonData)
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPICarsStatsV1 func
+func TeslaMateAPICarsStatsV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsStatsError1 = "Unable to load statistics."
+		CarsStatsError2 = "Invalid date format."
+		CarsStatsError3 = "Invalid period."
+	)
+
+	// getting CarID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+	// query options to modify query when collecting data
+	ResultPage := convertStringToInteger(c.DefaultQuery("page", "1"))
+	ResultShow := convertStringToInteger(c.DefaultQuery("show", "100"))
+
+	// get period of the buckets from query parameters
+	Period := c.DefaultQuery("period", "month")
+	periodFormat, ok := statisticsPeriodFormats[Period]
+	if !ok {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsStatsV1", CarsStatsError3, "period has to be day, week, month or year, got "+strconv.Quote(Period))
+		return
+	}
+
+	// get startDate and endDate from query parameters
+	parsedStartDate, err := parseDateParam(c.Query("startDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsStatsV1", CarsStatsError2, err.Error())
+		return
+	}
+	parsedEndDate, err := parseDateParam(c.Query("endDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsStatsV1", CarsStatsError2, err.Error())
+		return
+	}
+
+	// creating structs for /cars/<CarID>/stats
+	// Car struct - child of Data
+	type Car struct {
+		CarID   int        `json:"car_id"`   // smallint
+		CarName NullString `json:"car_name"` // text (nullable)
+	}
+	// Statistic struct - child of Data
+	type Statistic struct {
+		Period         string      `json:"period"`           // string
+		StartDate      string      `json:"start_date"`       // string
+		Drives         int         `json:"drives"`           // int
+		Distance       float64     `json:"distance"`         // float64
+		DurationMin    int         `json:"duration_min"`     // int
+		DurationStr    string      `json:"duration_str"`     // string
+		EnergyConsumed NullFloat64 `json:"energy_consumed"`  // float64 (kWh, nullable)
+		Efficiency     NullFloat64 `json:"efficiency"`       // float64 (Wh/km or Wh/mi, nullable)
+		Charges        int         `json:"charges"`          // int
+		EnergyCharged  float64     `json:"energy_charged"`   // float64 (kWh)
+		Cost           NullFloat64 `json:"cost"`             // float64 (nullable)
+		OutsideTempAvg NullFloat64 `json:"outside_temp_avg"` // float64 (nullable)
+	}
+	// TeslaMateUnits struct - child of Data
+	type TeslaMateUnits struct {
+		UnitsLength      string `json:"unit_of_length"`      // string
+		UnitsTemperature string `json:"unit_of_temperature"` // string
+	}
+	// Data struct - child of JSONData
+	type Data struct {
+		Car            Car            `json:"car"`
+		Period         string         `json:"period"`
+		Statistics     []Statistic    `json:"statistics"`
+		TeslaMateUnits TeslaMateUnits `json:"units"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// creating required vars
+	var (
+		CarName                       NullString
+		StatisticsData                = []Statistic{}
+		UnitsLength, UnitsTemperature string
+	)
+
+	// calculate offset based on page (page 0 is not possible, since first page is minimum 1)
+	if ResultPage > 0 {
+		ResultPage--
+	} else {
+		ResultPage = 0
+	}
+	ResultPage = (ResultPage * ResultShow)
+
+	// buckets are truncated in the users timezone by the database
+	TimeZone := getTimeZoneName()
+	location, err := time.LoadLocation(TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	// getting data from database
+	buckets, err := queries.ListStatistics(c.Request.Context(), teslamatedb.ListStatisticsParams{
+		Period:    Period,
+		TimeZone:  TimeZone,
+		CarID:     int16(CarID),
+		StartDate: parsedStartDate,
+		EndDate:   parsedEndDate,
+		Limit:     int32(ResultShow),
+		Offset:    int32(ResultPage),
+	})
+
+	// checking for errors in query
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsStatsV1", CarsStatsError1, err.Error())
+		return
+	}
+
+	// looping through all results
+	for _, row := range buckets {
+
+		// creating statistic object based on struct
+		statistic := Statistic{
+			Period:         periodFormat(row.Period),
+			StartDate:      statisticsPeriodStart(row.Period, location).Format(time.RFC3339),
+			Drives:         int(row.Drives),
+			Distance:       row.DistanceKm,
+			DurationMin:    int(row.DurationMin),
+			DurationStr:    fmt.Sprintf("%02d:%02d", row.DurationMin/60, row.DurationMin%60),
+			EnergyConsumed: NullFloat64{row.EnergyConsumed},
+			Charges:        int(row.Charges),
+			EnergyCharged:  row.EnergyCharged,
+			Cost:           NullFloat64{row.Cost},
+			OutsideTempAvg: NullFloat64{row.OutsideTempAvg},
+		}
+		UnitsLength = row.UnitOfLength
+		UnitsTemperature = row.UnitOfTemperature
+		CarName = NullString(row.CarName.String)
+
+		// efficiency only uses the distance of drives with a known consumption
+		efficiencyDistance := row.EnergyConsumedDistanceKm.Float64
+
+		// converting values based of settings UnitsLength
+		if UnitsLength == "mi" {
+			statistic.Distance = kilometersToMiles(statistic.Distance)
+			efficiencyDistance = kilometersToMiles(efficiencyDistance)
+		}
+		// converting values based of settings UnitsTemperature
+		if UnitsTemperature == "F" && statistic.OutsideTempAvg.Valid {
+			statistic.OutsideTempAvg = celsiusToFahrenheitNilSupport(statistic.OutsideTempAvg)
+		}
+
+		if row.EnergyConsumed.Valid && efficiencyDistance > 0 {
+			statistic.Efficiency = NullFloat64{pgtype.Float8{Float64: row.EnergyConsumed.Float64 * 1000 / efficiencyDistance, Valid: true}}
+		}
+
+		// appending statistic to StatisticsData
+		StatisticsData = append(StatisticsData, statistic)
+	}
+
+	//
+	// build the data-blob
+	jsonData := JSONData{
+		Data{
+			Car: Car{
+				CarID:   CarID,
+				CarName: CarName,
+			},
+			Period:     Period,
+			Statistics: StatisticsData,
+			TeslaMateUnits: TeslaMateUnits{
+				UnitsLength:      UnitsLength,
+				UnitsTemperature: UnitsTemperature,
+			},
+		},
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsStatsV1", jsonData)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeStatsQuerier returns a week with drives and charges and a week with a charge only, all other queries are not implemented
+type fakeStatsQuerier struct {
+	teslamatedb.Querier
+	params       teslamatedb.ListStatisticsParams
+	unitOfLength string
+}
+
+func (q *fakeStatsQuerier) ListStatistics(ctx context.Context, arg teslamatedb.ListStatisticsParams) ([]teslamatedb.ListStatisticsRow, error) {
+	q.params = arg
+	return []teslamatedb.ListStatisticsRow{{
+		Period:                   time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC),
+		Drives:                   3,
+		DistanceKm:               100,
+		DurationMin:              95,
+		EnergyConsumed:           pgtype.Float8{Float64: 15, Valid: true},
+		EnergyConsumedDistanceKm: pgtype.Float8{Float64: 80, Valid: true},
+		OutsideTempAvg:           pgtype.Float8{Float64: 10, Valid: true},
+		Charges:                  1,
+		EnergyCharged:            20.5,
+		Cost:                     pgtype.Float8{Float64: 6.15, Valid: true},
+		UnitOfLength:             q.unitOfLength,
+		UnitOfTemperature:        "C",
+		CarName:                  pgtype.Text{String: "Test Tesla", Valid: true},
+	}, {
+		Period:            time.Date(2024, 4, 22, 0, 0, 0, 0, time.UTC),
+		Charges:           1,
+		EnergyCharged:     10,
+		UnitOfLength:      q.unitOfLength,
+		UnitOfTemperature: "C",
+	}}, nil
+}
+
+func TestTeslaMateAPICarsStatsV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("Europe/Berlin")
+	defer func() { appUsersTimezone, _ = time.LoadLocation("UTC") }()
+
+	fake := &fakeStatsQuerier{unitOfLength: "km"}
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/stats", TeslaMateAPICarsStatsV1)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	type statsResponse struct {
+		Data struct {
+			Period     string `json:"period"`
+			Statistics []struct {
+				Period         string   `json:"period"`
+				StartDate      string   `json:"start_date"`
+				Distance       float64  `json:"distance"`
+				DurationStr    string   `json:"duration_str"`
+				Efficiency     *float64 `json:"efficiency"`
+				Cost           *float64 `json:"cost"`
+				OutsideTempAvg *float64 `json:"outside_temp_avg"`
+			} `json:"statistics"`
+		} `json:"data"`
+	}
+
+	t.Run("Weekly statistics", func(t *testing.T) {
+		w := request("/api/v1/cars/1/stats?period=week")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if fake.params.Period != "week" || fake.params.TimeZone != "Europe/Berlin" || fake.params.CarID != 1 || fake.params.Limit != 100 {
+			t.Errorf("Unexpected query parameters: %+v", fake.params)
+		}
+
+		var resp statsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if resp.Data.Period != "week" || len(resp.Data.Statistics) != 2 {
+			t.Fatalf("Unexpected statistics: %s", w.Body.String())
+		}
+		week := resp.Data.Statistics[0]
+		if week.Period != "2024-W18" || week.StartDate != "2024-04-29T00:00:00+02:00" || week.DurationStr != "01:35" {
+			t.Errorf("Unexpected week: %+v", week)
+		}
+		if week.Efficiency == nil || math.Abs(*week.Efficiency-187.5) > 1e-9 {
+			t.Errorf("Expected efficiency of 187.5 Wh/km, got %v", week.Efficiency)
+		}
+		if week.Cost == nil || *week.Cost != 6.15 || week.OutsideTempAvg == nil || *week.OutsideTempAvg != 10 {
+			t.Errorf("Unexpected cost or temperature: %v %v", week.Cost, week.OutsideTempAvg)
+		}
+		if week := resp.Data.Statistics[1]; week.Efficiency != nil || week.Cost != nil || week.OutsideTempAvg != nil {
+			t.Errorf("Expected null values for a week without drives and cost, got %+v", week)
+		}
+	})
+
+	t.Run("Miles", func(t *testing.T) {
+		fake.unitOfLength = "mi"
+		defer func() { fake.unitOfLength = "km" }()
+
+		var resp statsResponse
+		if err := json.Unmarshal(request("/api/v1/cars/1/stats").Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if fake.params.Period != "month" {
+			t.Errorf("Expected month as default period, got %s", fake.params.Period)
+		}
+		if week := resp.Data.Statistics[0]; math.Abs(week.Distance-62.137119223733) > 1e-9 || math.Abs(*week.Efficiency-301.7518) > 1e-3 {
+			t.Errorf("Expected distance and efficiency in miles, got %v and %v", week.Distance, *week.Efficiency)
+		}
+	})
+
+	t.Run("Invalid period", func(t *testing.T) {
+		w := request("/api/v1/cars/1/stats?period=quarter")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
@@ -20,9 +20,9 @@ func TeslaMateAPICarsStatusV1(c *gin.Context) {
 	mapper := NewCarStatusMapper()
 
 	// Get car status from database
-	statusData, err := statusService.GetCarStatus(carID)
+	statusData, err := statusService.GetCarStatusContext(c.Request.Context(), carID)
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsStatusV1", "Failed to retrieve car status", err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsStatusV1", "Failed to retrieve car status", err.Error())
 		return
 	}
 
@@ -32,15 +32,22 @@ func TeslaMateAPICarsStatusV1(c *gin.Context) {
 	// Map database results to API response format
 	response := mapper.MapToResponse(statusData, vehicleState)
 
+	// Resolve geofence and address of the latest position, the status is returned without them on failure
+	location, err := statusService.GetCarLocation(c.Request.Context(), statusData)
+	if err != nil {
+		log.Printf("[warning] TeslaMateAPICarsStatusV1 - unable to resolve location of car %d: %s", carID, err)
+	}
+	mapper.ApplyLocation(response, location)
+
 	// Apply unit conversions based on user preferences
 	mapper.ApplyUnitConversions(response)
 
 	// Log charging status for debugging
 	if gin.IsDebugging() {
-		log.Printf("[debug] TeslaMateAPICarsStatusV1 - Car %d: plugged_in=%t, is_charging_from_db=%t", 
-			carID, 
+		log.Printf("[debug] TeslaMateAPICarsStatusV1 - Car %d: plugged_in=%t, is_charging_from_db=%t",
+			carID,
 			response.Status.ChargingDetails.PluggedIn,
-			statusData.IsCharging.Valid && statusData.IsCharging.Bool,
+			statusData.IsCharging,
 		)
 	}
 	respData := Data{Data: response}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// carStatusBroker is shared by all status streams
+var carStatusBroker *CarStatusBroker
+
+// carStatusStreamHeartbeat is the interval of heartbeat events of STATUS_STREAM_HEARTBEAT
+var carStatusStreamHeartbeat = 15 * time.Second
+
+// initCarStatusBroker func
+func initCarStatusBroker() {
+	interval := time.Duration(getEnvAsPositiveInt("STATUS_STREAM_INTERVAL", 5)) * time.Second
+	carStatusStreamHeartbeat = time.Duration(getEnvAsPositiveInt("STATUS_STREAM_HEARTBEAT", 15)) * time.Second
+	carStatusBroker = NewCarStatusBroker(NewCarStatusService(db), NewCarStatusMapper(), interval)
+	if changeFeed != nil {
+		carStatusBroker.UseChangeFeed(changeFeed)
+	}
+	if gin.IsDebugging() {
+		log.Println("[debug] initCarStatusBroker - checking car status changes every", interval)
+	}
+}
+
+// TeslaMateAPICarsStatusStreamV1 pushes the car status as Server-Sent Events whenever it changes
+func TeslaMateAPICarsStatusStreamV1(c *gin.Context) {
+	// Parse car ID from URL
+	carID := convertStringToInteger(c.Param("CarID"))
+
+	// Subscribe to status changes, resuming after Last-Event-ID if the client reconnects
+	events, updates, unsubscribe, err := carStatusBroker.Subscribe(carID, c.GetHeader("Last-Event-ID"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsStatusStreamV1", "Failed to retrieve car status", err.Error())
+		return
+	}
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(carStatusStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream;charset=utf-8")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	log.Printf("[info] TeslaMateAPICarsStatusStreamV1 - (%s) stream opened.", c.Request.RequestURI)
+
+	for _, event := range events {
+		writeCarStatusEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			log.Printf("[info] TeslaMateAPICarsStatusStreamV1 - (%s) stream closed.", c.Request.RequestURI)
+			return
+		case event := <-updates:
+			writeCarStatusEvent(c, event)
+		case t := <-heartbeat.C:
+			c.Render(-1, sse.Event{Event: "heartbeat", Data: gin.H{"time": t.In(appUsersTimezone).Format(time.RFC3339)}})
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeCarStatusEvent writes a status event with the same payload as /status
+func writeCarStatusEvent(c *gin.Context, event CarStatusEvent) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatInt(event.ID, 10),
+		Event: "status",
+		Data:  Data{Data: event.Response},
+	})
+}
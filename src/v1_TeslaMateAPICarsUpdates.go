@@ -3,6 +3,7 @@ package main
 import (
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
 // TeslaMateAPICarsUpdatesV1 func
@@ -55,67 +56,38 @@ func TeslaMateAPICarsUpdatesV1(c *gin.Context) {
 	ResultPage = (ResultPage * ResultShow)
 
 	// getting data from database
-	query := `
-		SELECT
-			updates.id,
-			cars.name,
-			start_date,
-			end_date,
-			version
-		FROM updates
-		LEFT JOIN cars ON car_id = cars.id
-		WHERE car_id = $1
-		ORDER BY start_date DESC
-		LIMIT $2 OFFSET $3;`
-	rows, err := db.Query(query, CarID, ResultShow, ResultPage)
+	updates, err := queries.ListUpdates(c.Request.Context(), teslamatedb.ListUpdatesParams{
+		CarID:  int16(CarID),
+		Limit:  int32(ResultShow),
+		Offset: int32(ResultPage),
+	})
 
 	// checking for errors in query
 	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsUpdatesV1", CarsUpdatesError1, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsUpdatesV1", CarsUpdatesError1, err.Error())
 		return
 
 	}
 
-	// defer closing rows
-	defer rows.Close()
-
 	// looping through all results
-	for rows.Next() {
+	for _, row := range updates {
 
 		// creating update object based on struct
-		update := Updates{}
-
-		// scanning row and putting values into the update
-		err = rows.Scan(
-			&update.UpdateID,
-			&CarData.CarName,
-			&update.StartDate,
-			&update.EndDate,
-			&update.Version,
-		)
-
-		// checking for errors after scanning
-		if err != nil {
-			TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsUpdatesV1", CarsUpdatesError1, err.Error())
-			return
+		update := Updates{
+			UpdateID: int(row.ID),
+			Version:  row.Version.String,
 		}
+		CarData.CarName = NullString(row.CarName.String)
 
 		// adjusting to timezone differences from UTC to be userspecific
-		update.StartDate = getTimeInTimeZone(update.StartDate)
-		update.EndDate = getTimeInTimeZone(update.EndDate)
+		update.StartDate = getTimeInTimeZone(row.StartDate)
+		update.EndDate = getTimeInTimeZone(row.EndDate.Time)
 
 		// appending update to UpdatesData
 		UpdatesData = append(UpdatesData, update)
 		CarData.CarID = CarID
 	}
 
-	// checking for errors in the rows result
-	err = rows.Err()
-	if err != nil {
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPICarsUpdatesV1", CarsUpdatesError1, err.Error())
-		return
-	}
-
 	//
 	// build the data-blob
 	jsonData := JSONData{
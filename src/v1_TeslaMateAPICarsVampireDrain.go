@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPICarsVampireDrainV1 func
+func TeslaMateAPICarsVampireDrainV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		CarsVampireDrainError1 = "Unable to load vampire drain."
+		CarsVampireDrainError2 = "Invalid date format."
+		CarsVampireDrainError3 = "Invalid minimum duration."
+	)
+
+	// getting CarID param from URL
+	CarID := convertStringToInteger(c.Param("CarID"))
+	// query options to modify query when collecting data
+	ResultPage := convertStringToInteger(c.DefaultQuery("page", "1"))
+	ResultShow := convertStringToInteger(c.DefaultQuery("show", "100"))
+
+	// get minimum idle duration in minutes from query parameters
+	MinDuration, err := strconv.Atoi(c.DefaultQuery("min_duration", "60"))
+	if err != nil || MinDuration < 0 {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsVampireDrainV1", CarsVampireDrainError3, "min_duration has to be a number of minutes, got "+strconv.Quote(c.Query("min_duration")))
+		return
+	}
+
+	// get startDate and endDate from query parameters
+	parsedStartDate, err := parseDateParam(c.Query("startDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsVampireDrainV1", CarsVampireDrainError2, err.Error())
+		return
+	}
+	parsedEndDate, err := parseDateParam(c.Query("endDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPICarsVampireDrainV1", CarsVampireDrainError2, err.Error())
+		return
+	}
+
+	// creating structs for /cars/<CarID>/vampire-drain
+	// Car struct - child of Data
+	type Car struct {
+		CarID   int        `json:"car_id"`   // smallint
+		CarName NullString `json:"car_name"` // text (nullable)
+	}
+	// BatteryDetails struct - child of VampireDrain
+	type BatteryDetails struct {
+		StartBatteryLevel int `json:"start_battery_level"` // int
+		EndBatteryLevel   int `json:"end_battery_level"`   // int
+	}
+	// PreferredRange struct - child of VampireDrain
+	type PreferredRange struct {
+		StartRange float64 `json:"start_range"` // float64
+		EndRange   float64 `json:"end_range"`   // float64
+		RangeLost  float64 `json:"range_lost"`  // float64
+	}
+	// VampireDrain struct - child of Data
+	type VampireDrain struct {
+		StartDate      string         `json:"start_date"`      // string
+		EndDate        string         `json:"end_date"`        // string
+		DurationMin    int            `json:"duration_min"`    // int
+		DurationStr    string         `json:"duration_str"`    // string
+		BatteryDetails BatteryDetails `json:"battery_details"` // BatteryDetails
+		RangeIdeal     PreferredRange `json:"range_ideal"`     // PreferredRange
+		RangeRated     PreferredRange `json:"range_rated"`     // PreferredRange
+		EnergyLost     NullFloat64    `json:"energy_lost"`     // float64 (kWh, nullable)
+		AvgPower       NullFloat64    `json:"avg_power"`       // float64 (W, nullable)
+		AsleepPercent  float64        `json:"asleep_percent"`  // float64
+		OnlinePercent  float64        `json:"online_percent"`  // float64
+	}
+	// TeslaMateUnits struct - child of Data
+	type TeslaMateUnits struct {
+		UnitsLength string `json:"unit_of_length"` // string
+	}
+	// Data struct - child of JSONData
+	type Data struct {
+		Car            Car            `json:"car"`
+		VampireDrain   []VampireDrain `json:"vampire_drain"`
+		TeslaMateUnits TeslaMateUnits `json:"units"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// creating required vars
+	var (
+		CarName          NullString
+		VampireDrainData = []VampireDrain{}
+		UnitsLength      string
+	)
+
+	// calculate offset based on page (page 0 is not possible, since first page is minimum 1)
+	if ResultPage > 0 {
+		ResultPage--
+	} else {
+		ResultPage = 0
+	}
+	ResultPage = (ResultPage * ResultShow)
+
+	// getting data from database
+	periods, err := queries.ListVampireDrain(c.Request.Context(), teslamatedb.ListVampireDrainParams{
+		CarID:          int16(CarID),
+		MinDurationMin: int32(MinDuration),
+		StartDate:      parsedStartDate,
+		EndDate:        parsedEndDate,
+		Limit:          int32(ResultShow),
+		Offset:         int32(ResultPage),
+	})
+
+	// checking for errors in query
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPICarsVampireDrainV1", CarsVampireDrainError1, err.Error())
+		return
+	}
+
+	// looping through all results
+	for _, row := range periods {
+
+		// creating vampire drain object based on struct
+		durationMin := int(row.DurationSec / 60)
+		drain := VampireDrain{
+			DurationMin: durationMin,
+			DurationStr: fmt.Sprintf("%02d:%02d", durationMin/60, durationMin%60),
+			BatteryDetails: BatteryDetails{
+				StartBatteryLevel: int(row.StartBatteryLevel.Int16),
+				EndBatteryLevel:   int(row.EndBatteryLevel.Int16),
+			},
+			RangeIdeal: PreferredRange{
+				StartRange: row.StartIdealRangeKm.Float64,
+				EndRange:   row.EndIdealRangeKm.Float64,
+				RangeLost:  row.StartIdealRangeKm.Float64 - row.EndIdealRangeKm.Float64,
+			},
+			RangeRated: PreferredRange{
+				StartRange: row.StartRatedRangeKm.Float64,
+				EndRange:   row.EndRatedRangeKm.Float64,
+				RangeLost:  row.StartRatedRangeKm.Float64 - row.EndRatedRangeKm.Float64,
+			},
+		}
+		if row.DurationSec > 0 {
+			drain.AsleepPercent = row.AsleepSec / row.DurationSec * 100
+			drain.OnlinePercent = row.OnlineSec / row.DurationSec * 100
+		}
+		UnitsLength = row.UnitOfLength
+		CarName = NullString(row.CarName.String)
+
+		// energy lost is based on the rated range, like TeslaMate does for its efficiency
+		if row.Efficiency.Valid && row.StartRatedRangeKm.Valid && row.EndRatedRangeKm.Valid {
+			energy := drain.RangeRated.RangeLost * row.Efficiency.Float64
+			drain.EnergyLost = NullFloat64{pgtype.Float8{Float64: energy, Valid: true}}
+			if row.DurationSec > 0 {
+				drain.AvgPower = NullFloat64{pgtype.Float8{Float64: energy * 1000 / (row.DurationSec / 3600), Valid: true}}
+			}
+		}
+
+		// converting values based of settings UnitsLength
+		if UnitsLength == "mi" {
+			drain.RangeIdeal.StartRange = kilometersToMiles(drain.RangeIdeal.StartRange)
+			drain.RangeIdeal.EndRange = kilometersToMiles(drain.RangeIdeal.EndRange)
+			drain.RangeIdeal.RangeLost = kilometersToMiles(drain.RangeIdeal.RangeLost)
+			drain.RangeRated.StartRange = kilometersToMiles(drain.RangeRated.StartRange)
+			drain.RangeRated.EndRange = kilometersToMiles(drain.RangeRated.EndRange)
+			drain.RangeRated.RangeLost = kilometersToMiles(drain.RangeRated.RangeLost)
+		}
+
+		// adjusting to timezone differences from UTC to be userspecific
+		drain.StartDate = getTimeInTimeZone(row.StartDate)
+		drain.EndDate = getTimeInTimeZone(row.EndDate)
+
+		// appending period to VampireDrainData
+		VampireDrainData = append(VampireDrainData, drain)
+	}
+
+	//
+	// build the data-blob
+	jsonData := JSONData{
+		Data{
+			Car: Car{
+				CarID:   CarID,
+				CarName: CarName,
+			},
+			VampireDrain: VampireDrainData,
+			TeslaMateUnits: TeslaMateUnits{
+				UnitsLength: UnitsLength,
+			},
+		},
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPICarsVampireDrainV1", jsonData)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeVampireDrainQuerier returns fixed idle periods, all other queries are not implemented
+type fakeVampireDrainQuerier struct {
+	teslamatedb.Querier
+	params teslamatedb.ListVampireDrainParams
+	rows   []teslamatedb.ListVampireDrainRow
+}
+
+func (q *fakeVampireDrainQuerier) ListVampireDrain(ctx context.Context, arg teslamatedb.ListVampireDrainParams) ([]teslamatedb.ListVampireDrainRow, error) {
+	q.params = arg
+	return q.rows, nil
+}
+
+func TestTeslaMateAPICarsVampireDrainV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	appUsersTimezone, _ = time.LoadLocation("UTC")
+
+	nullFloat := func(f float64) pgtype.Float8 { return pgtype.Float8{Float64: f, Valid: true} }
+	startDate := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	fake := &fakeVampireDrainQuerier{rows: []teslamatedb.ListVampireDrainRow{{
+		StartDate:         startDate,
+		EndDate:           startDate.Add(10 * time.Hour),
+		DurationSec:       10 * 3600,
+		StartRatedRangeKm: nullFloat(300),
+		EndRatedRangeKm:   nullFloat(290),
+		StartIdealRangeKm: nullFloat(320),
+		EndIdealRangeKm:   nullFloat(309),
+		StartBatteryLevel: pgtype.Int2{Int16: 70, Valid: true},
+		EndBatteryLevel:   pgtype.Int2{Int16: 68, Valid: true},
+		Efficiency:        nullFloat(0.15),
+		AsleepSec:         9 * 3600,
+		OnlineSec:         1800,
+		UnitOfLength:      "km",
+		CarName:           pgtype.Text{String: "Test Tesla", Valid: true},
+	}, {
+		StartDate:    startDate.Add(-48 * time.Hour),
+		EndDate:      startDate.Add(-46 * time.Hour),
+		DurationSec:  2 * 3600,
+		UnitOfLength: "km",
+	}}}
+
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	router.GET("/api/v1/cars/:CarID/vampire-drain", TeslaMateAPICarsVampireDrainV1)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Idle periods", func(t *testing.T) {
+		w := request("/api/v1/cars/1/vampire-drain?min_duration=120&endDate=2024-06-01T00:00:00Z&page=2&show=20")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if fake.params.CarID != 1 || fake.params.MinDurationMin != 120 || !fake.params.EndDate.Valid || fake.params.StartDate.Valid || fake.params.Limit != 20 || fake.params.Offset != 20 {
+			t.Errorf("Unexpected query parameters: %+v", fake.params)
+		}
+
+		var resp struct {
+			Data struct {
+				VampireDrain []struct {
+					StartDate   string `json:"start_date"`
+					DurationStr string `json:"duration_str"`
+					RangeRated  struct {
+						RangeLost float64 `json:"range_lost"`
+					} `json:"range_rated"`
+					EnergyLost    *float64 `json:"energy_lost"`
+					AvgPower      *float64 `json:"avg_power"`
+					AsleepPercent float64  `json:"asleep_percent"`
+					OnlinePercent float64  `json:"online_percent"`
+				} `json:"vampire_drain"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if len(resp.Data.VampireDrain) != 2 {
+			t.Fatalf("Expected 2 idle periods, got %d", len(resp.Data.VampireDrain))
+		}
+
+		drain := resp.Data.VampireDrain[0]
+		if drain.StartDate != "2024-05-01T18:00:00Z" || drain.DurationStr != "10:00" || drain.RangeRated.RangeLost != 10 {
+			t.Errorf("Unexpected idle period: %+v", drain)
+		}
+		if drain.EnergyLost == nil || math.Abs(*drain.EnergyLost-1.5) > 1e-9 || math.Abs(*drain.AvgPower-150) > 1e-9 {
+			t.Errorf("Expected 1.5 kWh lost at 150 W, got %v and %v", drain.EnergyLost, drain.AvgPower)
+		}
+		if drain.AsleepPercent != 90 || drain.OnlinePercent != 5 {
+			t.Errorf("Expected 90%% asleep and 5%% online, got %v and %v", drain.AsleepPercent, drain.OnlinePercent)
+		}
+		if drain := resp.Data.VampireDrain[1]; drain.EnergyLost != nil || drain.AvgPower != nil {
+			t.Errorf("Expected no energy without range and efficiency, got %v and %v", drain.EnergyLost, drain.AvgPower)
+		}
+	})
+
+	t.Run("Invalid minimum duration", func(t *testing.T) {
+		w := request("/api/v1/cars/1/vampire-drain?min_duration=-5")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
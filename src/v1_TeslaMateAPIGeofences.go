@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPIGeofencesV1 func
+func TeslaMateAPIGeofencesV1(c *gin.Context) {
+
+	// define error messages
+	var GeofencesError1 = "Unable to load geofences."
+
+	// getting GeofenceID param from URL, zero lists all geofences
+	GeofenceID := convertStringToInteger(c.Param("GeofenceID"))
+	// query options to modify query when collecting data
+	ResultPage := convertStringToInteger(c.DefaultQuery("page", "1"))
+	ResultShow := convertStringToInteger(c.DefaultQuery("show", "100"))
+
+	// creating structs for /geofences and /geofences/<GeofenceID>
+	// Data struct - child of JSONData
+	type Data struct {
+		Geofences []geofence `json:"geofences"`
+	}
+	// GeofenceData struct - child of JSONData for a single geofence
+	type GeofenceData struct {
+		Geofence *geofence `json:"geofence"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data any `json:"data"`
+	}
+
+	// getting a single geofence
+	if GeofenceID != 0 {
+		row, err := queries.GetGeofence(c.Request.Context(), int32(GeofenceID))
+
+		switch err {
+		case pgx.ErrNoRows:
+			TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPIGeofencesV1", "No rows were returned!", err.Error())
+			return
+		case nil:
+			// nothing wrong.. continuing
+			break
+		default:
+			TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPIGeofencesV1", GeofencesError1, err.Error())
+			return
+		}
+
+		// return jsonData
+		TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPIGeofencesV1", JSONData{GeofenceData{Geofence: newGeofence(teslamatedb.ListGeofencesRow(row))}})
+		return
+	}
+
+	// calculate offset based on page (page 0 is not possible, since first page is minimum 1)
+	if ResultPage > 0 {
+		ResultPage--
+	} else {
+		ResultPage = 0
+	}
+	ResultPage = (ResultPage * ResultShow)
+
+	// getting data from database
+	rows, err := queries.ListGeofences(c.Request.Context(), teslamatedb.ListGeofencesParams{
+		Limit:  int32(ResultShow),
+		Offset: int32(ResultPage),
+	})
+
+	// checking for errors in query
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPIGeofencesV1", GeofencesError1, err.Error())
+		return
+	}
+
+	// looping through all results
+	GeofencesData := []geofence{}
+	for _, row := range rows {
+		GeofencesData = append(GeofencesData, *newGeofence(row))
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPIGeofencesV1", JSONData{Data{Geofences: GeofencesData}})
+}
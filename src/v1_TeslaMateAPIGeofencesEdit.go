@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPIGeofencesEditV1 func
+func TeslaMateAPIGeofencesEditV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		GeofencesEditError1 = "Unable to save geofence."
+		GeofencesEditError2 = "Invalid geofence."
+		GeofencesEditError3 = "Unable to delete geofence."
+	)
+
+	// edits need ENABLE_EDITS and authentication
+	if !checkEditPermission(c, "TeslaMateAPIGeofencesEditV1") {
+		return
+	}
+
+	// getting GeofenceID param from URL, POST creates a new geofence
+	GeofenceID := convertStringToInteger(c.Param("GeofenceID"))
+
+	// creating structs for /geofences/<GeofenceID>
+	// Data struct - child of JSONData
+	type Data struct {
+		Geofence *geofence              `json:"geofence"`
+		Changes  map[string]auditChange `json:"changes"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	var (
+		row     teslamatedb.ListGeofencesRow
+		changes map[string]auditChange
+		err     error
+	)
+
+	switch c.Request.Method {
+	case http.MethodDelete:
+		var deleted teslamatedb.DeleteGeofenceRow
+		deleted, err = queries.DeleteGeofence(c.Request.Context(), int32(GeofenceID))
+		if err != nil && err != pgx.ErrNoRows {
+			TeslaMateAPIHandleOtherErrorResponse(c, httpStatusForError(err), "TeslaMateAPIGeofencesEditV1", GeofencesEditError3, err.Error())
+			return
+		}
+		row = teslamatedb.ListGeofencesRow(deleted)
+		changes = geofenceChanges(row, teslamatedb.ListGeofencesRow{})
+
+	default:
+		params, parseErr := parseGeofenceRequest(c.Request.Body)
+		if parseErr != nil {
+			TeslaMateAPIHandleOtherErrorResponse(c, http.StatusBadRequest, "TeslaMateAPIGeofencesEditV1", GeofencesEditError2, parseErr.Error())
+			return
+		}
+
+		if c.Request.Method == http.MethodPost {
+			var created teslamatedb.CreateGeofenceRow
+			created, err = queries.CreateGeofence(c.Request.Context(), params)
+			row = teslamatedb.ListGeofencesRow(created)
+			changes = geofenceChanges(teslamatedb.ListGeofencesRow{}, row)
+		} else {
+			var updated teslamatedb.UpdateGeofenceRow
+			updated, err = queries.UpdateGeofence(c.Request.Context(), teslamatedb.UpdateGeofenceParams{
+				Name:        params.Name,
+				Latitude:    params.Latitude,
+				Longitude:   params.Longitude,
+				Radius:      params.Radius,
+				BillingType: params.BillingType,
+				CostPerUnit: params.CostPerUnit,
+				SessionFee:  params.SessionFee,
+				ID:          int32(GeofenceID),
+			})
+			row = teslamatedb.ListGeofencesRow{
+				GeofenceID:  updated.GeofenceID,
+				Name:        updated.Name,
+				Latitude:    updated.Latitude,
+				Longitude:   updated.Longitude,
+				Radius:      updated.Radius,
+				BillingType: updated.BillingType,
+				CostPerUnit: updated.CostPerUnit,
+				SessionFee:  updated.SessionFee,
+				InsertedAt:  updated.InsertedAt,
+				UpdatedAt:   updated.UpdatedAt,
+			}
+			changes = geofenceChanges(teslamatedb.ListGeofencesRow{
+				Name:        updated.OldName,
+				Latitude:    updated.OldLatitude,
+				Longitude:   updated.OldLongitude,
+				Radius:      updated.OldRadius,
+				BillingType: updated.OldBillingType,
+				CostPerUnit: updated.OldCostPerUnit,
+				SessionFee:  updated.OldSessionFee,
+			}, row)
+		}
+		if err != nil && err != pgx.ErrNoRows {
+			TeslaMateAPIHandleOtherErrorResponse(c, httpStatusForError(err), "TeslaMateAPIGeofencesEditV1", GeofencesEditError1, err.Error())
+			return
+		}
+	}
+
+	if err == pgx.ErrNoRows {
+		TeslaMateAPIHandleOtherErrorResponse(c, http.StatusNotFound, "TeslaMateAPIGeofencesEditV1", "No rows were returned!", err.Error())
+		return
+	}
+	writeAuditRecord(c, 0, "geofence", int(row.GeofenceID), changes)
+
+	jsonData := JSONData{
+		Data{
+			Geofence: newGeofence(row),
+			Changes:  changes,
+		},
+	}
+
+	// return jsonData, 201 for a new geofence
+	if c.Request.Method == http.MethodPost {
+		c.Header("Location", c.Request.URL.Path+"/"+strconv.Itoa(int(row.GeofenceID)))
+		TeslaMateAPIHandleOtherResponse(c, http.StatusCreated, "TeslaMateAPIGeofencesEditV1", jsonData)
+		return
+	}
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPIGeofencesEditV1", jsonData)
+}
+
+// geofenceChanges func - returns the changed fields between two versions of a geofence, the zero row is a missing geofence
+func geofenceChanges(before teslamatedb.ListGeofencesRow, after teslamatedb.ListGeofencesRow) map[string]auditChange {
+	value := func(row teslamatedb.ListGeofencesRow) map[string]any {
+		if row.Name == "" {
+			return map[string]any{}
+		}
+		return map[string]any{
+			"name":          row.Name,
+			"latitude":      row.Latitude,
+			"longitude":     row.Longitude,
+			"radius":        row.Radius,
+			"billing_type":  row.BillingType,
+			"cost_per_unit": auditValue(row.CostPerUnit),
+			"session_fee":   auditValue(row.SessionFee),
+		}
+	}
+	oldValues, newValues := value(before), value(after)
+
+	changes := map[string]auditChange{}
+	for _, name := range []string{"name", "latitude", "longitude", "radius", "billing_type", "cost_per_unit", "session_fee"} {
+		if oldValues[name] != newValues[name] {
+			changes[name] = auditChange{Old: oldValues[name], New: newValues[name]}
+		}
+	}
+	return changes
+}
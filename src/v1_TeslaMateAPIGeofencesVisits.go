@@ -0,0 +1,186 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	_ "github.com/lib/pq"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// TeslaMateAPIGeofencesVisitsV1 func
+func TeslaMateAPIGeofencesVisitsV1(c *gin.Context) {
+
+	// define error messages
+	var (
+		GeofencesVisitsError1 = "Unable to load geofence."
+		GeofencesVisitsError2 = "Unable to load drives."
+		GeofencesVisitsError3 = "Unable to load charges."
+		GeofencesVisitsError4 = "Invalid date format."
+	)
+
+	// getting GeofenceID param from URL
+	GeofenceID := convertStringToInteger(c.Param("GeofenceID"))
+	// query options to modify query when collecting data
+	ResultPage := convertStringToInteger(c.DefaultQuery("page", "1"))
+	ResultShow := convertStringToInteger(c.DefaultQuery("show", "100"))
+
+	// get startDate and endDate from query parameters
+	parsedStartDate, err := parseDateParam(c.Query("startDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPIGeofencesVisitsV1", GeofencesVisitsError4, err.Error())
+		return
+	}
+	parsedEndDate, err := parseDateParam(c.Query("endDate"))
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, http.StatusBadRequest, "TeslaMateAPIGeofencesVisitsV1", GeofencesVisitsError4, err.Error())
+		return
+	}
+
+	// creating structs for /geofences/<GeofenceID>/visits
+	// Drive struct - child of Data
+	type Drive struct {
+		DriveID     int         `json:"drive_id"`     // int
+		CarID       int         `json:"car_id"`       // smallint
+		CarName     NullText    `json:"car_name"`     // text (nullable)
+		StartDate   string      `json:"start_date"`   // string
+		EndDate     string      `json:"end_date"`     // string
+		Distance    NullFloat64 `json:"distance"`     // float64 (nullable)
+		DurationMin NullInt64   `json:"duration_min"` // int (nullable)
+	}
+	// Charge struct - child of Data
+	type Charge struct {
+		ChargeID          int         `json:"charge_id"`           // int
+		CarID             int         `json:"car_id"`              // smallint
+		CarName           NullText    `json:"car_name"`            // text (nullable)
+		StartDate         string      `json:"start_date"`          // string
+		EndDate           string      `json:"end_date"`            // string
+		ChargeEnergyAdded float64     `json:"charge_energy_added"` // float64
+		Cost              NullFloat64 `json:"cost"`                // float64 (nullable)
+		DurationMin       NullInt64   `json:"duration_min"`        // int (nullable)
+	}
+	// TeslaMateUnits struct - child of Data
+	type TeslaMateUnits struct {
+		UnitsLength string `json:"unit_of_length"` // string
+	}
+	// Data struct - child of JSONData
+	type Data struct {
+		Geofence       *geofence      `json:"geofence"`
+		Drives         []Drive        `json:"drives"`
+		Charges        []Charge       `json:"charges"`
+		TeslaMateUnits TeslaMateUnits `json:"units"`
+	}
+	// JSONData struct - main
+	type JSONData struct {
+		Data Data `json:"data"`
+	}
+
+	// calculate offset based on page (page 0 is not possible, since first page is minimum 1)
+	if ResultPage > 0 {
+		ResultPage--
+	} else {
+		ResultPage = 0
+	}
+	ResultPage = (ResultPage * ResultShow)
+
+	// getting data from database
+	row, err := queries.GetGeofence(c.Request.Context(), int32(GeofenceID))
+
+	switch err {
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPIGeofencesVisitsV1", "No rows were returned!", err.Error())
+		return
+	case nil:
+		// nothing wrong.. continuing
+		break
+	default:
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPIGeofencesVisitsV1", GeofencesVisitsError1, err.Error())
+		return
+	}
+
+	// getting drives ending inside of the geofence
+	drives, err := queries.ListGeofenceDriveVisits(c.Request.Context(), teslamatedb.ListGeofenceDriveVisitsParams{
+		GeofenceID: int32(GeofenceID),
+		StartDate:  parsedStartDate,
+		EndDate:    parsedEndDate,
+		Limit:      int32(ResultShow),
+		Offset:     int32(ResultPage),
+	})
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPIGeofencesVisitsV1", GeofencesVisitsError2, err.Error())
+		return
+	}
+
+	// getting charges inside of the geofence
+	charges, err := queries.ListGeofenceChargeVisits(c.Request.Context(), teslamatedb.ListGeofenceChargeVisitsParams{
+		GeofenceID: int32(GeofenceID),
+		StartDate:  parsedStartDate,
+		EndDate:    parsedEndDate,
+		Limit:      int32(ResultShow),
+		Offset:     int32(ResultPage),
+	})
+	if err != nil {
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPIGeofencesVisitsV1", GeofencesVisitsError3, err.Error())
+		return
+	}
+
+	// creating required vars
+	var (
+		DrivesData  = []Drive{}
+		ChargesData = []Charge{}
+		UnitsLength string
+	)
+
+	// looping through all drives
+	for _, drive := range drives {
+		visit := Drive{
+			DriveID:     int(drive.DriveID),
+			CarID:       int(drive.CarID),
+			CarName:     NullText{drive.CarName},
+			StartDate:   getTimeInTimeZone(drive.StartDate),
+			EndDate:     getTimeInTimeZone(drive.EndDate.Time),
+			Distance:    NullFloat64{drive.Distance},
+			DurationMin: NullInt64{pgtype.Int8{Int64: int64(drive.DurationMin.Int16), Valid: drive.DurationMin.Valid}},
+		}
+		UnitsLength = drive.UnitOfLength
+
+		// converting values based of settings UnitsLength
+		if UnitsLength == "mi" {
+			visit.Distance = kilometersToMilesNilSupport(visit.Distance)
+		}
+
+		DrivesData = append(DrivesData, visit)
+	}
+
+	// looping through all charges
+	for _, charge := range charges {
+		ChargesData = append(ChargesData, Charge{
+			ChargeID:          int(charge.ChargeID),
+			CarID:             int(charge.CarID),
+			CarName:           NullText{charge.CarName},
+			StartDate:         getTimeInTimeZone(charge.StartDate),
+			EndDate:           getTimeInTimeZone(charge.EndDate.Time),
+			ChargeEnergyAdded: charge.ChargeEnergyAdded,
+			Cost:              NullFloat64{charge.Cost},
+			DurationMin:       NullInt64{pgtype.Int8{Int64: int64(charge.DurationMin.Int16), Valid: charge.DurationMin.Valid}},
+		})
+	}
+
+	//
+	// build the data-blob
+	jsonData := JSONData{
+		Data{
+			Geofence: newGeofence(teslamatedb.ListGeofencesRow(row)),
+			Drives:   DrivesData,
+			Charges:  ChargesData,
+			TeslaMateUnits: TeslaMateUnits{
+				UnitsLength: UnitsLength,
+			},
+		},
+	}
+
+	// return jsonData
+	TeslaMateAPIHandleSuccessResponse(c, "TeslaMateAPIGeofencesVisitsV1", jsonData)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
+)
+
+// fakeGeofencesQuerier serves geofence 1 "Home" with a drive and a charge, other geofences don't exist
+type fakeGeofencesQuerier struct {
+	teslamatedb.Querier
+	createParams *teslamatedb.CreateGeofenceParams
+	updateParams *teslamatedb.UpdateGeofenceParams
+	visitParams  *teslamatedb.ListGeofenceDriveVisitsParams
+}
+
+var fakeGeofenceHome = teslamatedb.ListGeofencesRow{
+	GeofenceID:  1,
+	Name:        "Home",
+	Latitude:    52.520008,
+	Longitude:   13.404954,
+	Radius:      50,
+	BillingType: "per_kwh",
+	CostPerUnit: pgtype.Float8{Float64: 0.3, Valid: true},
+	InsertedAt:  time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	UpdatedAt:   time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func (q *fakeGeofencesQuerier) ListGeofences(ctx context.Context, arg teslamatedb.ListGeofencesParams) ([]teslamatedb.ListGeofencesRow, error) {
+	return []teslamatedb.ListGeofencesRow{fakeGeofenceHome}, nil
+}
+
+func (q *fakeGeofencesQuerier) GetGeofence(ctx context.Context, id int32) (teslamatedb.GetGeofenceRow, error) {
+	if id != 1 {
+		return teslamatedb.GetGeofenceRow{}, pgx.ErrNoRows
+	}
+	return teslamatedb.GetGeofenceRow(fakeGeofenceHome), nil
+}
+
+func (q *fakeGeofencesQuerier) CreateGeofence(ctx context.Context, arg teslamatedb.CreateGeofenceParams) (teslamatedb.CreateGeofenceRow, error) {
+	q.createParams = &arg
+	return teslamatedb.CreateGeofenceRow{GeofenceID: 7, Name: arg.Name, Latitude: arg.Latitude, Longitude: arg.Longitude, Radius: arg.Radius, BillingType: arg.BillingType, CostPerUnit: arg.CostPerUnit, SessionFee: arg.SessionFee}, nil
+}
+
+func (q *fakeGeofencesQuerier) UpdateGeofence(ctx context.Context, arg teslamatedb.UpdateGeofenceParams) (teslamatedb.UpdateGeofenceRow, error) {
+	q.updateParams = &arg
+	if arg.ID != 1 {
+		return teslamatedb.UpdateGeofenceRow{}, pgx.ErrNoRows
+	}
+	return teslamatedb.UpdateGeofenceRow{
+		GeofenceID: 1, Name: arg.Name, Latitude: arg.Latitude, Longitude: arg.Longitude, Radius: arg.Radius, BillingType: arg.BillingType, CostPerUnit: arg.CostPerUnit, SessionFee: arg.SessionFee,
+		OldName: fakeGeofenceHome.Name, OldLatitude: fakeGeofenceHome.Latitude, OldLongitude: fakeGeofenceHome.Longitude, OldRadius: fakeGeofenceHome.Radius, OldBillingType: fakeGeofenceHome.BillingType, OldCostPerUnit: fakeGeofenceHome.CostPerUnit, OldSessionFee: fakeGeofenceHome.SessionFee,
+	}, nil
+}
+
+func (q *fakeGeofencesQuerier) DeleteGeofence(ctx context.Context, id int32) (teslamatedb.DeleteGeofenceRow, error) {
+	if id != 1 {
+		return teslamatedb.DeleteGeofenceRow{}, pgx.ErrNoRows
+	}
+	return teslamatedb.DeleteGeofenceRow(fakeGeofenceHome), nil
+}
+
+func (q *fakeGeofencesQuerier) ListGeofenceDriveVisits(ctx context.Context, arg teslamatedb.ListGeofenceDriveVisitsParams) ([]teslamatedb.ListGeofenceDriveVisitsRow, error) {
+	q.visitParams = &arg
+	return []teslamatedb.ListGeofenceDriveVisitsRow{{
+		DriveID:      3,
+		CarID:        1,
+		CarName:      pgtype.Text{String: "Model 3", Valid: true},
+		StartDate:    time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC),
+		EndDate:      pgtype.Timestamp{Time: time.Date(2024, 1, 2, 8, 30, 0, 0, time.UTC), Valid: true},
+		Distance:     pgtype.Float8{Float64: 16.09344, Valid: true},
+		DurationMin:  pgtype.Int2{Int16: 30, Valid: true},
+		UnitOfLength: "mi",
+	}}, nil
+}
+
+func (q *fakeGeofencesQuerier) ListGeofenceChargeVisits(ctx context.Context, arg teslamatedb.ListGeofenceChargeVisitsParams) ([]teslamatedb.ListGeofenceChargeVisitsRow, error) {
+	return []teslamatedb.ListGeofenceChargeVisitsRow{{
+		ChargeID:          4,
+		CarID:             1,
+		StartDate:         time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+		EndDate:           pgtype.Timestamp{Time: time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC), Valid: true},
+		ChargeEnergyAdded: 20.5,
+		DurationMin:       pgtype.Int2{Int16: 120, Valid: true},
+	}}, nil
+}
+
+func TestTeslaMateAPIGeofencesV1(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ENABLE_EDITS", "true")
+
+	const envToken = "0123456789abcdef0123456789abcdef"
+	originalSecret := envTokenSecret
+	defer func() { envTokenSecret = originalSecret }()
+	setEnvToken(envToken)
+
+	originalTimezone := appUsersTimezone
+	defer func() { appUsersTimezone = originalTimezone }()
+	appUsersTimezone = time.UTC
+
+	fake := &fakeGeofencesQuerier{}
+	originalQueries := queries
+	queries = fake
+	defer func() { queries = originalQueries }()
+
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.GET("/geofences", TeslaMateAPIGeofencesV1)
+	v1.POST("/geofences", TeslaMateAPIGeofencesEditV1)
+	v1.GET("/geofences/:GeofenceID", TeslaMateAPIGeofencesV1)
+	v1.PUT("/geofences/:GeofenceID", TeslaMateAPIGeofencesEditV1)
+	v1.DELETE("/geofences/:GeofenceID", TeslaMateAPIGeofencesEditV1)
+	v1.GET("/geofences/:GeofenceID/visits", TeslaMateAPIGeofencesVisitsV1)
+
+	request := func(method string, path string, body string, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("List geofences", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/geofences", "", "")
+		if w.Code != http.StatusOK || !contains(w.Body.String(), `"geofences":[{"geofence_id":1,"name":"Home","latitude":52.520008,"longitude":13.404954,"radius":50,"billing_type":"per_kwh","cost_per_unit":0.3,"session_fee":null,`) {
+			t.Errorf("Unexpected response %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Get geofence", func(t *testing.T) {
+		if w := request(http.MethodGet, "/api/v1/geofences/1", "", ""); !contains(w.Body.String(), `"geofence":{"geofence_id":1,"name":"Home"`) {
+			t.Errorf("Unexpected response: %s", w.Body.String())
+		}
+		if w := request(http.MethodGet, "/api/v1/geofences/2", "", ""); !contains(w.Body.String(), `"error":"No rows were returned!"`) {
+			t.Errorf("Expected the legacy not found error, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("Create geofence", func(t *testing.T) {
+		w := request(http.MethodPost, "/api/v1/geofences", `{"name": " Work ", "latitude": 48.1371079, "longitude": 11.5753822, "radius": 100, "session_fee": 1.5}`, envToken)
+		if w.Code != http.StatusCreated || w.Header().Get("Location") != "/api/v1/geofences/7" {
+			t.Fatalf("Expected status 201 with location, got %d %q: %s", w.Code, w.Header().Get("Location"), w.Body.String())
+		}
+		params := fake.createParams
+		if params.Name != "Work" || params.Latitude != 48.137108 || params.BillingType != "per_kwh" || params.CostPerUnit.Valid || params.SessionFee.Float64 != 1.5 {
+			t.Errorf("Unexpected create: %+v", params)
+		}
+		if !contains(w.Body.String(), `"name":{"old":null,"new":"Work"}`) {
+			t.Errorf("Expected the created fields as changes: %s", w.Body.String())
+		}
+	})
+
+	t.Run("Replace geofence", func(t *testing.T) {
+		w := request(http.MethodPut, "/api/v1/geofences/1", `{"name": "Home", "latitude": 52.520008, "longitude": 13.404954, "radius": 75, "billing_type": "per_minute", "cost_per_unit": 0.3}`, envToken)
+		if w.Code != http.StatusOK || fake.updateParams.ID != 1 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if body := w.Body.String(); !contains(body, `"changes":{"billing_type":{"old":"per_kwh","new":"per_minute"},"radius":{"old":50,"new":75}}`) {
+			t.Errorf("Expected only the changed fields, got %s", body)
+		}
+		if w := request(http.MethodPut, "/api/v1/geofences/2", `{"name": "Home", "latitude": 1, "longitude": 1, "radius": 75}`, envToken); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("Invalid geofences", func(t *testing.T) {
+		for _, body := range []string{
+			`{"latitude": 1, "longitude": 1, "radius": 10}`,
+			`{"name": "A", "latitude": 91, "longitude": 1, "radius": 10}`,
+			`{"name": "A", "latitude": 1, "longitude": 1, "radius": 0}`,
+			`{"name": "A", "latitude": 1, "longitude": 1, "radius": 10, "billing_type": "per_hour"}`,
+			`{"name": "A", "latitude": 1, "longitude": 1, "radius": 10, "cost_per_unit": -1}`,
+			`{"name": "A", "latitude": 1, "longitude": 1, "radius": 10, "color": "red"}`,
+		} {
+			if w := request(http.MethodPost, "/api/v1/geofences", body, envToken); w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+			}
+		}
+	})
+
+	t.Run("Delete geofence", func(t *testing.T) {
+		if w := request(http.MethodDelete, "/api/v1/geofences/1", "", envToken); w.Code != http.StatusOK || !contains(w.Body.String(), `"name":{"old":"Home","new":null}`) {
+			t.Errorf("Unexpected response %d: %s", w.Code, w.Body.String())
+		}
+		if w := request(http.MethodDelete, "/api/v1/geofences/2", "", envToken); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("Writes need authentication", func(t *testing.T) {
+		if w := request(http.MethodDelete, "/api/v1/geofences/1", "", "invalid"); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("Visits", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/geofences/1/visits?page=2&show=10&startDate=2024-01-01T00:00:00Z", "", "")
+		body := w.Body.String()
+		if w.Code != http.StatusOK || fake.visitParams.Offset != 10 || !fake.visitParams.StartDate.Valid {
+			t.Fatalf("Unexpected response %d with %+v: %s", w.Code, fake.visitParams, body)
+		}
+		if !contains(body, `"drives":[{"drive_id":3,"car_id":1,"car_name":"Model 3","start_date":"2024-01-02T08:00:00Z","end_date":"2024-01-02T08:30:00Z","distance":9.99999`) {
+			t.Errorf("Expected the drive with distance in miles, got %s", body)
+		}
+		if !contains(body, `"duration_min":30}]`) || !contains(body, `"charges":[{"charge_id":4,"car_id":1,"car_name":null,`) || !contains(body, `"units":{"unit_of_length":"mi"}`) {
+			t.Errorf("Unexpected charges or units: %s", body)
+		}
+		if w := request(http.MethodGet, "/api/v1/geofences/2/visits", "", ""); !contains(w.Body.String(), `"error":"No rows were returned!"`) {
+			t.Errorf("Expected the legacy not found error, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestTeslaMateAPIGeofencesEditV1Disabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ENABLE_EDITS", "false")
+
+	router := gin.New()
+	router.POST("/api/v1/geofences", TeslaMateAPIGeofencesEditV1)
+
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/geofences", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+}
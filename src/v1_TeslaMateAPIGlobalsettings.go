@@ -1,9 +1,10 @@
 package main
 
 import (
-	"database/sql"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 	_ "github.com/lib/pq"
 )
 
@@ -55,49 +56,41 @@ func TeslaMateAPIGlobalsettingsV1(c *gin.Context) {
 	var globalSetting GlobalSettings
 
 	// getting data from database
-	query := `
-		SELECT
-			id,
-			inserted_at,
-			updated_at,
-			unit_of_length,
-			unit_of_temperature,
-			preferred_range,
-			language,
-			base_url,
-			grafana_url
-		FROM settings
-		LIMIT 1;`
-	row := db.QueryRow(query)
-
-	// scanning row and putting values into the globalSetting
-	err := row.Scan(
-		&globalSetting.SettingID,
-		&globalSetting.AccountInfo.InsertedAt,
-		&globalSetting.AccountInfo.UpdatedAt,
-		&globalSetting.TeslaMateUnits.UnitsLength,
-		&globalSetting.TeslaMateUnits.UnitsTemperature,
-		&globalSetting.TeslaMateGUI.PreferredRange,
-		&globalSetting.TeslaMateGUI.Language,
-		&globalSetting.TeslaMateURLs.BaseURL,
-		&globalSetting.TeslaMateURLs.GrafanaURL,
-	)
+	row, err := queries.GetSettings(c.Request.Context())
 
 	switch err {
-	case sql.ErrNoRows:
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPIGlobalsettingsV1", "No rows were returned!", err.Error())
+	case pgx.ErrNoRows:
+		TeslaMateAPIHandleErrorResponse(c, http.StatusNotFound, "TeslaMateAPIGlobalsettingsV1", "No rows were returned!", err.Error())
 		return
 	case nil:
 		// nothing wrong.. continuing
 		break
 	default:
-		TeslaMateAPIHandleErrorResponse(c, "TeslaMateAPIGlobalsettingsV1", CarsGlobalsettingsError1, err.Error())
+		TeslaMateAPIHandleErrorResponse(c, httpStatusForError(err), "TeslaMateAPIGlobalsettingsV1", CarsGlobalsettingsError1, err.Error())
 		return
 	}
 
-	// adjusting to timezone differences from UTC to be userspecific
-	globalSetting.AccountInfo.InsertedAt = getTimeInTimeZone(globalSetting.AccountInfo.InsertedAt)
-	globalSetting.AccountInfo.UpdatedAt = getTimeInTimeZone(globalSetting.AccountInfo.UpdatedAt)
+	// putting values into the globalSetting
+	globalSetting = GlobalSettings{
+		SettingID: int(row.ID),
+		AccountInfo: AccountInfo{
+			// adjusting to timezone differences from UTC to be userspecific
+			InsertedAt: getTimeInTimeZone(row.InsertedAt),
+			UpdatedAt:  getTimeInTimeZone(row.UpdatedAt),
+		},
+		TeslaMateUnits: TeslaMateUnits{
+			UnitsLength:      row.UnitOfLength,
+			UnitsTemperature: row.UnitOfTemperature,
+		},
+		TeslaMateGUI: TeslaMateGUI{
+			PreferredRange: row.PreferredRange,
+			Language:       row.Language,
+		},
+		TeslaMateURLs: TeslaMateURLs{
+			BaseURL:    row.BaseUrl.String,
+			GrafanaURL: row.GrafanaUrl.String,
+		},
+	}
 
 	//
 	// build the data-blob
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -15,7 +15,10 @@ import (
 
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	teslamatedb "github.com/tobiasehlert/teslamateapi/internal/db"
 )
 
 var (
@@ -23,22 +26,30 @@ var (
 	isReady *atomic.Value
 
 	// setting TeslaMateApi parameters
-	apiVersion        = "unspecified"
-	dbTimestampFormat = "2006-01-02T15:04:05Z" // format used in postgres for dates
+	apiVersion = "unspecified"
 
 	// defining db var
-	db *sql.DB
+	db database
 
-	// defining envToken that contains API_TOKEN value
-	envToken string
+	// defining queries var with the generated queries of internal/db
+	queries teslamatedb.Querier
 
-	// list of allowed commands
-	allowList []string
+	// defining envTokenSecret that contains the salted hash of the API_TOKEN value
+	envTokenSecret *hashedSecret
 
 	// app-settings
 	appUsersTimezone *time.Location
 )
 
+// database is the part of pgxpool.Pool used by the API, so tests can replace it with pgxmock
+type database interface {
+	teslamatedb.DBTX
+	Begin(ctx context.Context) (pgx.Tx, error)
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+	Ping(ctx context.Context) error
+	Close()
+}
+
 // main function
 func main() {
 	// setup of readyness endpoint code
@@ -71,33 +82,118 @@ func main() {
 
 	// run initAuthToken to validate environment vars
 	initAuthToken()
-	// initialize allowList stored for /command section
+	// initialize optional tokens with scopes of API_TOKENS_FILE
+	initTokenStore()
+	// initialize optional JWTs of an identity provider with the keys of JWT_JWKS
+	initJWTVerifier()
+	// initialize commandAllowList stored for /command section
 	initCommandAllowList()
+	// initialize backends sending the commands to Tesla
+	initCommandBackends()
+	// initialize tariffs used for /charges/:ChargeID/cost section
+	initTariffs()
+	// initialize optional change feed based on postgres LISTEN/NOTIFY
+	initChangeFeed()
+	defer closeChangeFeed()
+	// initialize broker for /status/stream section
+	initCarStatusBroker()
+	// initialize vehicle telemetry for /metrics section
+	initMetrics()
 
 	// MQTT connection removed - now using Postgres-only approach
 	log.Printf("[info] TeslaMateApi using Postgres-only data access.")
 
 	if getEnvAsBool("API_TOKEN_DISABLE", false) {
-		log.Println("[warning] validateAuthToken - header authorization bearer token disabled. Authorization: Bearer token will not be required for commands.")
+		if scopedAuthEnabled() {
+			log.Println("[warning] validateAuthToken - API_TOKEN_DISABLE is ignored, every endpoint needs a token with its scope since API_TOKENS_FILE or JWT_JWKS is set.")
+		} else {
+			log.Println("[warning] validateAuthToken - header authorization bearer token disabled. Authorization: Bearer token will not be required for commands.")
+		}
 	}
 
-	if teslaApiHost := getEnv("TESLA_API_HOST", ""); teslaApiHost != "" {
-		log.Printf("[info] TESLA_API_HOST is set: %s", teslaApiHost)
+	// build the http server, listening on LISTEN_ADDRESS (0.0.0.0:8080 by default)
+	server := &http.Server{
+		Handler: newRouter(),
 	}
 
-	// kicking off Gin in value r
-	r := gin.Default()
+	// setting readyz endpoint to true (using Postgres-only approach)
+	isReady.Store(true)
+
+	// graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
+
+	// we run a go routine that will receive the shutdown input
+	go func() {
+		<-quit
+		log.Println("[info] TeslaMateAPI received shutdown input")
+		if err := server.Close(); err != nil {
+			log.Fatal("[error] TeslaMateAPI server close error:", err)
+		}
+	}()
+
+	// run the server
+	if err := serve(server); err != nil {
+		if err == http.ErrServerClosed {
+			log.Println("[info] TeslaMateAPI server gracefully shut down")
+		} else {
+			log.Fatal("[error] TeslaMateAPI server closed unexpectedly: ", err)
+		}
+	}
+}
+
+// legacyV1Paths are redirected to /api/v1 since they were served before versioning
+var legacyV1Paths = []string{
+	"/cars",
+	"/cars/:CarID",
+	"/cars/:CarID/charges",
+	"/cars/:CarID/charges/:ChargeID",
+	"/cars/:CarID/drives",
+	"/cars/:CarID/drives/:DriveID",
+	"/cars/:CarID/status",
+	"/cars/:CarID/updates",
+	"/globalsettings",
+}
+
+// newRouter func - sets up gin with all TeslaMateApi endpoints
+func newRouter() *gin.Engine {
+	// kicking off Gin in value r, the token query parameter is removed before the request is logged
+	r := gin.New()
+	r.Use(redactTokenQuery, gin.Logger(), gin.Recovery())
 
 	// gin middleware to enable GZIP support
 	r.Use(gzip.Gzip(gzip.DefaultCompression))
 
+	// gin middleware to set X-Request-ID on every request
+	r.Use(requestID)
+
+	// slows down and locks out clients sending invalid tokens to any endpoint
+	authLimiter := newAuthLimiterFromEnv()
+
+	// gin middleware to count requests and serve /metrics for prometheus
+	if getEnvAsBool("ENABLE_METRICS", false) {
+		r.Use(metricsMiddleware)
+		r.GET("/metrics", authBackoff(authLimiter), requireIdentity, requireScope(scopeReadMetrics), metricsHandler)
+	}
+
 	// set 404 not found page
 	r.NoRoute(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/v2/") {
+			c.JSON(http.StatusNotFound, APIErrorResponse{Error: APIError{Code: "PAGE_NOT_FOUND", Message: "Page not found", RequestID: c.GetString(requestIDKey)}})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"code": "PAGE_NOT_FOUND", "message": "Page not found"})
 	})
 
-	// disable proxy feature of gin
-	_ = r.SetTrustedProxies(nil)
+	// disable proxy feature of gin, unless TRUSTED_PROXIES is set so the lockout of clients uses their real ip
+	if trustedProxies := getEnv("TRUSTED_PROXIES", ""); trustedProxies != "" {
+		if err := r.SetTrustedProxies(strings.Split(strings.ReplaceAll(trustedProxies, " ", ""), ",")); err != nil {
+			log.Println("[error] newRouter - invalid TRUSTED_PROXIES, trusting no proxy: " + err.Error())
+			_ = r.SetTrustedProxies(nil)
+		}
+	} else {
+		_ = r.SetTrustedProxies(nil)
+	}
 
 	// root endpoint telling API is running
 	r.GET("/", func(c *gin.Context) {
@@ -120,38 +216,20 @@ func main() {
 				c.JSON(http.StatusOK, gin.H{"message": "TeslaMateApi v1 runnnig..", "path": v1.BasePath()})
 			})
 
-			// v1 /api/v1/cars endpoints
-			v1.GET("/cars", TeslaMateAPICarsV1)
-			v1.GET("/cars/:CarID", TeslaMateAPICarsV1)
-
-			// v1 /api/v1/cars/:CarID/charges endpoints
-			v1.GET("/cars/:CarID/charges", TeslaMateAPICarsChargesV1)
-			v1.GET("/cars/:CarID/charges/:ChargeID", TeslaMateAPICarsChargesDetailsV1)
-
-			// v1 /api/v1/cars/:CarID/command endpoints
-			v1.GET("/cars/:CarID/command", TeslaMateAPICarsCommandV1)
-			v1.GET("/cars/:CarID/commands", TeslaMateAPICarsCommandV1)
-			v1.POST("/cars/:CarID/command/:Command", TeslaMateAPICarsCommandV1)
-
-			// v1 /api/v1/cars/:CarID/drives endpoints
-			v1.GET("/cars/:CarID/drives", TeslaMateAPICarsDrivesV1)
-			v1.GET("/cars/:CarID/drives/:DriveID", TeslaMateAPICarsDrivesDetailsV1)
-
-			// v1 /api/v1/cars/:CarID/logging endpoints
-			v1.GET("/cars/:CarID/logging", TeslaMateAPICarsLoggingV1)
-			v1.PUT("/cars/:CarID/logging/:Command", TeslaMateAPICarsLoggingV1)
-
-			// v1 /api/v1/cars/:CarID/status endpoints
-			v1.GET("/cars/:CarID/status", TeslaMateAPICarsStatusV1)
-
-			// v1 /api/v1/cars/:CarID/updates endpoints
-			v1.GET("/cars/:CarID/updates", TeslaMateAPICarsUpdatesV1)
+			// v1 /api/v1 endpoints with the legacy error responses
+			registerAPIRoutes(v1, authLimiter)
+		}
 
-			// v1 /api/v1/cars/:CarID/wake_up endpoints
-			v1.POST("/cars/:CarID/wake_up", TeslaMateAPICarsCommandV1)
+		// TeslaMateApi /api/v2 endpoints
+		v2 := api.Group("/v2", errorEnvelope)
+		{
+			// TeslaMateApi /api/v2 root
+			v2.GET("/", func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "TeslaMateApi v2 runnnig..", "path": v2.BasePath()})
+			})
 
-			// v1 /api/v1/globalsettings endpoints
-			v1.GET("/globalsettings", TeslaMateAPIGlobalsettingsV1)
+			// v2 /api/v2 endpoints are the v1 endpoints with APIErrorResponse errors and status codes
+			registerAPIRoutes(v2, authLimiter, requireCar)
 		}
 
 		// /api/ping endpoint
@@ -160,50 +238,110 @@ func main() {
 		// health endpoints for kubernetes
 		api.GET("/healthz", healthz)
 		api.GET("/readyz", readyz)
+
+		// OpenAPI document and documentation page
+		api.GET("/openapi.json", openAPISpec)
+		api.GET("/docs", openAPIDocs)
+		api.GET("/docs/redoc.standalone.js", openAPIDocsScript)
 	}
 
 	// TeslaMateApi endpoints (before versioning)
 	BasePathV1 := api.BasePath() + "/v1"
-	r.GET("/cars", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-	r.GET("/cars/:CarID", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-	r.GET("/cars/:CarID/charges", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-	r.GET("/cars/:CarID/charges/:ChargeID", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-	r.GET("/cars/:CarID/drives", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-	r.GET("/cars/:CarID/drives/:DriveID", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-	r.GET("/cars/:CarID/status", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-	r.GET("/cars/:CarID/updates", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-	r.GET("/globalsettings", func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
-
-	// build the http server
-	server := &http.Server{
-		Addr:    ":8080", // listen and serve on 0.0.0.0:8080 (for windows "localhost:8080")
-		Handler: r,
+	for _, path := range legacyV1Paths {
+		r.GET(path, func(c *gin.Context) { c.Redirect(http.StatusMovedPermanently, BasePathV1+c.Request.RequestURI) })
 	}
 
-	// setting readyz endpoint to true (using Postgres-only approach)
-	isReady.Store(true)
-
-	// graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
+	return r
+}
 
-	// we run a go routine that will receive the shutdown input
-	go func() {
-		<-quit
-		log.Println("[info] TeslaMateAPI received shutdown input")
-		if err := server.Close(); err != nil {
-			log.Fatal("[error] TeslaMateAPI server close error:", err)
-		}
-	}()
-
-	// run the server
-	if err := server.ListenAndServe(); err != nil {
-		if err == http.ErrServerClosed {
-			log.Println("[info] TeslaMateAPI server gracefully shut down")
-		} else {
-			log.Fatal("[error] TeslaMateAPI server closed unexpectedly")
+// registerAPIRoutes func - registers the endpoints of an api version, carHandlers are run before all /cars/:CarID endpoints
+// and every endpoint needs its scope if API_TOKENS_FILE or JWT_JWKS is set
+func registerAPIRoutes(rg *gin.RouterGroup, authLimiter *AuthLimiter, carHandlers ...gin.HandlerFunc) {
+	// authentication of all endpoints, before the car is validated, and ids of the path that fit the columns of TeslaMate
+	rg.Use(authBackoff(authLimiter), requireIdentity, requireValidIDs)
+
+	var (
+		readStatus     = requireScope(scopeReadStatus)
+		readHistory    = requireScope(scopeReadHistory)
+		readGeofences  = requireScope(scopeReadGeofences)
+		readSettings   = requireScope(scopeReadSettings)
+		writeCharges   = requireScope(scopeWriteCharges)
+		writeDrives    = requireScope(scopeWriteDrives)
+		writeGeofences = requireScope(scopeWriteGeofences)
+		logging        = requireScope(scopeLogging)
+	)
+
+	// /cars endpoints
+	rg.GET("/cars", readStatus, TeslaMateAPICarsV1)
+
+	// /cars/:CarID endpoints, the scope is checked before carHandlers so a token without it can't probe for cars
+	withCar := func(scope gin.HandlerFunc, handler gin.HandlerFunc) []gin.HandlerFunc {
+		handlers := []gin.HandlerFunc{}
+		if scope != nil {
+			handlers = append(handlers, scope)
 		}
+		return append(append(handlers, carHandlers...), handler)
 	}
+	car := rg.Group("/cars/:CarID")
+	car.GET("", withCar(readStatus, TeslaMateAPICarsV1)...)
+
+	// /cars/:CarID/battery endpoints
+	car.GET("/battery/health", withCar(readHistory, TeslaMateAPICarsBatteryHealthV1)...)
+
+	// /cars/:CarID/charges endpoints
+	car.GET("/charges", withCar(readHistory, TeslaMateAPICarsChargesV1)...)
+	car.GET("/charges/export", withCar(readHistory, TeslaMateAPICarsChargesBulkExportV1)...)
+	car.GET("/charges/:ChargeID", withCar(readHistory, TeslaMateAPICarsChargesDetailsV1)...)
+	car.PATCH("/charges/:ChargeID", withCar(writeCharges, TeslaMateAPICarsChargesEditV1)...)
+	car.GET("/charges/:ChargeID/curve", withCar(readHistory, TeslaMateAPICarsChargesCurveV1)...)
+	car.GET("/charges/:ChargeID/cost", withCar(readHistory, TeslaMateAPICarsChargesCostV1)...)
+	car.PUT("/charges/:ChargeID/cost", withCar(writeCharges, TeslaMateAPICarsChargesCostV1)...)
+
+	// /cars/:CarID/command endpoints (the command:<group> scope is checked per command)
+	car.GET("/command", withCar(nil, TeslaMateAPICarsCommandV1)...)
+	car.GET("/commands", withCar(nil, TeslaMateAPICarsCommandV1)...)
+	car.POST("/command/:Command", withCar(nil, TeslaMateAPICarsCommandV1)...)
+
+	// /cars/:CarID/drives endpoints
+	car.GET("/drives", withCar(readHistory, TeslaMateAPICarsDrivesV1)...)
+	car.GET("/drives/export", withCar(readHistory, TeslaMateAPICarsDrivesBulkExportV1)...)
+	car.GET("/drives/:DriveID", withCar(readHistory, TeslaMateAPICarsDrivesDetailsV1)...)
+	car.PATCH("/drives/:DriveID", withCar(writeDrives, TeslaMateAPICarsDrivesEditV1)...)
+	car.GET("/drives/:DriveID/export", withCar(readHistory, TeslaMateAPICarsDrivesExportV1)...)
+
+	// /cars/:CarID/logging endpoints
+	car.GET("/logging", withCar(logging, TeslaMateAPICarsLoggingV1)...)
+	car.PUT("/logging/:Command", withCar(logging, TeslaMateAPICarsLoggingV1)...)
+
+	// /cars/:CarID/status endpoints
+	car.GET("/status", withCar(readStatus, TeslaMateAPICarsStatusV1)...)
+	car.GET("/status/stream", withCar(readStatus, TeslaMateAPICarsStatusStreamV1)...)
+
+	// /cars/:CarID/stats endpoints
+	car.GET("/stats", withCar(readHistory, TeslaMateAPICarsStatsV1)...)
+
+	// /cars/:CarID/vampire-drain endpoints
+	car.GET("/vampire-drain", withCar(readHistory, TeslaMateAPICarsVampireDrainV1)...)
+
+	// /cars/:CarID/updates endpoints
+	car.GET("/updates", withCar(readHistory, TeslaMateAPICarsUpdatesV1)...)
+
+	// /cars/:CarID/wake_up endpoints
+	car.POST("/wake_up", withCar(nil, TeslaMateAPICarsCommandV1)...)
+
+	// /globalsettings endpoints
+	rg.GET("/globalsettings", readSettings, TeslaMateAPIGlobalsettingsV1)
+
+	// /geofences endpoints
+	rg.GET("/geofences", readGeofences, TeslaMateAPIGeofencesV1)
+	rg.POST("/geofences", writeGeofences, TeslaMateAPIGeofencesEditV1)
+	rg.GET("/geofences/:GeofenceID", readGeofences, TeslaMateAPIGeofencesV1)
+	rg.PUT("/geofences/:GeofenceID", writeGeofences, TeslaMateAPIGeofencesEditV1)
+	rg.DELETE("/geofences/:GeofenceID", writeGeofences, TeslaMateAPIGeofencesEditV1)
+	rg.GET("/geofences/:GeofenceID/visits", readGeofences, requireAllCars, TeslaMateAPIGeofencesVisitsV1)
+
+	// /auth endpoints
+	rg.GET("/auth/lockouts", TeslaMateAPIAuthLockoutsV1)
 }
 
 // initDBconnection func
@@ -211,44 +349,46 @@ func initDBconnection() {
 
 	// declare error var for use insite initAPI
 	var err error
-	dbsslmode := "disable"
 
-	// creating connection string towards postgres
-	dbhost := getEnv("DATABASE_HOST", "database")
-	dbport := getEnvAsInt("DATABASE_PORT", 5432)
-	dbuser := getEnv("DATABASE_USER", "teslamate")
-	dbpass := getEnv("DATABASE_PASS", "secret")
-	dbname := getEnv("DATABASE_NAME", "teslamate")
-	// dbpool := getEnvAsInt("DATABASE_POOL_SIZE", 10)
-	dbtimeout := (getEnvAsInt("DATABASE_TIMEOUT", 60000) / 1000)
-	dbssl := getEnvAsBool("DATABASE_SSL", false)
-	// dbipv6 := getEnvAsBool("DATABASE_IPV6", false)
-	if dbssl {
-		dbsslmode = "prefer"
-	}
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d", dbhost, dbport, dbuser, dbpass, dbname, dbsslmode, dbtimeout)
-
-	// opening connection to postgres
-	db, err = sql.Open("postgres", psqlInfo)
+	// opening connection pool to postgres
+	db, err = pgxpool.New(context.Background(), getDBConnectionString())
 	if err != nil {
 		log.Panic(err)
 	}
 
 	// doing ping to database to test connection
-	err = db.Ping()
+	err = db.Ping(context.Background())
 	if err != nil {
 		log.Panic(err)
 	}
 
+	// using the connection for the generated queries
+	queries = teslamatedb.New(instrumentDB(db))
+
 	// showing database successfully connected
 	if gin.IsDebugging() {
 		log.Println("[debug] initDBconnection - successfully completed (connected to postgres).")
 	}
 }
 
-func TeslaMateAPIHandleErrorResponse(c *gin.Context, s1 string, s2 string, s3 string) {
-	log.Println("[error] " + s1 + " - (" + c.Request.RequestURI + "). " + s2 + "; " + s3)
-	c.JSON(http.StatusOK, gin.H{"error": s2})
+// getDBConnectionString func - build postgres connection string from environment
+func getDBConnectionString() string {
+	dbsslmode := "disable"
+
+	// creating connection string towards postgres
+	dbhost := getEnv("DATABASE_HOST", "database")
+	dbport := getEnvAsInt("DATABASE_PORT", 5432)
+	dbuser := getEnv("DATABASE_USER", "teslamate")
+	dbpass := getEnv("DATABASE_PASS", "secret")
+	dbname := getEnv("DATABASE_NAME", "teslamate")
+	// dbpool := getEnvAsInt("DATABASE_POOL_SIZE", 10)
+	dbtimeout := (getEnvAsInt("DATABASE_TIMEOUT", 60000) / 1000)
+	dbssl := getEnvAsBool("DATABASE_SSL", false)
+	// dbipv6 := getEnvAsBool("DATABASE_IPV6", false)
+	if dbssl {
+		dbsslmode = "prefer"
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d", dbhost, dbport, dbuser, dbpass, dbname, dbsslmode, dbtimeout)
 }
 
 func TeslaMateAPIHandleOtherResponse(c *gin.Context, httpCode int, s string, j interface{}) {
@@ -270,10 +410,15 @@ func TeslaMateAPIHandleSuccessResponse(c *gin.Context, s string, j interface{})
 	c.JSON(http.StatusOK, j)
 }
 
-func getTimeInTimeZone(datestring string) string {
-	// parsing datestring into dbTimestampFormat
-	t, _ := time.Parse(dbTimestampFormat, datestring)
+// getTimeZoneName func - returns the name of TZ, used to group by local dates in the database
+func getTimeZoneName() string {
+	if appUsersTimezone == nil || appUsersTimezone == time.Local {
+		return "UTC"
+	}
+	return appUsersTimezone.String()
+}
 
+func getTimeInTimeZone(t time.Time) string {
 	// formatting in users location in RFC3339 format
 	ReturnDate := t.In(appUsersTimezone).Format(time.RFC3339)
 
@@ -285,24 +430,24 @@ func getTimeInTimeZone(datestring string) string {
 	return ReturnDate
 }
 
-func parseDateParam(datestring string) (string, error) {
+func parseDateParam(datestring string) (pgtype.Timestamp, error) {
 	if datestring == "" {
-		return "", nil
+		return pgtype.Timestamp{}, nil
 	}
 
 	// RFC3339 formats first — includes Z or timezone offset
 	if t, err := time.Parse(time.RFC3339, datestring); err == nil {
-		return t.UTC().Format(dbTimestampFormat), nil
+		return pgtype.Timestamp{Time: t.UTC(), Valid: true}, nil
 	}
 
 	// DateTime format (2006-01-02 15:04:05) without timezone info, interpret in user's timezone
 	normalizedDateString := strings.ReplaceAll(datestring, "T", " ")
 	if t, err := time.ParseInLocation(time.DateTime, normalizedDateString, appUsersTimezone); err == nil {
-		return t.UTC().Format(dbTimestampFormat), nil
+		return pgtype.Timestamp{Time: t.UTC(), Valid: true}, nil
 	}
 
 	sanitizedInput := strings.NewReplacer("\n", "\\n", "\r", "\\r", "\t", "\\t").Replace(datestring)
-	return "", fmt.Errorf("invalid date format: %s, please use RFC3339 format", sanitizedInput)
+	return pgtype.Timestamp{}, fmt.Errorf("invalid date format: %s, please use RFC3339 format", sanitizedInput)
 }
 
 /*
@@ -361,6 +506,16 @@ func getEnvAsInt(name string, defaultVal int) int {
 	return defaultVal
 }
 
+// getEnvAsPositiveInt func - returns defaultVal with a warning if the value isn't greater than zero
+func getEnvAsPositiveInt(name string, defaultVal int) int {
+	value := getEnvAsInt(name, defaultVal)
+	if value <= 0 {
+		log.Printf("[warning] getEnvAsPositiveInt - %s has to be greater than 0, using %d instead of %d.", name, defaultVal, value)
+		return defaultVal
+	}
+	return value
+}
+
 // convertStringToBool func
 func convertStringToBool(data string) bool {
 	value, err := strconv.ParseBool(data)